@@ -98,7 +98,7 @@ func main() {
 	locationRepo := repository.NewLocationRepository(repository.DB)
 
 	// Initialize Services
-	bulkImportSvc := services.NewBulkImportService(categoryRepo, supplierRepo, locationRepo)
+	bulkImportSvc := services.NewBulkImportService(productRepo, categoryRepo, supplierRepo, locationRepo)
 	bulkExportSvc := services.NewBulkExportService()
 	reportingService := services.NewReportingService(reportsRepo, minioUploader, jobRepo, hub, cfg)
 
@@ -158,10 +158,56 @@ func main() {
 		handlers.CheckAndTriggerAlerts()
 	})
 
+	c.AddFunc("@every 1m", func() {
+		if expired, err := repository.ExpireStockReservations(repository.DB); err != nil {
+			logrus.Errorf("Failed to expire stock reservations: %v", err)
+		} else if expired > 0 {
+			logrus.Infof("Expired %d stock reservations", expired)
+		}
+	})
+
 	c.AddFunc("@daily", func() {
 		logrus.Info("Running daily sales summary generation...")
 		reportingService.GenerateDailySalesSummary()
 	})
+
+	// Snapshot the day that just ended
+	snapshotRepo := repository.NewSnapshotRepository(repository.DB)
+	c.AddFunc("@daily", func() {
+		day := time.Now().AddDate(0, 0, -1)
+		if count, err := snapshotRepo.CaptureDailySnapshot(day); err != nil {
+			logrus.Errorf("Failed to capture inventory snapshot: %v", err)
+		} else {
+			logrus.Infof("Captured %d inventory snapshot rows for %s", count, day.Format("2006-01-02"))
+		}
+	})
+
+	settingsService := services.NewSettingsService(repository.NewSettingsRepository(repository.DB))
+	stockTakeService := services.NewStockTakeService(repository.NewStockTakeRepository(repository.DB), repository.DB, settingsService, services.NewBarcodeService(repository.NewBarcodeRepository(repository.DB)))
+	c.AddFunc("@daily", func() {
+		logrus.Info("Running cycle-count scheduling...")
+		services.RunScheduledCycleCounts(stockTakeService, settingsService)
+	})
+	ledgerService := services.NewLedgerService(repository.DB)
+	c.AddFunc("@daily", func() {
+		logrus.Info("Running stock ledger reconciliation...")
+		services.RunScheduledReconciliation(ledgerService)
+	})
+	expiryService := services.NewExpiryService(repository.DB)
+	c.AddFunc("@hourly", func() {
+		logrus.Info("Running expiry quarantine and markdowns...")
+		services.RunScheduledExpiryChecks(expiryService)
+	})
+	forecastingService := services.NewForecastingService(repository.NewForecastingRepository(repository.DB), cfg)
+	c.AddFunc("@daily", func() {
+		logrus.Info("Running forecast accuracy evaluation...")
+		services.RunScheduledForecastEvaluation(forecastingService)
+	})
+	consolidationService := services.NewPOConsolidationService(repository.DB)
+	c.AddFunc("@daily", func() {
+		logrus.Info("Running reorder suggestion consolidation...")
+		services.RunScheduledSuggestionConsolidation(consolidationService, settingsService)
+	})
 	go c.Start()
 	defer c.Stop()
 
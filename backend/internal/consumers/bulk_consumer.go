@@ -10,6 +10,7 @@ import (
 	"inventory/backend/internal/services"
 	"inventory/backend/internal/storage"
 	ws "inventory/backend/internal/websocket"
+	"io"
 	"strings"
 	"time"
 
@@ -178,6 +179,11 @@ func (c *BulkConsumer) processImportDelivery(d amqp091.Delivery) {
 	}
 	defer file.Close()
 
+	if job.Type == "SUPPLIER_PRICE_IMPORT" {
+		c.processSupplierPriceImport(d, job, file)
+		return
+	}
+
 	result, err := c.BulkImportService.ProcessBulkImport(file)
 	if err != nil {
 		logrus.Errorf("Failed to process bulk import for job %d: %v", jobID, err)
@@ -291,6 +297,23 @@ func (c *BulkConsumer) processImportConfirmDelivery(d amqp091.Delivery) {
 		return
 	}
 
+	if job.Type == "SUPPLIER_PRICE_IMPORT" {
+		var priceResult services.SupplierPriceImportResult
+		if err := json.Unmarshal([]byte(job.Result), &priceResult); err != nil {
+			logrus.Errorf("Failed to unmarshal price import result for job %d: %v", jobID, err)
+			c.failJob(job, err.Error())
+			d.Nack(false, false)
+			return
+		}
+		if err := c.finalizeSupplierPriceImport(job, &priceResult); err != nil {
+			logrus.Errorf("Failed to finalize price import for job %d: %v", jobID, err)
+			d.Nack(false, false)
+			return
+		}
+		d.Ack(false)
+		return
+	}
+
 	var importResult services.BulkImportResult
 
 	if err := json.Unmarshal([]byte(job.Result), &importResult); err != nil {
@@ -503,6 +526,62 @@ func (c *BulkConsumer) finalizeImport(job *domain.Job, importResult *services.Bu
 	return c.saveJob(job)
 }
 
+// processSupplierPriceImport validates a downloaded supplier price list and applies it.
+func (c *BulkConsumer) processSupplierPriceImport(d amqp091.Delivery, job *domain.Job, file io.Reader) {
+	result, err := c.BulkImportService.ProcessSupplierPriceImport(file)
+	if err != nil {
+		logrus.Errorf("Failed to process supplier price import for job %d: %v", job.ID, err)
+		c.failJob(job, err.Error())
+		d.Nack(false, false)
+		return
+	}
+
+	resultBytes, _ := json.Marshal(result)
+	job.Result = string(resultBytes)
+	if result.ValidRecords == 0 {
+		c.failJob(job, "No valid supplier prices found in uploaded file")
+		logrus.Warnf("Supplier price import job %d finished validation with no valid records", job.ID)
+		d.Ack(false)
+		return
+	}
+
+	job.Status = "PENDING_CONFIRMATION"
+	if err := c.saveJob(job); err != nil {
+		logrus.Errorf("Failed to update job %d: %v", job.ID, err)
+		d.Nack(false, false)
+		return
+	}
+
+	if err := c.finalizeSupplierPriceImport(job, result); err != nil {
+		logrus.Errorf("Failed to finalize supplier price import job %d: %v", job.ID, err)
+		d.Nack(false, false)
+		return
+	}
+
+	logrus.Infof("Supplier price import job %d completed successfully after auto-confirmation", job.ID)
+	d.Ack(false)
+}
+
+// finalizeSupplierPriceImport creates or updates the catalog entries of a validated price list in one transaction.
+func (c *BulkConsumer) finalizeSupplierPriceImport(job *domain.Job, result *services.SupplierPriceImportResult) error {
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range result.ValidEntries {
+			if err := repository.SaveProductSupplier(tx, &result.ValidEntries[i]); err != nil {
+				return fmt.Errorf("failed to save price for product %d from supplier %d: %w", result.ValidEntries[i].ProductID, result.ValidEntries[i].SupplierID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.failJob(job, err.Error())
+		return err
+	}
+
+	job.Status = "COMPLETED"
+	job.LastError = ""
+	return c.saveJob(job)
+}
+
 func (c *BulkConsumer) failJob(job *domain.Job, lastError string) {
 	job.Status = "FAILED"
 	job.LastError = lastError
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Scale barcode value types.
+const (
+	ScaleValueWeight = "WEIGHT"
+	ScaleValuePrice  = "PRICE"
+)
+
+// ScaleBarcodePattern describes how a GS1 variable-measure barcode (prefix 2x) printed by
+// a scale is laid out. Positions are zero-based offsets into the scanned barcode.
+type ScaleBarcodePattern struct {
+	gorm.Model
+	Name          string `gorm:"not null"`
+	Prefix        string `gorm:"not null;index"` // e.g., "20", "21" or "2"
+	Length        int    `gorm:"default:13"`     // Total barcode length including the check digit
+	PLUStart      int    `gorm:"not null"`
+	PLULength     int    `gorm:"not null"`
+	ValueStart    int    `gorm:"not null"`
+	ValueLength   int    `gorm:"not null"`
+	ValueType     string `gorm:"not null"`  // WEIGHT or PRICE
+	ValueDecimals int    `gorm:"default:3"` // Implied decimals (3 = grams to kg, 2 = cents)
+	VerifyCheck   bool   `gorm:"default:true"`
+	Priority      int    `gorm:"default:0"` // Higher priority patterns are tried first
+	IsActive      bool   `gorm:"default:true;index"`
+}
+
+// ScaleBarcodeValue is the decoded content of a scale barcode.
+type ScaleBarcodeValue struct {
+	PLU       string
+	ValueType string
+	Value     float64
+}
+
+// ScaleLine is the cart line a scale barcode stands for.
+type ScaleLine struct {
+	Quantity   float64
+	UnitPrice  float64
+	TotalPrice float64
+	FixedPrice bool // The label's printed price is charged as is, without promotions
+}
+
+// LineFor derives the cart line for the product. Weight barcodes carry the quantity, priced at the
+// selling price. Price barcodes carry the line total: measured products back-calculate the quantity
+// from it, while whole-unit products count the label as one package at the printed price.
+func (v *ScaleBarcodeValue) LineFor(product *Product) ScaleLine {
+	if v.ValueType == ScaleValueWeight {
+		quantity := product.RoundQuantity(v.Value)
+		return ScaleLine{
+			Quantity:   quantity,
+			UnitPrice:  product.SellingPrice,
+			TotalPrice: RoundMoney(quantity * product.SellingPrice),
+		}
+	}
+
+	quantity := 1.0
+	if product.QuantityPrecision > 0 && product.SellingPrice > 0 {
+		// Never below the smallest quantity the product tracks, however cheap the label
+		step := math.Pow(10, -math.Min(float64(product.QuantityPrecision), MaxQuantityPrecision))
+		quantity = math.Max(product.RoundQuantity(v.Value/product.SellingPrice), step)
+	}
+	return ScaleLine{
+		Quantity:   quantity,
+		UnitPrice:  RoundMoney(v.Value / quantity),
+		TotalPrice: v.Value,
+		FixedPrice: true,
+	}
+}
+
+// Validate checks that the pattern's segments fit within the barcode length.
+func (p *ScaleBarcodePattern) Validate() error {
+	if p.Prefix == "" || !isDigits(p.Prefix) {
+		return fmt.Errorf("prefix must be numeric")
+	}
+	if p.ValueType != ScaleValueWeight && p.ValueType != ScaleValuePrice {
+		return fmt.Errorf("valueType must be %s or %s", ScaleValueWeight, ScaleValuePrice)
+	}
+	if p.PLULength <= 0 || p.ValueLength <= 0 {
+		return fmt.Errorf("PLU and value lengths must be positive")
+	}
+	if p.PLUStart < len(p.Prefix) || p.ValueStart < len(p.Prefix) {
+		return fmt.Errorf("PLU and value segments must start after the prefix")
+	}
+	if p.PLUStart+p.PLULength > p.Length || p.ValueStart+p.ValueLength > p.Length {
+		return fmt.Errorf("segments exceed barcode length %d", p.Length)
+	}
+	if p.PLUStart < p.ValueStart+p.ValueLength && p.ValueStart < p.PLUStart+p.PLULength {
+		return fmt.Errorf("PLU and value segments overlap")
+	}
+	if p.ValueDecimals < 0 || p.ValueDecimals > 4 {
+		return fmt.Errorf("valueDecimals must be between 0 and 4")
+	}
+	return nil
+}
+
+// Matches reports whether the barcode has this pattern's length and prefix.
+func (p *ScaleBarcodePattern) Matches(code string) bool {
+	return len(code) == p.Length && strings.HasPrefix(code, p.Prefix) && isDigits(code)
+}
+
+// Decode extracts the PLU and embedded weight or price from a matching barcode.
+func (p *ScaleBarcodePattern) Decode(code string) (*ScaleBarcodeValue, error) {
+	if !p.Matches(code) {
+		return nil, fmt.Errorf("barcode does not match pattern %s", p.Name)
+	}
+	if p.VerifyCheck && !ValidGS1CheckDigit(code) {
+		return nil, fmt.Errorf("invalid check digit")
+	}
+
+	raw, err := strconv.Atoi(code[p.ValueStart : p.ValueStart+p.ValueLength])
+	if err != nil {
+		return nil, fmt.Errorf("invalid value segment: %w", err)
+	}
+
+	return &ScaleBarcodeValue{
+		PLU:       NormalizePLU(code[p.PLUStart : p.PLUStart+p.PLULength]),
+		ValueType: p.ValueType,
+		Value:     float64(raw) / math.Pow(10, float64(p.ValueDecimals)),
+	}, nil
+}
+
+// NormalizePLU strips leading zeros so "00123" and "123" resolve to the same PLU.
+func NormalizePLU(plu string) string {
+	if plu == "" {
+		return ""
+	}
+	if trimmed := strings.TrimLeft(plu, "0"); trimmed != "" {
+		return trimmed
+	}
+	return "0"
+}
+
+// ValidGS1CheckDigit verifies the trailing GS1 mod-10 check digit of a numeric barcode.
+func ValidGS1CheckDigit(code string) bool {
+	if len(code) < 2 || !isDigits(code) {
+		return false
+	}
+	sum := 0
+	// Weights alternate 3,1,3,... starting from the digit immediately left of the check digit.
+	for i := len(code) - 2; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
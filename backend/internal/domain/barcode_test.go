@@ -0,0 +1,139 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func weightPattern() *ScaleBarcodePattern {
+	return &ScaleBarcodePattern{
+		Name: "weight", Prefix: "20", Length: 13,
+		PLUStart: 2, PLULength: 5, ValueStart: 7, ValueLength: 5,
+		ValueType: ScaleValueWeight, ValueDecimals: 3, VerifyCheck: true,
+	}
+}
+
+func pricePattern() *ScaleBarcodePattern {
+	return &ScaleBarcodePattern{
+		Name: "price", Prefix: "21", Length: 13,
+		PLUStart: 2, PLULength: 5, ValueStart: 7, ValueLength: 5,
+		ValueType: ScaleValuePrice, ValueDecimals: 2, VerifyCheck: true,
+	}
+}
+
+func TestValidGS1CheckDigit(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"4006381333931", true},
+		{"4006381333932", false},
+		{"2000123012506", true},
+		{"2100045003498", true},
+		{"2000123012507", false},
+		{"1", false},
+		{"", false},
+		{"20001230125a6", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			assert.Equal(t, tt.want, ValidGS1CheckDigit(tt.code))
+		})
+	}
+}
+
+func TestScaleBarcodePatternDecode(t *testing.T) {
+	noCheck := weightPattern()
+	noCheck.VerifyCheck = false
+
+	tests := []struct {
+		name      string
+		pattern   *ScaleBarcodePattern
+		code      string
+		wantPLU   string
+		wantValue float64
+		wantErr   bool
+	}{
+		{"weight in grams", weightPattern(), "2000123012506", "123", 1.25, false},
+		{"price in cents", pricePattern(), "2100045003498", "45", 3.49, false},
+		{"zero value", weightPattern(), "2000123000008", "123", 0, false},
+		{"bad check digit", weightPattern(), "2000123012507", "", 0, true},
+		{"check digit not verified", noCheck, "2000123012507", "123", 1.25, false},
+		{"other prefix", weightPattern(), "2100045003498", "", 0, true},
+		{"too short", weightPattern(), "200012301250", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := tt.pattern.Decode(tt.code)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPLU, decoded.PLU)
+			assert.Equal(t, tt.pattern.ValueType, decoded.ValueType)
+			assert.InDelta(t, tt.wantValue, decoded.Value, 1e-9)
+		})
+	}
+}
+
+func TestScaleBarcodeValueLineFor(t *testing.T) {
+	perKg := &Product{SellingPrice: 6.98, QuantityPrecision: 3}
+	perUnit := &Product{SellingPrice: 4.99, QuantityPrecision: 0}
+
+	tests := []struct {
+		name    string
+		value   ScaleBarcodeValue
+		product *Product
+		want    ScaleLine
+	}{
+		{
+			name:    "weight priced at the selling price",
+			value:   ScaleBarcodeValue{ValueType: ScaleValueWeight, Value: 1.25},
+			product: perKg,
+			want:    ScaleLine{Quantity: 1.25, UnitPrice: 6.98, TotalPrice: 8.73},
+		},
+		{
+			name:    "price back-calculates a measured quantity",
+			value:   ScaleBarcodeValue{ValueType: ScaleValuePrice, Value: 3.49},
+			product: perKg,
+			want:    ScaleLine{Quantity: 0.5, UnitPrice: 6.98, TotalPrice: 3.49, FixedPrice: true},
+		},
+		{
+			name:    "price below one unit on a whole-unit product is one package",
+			value:   ScaleBarcodeValue{ValueType: ScaleValuePrice, Value: 1.99},
+			product: perUnit,
+			want:    ScaleLine{Quantity: 1, UnitPrice: 1.99, TotalPrice: 1.99, FixedPrice: true},
+		},
+		{
+			name:    "price above one unit on a whole-unit product is still one package",
+			value:   ScaleBarcodeValue{ValueType: ScaleValuePrice, Value: 12.5},
+			product: perUnit,
+			want:    ScaleLine{Quantity: 1, UnitPrice: 12.5, TotalPrice: 12.5, FixedPrice: true},
+		},
+		{
+			name:    "tiny price keeps the smallest measurable quantity",
+			value:   ScaleBarcodeValue{ValueType: ScaleValuePrice, Value: 0.01},
+			product: &Product{SellingPrice: 100, QuantityPrecision: 3},
+			want:    ScaleLine{Quantity: 0.001, UnitPrice: 10, TotalPrice: 0.01, FixedPrice: true},
+		},
+		{
+			name:    "unpriced product counts one package",
+			value:   ScaleBarcodeValue{ValueType: ScaleValuePrice, Value: 2.5},
+			product: &Product{QuantityPrecision: 2},
+			want:    ScaleLine{Quantity: 1, UnitPrice: 2.5, TotalPrice: 2.5, FixedPrice: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.value.LineFor(tt.product)
+			assert.InDelta(t, tt.want.Quantity, got.Quantity, 1e-9)
+			assert.InDelta(t, tt.want.UnitPrice, got.UnitPrice, 1e-9)
+			assert.InDelta(t, tt.want.TotalPrice, got.TotalPrice, 1e-9)
+			assert.Equal(t, tt.want.FixedPrice, got.FixedPrice)
+			assert.Greater(t, got.Quantity, 0.0)
+		})
+	}
+}
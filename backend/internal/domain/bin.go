@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Bin levels, outermost first.
+const (
+	BinTypeZone  = "ZONE"
+	BinTypeAisle = "AISLE"
+	BinTypeShelf = "SHELF"
+	BinTypeBin   = "BIN"
+)
+
+// BinPathSeparator joins the codes of a bin and its ancestors into its path, e.g. "A-03-2".
+const BinPathSeparator = "-"
+
+// Bin is a zone, aisle, shelf or bin inside a Location. Bins nest through ParentID and batches are
+// put away into them.
+type Bin struct {
+	gorm.Model
+	LocationID uint `gorm:"not null;uniqueIndex:idx_bin_location_path"`
+	Location   Location
+	ParentID   *uint   `gorm:"index"`
+	Code       string  `gorm:"not null"`                                   // Unique among siblings, e.g. "A" or "03"
+	Path       string  `gorm:"not null;uniqueIndex:idx_bin_location_path"` // Codes from the zone down, e.g. "A-03-2"
+	Type       string  `gorm:"default:'BIN'"`                              // ZONE, AISLE, SHELF, BIN
+	Capacity   float64 // Most stock the bin holds, in product units; 0 means unlimited
+	IsActive   bool    // Inactive bins receive no putaway or moves
+	Children   []Bin   `gorm:"foreignKey:ParentID"`
+}
+
+// ChildPath returns the path of a child bin with the given code.
+func (b *Bin) ChildPath(code string) string {
+	return b.Path + BinPathSeparator + code
+}
+
+// HasRoomFor reports whether the bin can take quantity on top of what it already holds.
+func (b *Bin) HasRoomFor(held, quantity float64) bool {
+	return b.Capacity <= 0 || held+quantity <= b.Capacity
+}
+
+// CompareBinPaths orders bin paths the way a picker walks them: segment by segment, numeric
+// segments by value so that aisle "2" comes before aisle "10". It returns -1, 0 or 1.
+func CompareBinPaths(a, b string) int {
+	as := strings.Split(a, BinPathSeparator)
+	bs := strings.Split(b, BinPathSeparator)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareBinSegments(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+func compareBinSegments(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	if aErr == nil && bErr == nil {
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.ToUpper(a), strings.ToUpper(b))
+}
+
+// BinMove records stock moved between bins inside a location. A partial move splits the batch, so
+// ToBatchID differs from FromBatchID.
+type BinMove struct {
+	gorm.Model
+	ProductID   uint `gorm:"not null;index"`
+	Product     Product
+	LocationID  uint  `gorm:"index"`
+	FromBatchID uint  `gorm:"not null"`
+	ToBatchID   uint  `gorm:"not null"`
+	FromBinID   *uint // Nil when the stock had not been put away yet
+	ToBinID     uint  `gorm:"not null;index"`
+	Quantity    float64
+	MovedBy     uint
+	MovedAt     time.Time `gorm:"index"`
+	Notes       string
+}
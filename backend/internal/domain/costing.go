@@ -0,0 +1,72 @@
+package domain
+
+import "math"
+
+const (
+	CostingMethodFIFO    = "FIFO"
+	CostingMethodAverage = "AVERAGE"
+)
+
+// CostLayerDraw records a quantity taken from one batch (cost layer).
+type CostLayerDraw struct {
+	BatchID  uint
+	Quantity float64
+	UnitCost float64
+}
+
+// CurrentUnitCost is the cost assigned to stock that arrives without a document cost
+// (manual stock-ins, count gains): the moving average for AVERAGE products, else the purchase price.
+func (p *Product) CurrentUnitCost() float64 {
+	if p.CostingMethod == CostingMethodAverage && p.AverageCost > 0 {
+		return p.AverageCost
+	}
+	return p.PurchasePrice
+}
+
+// LayerCost returns the batch's unit cost, falling back to the product's current cost for
+// batches recorded before receipt costs were captured.
+func (b *Batch) LayerCost(p *Product) float64 {
+	if b.UnitCost > 0 {
+		return b.UnitCost
+	}
+	return p.CurrentUnitCost()
+}
+
+// IssueCost returns the cost of goods drawn from the given layers. FIFO products are costed at
+// the layers actually consumed; AVERAGE products at the moving-average cost.
+func (p *Product) IssueCost(draws []CostLayerDraw) float64 {
+	var qty, cost float64
+	for _, d := range draws {
+		qty += d.Quantity
+		cost += d.Quantity * d.UnitCost
+	}
+	if p.CostingMethod == CostingMethodAverage {
+		cost = qty * p.CurrentUnitCost()
+	}
+	return RoundMoney(cost)
+}
+
+// UnitCostOf returns the per-unit cost of a movement, or 0 when nothing moved.
+func UnitCostOf(totalCost, quantity float64) float64 {
+	if quantity == 0 {
+		return 0
+	}
+	return math.Round(totalCost/quantity*10000) / 10000
+}
+
+// MovingAverageCost folds a receipt into an average cost. Negative on-hand stock is treated as zero.
+func MovingAverageCost(onHand, averageCost, receivedQty, receivedCost float64) float64 {
+	if onHand < 0 {
+		onHand = 0
+	}
+	total := onHand + receivedQty
+	if total <= 0 {
+		return averageCost
+	}
+	return math.Round((onHand*averageCost+receivedQty*receivedCost)/total*10000) / 10000
+}
+
+// RoundMoney rounds an amount to cents.
+func RoundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Write-off statuses.
+const (
+	WriteOffStatusPending  = "PENDING"
+	WriteOffStatusApproved = "APPROVED"
+	WriteOffStatusRejected = "REJECTED"
+)
+
+// WasteReasonCodes are the stock-out reasons counted as waste in the waste report.
+var WasteReasonCodes = []string{"EXPIRED", "DAMAGED_GOODS", "SPOILAGE"}
+
+// ExpiryMarkdownRule discounts batches automatically as their expiry date approaches. When several
+// rules match a batch, the one with the fewest days before expiry (the most urgent tier) wins.
+type ExpiryMarkdownRule struct {
+	gorm.Model
+	Name             string  `gorm:"not null"`
+	DaysBeforeExpiry int     `gorm:"not null"` // Markdown starts this many days before the batch expires
+	DiscountType     string  `gorm:"not null"` // "PERCENTAGE" or "FIXED_AMOUNT"
+	DiscountValue    float64 `gorm:"not null"`
+	CategoryID       *uint   `gorm:"index"` // Nil applies the rule to every perishable product
+	Category         *Category
+	IsActive         bool `gorm:"default:true"`
+}
+
+// Matches reports whether the rule covers a product batch expiring at expiry, seen at now.
+func (r *ExpiryMarkdownRule) Matches(product *Product, expiry time.Time, now time.Time) bool {
+	if !r.IsActive {
+		return false
+	}
+	if r.CategoryID != nil && *r.CategoryID != product.CategoryID {
+		return false
+	}
+	return expiry.After(now) && !expiry.After(now.AddDate(0, 0, r.DaysBeforeExpiry))
+}
+
+// StockWriteOff removes a quarantined or damaged batch from stock once a manager approves it.
+type StockWriteOff struct {
+	gorm.Model
+	ProductID         uint `gorm:"not null;index"`
+	Product           Product
+	LocationID        uint `gorm:"not null"`
+	Location          Location
+	BatchID           uint `gorm:"not null;index"`
+	Batch             Batch
+	Quantity          float64 `gorm:"not null"`
+	ReasonCode        string  `gorm:"not null;default:'EXPIRED'"` // EXPIRED, DAMAGED_GOODS, SPOILAGE
+	Status            string  `gorm:"default:'PENDING';index"`    // PENDING, APPROVED, REJECTED
+	Notes             string
+	RequestedBy       *uint // Nil when raised by the expiry quarantine job
+	ReviewedBy        *uint
+	ReviewedAt        *time.Time
+	TotalCost         float64 // Cost of the stock written off, set on approval
+	StockAdjustmentID *uint   // The adjustment posted on approval
+}
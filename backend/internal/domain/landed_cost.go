@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	LandedCostFreight   = "FREIGHT"
+	LandedCostDuty      = "DUTY"
+	LandedCostHandling  = "HANDLING"
+	LandedCostInsurance = "INSURANCE"
+	LandedCostOther     = "OTHER"
+
+	AllocateByValue    = "VALUE"
+	AllocateByQuantity = "QUANTITY"
+	AllocateByWeight   = "WEIGHT"
+
+	LandedCostStatusPending   = "PENDING"
+	LandedCostStatusAllocated = "ALLOCATED"
+)
+
+// LandedCostCharge is a freight, duty, handling or similar cost incurred to bring purchased goods
+// in. It is spread over the received lines of its purchase order and added to their batch cost.
+type LandedCostCharge struct {
+	gorm.Model
+	PurchaseOrderID  uint `gorm:"not null;index"`
+	PurchaseOrder    PurchaseOrder
+	ChargeType       string `gorm:"not null;index"` // FREIGHT, DUTY, HANDLING, INSURANCE, OTHER
+	Description      string
+	Reference        string  // Carrier invoice, customs entry or similar document number
+	Amount           float64 `gorm:"not null"`
+	AllocationMethod string  `gorm:"not null"`                // VALUE, QUANTITY, WEIGHT
+	Status           string  `gorm:"default:'PENDING';index"` // PENDING, ALLOCATED
+	AllocatedAt      *time.Time
+	CreatedBy        uint
+	Allocations      []LandedCostAllocation `gorm:"foreignKey:ChargeID"`
+}
+
+// LandedCostAllocation is the share of a charge borne by one received batch.
+type LandedCostAllocation struct {
+	gorm.Model
+	ChargeID            uint `gorm:"not null;index"`
+	PurchaseOrderItemID uint `gorm:"not null;index"`
+	ProductID           uint `gorm:"not null;index"`
+	Product             Product
+	BatchID             uint    `gorm:"not null;index"`
+	Quantity            float64 // Quantity received into the batch
+	BaseValue           float64 // Quantity x PO unit price
+	Amount              float64 // Share of the charge
+	UnitAmount          float64 // Amount per received unit, added to the batch cost
+}
+
+// LandedCostBasis describes one received line for allocation purposes.
+type LandedCostBasis struct {
+	Quantity float64
+	Value    float64
+	Weight   float64
+}
+
+// ErrLandedCostBasis is returned when the lines give a charge nothing to be spread by, such as
+// WEIGHT allocation over products without a recorded weight.
+var ErrLandedCostBasis = errors.New("landed cost cannot be allocated")
+
+// basisWeights picks each line's weight for the allocation method and returns their total.
+func basisWeights(method string, lines []LandedCostBasis) ([]float64, float64, error) {
+	weights := make([]float64, len(lines))
+	var total float64
+	for i, l := range lines {
+		switch method {
+		case AllocateByValue:
+			weights[i] = l.Value
+		case AllocateByQuantity:
+			weights[i] = l.Quantity
+		case AllocateByWeight:
+			weights[i] = l.Weight
+		default:
+			return nil, 0, fmt.Errorf("%w: unknown allocation method %q", ErrLandedCostBasis, method)
+		}
+		if weights[i] < 0 {
+			weights[i] = 0
+		}
+		total += weights[i]
+	}
+	return weights, total, nil
+}
+
+func noBasisError(method string) error {
+	if method == AllocateByWeight {
+		return fmt.Errorf("%w: products have no weight recorded", ErrLandedCostBasis)
+	}
+	return fmt.Errorf("%w: lines have no %s to allocate by", ErrLandedCostBasis, method)
+}
+
+// AllocateLandedCost splits amount across lines in proportion to value, quantity or weight.
+// Shares are rounded to cents and any rounding remainder is given to the largest line so the
+// shares always add back to the charge.
+func AllocateLandedCost(amount float64, method string, lines []LandedCostBasis) ([]float64, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no received lines to allocate to")
+	}
+
+	weights, total, err := basisWeights(method, lines)
+	if err != nil {
+		return nil, err
+	}
+	if total <= 0 {
+		return nil, noBasisError(method)
+	}
+
+	shares := make([]float64, len(lines))
+	var allocated float64
+	largest := 0
+	for i, w := range weights {
+		shares[i] = RoundMoney(amount * w / total)
+		allocated += shares[i]
+		if w > weights[largest] {
+			largest = i
+		}
+	}
+	shares[largest] = RoundMoney(shares[largest] + amount - allocated)
+	return shares, nil
+}
+
+// AllocateLandedCostShare gives each received line its part of a charge that covers the whole
+// order: amount in proportion to the line's basis over the basis of everything ordered. Lines
+// received later take the rest, so the shares only add up to amount once the order is complete.
+func AllocateLandedCostShare(amount float64, method string, ordered []LandedCostBasis, received []LandedCostBasis) ([]float64, error) {
+	_, orderedTotal, err := basisWeights(method, ordered)
+	if err != nil {
+		return nil, err
+	}
+	if orderedTotal <= 0 {
+		return nil, noBasisError(method)
+	}
+	weights, _, err := basisWeights(method, received)
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]float64, len(received))
+	for i, w := range weights {
+		shares[i] = RoundMoney(amount * w / orderedTotal)
+	}
+	return shares, nil
+}
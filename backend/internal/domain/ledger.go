@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ledger reconciliation statuses.
+const (
+	ReconciliationStatusClean           = "CLEAN" // No discrepancies found
+	ReconciliationStatusPendingApproval = "PENDING_APPROVAL"
+	ReconciliationStatusApproved        = "APPROVED"
+	ReconciliationStatusRejected        = "REJECTED"
+)
+
+// Discrepancy statuses.
+const (
+	DiscrepancyStatusOpen      = "OPEN"
+	DiscrepancyStatusCorrected = "CORRECTED"
+	DiscrepancyStatusIgnored   = "IGNORED"
+)
+
+// LedgerReconciliation is one run of the integrity check that replays the stock ledger per product
+// and location and compares it with the batches actually held.
+type LedgerReconciliation struct {
+	gorm.Model
+	Status             string    `gorm:"default:'PENDING_APPROVAL';index"` // CLEAN, PENDING_APPROVAL, APPROVED, REJECTED
+	RunAt              time.Time `gorm:"index"`
+	TriggeredBy        *uint     // Nil for the scheduled run
+	PairsChecked       int       // Product/location pairs compared
+	DiscrepancyCount   int
+	NegativeBatchCount int
+	SnapshotDriftCount int // Adjustments whose PreviousQuantity disagrees with the replayed ledger
+	ReviewedBy         *uint
+	ReviewedAt         *time.Time
+	Notes              string
+	Discrepancies      []LedgerDiscrepancy `gorm:"foreignKey:ReconciliationID"`
+}
+
+// LedgerDiscrepancy is a product/location whose replayed ledger disagrees with its batches, or
+// which holds negative batches.
+type LedgerDiscrepancy struct {
+	gorm.Model
+	ReconciliationID  uint `gorm:"not null;index"`
+	ProductID         uint `gorm:"not null;index"`
+	Product           Product
+	LocationID        uint `gorm:"index"`
+	Location          Location
+	LedgerQuantity    float64 // Net of all stock adjustments
+	InTransitQuantity float64 // Pending transfers out minus in: already in the ledger, not yet moved in batches
+	BatchQuantity     float64 // Sum of batch quantities
+	Difference        float64 // BatchQuantity - (LedgerQuantity + InTransitQuantity)
+	NegativeBatches   int
+	NegativeQuantity  float64 // Total below zero across negative batches
+	SnapshotDrifts    int     // Product-wide count, reported on each of the product's rows
+	Status            string  `gorm:"default:'OPEN'"` // OPEN, CORRECTED, IGNORED
+	AdjustmentID      *uint   // Correcting adjustment posted on approval
+}
+
+// NeedsCorrection reports whether approving the discrepancy changes batches or the ledger.
+func (d *LedgerDiscrepancy) NeedsCorrection() bool {
+	return d.Difference != 0 || d.NegativeBatches > 0
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -27,6 +28,25 @@ type Product struct {
 	Status        string `gorm:"default:'Active';index"` // Index for status filters (Active, Archived, Discontinued)
 	LocationID    uint   `gorm:"index"`                  // Index for location filters
 	Location      Location
+	// UnitOfMeasure is the unit stock is counted in (e.g., "unit", "kg", "m").
+	UnitOfMeasure string `gorm:"default:'unit'"`
+	// QuantityPrecision is the number of decimal places allowed for quantities (0 = whole units only).
+	QuantityPrecision int `gorm:"default:0"`
+	// PLUCode is the price look-up code printed inside scale (variable-measure) barcodes. It is
+	// unique among live products, enforced by a partial index.
+	PLUCode string `gorm:"index"`
+	// IsSerialized requires every unit to be tracked by serial number or IMEI.
+	IsSerialized bool `gorm:"default:false"`
+	// WarrantyDays is the warranty period counted from the sale date (0 = no warranty).
+	WarrantyDays int `gorm:"default:0"`
+	// ABCClass is the consumption-value class (A, B, C) assigned by the cycle-count plan.
+	ABCClass string `gorm:"index"`
+	// CostingMethod decides how issued stock is costed: FIFO (actual batch layers) or AVERAGE (moving average).
+	CostingMethod string `gorm:"default:'FIFO'"`
+	// AverageCost is the moving-average unit cost, updated on every receipt.
+	AverageCost float64 `gorm:"default:0"`
+	// Weight is the shipping weight of one unit in kilograms, used to allocate freight by weight.
+	Weight float64 `gorm:"default:0"`
 }
 
 // GetID implements the Searchable interface for Product.
@@ -82,6 +102,11 @@ type Supplier struct {
 	Email         string `gorm:"index"` // Index for email lookups
 	Phone         string `gorm:"index"` // Index for phone lookups
 	Address       string
+
+	// Purchasing terms applied when reorder suggestions are consolidated into purchase orders
+	MinimumOrderValue     float64 `gorm:"default:0"` // Orders below this value are not placed; 0 = no minimum
+	FreeShippingThreshold float64 `gorm:"default:0"` // Order value from which delivery is free; 0 = always free
+	AutoApproveLimit      float64 `gorm:"default:0"` // Consolidated orders up to this value are approved automatically; 0 = never
 }
 
 // GetID implements the Searchable interface for Supplier.
@@ -114,8 +139,21 @@ type Batch struct {
 	LocationID  uint `gorm:"index:idx_product_location"`
 	Location    Location
 	BatchNumber string     `gorm:"not null"`
-	Quantity    int        `gorm:"not null"`
+	Quantity    float64    `gorm:"not null"`
 	ExpiryDate  *time.Time // Pointer to allow null for non-perishable
+	// UnitCost is the receipt cost of one unit in this layer (0 for batches recorded before costs were captured).
+	UnitCost float64 `gorm:"default:0"`
+	// PurchaseOrderItemID links batches received against a purchase order.
+	PurchaseOrderItemID *uint `gorm:"index"`
+	// ReceivedQuantity is the quantity originally received into this layer (0 for older batches).
+	ReceivedQuantity float64 `gorm:"default:0"`
+	// LandedCost is the per-unit freight, duty and handling included in UnitCost.
+	LandedCost float64 `gorm:"default:0"`
+	// BinID is the bin the batch is put away in; nil while it sits unassigned in the location.
+	BinID *uint `gorm:"index"`
+	Bin   *Bin
+	// QuarantinedAt is set once the batch has expired; quarantined stock cannot be sold or reserved.
+	QuarantinedAt *time.Time `gorm:"index"`
 }
 
 // StockAdjustment represents a manual adjustment to stock levels.
@@ -125,14 +163,18 @@ type StockAdjustment struct {
 	Product          Product
 	LocationID       uint
 	Location         Location
-	Type             string `gorm:"not null"` // e.g., "STOCK_IN", "STOCK_OUT"
-	Quantity         int    `gorm:"not null"`
-	ReasonCode       string `gorm:"not null"` // e.g., "DAMAGED_GOODS", "STOCK_TAKE_CORRECTION"
+	Type             string  `gorm:"not null"` // e.g., "STOCK_IN", "STOCK_OUT"
+	Quantity         float64 `gorm:"not null"`
+	ReasonCode       string  `gorm:"not null"` // e.g., "DAMAGED_GOODS", "STOCK_TAKE_CORRECTION"
 	Notes            string
 	AdjustedBy       uint      // UserID of the person who made the adjustment
 	AdjustedAt       time.Time `gorm:"index"`
-	PreviousQuantity int       // Snapshot of quantity before adjustment
-	NewQuantity      int       // Snapshot of quantity after adjustment
+	PreviousQuantity float64   // Snapshot of quantity before adjustment
+	NewQuantity      float64   // Snapshot of quantity after adjustment
+	// UnitCost is the actual cost per unit moved, taken from the cost layers (nil for movements recorded before costing).
+	UnitCost *float64
+	// BatchID is the batch a purchase receipt was booked into, so landed costs allocated later reach the receipt's cost.
+	BatchID *uint `gorm:"index"`
 }
 
 // Alert represents a stock-related alert.
@@ -146,6 +188,7 @@ type Alert struct {
 	Status      string    `gorm:"default:'ACTIVE';index:idx_alert_product_status"` // ACTIVE, RESOLVED
 	BatchID     *uint     // Optional, for expiry alerts
 	Batch       *Batch
+	LocationID  *uint `gorm:"index"` // Set for alerts raised by location-level thresholds
 }
 
 // User represents a system user (for AdjustedBy in StockAdjustment, etc.)
@@ -164,6 +207,8 @@ type User struct {
 	PhoneNumber    string `gorm:"uniqueIndex"`
 	Address        string
 	LoyaltyAccount *LoyaltyAccount `json:"loyalty,omitempty"`
+	SupplierID     *uint           `gorm:"index"` // Set for supplier portal users; they only see this supplier's orders
+	Supplier       *Supplier
 }
 
 // GetID implements the Searchable interface for User.
@@ -181,16 +226,40 @@ func (u *User) GetEntityType() string {
 	return "user"
 }
 
-// ProductAlertSettings stores alert thresholds per product.
+// ProductAlertSettings stores stock thresholds for a product at one location, or with LocationID 0
+// for its total stock across all locations.
 type ProductAlertSettings struct {
 	gorm.Model
-	ProductID       uint `gorm:"uniqueIndex;not null"`
+	ProductID       uint `gorm:"not null;uniqueIndex:idx_alert_settings_product_location"`
 	Product         Product
-	LowStockLevel   int
-	OverStockLevel  int
+	LocationID      uint    `gorm:"not null;default:0;uniqueIndex:idx_alert_settings_product_location"` // 0 = all locations
+	LowStockLevel   float64 // Reorder point: a low-stock alert and reorder suggestion at or below it
+	SafetyStock     float64 // Buffer against demand and lead-time variation; the reorder point never falls below it
+	MaxStockLevel   float64 // Order-up-to level for reorder suggestions; 0 falls back to OverStockLevel
+	OverStockLevel  float64
 	ExpiryAlertDays int
 }
 
+// ReorderPoint is the stock level at or below which the product needs replenishing.
+func (s *ProductAlertSettings) ReorderPoint() float64 {
+	if s.SafetyStock > s.LowStockLevel {
+		return s.SafetyStock
+	}
+	return s.LowStockLevel
+}
+
+// OrderUpToLevel is the stock level a replenishment order should restore.
+func (s *ProductAlertSettings) OrderUpToLevel() float64 {
+	reorderPoint := s.ReorderPoint()
+	switch {
+	case s.MaxStockLevel > reorderPoint:
+		return s.MaxStockLevel
+	case s.OverStockLevel > reorderPoint:
+		return s.OverStockLevel
+	}
+	return reorderPoint * 3 // Default heuristic
+}
+
 // UserNotificationSettings stores user preferences for notifications.
 type UserNotificationSettings struct {
 	gorm.Model
@@ -212,6 +281,34 @@ type DemandForecast struct {
 	ConfidenceScore float64
 	Reasoning       string
 	GeneratedAt     time.Time
+	// Method is the forecasting method used (MOVING_AVERAGE, HOLT_WINTERS, CROSTON or AI); empty on older forecasts.
+	Method string `gorm:"index"`
+	// ActualDemand is what sold over the forecast period, recorded once the period has ended.
+	ActualDemand *float64
+	EvaluatedAt  *time.Time `gorm:"index"`
+}
+
+// PeriodDays is the length of the forecast period ("30_DAYS" etc.), or 0 when it cannot be parsed.
+func (f *DemandForecast) PeriodDays() int {
+	var days int
+	if _, err := fmt.Sscanf(f.ForecastPeriod, "%d_DAYS", &days); err != nil || days <= 0 {
+		return 0
+	}
+	return days
+}
+
+// PeriodEnd is when the forecast period, which starts when the forecast is generated, ends.
+func (f *DemandForecast) PeriodEnd() time.Time {
+	return f.GeneratedAt.AddDate(0, 0, f.PeriodDays())
+}
+
+// DailyDemand spreads the predicted demand evenly over the forecast period ("30_DAYS" etc.).
+func (f *DemandForecast) DailyDemand() float64 {
+	days := f.PeriodDays()
+	if days == 0 {
+		return 0
+	}
+	return float64(f.PredictedDemand) / float64(days)
 }
 
 // ReorderSuggestion represents a suggestion to reorder a product.
@@ -221,12 +318,29 @@ type ReorderSuggestion struct {
 	Product                Product
 	SupplierID             uint `gorm:"not null"`
 	Supplier               Supplier
-	CurrentStock           int
+	CurrentStock           float64
 	PredictedDemand        int
-	SuggestedOrderQuantity int `gorm:"not null"`
+	SuggestedOrderQuantity float64 `gorm:"not null"`
 	LeadTimeDays           int
 	Status                 string `gorm:"default:'PENDING'"` // PENDING, APPROVED, REJECTED, PO_CREATED
 	SuggestedAt            time.Time
+	PurchaseOrderID        *uint   `gorm:"index"`           // Purchase order the suggestion was ordered on
+	LocationID             uint    `gorm:"default:0;index"` // Location whose stock fell to its reorder point; 0 = all locations
+	UnitCost               float64 // Catalog cost from the chosen supplier; 0 falls back to the product's purchase price
+
+	// Inputs behind the suggested quantity, so buyers can see why it was proposed
+	PolicyType            string  // Reorder policy that sized the order
+	ServiceLevel          float64 // Target cycle service level of that policy
+	DailyDemand           float64 // Mean daily sales over the demand window
+	DemandStdDev          float64 // Standard deviation of daily sales
+	ExpectedLeadTimeDays  float64 // Learned mean lead time, else the catalog lead time
+	LeadTimeStdDev        float64 // Standard deviation of learned lead times; 0 without delivery history
+	ReviewPeriodDays      int     // Days between reviews under periodic review
+	SafetyStock           float64
+	ReorderPoint          float64
+	OrderUpToLevel        float64
+	EconomicOrderQuantity float64 // 0 unless the EOQ policy sized the order
+	Rationale             string  `gorm:"type:text"`
 }
 
 // PurchaseOrder represents a purchase order to a supplier.
@@ -234,14 +348,28 @@ type PurchaseOrder struct {
 	gorm.Model
 	SupplierID           uint `gorm:"not null"`
 	Supplier             Supplier
-	Status               string `gorm:"default:'DRAFT'"` // DRAFT, APPROVED, SENT, PARTIALLY_RECEIVED, RECEIVED, CANCELLED
+	Status               string `gorm:"default:'DRAFT'"` // DRAFT, PENDING_APPROVAL, APPROVED, SENT, PARTIALLY_RECEIVED, RECEIVED, CANCELLED
 	OrderDate            time.Time
 	ExpectedDeliveryDate *time.Time
 	ActualDeliveryDate   *time.Time
 	CreatedBy            uint  // UserID of the creator
 	ApprovedBy           *uint // UserID of the approver
 	ApprovedAt           *time.Time
+	SentAt               *time.Time          // When the order went to the supplier
+	SupplierResponse     string              `gorm:"index"` // "", CONFIRMED, CHANGES_PROPOSED, CHANGES_REJECTED
+	SupplierRespondedAt  *time.Time          // When the supplier confirmed or proposed changes
+	PromisedDeliveryDate *time.Time          // Delivery date the supplier committed to
+	LocationID           uint                `gorm:"default:0;index"` // Delivery location; 0 = each product's default location
 	PurchaseOrderItems   []PurchaseOrderItem `gorm:"foreignKey:PurchaseOrderID"`
+	Approvals            []POApproval        `gorm:"foreignKey:PurchaseOrderID"` // Sign-off steps of every submission, oldest first
+}
+
+// DeliveryLocation is where the product is received against this order.
+func (po *PurchaseOrder) DeliveryLocation(product *Product) uint {
+	if po.LocationID != 0 {
+		return po.LocationID
+	}
+	return product.LocationID
 }
 
 type StockTransfer struct {
@@ -252,10 +380,17 @@ type StockTransfer struct {
 	SourceLocation   Location
 	DestLocationID   uint `gorm:"not null"`
 	DestLocation     Location
-	Quantity         int    `gorm:"not null"`
-	Status           string `gorm:"default:'PENDING'"` // PENDING, COMPLETED, CANCELLED
-	TransferredBy    uint   // UserID of the person who initiated the transfer
+	Quantity         float64 `gorm:"not null"`
+	Status           string  `gorm:"default:'PENDING';index"` // DRAFT, PENDING, COMPLETED, CANCELLED
+	Origin           string  `gorm:"default:'MANUAL';index"`  // MANUAL, REBALANCE
+	BatchID          *uint   // Batch to ship from; rebalancing drafts pick the earliest-expiring surplus first
+	Reason           string
+	TransferredBy    uint // UserID of the person who initiated the transfer
 	TransferredAt    time.Time
+	ApprovedBy       *uint // UserID of the manager who approved a draft
+	ApprovedAt       *time.Time
+	ReceivedBy       *uint // UserID of the person who received the stock at the destination
+	ReceivedAt       *time.Time
 }
 
 type RefreshToken struct {
@@ -273,8 +408,8 @@ type PurchaseOrderItem struct {
 	PurchaseOrder    PurchaseOrder
 	ProductID        uint `gorm:"not null"`
 	Product          Product
-	OrderedQuantity  int     `gorm:"not null"`
-	ReceivedQuantity int     `gorm:"default:0"` // Quantity actually received
+	OrderedQuantity  float64 `gorm:"not null"`
+	ReceivedQuantity float64 `gorm:"default:0"` // Quantity actually received
 	UnitPrice        float64 `gorm:"not null"`
 }
 
@@ -366,9 +501,9 @@ type PurchaseReturnItem struct {
 	PurchaseReturn   PurchaseReturn
 	ProductID        uint `gorm:"not null"`
 	Product          Product
-	Quantity         int    `gorm:"not null"`
-	Reason           string `gorm:"not null"`
-	BatchID          *uint  // Specific batch being returned (critical for stock deduction)
+	Quantity         float64 `gorm:"not null"`
+	Reason           string  `gorm:"not null"`
+	BatchID          *uint   // Specific batch being returned (critical for stock deduction)
 	Batch            *Batch
 }
 
@@ -400,4 +535,23 @@ type Promotion struct {
 	Category      *Category
 	SubCategoryID *uint `gorm:"index"`
 	SubCategory   *SubCategory
+
+	// Batch markdowns discount only the units sold from one batch, ahead of product and category promotions.
+	BatchID        *uint `gorm:"index"`
+	Batch          *Batch
+	MarkdownRuleID *uint `gorm:"index"` // Set on markdowns created by an expiry rule
+}
+
+// DiscountedPrice applies the promotion's discount to price, never going below zero.
+func (p *Promotion) DiscountedPrice(price float64) float64 {
+	switch p.DiscountType {
+	case "PERCENTAGE":
+		price -= price * (p.DiscountValue / 100.0)
+	case "FIXED_AMOUNT":
+		price -= p.DiscountValue
+	}
+	if price < 0 {
+		return 0
+	}
+	return price
 }
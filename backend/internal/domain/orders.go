@@ -33,11 +33,16 @@ type OrderItem struct {
 	Order       Order
 	ProductID   uint `gorm:"not null;index"`
 	Product     Product
-	Quantity    int     `gorm:"not null"`
+	Quantity    float64 `gorm:"not null"`
 	UnitPrice   float64 `gorm:"not null"`
 	TotalPrice  float64 `gorm:"not null"`
 	IsReturned  bool    `gorm:"default:false"`
-	ReturnedQty int     `gorm:"default:0"`
+	ReturnedQty float64 `gorm:"default:0"`
+	// SerialNumbers lists the units sold on this line (comma-separated) for serialized products.
+	SerialNumbers string
+	// UnitCost and TotalCost are the cost of goods sold on this line, stamped from the cost layers at sale time.
+	UnitCost  float64 `gorm:"default:0"`
+	TotalCost float64 `gorm:"default:0"`
 }
 
 // Return represents a return request or processed return.
@@ -64,7 +69,9 @@ type ReturnItem struct {
 	OrderItem   OrderItem
 	ProductID   uint `gorm:"not null"`
 	Product     Product
-	Quantity    int    `gorm:"not null"`
-	Condition   string `gorm:"default:'GOOD'"` // GOOD, DAMAGED, OPENED
-	Reason      string `gorm:"not null"`
+	Quantity    float64 `gorm:"not null"`
+	Condition   string  `gorm:"default:'GOOD'"` // GOOD, DAMAGED, OPENED
+	Reason      string  `gorm:"not null"`
+	// SerialNumbers lists the returned units (comma-separated) for serialized products.
+	SerialNumbers string
 }
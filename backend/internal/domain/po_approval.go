@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Purchase order approval step statuses.
+const (
+	ApprovalStatusPending   = "PENDING"
+	ApprovalStatusApproved  = "APPROVED"
+	ApprovalStatusRejected  = "REJECTED"
+	ApprovalStatusCancelled = "CANCELLED" // Left undecided when an earlier step was rejected or the order was cancelled
+)
+
+// POApprovalRule requires sign-off from a role on purchase orders matching its amount, supplier and
+// category. Every matching rule adds a step; steps are signed off in level order.
+type POApprovalRule struct {
+	gorm.Model
+	Name           string  `gorm:"not null"`
+	Level          int     `gorm:"not null;default:1"` // Steps of lower levels are signed off first
+	MinAmount      float64 `gorm:"default:0"`          // Applies to orders worth at least this much
+	SupplierID     *uint   `gorm:"index"`              // nil = orders from any supplier
+	Supplier       *Supplier
+	CategoryID     *uint `gorm:"index"` // nil = any order; otherwise orders with a product in this category
+	Category       *Category
+	ApproverRoleID uint `gorm:"not null"`
+	ApproverRole   Role
+	IsActive       bool `gorm:"default:true;index"`
+}
+
+// Matches reports whether the rule applies to an order of the given value from the supplier, containing
+// products of the given categories.
+func (r *POApprovalRule) Matches(amount float64, supplierID uint, categoryIDs map[uint]bool) bool {
+	if !r.IsActive || amount < r.MinAmount {
+		return false
+	}
+	if r.SupplierID != nil && *r.SupplierID != supplierID {
+		return false
+	}
+	return r.CategoryID == nil || categoryIDs[*r.CategoryID]
+}
+
+// POApproval is one sign-off step on a purchase order. Steps are kept after they are decided, so the
+// steps of all rounds form the order's approval history.
+type POApproval struct {
+	gorm.Model
+	PurchaseOrderID uint  `gorm:"not null;index"`
+	Round           int   `gorm:"not null;default:1"` // Submission the step belongs to; a rejection ends the round
+	Level           int   `gorm:"not null"`
+	RuleID          *uint // nil for the default single sign-off and automatic approvals
+	Rule            *POApprovalRule
+	ApproverRoleID  *uint // nil = anyone allowed to approve purchase orders
+	ApproverRole    *Role
+	OrderValue      float64 // Value of the order when it was submitted
+	Status          string  `gorm:"not null;default:'PENDING';index"` // PENDING, APPROVED, REJECTED, CANCELLED
+	DecidedBy       *uint
+	DecidedAt       *time.Time
+	Comments        string `gorm:"type:text"`
+}
+
+// BuildApprovalChain returns the pending steps for an order: one per matching rule, in level order,
+// with a role asked only once per level. Orders no rule matches need a single sign-off from anyone
+// allowed to approve purchase orders.
+func BuildApprovalChain(rules []POApprovalRule, amount float64, supplierID uint, categoryIDs map[uint]bool, round int) []POApproval {
+	matched := make([]POApprovalRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Matches(amount, supplierID, categoryIDs) {
+			matched = append(matched, rule)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Level < matched[j].Level })
+
+	type levelRole struct {
+		level int
+		role  uint
+	}
+	seen := make(map[levelRole]bool)
+	steps := make([]POApproval, 0, len(matched))
+	for _, rule := range matched {
+		key := levelRole{level: rule.Level, role: rule.ApproverRoleID}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		ruleID, roleID := rule.ID, rule.ApproverRoleID
+		steps = append(steps, POApproval{Round: round, Level: rule.Level, RuleID: &ruleID, ApproverRoleID: &roleID, OrderValue: amount, Status: ApprovalStatusPending})
+	}
+	if len(steps) == 0 {
+		steps = append(steps, POApproval{Round: round, Level: 1, OrderValue: amount, Status: ApprovalStatusPending})
+	}
+	return steps
+}
+
+// OrderValue is the value of the order's lines at their unit prices.
+func (po *PurchaseOrder) OrderValue() float64 {
+	var total float64
+	for _, item := range po.PurchaseOrderItems {
+		total += item.OrderedQuantity * item.UnitPrice
+	}
+	return total
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "math"
+
+// MaxQuantityPrecision is the largest number of decimal places a product may track.
+const MaxQuantityPrecision = 3
+
+// RoundQuantity rounds q to the given number of decimal places.
+// Precision is clamped to the range [0, MaxQuantityPrecision].
+func RoundQuantity(q float64, precision int) float64 {
+	if precision < 0 {
+		precision = 0
+	}
+	if precision > MaxQuantityPrecision {
+		precision = MaxQuantityPrecision
+	}
+	factor := math.Pow(10, float64(precision))
+	return math.Round(q*factor) / factor
+}
+
+// RoundQuantity rounds q to the product's configured quantity precision.
+func (p *Product) RoundQuantity(q float64) float64 {
+	return RoundQuantity(q, p.QuantityPrecision)
+}
+
+// IsValidQuantity reports whether q is positive and has no more decimal places
+// than the product allows (e.g., 1.25 kg is valid at precision 3, 1.5 units is not at precision 0).
+func (p *Product) IsValidQuantity(q float64) bool {
+	if q <= 0 {
+		return false
+	}
+	return math.Abs(p.RoundQuantity(q)-q) < 1e-9
+}
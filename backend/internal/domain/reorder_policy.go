@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// Reorder policy types.
+const (
+	// PolicyMinMax orders up to the maximum level whenever stock falls to the reorder point.
+	PolicyMinMax = "MIN_MAX"
+	// PolicyEOQ orders the economic order quantity whenever stock falls to the reorder point.
+	PolicyEOQ = "EOQ"
+	// PolicyPeriodicReview orders up to a level covering the review period and lead time at every review.
+	PolicyPeriodicReview = "PERIODIC_REVIEW"
+)
+
+// DefaultServiceLevel is the cycle service level planned for when no policy applies.
+const DefaultServiceLevel = 0.95
+
+// ReorderPolicy configures how reorder suggestions are sized for the products in a category. The
+// policy without a category is the default for every other product.
+type ReorderPolicy struct {
+	gorm.Model
+	CategoryID       *uint `gorm:"uniqueIndex"` // nil = default policy; kept single by a partial index
+	Category         *Category
+	PolicyType       string  `gorm:"not null;default:'MIN_MAX'"` // MIN_MAX, EOQ or PERIODIC_REVIEW
+	ServiceLevel     float64 `gorm:"not null"`                   // Target probability of not running out before a delivery, e.g. 0.95
+	OrderingCost     float64 // Fixed cost of placing one purchase order (EOQ)
+	HoldingCostRate  float64 // Yearly cost of holding stock as a fraction of its unit cost, e.g. 0.25 (EOQ)
+	ReviewPeriodDays int     // Days between reviews (PERIODIC_REVIEW)
+}
+
+// DefaultReorderPolicy is used when neither the product's category nor the default has a policy:
+// min/max at the default service level.
+func DefaultReorderPolicy() ReorderPolicy {
+	return ReorderPolicy{PolicyType: PolicyMinMax, ServiceLevel: DefaultServiceLevel}
+}
+
+// Validate checks that the policy has the costs or review period its type needs.
+func (p *ReorderPolicy) Validate() error {
+	if p.ServiceLevel < 0.5 || p.ServiceLevel >= 1 {
+		return fmt.Errorf("serviceLevel must be at least 0.5 and below 1")
+	}
+	switch p.PolicyType {
+	case PolicyMinMax:
+	case PolicyEOQ:
+		if p.OrderingCost <= 0 || p.HoldingCostRate <= 0 {
+			return fmt.Errorf("EOQ policies need a positive orderingCost and holdingCostRate")
+		}
+	case PolicyPeriodicReview:
+		if p.ReviewPeriodDays <= 0 {
+			return fmt.Errorf("periodic review policies need a positive reviewPeriodDays")
+		}
+	default:
+		return fmt.Errorf("policyType must be %s, %s or %s", PolicyMinMax, PolicyEOQ, PolicyPeriodicReview)
+	}
+	return nil
+}
+
+// ZScore is the standard normal quantile of the service level: the number of standard deviations of
+// demand over the lead time that safety stock has to cover.
+func (p *ReorderPolicy) ZScore() float64 {
+	return math.Sqrt2 * math.Erfinv(2*p.ServiceLevel-1)
+}
+
+// EconomicOrderQuantity is the order size minimising the yearly cost of ordering and holding stock:
+// sqrt(2DS / H) for yearly demand D, ordering cost S and yearly holding cost H of one unit. It returns
+// 0 when there is no demand or no holding cost to balance.
+func (p *ReorderPolicy) EconomicOrderQuantity(dailyDemand, unitCost float64) float64 {
+	holding := p.HoldingCostRate * unitCost
+	if dailyDemand <= 0 || holding <= 0 || p.OrderingCost <= 0 {
+		return 0
+	}
+	return math.Sqrt(2 * dailyDemand * 365 * p.OrderingCost / holding)
+}
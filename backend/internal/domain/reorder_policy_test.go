@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorderPolicyZScore(t *testing.T) {
+	tests := []struct {
+		serviceLevel float64
+		want         float64
+	}{
+		{0.5, 0},
+		{0.9, 1.2816},
+		{0.95, 1.6449},
+		{0.975, 1.96},
+		{0.99, 2.3263},
+	}
+	for _, tt := range tests {
+		policy := ReorderPolicy{ServiceLevel: tt.serviceLevel}
+		assert.InDelta(t, tt.want, policy.ZScore(), 1e-4, "service level %g", tt.serviceLevel)
+	}
+}
+
+func TestReorderPolicyEconomicOrderQuantity(t *testing.T) {
+	policy := ReorderPolicy{PolicyType: PolicyEOQ, OrderingCost: 50, HoldingCostRate: 0.25}
+
+	tests := []struct {
+		name        string
+		policy      ReorderPolicy
+		dailyDemand float64
+		unitCost    float64
+		want        float64
+	}{
+		// sqrt(2 * 3650 * 50 / (0.25 * 5))
+		{"yearly demand against holding cost", policy, 10, 5, math.Sqrt(292000)},
+		{"four times the demand doubles the quantity", policy, 40, 5, 2 * math.Sqrt(292000)},
+		{"no demand", policy, 0, 5, 0},
+		{"no unit cost", policy, 10, 0, 0},
+		{"no ordering cost", ReorderPolicy{HoldingCostRate: 0.25}, 10, 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, tt.policy.EconomicOrderQuantity(tt.dailyDemand, tt.unitCost), 1e-9)
+		})
+	}
+}
+
+func TestReorderPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ReorderPolicy
+		wantErr bool
+	}{
+		{"min/max", ReorderPolicy{PolicyType: PolicyMinMax, ServiceLevel: 0.95}, false},
+		{"service level too low", ReorderPolicy{PolicyType: PolicyMinMax, ServiceLevel: 0.4}, true},
+		{"service level of one", ReorderPolicy{PolicyType: PolicyMinMax, ServiceLevel: 1}, true},
+		{"EOQ with costs", ReorderPolicy{PolicyType: PolicyEOQ, ServiceLevel: 0.95, OrderingCost: 50, HoldingCostRate: 0.2}, false},
+		{"EOQ without holding cost", ReorderPolicy{PolicyType: PolicyEOQ, ServiceLevel: 0.95, OrderingCost: 50}, true},
+		{"periodic review", ReorderPolicy{PolicyType: PolicyPeriodicReview, ServiceLevel: 0.95, ReviewPeriodDays: 7}, false},
+		{"periodic review without a period", ReorderPolicy{PolicyType: PolicyPeriodicReview, ServiceLevel: 0.95}, true},
+		{"unknown type", ReorderPolicy{PolicyType: "JIT", ServiceLevel: 0.95}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
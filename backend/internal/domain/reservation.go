@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReservationStatusActive   = "ACTIVE"
+	ReservationStatusConsumed = "CONSUMED"
+	ReservationStatusReleased = "RELEASED"
+	ReservationStatusExpired  = "EXPIRED"
+)
+
+// StockReservation allocates stock at a location to a pending order, held cart or other
+// commitment so that it is no longer offered as available.
+type StockReservation struct {
+	gorm.Model
+	ProductID  uint `gorm:"not null;index:idx_reservation_product_location"`
+	Product    Product
+	LocationID uint `gorm:"not null;index:idx_reservation_product_location"`
+	Location   Location
+	Quantity   float64    `gorm:"not null"`
+	Status     string     `gorm:"default:'ACTIVE';index"` // ACTIVE, CONSUMED, RELEASED, EXPIRED
+	SourceType string     `gorm:"not null;index"`         // ORDER, CART, MANUAL
+	SourceRef  string     `gorm:"index"`                  // e.g. external order number or cart ID
+	ExpiresAt  *time.Time `gorm:"index"`                  // Nil holds until released
+	ReservedBy uint
+	ReleasedAt *time.Time
+	Notes      string
+}
+
+// IsActive reports whether the reservation still holds stock at the given time.
+func (r *StockReservation) IsActive(now time.Time) bool {
+	if r.Status != ReservationStatusActive {
+		return false
+	}
+	return r.ExpiresAt == nil || r.ExpiresAt.After(now)
+}
+
+// StockPosition summarises a product's stock at one location (or all locations when LocationID is 0).
+type StockPosition struct {
+	ProductID   uint    `json:"productId"`
+	LocationID  uint    `json:"locationId"`
+	OnHand      float64 `json:"onHand"`
+	Reserved    float64 `json:"reserved"`    // Active reservations
+	InTransit   float64 `json:"inTransit"`   // Pending transfers out of the location, still in its batches until received
+	Quarantined float64 `json:"quarantined"` // Expired stock awaiting write-off
+	Available   float64 `json:"available"`
+}
+
+// CalculateAvailable sets Available to on-hand stock less reservations, outbound transfers and
+// quarantined stock, never below zero.
+func (sp *StockPosition) CalculateAvailable(precision int) {
+	sp.OnHand = RoundQuantity(sp.OnHand, precision)
+	sp.Reserved = RoundQuantity(sp.Reserved, precision)
+	sp.InTransit = RoundQuantity(sp.InTransit, precision)
+	sp.Quarantined = RoundQuantity(sp.Quarantined, precision)
+	sp.Available = RoundQuantity(sp.OnHand-sp.Reserved-sp.InTransit-sp.Quarantined, precision)
+	if sp.Available < 0 {
+		sp.Available = 0
+	}
+}
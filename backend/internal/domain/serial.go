@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Serial number statuses.
+const (
+	SerialStatusInStock   = "IN_STOCK"
+	SerialStatusSold      = "SOLD"
+	SerialStatusDefective = "DEFECTIVE"
+	SerialStatusRemoved   = "REMOVED"
+)
+
+// SerialNumber tracks an individual unit of a serialized product (serial number or IMEI).
+type SerialNumber struct {
+	gorm.Model
+	ProductID         uint `gorm:"not null;uniqueIndex:idx_product_serial"`
+	Product           Product
+	SerialNumber      string `gorm:"not null;uniqueIndex:idx_product_serial;index"` // Serial or IMEI
+	BatchID           *uint  `gorm:"index"`                                         // Batch the unit was received into
+	LocationID        uint   `gorm:"index"`
+	Status            string `gorm:"default:'IN_STOCK';index"` // IN_STOCK, SOLD, DEFECTIVE, REMOVED
+	OrderItemID       *uint  `gorm:"index"`                    // Sale the unit currently belongs to
+	SoldAt            *time.Time
+	WarrantyExpiresAt *time.Time
+	Events            []SerialNumberEvent
+}
+
+// SerialNumberEvent records one step in a unit's history.
+type SerialNumberEvent struct {
+	gorm.Model
+	SerialNumberID uint   `gorm:"not null;index"`
+	EventType      string `gorm:"not null"` // RECEIVED, STOCK_IN, SOLD, RETURNED, REMOVED, TRANSFERRED
+	ReferenceType  string // PURCHASE_ORDER, BATCH, ADJUSTMENT, ORDER, RETURN, TRANSFER
+	ReferenceID    uint   // ID of the referenced record
+	Notes          string
+	UserID         uint
+	OccurredAt     time.Time `gorm:"index"`
+}
+
+// WarrantyActive reports whether the unit is still under warranty at the given time.
+func (s *SerialNumber) WarrantyActive(at time.Time) bool {
+	return s.WarrantyExpiresAt != nil && at.Before(*s.WarrantyExpiresAt)
+}
+
+// NormalizeSerialNumbers trims the given serials, drops blanks and rejects duplicates.
+func NormalizeSerialNumbers(serials []string) ([]string, error) {
+	seen := make(map[string]bool, len(serials))
+	normalized := make([]string, 0, len(serials))
+	for _, s := range serials {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if seen[s] {
+			return nil, fmt.Errorf("duplicate serial number %s", s)
+		}
+		seen[s] = true
+		normalized = append(normalized, s)
+	}
+	return normalized, nil
+}
+
+// JoinSerialNumbers stores a serial list in a comma-separated column.
+func JoinSerialNumbers(serials []string) string {
+	return strings.Join(serials, ",")
+}
+
+// SplitSerialNumbers parses a comma-separated serial column.
+func SplitSerialNumbers(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InventorySnapshot records a product's closing stock at one location for one day, with the
+// day's movements, so history and coverage can be read without replaying the ledger.
+type InventorySnapshot struct {
+	gorm.Model
+	SnapshotDate time.Time `gorm:"type:date;not null;uniqueIndex:idx_snapshot_day_product_location"`
+	ProductID    uint      `gorm:"not null;uniqueIndex:idx_snapshot_day_product_location;index"`
+	Product      Product
+	LocationID   uint `gorm:"not null;uniqueIndex:idx_snapshot_day_product_location"`
+	Location     Location
+	Quantity     float64 // Closing on-hand quantity
+	Value        float64 // Closing stock value at layer (or average) cost
+	QuantityIn   float64 // Stock received during the day
+	QuantityOut  float64 // Stock issued during the day, including sales
+	QuantitySold float64 // Stock sold during the day
+}
+
+// StockLevelPoint is one day in a product's stock history.
+type StockLevelPoint struct {
+	Date         time.Time `json:"date"`
+	Quantity     float64   `json:"quantity"`
+	Value        float64   `json:"value"`
+	QuantityIn   float64   `json:"quantityIn"`
+	QuantityOut  float64   `json:"quantityOut"`
+	QuantitySold float64   `json:"quantitySold"`
+}
+
+// SnapshotDay truncates a time to the calendar day used as a snapshot key.
+func SnapshotDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DaysOfCover returns how many days the on-hand quantity lasts at the average daily sales rate.
+// It returns nil when there are no sales to measure against (cover is unbounded).
+func DaysOfCover(onHand, averageDailySales float64) *float64 {
+	if averageDailySales <= 0 {
+		return nil
+	}
+	if onHand < 0 {
+		onHand = 0
+	}
+	days := math.Round(onHand/averageDailySales*10) / 10
+	return &days
+}
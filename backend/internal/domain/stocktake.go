@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stock-take session statuses.
+const (
+	StockTakeStatusOpen      = "OPEN"
+	StockTakeStatusSubmitted = "SUBMITTED"
+	StockTakeStatusApproved  = "APPROVED"
+	StockTakeStatusCancelled = "CANCELLED"
+)
+
+// StockTakeSession is a physical count of a location and/or category. System quantities are frozen
+// into its lines when the session opens so that counts are compared against a fixed snapshot.
+type StockTakeSession struct {
+	gorm.Model
+	Name        string `gorm:"not null"`
+	Type        string `gorm:"default:'FULL';index"` // FULL, CYCLE
+	LocationID  *uint  `gorm:"index"`                // Optional scope
+	Location    *Location
+	CategoryID  *uint `gorm:"index"` // Optional scope
+	Category    *Category
+	ABCClass    string // Set for cycle counts generated from the ABC plan
+	BlindCount  bool   // Hide system quantities from counters
+	Status      string `gorm:"default:'OPEN';index"` // OPEN, SUBMITTED, APPROVED, CANCELLED
+	CreatedBy   uint   `gorm:"not null"`
+	SubmittedBy *uint
+	SubmittedAt *time.Time
+	ApprovedBy  *uint
+	ApprovedAt  *time.Time
+	Notes       string
+	Lines       []StockTakeLine `gorm:"foreignKey:SessionID"`
+}
+
+// StockTakeLine holds the frozen system quantity and the aggregated count for one product.
+type StockTakeLine struct {
+	gorm.Model
+	SessionID        uint `gorm:"not null;index;uniqueIndex:idx_stock_take_line"`
+	ProductID        uint `gorm:"not null;uniqueIndex:idx_stock_take_line"`
+	Product          Product
+	LocationID       uint     `gorm:"index"` // Location adjustments are posted to
+	SystemQuantity   float64  `gorm:"not null"`
+	CountedQuantity  *float64 // Nil until the first count is recorded
+	UnitCost         float64  // Cost used to value the variance
+	AddedDuringCount bool     `gorm:"default:false"` // Product was found but not in the snapshot
+	AdjustmentID     *uint    // Posted correction on approval
+	LastCountedAt    *time.Time
+	Counts           []StockTakeCount `gorm:"foreignKey:LineID"`
+}
+
+// StockTakeCount is a single count entry; several counters may add entries to the same line.
+type StockTakeCount struct {
+	gorm.Model
+	SessionID uint    `gorm:"not null;index"`
+	LineID    uint    `gorm:"not null;index"`
+	ProductID uint    `gorm:"not null"`
+	Quantity  float64 `gorm:"not null"` // Increment applied to the line (negative for corrections)
+	Source    string  `gorm:"not null"` // SCAN, MANUAL, SET
+	Barcode   string
+	CountedBy uint      `gorm:"not null;index"`
+	CountedAt time.Time `gorm:"index"`
+}
+
+// Variance returns counted minus system quantity; uncounted lines have no variance.
+func (l *StockTakeLine) Variance() float64 {
+	if l.CountedQuantity == nil {
+		return 0
+	}
+	return *l.CountedQuantity - l.SystemQuantity
+}
+
+// ABC classes.
+const (
+	ABCClassA = "A"
+	ABCClassB = "B"
+	ABCClassC = "C"
+)
+
+// ClassifyABC ranks products by consumption value and assigns A to those making up the first
+// aThreshold percent of cumulative value, B up to bThreshold percent, and C to the rest
+// (including products with no consumption).
+func ClassifyABC(values map[uint]float64, aThreshold, bThreshold float64) map[uint]string {
+	type entry struct {
+		productID uint
+		value     float64
+	}
+	entries := make([]entry, 0, len(values))
+	var total float64
+	for id, v := range values {
+		entries = append(entries, entry{id, v})
+		if v > 0 {
+			total += v
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].value == entries[j].value {
+			return entries[i].productID < entries[j].productID
+		}
+		return entries[i].value > entries[j].value
+	})
+
+	classes := make(map[uint]string, len(entries))
+	var cumulative float64
+	for _, e := range entries {
+		if e.value <= 0 || total == 0 {
+			classes[e.productID] = ABCClassC
+			continue
+		}
+		// Classify on the share before adding this product so the top item is always A
+		share := cumulative / total * 100
+		cumulative += e.value
+		switch {
+		case share < aThreshold:
+			classes[e.productID] = ABCClassA
+		case share < bThreshold:
+			classes[e.productID] = ABCClassB
+		default:
+			classes[e.productID] = ABCClassC
+		}
+	}
+	return classes
+}
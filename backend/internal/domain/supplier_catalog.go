@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"math"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// DefaultLeadTimeDays is assumed for products with no supplier catalog entry.
+const DefaultLeadTimeDays = 7
+
+// ProductSupplier is one supplier's catalog entry for a product: what the supplier calls it, what it
+// costs and how it has to be ordered. A product can be bought from several suppliers.
+type ProductSupplier struct {
+	gorm.Model
+	ProductID            uint `gorm:"not null;uniqueIndex:idx_product_supplier"`
+	Product              Product
+	SupplierID           uint `gorm:"not null;uniqueIndex:idx_product_supplier;index"`
+	Supplier             Supplier
+	SupplierSKU          string  `gorm:"index"`
+	UnitCost             float64 `gorm:"not null"`
+	MinimumOrderQuantity float64 `gorm:"default:0"` // 0 = no minimum
+	PackSize             float64 `gorm:"default:0"` // Orders are placed in multiples of this; 0 = any quantity
+	LeadTimeDays         int     `gorm:"default:7"`
+	IsPreferred          bool    `gorm:"default:false"` // At most one preferred supplier per product
+}
+
+// OrderQuantity rounds qty up to the supplier's minimum order quantity and to a whole number of packs.
+func (ps *ProductSupplier) OrderQuantity(qty float64) float64 {
+	if qty < ps.MinimumOrderQuantity {
+		qty = ps.MinimumOrderQuantity
+	}
+	if ps.PackSize > 0 {
+		// Round first so float noise (e.g. 24.000000001) does not add a whole pack
+		packs := RoundQuantity(qty/ps.PackSize, MaxQuantityPrecision)
+		qty = math.Ceil(packs) * ps.PackSize
+	}
+	return qty
+}
+
+// OrderCost is the cost of buying qty from the supplier once it is rounded to an orderable quantity.
+func (ps *ProductSupplier) OrderCost(qty float64) float64 {
+	return ps.OrderQuantity(qty) * ps.UnitCost
+}
+
+// ChooseSupplier picks the catalog entry to order qty from. The preferred supplier is used when
+// there is one; otherwise the entry with the lowest cost for the order (after minimums and pack
+// rounding) wins, the shorter lead time breaking ties. When the order is urgent the shortest lead
+// time wins instead, cost breaking ties. It returns nil for an empty catalog.
+func ChooseSupplier(entries []ProductSupplier, qty float64, urgent bool) *ProductSupplier {
+	if len(entries) == 0 {
+		return nil
+	}
+	ranked := make([]ProductSupplier, len(entries))
+	copy(ranked, entries)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		costA, costB := a.OrderCost(qty), b.OrderCost(qty)
+		if urgent && a.LeadTimeDays != b.LeadTimeDays {
+			return a.LeadTimeDays < b.LeadTimeDays
+		}
+		if !urgent && a.IsPreferred != b.IsPreferred {
+			return a.IsPreferred
+		}
+		if costA != costB {
+			return costA < costB
+		}
+		if a.LeadTimeDays != b.LeadTimeDays {
+			return a.LeadTimeDays < b.LeadTimeDays
+		}
+		return a.IsPreferred && !b.IsPreferred
+	})
+	return &ranked[0]
+}
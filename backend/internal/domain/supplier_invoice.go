@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Supplier invoice statuses.
+const (
+	InvoiceStatusMatched       = "MATCHED"   // Every line agrees with the purchase order and receipts, or its mismatch was resolved
+	InvoiceStatusException     = "EXCEPTION" // At least one unresolved mismatch; the invoice cannot be approved
+	InvoiceStatusApproved      = "APPROVED"
+	InvoiceStatusPartiallyPaid = "PARTIALLY_PAID"
+	InvoiceStatusPaid          = "PAID"
+)
+
+// Supplier invoice line match results.
+const (
+	LineMatchMatched          = "MATCHED"
+	LineMatchPriceMismatch    = "PRICE_MISMATCH"
+	LineMatchQuantityMismatch = "QUANTITY_MISMATCH"
+	LineMatchBothMismatch     = "PRICE_AND_QUANTITY_MISMATCH"
+)
+
+// SupplierInvoice is a supplier's bill for goods on a purchase order. Its lines are matched against the
+// order's prices and the quantities received (three-way matching) before it can be approved for payment.
+type SupplierInvoice struct {
+	gorm.Model
+	SupplierID      uint `gorm:"not null;uniqueIndex:idx_supplier_invoice_number"`
+	Supplier        Supplier
+	InvoiceNumber   string `gorm:"not null;uniqueIndex:idx_supplier_invoice_number"` // The supplier's own number
+	PurchaseOrderID uint   `gorm:"not null;index"`
+	PurchaseOrder   PurchaseOrder
+	InvoiceDate     time.Time
+	DueDate         time.Time `gorm:"index"`
+	Subtotal        float64   // Sum of the line totals
+	TaxAmount       float64
+	TotalAmount     float64
+	AmountPaid      float64 `gorm:"default:0"`
+	Status          string  `gorm:"not null;default:'MATCHED';index"` // MATCHED, EXCEPTION, APPROVED, PARTIALLY_PAID, PAID
+	Notes           string
+	CreatedBy       uint
+	ApprovedBy      *uint
+	ApprovedAt      *time.Time
+	PaidAt          *time.Time            // When the invoice was paid in full
+	Lines           []SupplierInvoiceLine `gorm:"foreignKey:SupplierInvoiceID"`
+}
+
+// Outstanding is the amount still to be paid.
+func (inv *SupplierInvoice) Outstanding() float64 {
+	return math.Max(0, inv.TotalAmount-inv.AmountPaid)
+}
+
+// SupplierInvoiceLine bills a quantity of one purchase order line at a unit price. The match fields
+// record what the line was compared with when it was last matched.
+type SupplierInvoiceLine struct {
+	gorm.Model
+	SupplierInvoiceID   uint `gorm:"not null;index"`
+	PurchaseOrderItemID uint `gorm:"not null;index"`
+	ProductID           uint `gorm:"not null"`
+	Product             Product
+	Quantity            float64 `gorm:"not null"`
+	UnitPrice           float64 `gorm:"not null"`
+	LineTotal           float64
+
+	OrderedUnitPrice           float64 // PurchaseOrderItem.UnitPrice
+	ReceivedQuantity           float64 // PurchaseOrderItem.ReceivedQuantity
+	PreviouslyInvoicedQuantity float64 // Billed for the same order line on other invoices
+	PriceVariance              float64 // Invoiced minus ordered unit price
+	QuantityVariance           float64 // Invoiced quantity beyond what was received and not yet invoiced
+	MatchStatus                string  `gorm:"index"` // MATCHED, PRICE_MISMATCH, QUANTITY_MISMATCH, PRICE_AND_QUANTITY_MISMATCH
+	MatchNotes                 string
+	ResolvedBy                 *uint
+	ResolvedAt                 *time.Time
+	ResolutionNote             string
+}
+
+// Blocking reports whether the line holds up approval: it did not match and nobody resolved it.
+func (l *SupplierInvoiceLine) Blocking() bool {
+	return l.MatchStatus != LineMatchMatched && l.ResolvedAt == nil
+}
+
+// Match compares the line with its purchase order line. The price may differ from the ordered price
+// by the tolerance either way; the quantity may exceed what was received and not yet billed on earlier
+// invoices by the quantity tolerance. Billing less than was received is a partial invoice, not a mismatch.
+// A resolution is kept only while the line's outcome stays the same.
+func (l *SupplierInvoiceLine) Match(item *PurchaseOrderItem, previouslyInvoiced float64, tolerance *InvoiceToleranceRule) {
+	prevStatus, prevPrice, prevQuantity := l.MatchStatus, l.PriceVariance, l.QuantityVariance
+	l.OrderedUnitPrice = item.UnitPrice
+	l.ReceivedQuantity = item.ReceivedQuantity
+	l.PreviouslyInvoicedQuantity = previouslyInvoiced
+	l.PriceVariance = RoundMoney(l.UnitPrice - item.UnitPrice)
+	available := math.Max(0, item.ReceivedQuantity-previouslyInvoiced)
+	l.QuantityVariance = math.Max(0, l.Quantity-available)
+
+	var notes []string
+	priceOff := math.Abs(l.PriceVariance) > tolerance.PriceTolerance(item.UnitPrice)
+	if priceOff {
+		notes = append(notes, fmt.Sprintf("unit price %.2f differs from the ordered %.2f", l.UnitPrice, item.UnitPrice))
+	}
+	quantityOff := l.QuantityVariance > available*tolerance.QuantityPercent/100
+	if quantityOff {
+		notes = append(notes, fmt.Sprintf("billed %g but only %g received and not yet invoiced", l.Quantity, available))
+	}
+
+	switch {
+	case priceOff && quantityOff:
+		l.MatchStatus = LineMatchBothMismatch
+	case priceOff:
+		l.MatchStatus = LineMatchPriceMismatch
+	case quantityOff:
+		l.MatchStatus = LineMatchQuantityMismatch
+	default:
+		l.MatchStatus = LineMatchMatched
+	}
+	l.MatchNotes = ""
+	for i, note := range notes {
+		if i > 0 {
+			l.MatchNotes += "; "
+		}
+		l.MatchNotes += note
+	}
+	if l.MatchStatus != prevStatus || l.PriceVariance != prevPrice || l.QuantityVariance != prevQuantity {
+		// A different mismatch needs a fresh resolution
+		l.ResolvedBy, l.ResolvedAt, l.ResolutionNote = nil, nil, ""
+	}
+}
+
+// InvoiceToleranceRule sets how far invoices may deviate from purchase orders and receipts before a
+// line is flagged. The rule without a supplier is the default for every other supplier.
+type InvoiceToleranceRule struct {
+	gorm.Model
+	SupplierID      *uint `gorm:"uniqueIndex"` // nil = default rule
+	Supplier        *Supplier
+	PricePercent    float64 // Unit price may differ from the ordered price by this percentage
+	PriceAmount     float64 // or by this amount per unit, whichever allows more
+	QuantityPercent float64 // Quantity may exceed what was received and not yet invoiced by this percentage
+}
+
+// PriceTolerance is the largest unit price difference accepted on a line ordered at orderedPrice.
+func (r *InvoiceToleranceRule) PriceTolerance(orderedPrice float64) float64 {
+	return math.Max(r.PriceAmount, math.Abs(orderedPrice)*r.PricePercent/100) + 1e-9
+}
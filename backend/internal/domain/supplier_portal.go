@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Supplier responses to a sent purchase order. An empty response means the supplier has not answered yet.
+const (
+	SupplierResponseConfirmed       = "CONFIRMED"        // Accepted as ordered, or with changes the buyer accepted
+	SupplierResponseChangesProposed = "CHANGES_PROPOSED" // A change proposal awaits the buyer's review
+	SupplierResponseChangesRejected = "CHANGES_REJECTED" // The buyer turned the proposal down; the order stands as sent
+)
+
+// Change proposal statuses.
+const (
+	ProposalStatusPending  = "PENDING"
+	ProposalStatusAccepted = "ACCEPTED"
+	ProposalStatusRejected = "REJECTED"
+)
+
+// POChangeProposal is a supplier's counter-offer to a sent purchase order: different quantities on some
+// lines, a different delivery date, or both. Accepting it changes the order.
+type POChangeProposal struct {
+	gorm.Model
+	PurchaseOrderID      uint       `gorm:"not null;index"`
+	SupplierID           uint       `gorm:"not null;index"`
+	ProposedDeliveryDate *time.Time // nil = the expected delivery date stays
+	Notes                string
+	Status               string `gorm:"not null;default:'PENDING';index"` // PENDING, ACCEPTED, REJECTED
+	SubmittedBy          uint   // Supplier user who proposed the changes
+	ReviewedBy           *uint
+	ReviewedAt           *time.Time
+	ReviewNotes          string
+	Lines                []POChangeProposalLine `gorm:"foreignKey:ProposalID"`
+}
+
+// POChangeProposalLine proposes a new quantity for one purchase order line.
+type POChangeProposalLine struct {
+	gorm.Model
+	ProposalID          uint    `gorm:"not null;index"`
+	PurchaseOrderItemID uint    `gorm:"not null"`
+	ProductID           uint    `gorm:"not null"`
+	OrderedQuantity     float64 // The quantity on the order when the change was proposed
+	ProposedQuantity    float64 // 0 = the supplier cannot deliver the line
+}
+
+// AdvanceShippingNotice tells the buyer what a supplier has dispatched against a purchase order, so the
+// delivery can be expected and the batches checked on arrival.
+type AdvanceShippingNotice struct {
+	gorm.Model
+	PurchaseOrderID     uint   `gorm:"not null;index"`
+	SupplierID          uint   `gorm:"not null;uniqueIndex:idx_asn_supplier_shipment"`
+	ShipmentNumber      string `gorm:"not null;uniqueIndex:idx_asn_supplier_shipment"` // The supplier's own reference
+	ShippedAt           time.Time
+	ExpectedArrivalDate *time.Time
+	Carrier             string
+	TrackingNumber      string
+	Notes               string
+	SubmittedBy         uint
+	Lines               []AdvanceShippingNoticeLine `gorm:"foreignKey:ShippingNoticeID"`
+}
+
+// AdvanceShippingNoticeLine is a quantity of one purchase order line shipped from a single batch.
+type AdvanceShippingNoticeLine struct {
+	gorm.Model
+	ShippingNoticeID    uint `gorm:"not null;index"`
+	PurchaseOrderItemID uint `gorm:"not null;index"`
+	ProductID           uint `gorm:"not null"`
+	Product             Product
+	Quantity            float64    `gorm:"not null"`
+	BatchNumber         string     `gorm:"not null"`
+	ExpiryDate          *time.Time // nil for products that do not expire
+}
@@ -0,0 +1,48 @@
+package forecasting
+
+import "math"
+
+// Accuracy scores forecasts against the demand that materialised.
+type Accuracy struct {
+	Forecasts     int      `json:"forecasts"`
+	ForecastTotal float64  `json:"forecastTotal"`
+	ActualTotal   float64  `json:"actualTotal"`
+	MAPE          *float64 `json:"mape"` // Mean absolute percentage error over forecasts with non-zero actuals; nil when there are none
+	WAPE          *float64 `json:"wape"` // Total absolute error as a share of total actual demand; nil when nothing sold
+	Bias          *float64 `json:"bias"` // Net over- (positive) or under-forecast as a share of total actual demand
+}
+
+// Evaluate scores paired forecasts and actuals. Percentages are returned as fractions (0.25 = 25%).
+func Evaluate(forecasts, actuals []float64) Accuracy {
+	acc := Accuracy{Forecasts: len(forecasts)}
+	var absError, percentSum float64
+	percentCount := 0
+	for i := range forecasts {
+		acc.ForecastTotal += forecasts[i]
+		acc.ActualTotal += actuals[i]
+		absError += math.Abs(forecasts[i] - actuals[i])
+		if actuals[i] > 0 {
+			percentSum += math.Abs(forecasts[i]-actuals[i]) / actuals[i]
+			percentCount++
+		}
+	}
+	if percentCount > 0 {
+		acc.MAPE = ratio(percentSum, float64(percentCount))
+	}
+	if acc.ActualTotal > 0 {
+		acc.WAPE = ratio(absError, acc.ActualTotal)
+		acc.Bias = ratio(acc.ForecastTotal-acc.ActualTotal, acc.ActualTotal)
+	}
+	acc.ForecastTotal = round4(acc.ForecastTotal)
+	acc.ActualTotal = round4(acc.ActualTotal)
+	return acc
+}
+
+func ratio(a, b float64) *float64 {
+	v := round4(a / b)
+	return &v
+}
+
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
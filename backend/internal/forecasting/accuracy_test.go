@@ -0,0 +1,53 @@
+package forecasting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	pct := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		forecasts []float64
+		actuals   []float64
+		want      Accuracy
+	}{
+		{
+			name:      "perfect forecasts",
+			forecasts: []float64{10, 20},
+			actuals:   []float64{10, 20},
+			want:      Accuracy{Forecasts: 2, ForecastTotal: 30, ActualTotal: 30, MAPE: pct(0), WAPE: pct(0), Bias: pct(0)},
+		},
+		{
+			name:      "under-forecast",
+			forecasts: []float64{5},
+			actuals:   []float64{10},
+			want:      Accuracy{Forecasts: 1, ForecastTotal: 5, ActualTotal: 10, MAPE: pct(0.5), WAPE: pct(0.5), Bias: pct(-0.5)},
+		},
+		{
+			// MAPE skips the period without sales; WAPE and bias still count its error
+			name:      "over-forecast with a period without sales",
+			forecasts: []float64{12, 18, 5},
+			actuals:   []float64{10, 20, 0},
+			want:      Accuracy{Forecasts: 3, ForecastTotal: 35, ActualTotal: 30, MAPE: pct(0.15), WAPE: pct(0.3), Bias: pct(0.1667)},
+		},
+		{
+			name:      "nothing sold",
+			forecasts: []float64{3, 4},
+			actuals:   []float64{0, 0},
+			want:      Accuracy{Forecasts: 2, ForecastTotal: 7},
+		},
+		{
+			name: "no forecasts",
+			want: Accuracy{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Evaluate(tt.forecasts, tt.actuals))
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Package forecasting predicts product demand from daily sales history. It chooses between a moving
+// average, Holt-Winters with weekly seasonality and Croston's method for intermittent demand, and
+// derives a confidence score from the forecast's prediction interval.
+package forecasting
+
+import (
+	"fmt"
+	"math"
+)
+
+// Method names a forecasting method.
+type Method string
+
+const (
+	MovingAverage Method = "MOVING_AVERAGE"
+	HoltWinters   Method = "HOLT_WINTERS"
+	Croston       Method = "CROSTON"
+)
+
+const (
+	// SeasonLength is the weekly cycle Holt-Winters models in daily sales.
+	SeasonLength = 7
+	// movingAverageWindow is the number of most recent days the moving average covers.
+	movingAverageWindow = 28
+	// intermittentZeroShare is the share of days without sales above which demand is treated as intermittent.
+	intermittentZeroShare = 0.4
+	// holdoutDays is the tail of the history kept back to compare the continuous-demand methods.
+	holdoutDays = SeasonLength
+	// intervalZ is the normal quantile for the 95% prediction interval.
+	intervalZ = 1.96
+)
+
+// Forecast is the demand predicted over a horizon of days.
+type Forecast struct {
+	Method          Method
+	Daily           []float64 // Point forecast for each day of the horizon
+	Total           float64   // Demand over the whole horizon
+	Lower           float64   // Lower bound of the 95% prediction interval of Total
+	Upper           float64   // Upper bound of the 95% prediction interval of Total
+	ConfidenceScore float64   // 1 = interval collapses on the forecast, 0 = interval as wide as the forecast itself
+	Reasoning       string
+}
+
+// model is a fitted forecasting method: point forecasts for the horizon and the one-step-ahead
+// errors it made over the history, which size the prediction interval.
+type model struct {
+	method    Method
+	daily     []float64
+	residuals []float64
+}
+
+// Predict forecasts demand for the next horizon days from history, a series of daily sales oldest
+// first with zeros for days without sales. Intermittent series use Croston's method. Otherwise the
+// moving average and, given at least three weeks of history, Holt-Winters are both fitted on the
+// history without its last week and the one with the smaller error on that week is refitted on
+// the full history.
+func Predict(history []float64, horizon int) Forecast {
+	if horizon <= 0 {
+		return Forecast{Method: MovingAverage, ConfidenceScore: 1, Reasoning: "Empty forecast horizon"}
+	}
+	if len(history) == 0 {
+		return Forecast{Method: MovingAverage, Daily: make([]float64, horizon), Reasoning: "No sales history"}
+	}
+
+	zeros := 0
+	for _, v := range history {
+		if v <= 0 {
+			zeros++
+		}
+	}
+	zeroShare := float64(zeros) / float64(len(history))
+
+	var chosen model
+	var why string
+	switch {
+	case zeros == len(history):
+		chosen = movingAverage(history, horizon)
+		why = "no sales in the history"
+	case zeroShare >= intermittentZeroShare:
+		chosen = croston(history, horizon)
+		why = fmt.Sprintf("intermittent demand (%.0f%% of days without sales)", zeroShare*100)
+	case len(history) >= 2*SeasonLength+holdoutDays:
+		train, test := history[:len(history)-holdoutDays], history[len(history)-holdoutDays:]
+		maError := meanAbsoluteError(movingAverage(train, holdoutDays).daily, test)
+		hwError := meanAbsoluteError(holtWinters(train, holdoutDays).daily, test)
+		if hwError < maError {
+			chosen = holtWinters(history, horizon)
+			why = fmt.Sprintf("weekly pattern beat the moving average on the last week (MAE %.2f vs %.2f)", hwError, maError)
+		} else {
+			chosen = movingAverage(history, horizon)
+			why = fmt.Sprintf("moving average beat Holt-Winters on the last week (MAE %.2f vs %.2f)", maError, hwError)
+		}
+	default:
+		chosen = movingAverage(history, horizon)
+		why = fmt.Sprintf("only %d days of history, too short for a weekly pattern", len(history))
+	}
+
+	return finish(chosen, why, len(history), horizon)
+}
+
+// PredictWith forecasts with the given method instead of choosing one, e.g. to compare methods in a backtest.
+func PredictWith(method Method, history []float64, horizon int) Forecast {
+	if horizon <= 0 || len(history) == 0 {
+		return Predict(history, horizon)
+	}
+	var m model
+	switch method {
+	case HoltWinters:
+		m = holtWinters(history, horizon)
+	case Croston:
+		m = croston(history, horizon)
+	default:
+		m = movingAverage(history, horizon)
+	}
+	return finish(m, "requested", len(history), horizon)
+}
+
+// finish totals a fitted model's forecast and sizes its prediction interval.
+func finish(chosen model, why string, historyDays, horizon int) Forecast {
+	forecast := Forecast{Method: chosen.method, Daily: chosen.daily}
+	for _, v := range chosen.daily {
+		forecast.Total += v
+	}
+
+	// Assuming independent daily errors, the error of the total grows with the square root of the horizon
+	sigma := rootMeanSquare(chosen.residuals) * math.Sqrt(float64(horizon))
+	forecast.Lower = math.Max(0, forecast.Total-intervalZ*sigma)
+	forecast.Upper = forecast.Total + intervalZ*sigma
+	forecast.ConfidenceScore = confidenceScore(forecast.Total, intervalZ*sigma)
+	forecast.Reasoning = fmt.Sprintf("%s chosen: %s. 95%% interval %.1f to %.1f over %d days from %d days of history.",
+		chosen.method, why, forecast.Lower, forecast.Upper, horizon, historyDays)
+	return forecast
+}
+
+// confidenceScore turns the half-width of the prediction interval into a score between 0 and 1:
+// the narrower the interval relative to the forecast, the higher the score.
+func confidenceScore(total, halfWidth float64) float64 {
+	if halfWidth <= 0 {
+		return 1
+	}
+	if total <= 0 {
+		return 0
+	}
+	score := 1 - halfWidth/total
+	return math.Round(math.Max(0, math.Min(1, score))*1000) / 1000
+}
+
+func meanAbsoluteError(forecast, actual []float64) float64 {
+	var sum float64
+	for i := range actual {
+		sum += math.Abs(actual[i] - forecast[i])
+	}
+	return sum / float64(len(actual))
+}
+
+func rootMeanSquare(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,190 @@
+package forecasting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// repeat concatenates n copies of pattern.
+func repeat(pattern []float64, n int) []float64 {
+	series := make([]float64, 0, len(pattern)*n)
+	for i := 0; i < n; i++ {
+		series = append(series, pattern...)
+	}
+	return series
+}
+
+func TestPredict(t *testing.T) {
+	weekly := []float64{2, 2, 2, 2, 2, 10, 12}
+
+	tests := []struct {
+		name       string
+		history    []float64
+		horizon    int
+		wantMethod Method
+		wantDaily  []float64
+		wantTotal  float64
+		delta      float64
+	}{
+		{
+			name:       "flat series",
+			history:    constant(10, 56),
+			horizon:    7,
+			wantMethod: MovingAverage,
+			wantDaily:  constant(10, 7),
+			wantTotal:  70,
+		},
+		{
+			name:       "all-zero series",
+			history:    constant(0, 30),
+			horizon:    5,
+			wantMethod: MovingAverage,
+			wantDaily:  constant(0, 5),
+			wantTotal:  0,
+		},
+		{
+			name:       "intermittent series",
+			history:    repeat([]float64{0, 0, 0, 5}, 10),
+			horizon:    4,
+			wantMethod: Croston,
+			// Size 5 every 4 days, with the Syntetos-Boylan correction
+			wantDaily: constant(0.95*5.0/4, 4),
+			wantTotal: 0.95 * 5,
+			delta:     1e-9,
+		},
+		{
+			name:       "weekly-seasonal series",
+			history:    repeat(weekly, 8),
+			horizon:    7,
+			wantMethod: HoltWinters,
+			wantDaily:  weekly,
+			wantTotal:  32,
+			delta:      0.5,
+		},
+		{
+			name:       "history too short for a weekly pattern",
+			history:    []float64{4, 6, 5, 5, 4, 6, 5, 5, 4, 6},
+			horizon:    3,
+			wantMethod: MovingAverage,
+			wantDaily:  constant(5, 3),
+			wantTotal:  15,
+			delta:      1e-9,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := Predict(tt.history, tt.horizon)
+			assert.Equal(t, tt.wantMethod, forecast.Method)
+			require.Len(t, forecast.Daily, tt.horizon)
+			for i, want := range tt.wantDaily {
+				assert.InDelta(t, want, forecast.Daily[i], tt.delta, "day %d", i+1)
+			}
+			assert.InDelta(t, tt.wantTotal, forecast.Total, tt.delta*float64(tt.horizon))
+			assert.LessOrEqual(t, forecast.Lower, forecast.Total)
+			assert.GreaterOrEqual(t, forecast.Upper, forecast.Total)
+			assert.GreaterOrEqual(t, forecast.Lower, 0.0)
+			assert.GreaterOrEqual(t, forecast.ConfidenceScore, 0.0)
+			assert.LessOrEqual(t, forecast.ConfidenceScore, 1.0)
+			assert.NotEmpty(t, forecast.Reasoning)
+		})
+	}
+}
+
+func TestPredictFlatSeriesHasNoUncertainty(t *testing.T) {
+	forecast := Predict(constant(3, 28), 7)
+	assert.Equal(t, 21.0, forecast.Total)
+	assert.Equal(t, 21.0, forecast.Lower)
+	assert.Equal(t, 21.0, forecast.Upper)
+	assert.Equal(t, 1.0, forecast.ConfidenceScore)
+}
+
+func TestPredictAllZeroSeries(t *testing.T) {
+	forecast := Predict(constant(0, 28), 7)
+	assert.Zero(t, forecast.Total)
+	assert.Zero(t, forecast.Upper)
+	assert.Contains(t, forecast.Reasoning, "no sales")
+}
+
+func TestPredictEdgeCases(t *testing.T) {
+	empty := Predict(nil, 7)
+	assert.Equal(t, MovingAverage, empty.Method)
+	assert.Equal(t, constant(0, 7), empty.Daily)
+	assert.Zero(t, empty.ConfidenceScore)
+
+	noHorizon := Predict(constant(5, 28), 0)
+	assert.Empty(t, noHorizon.Daily)
+	assert.Equal(t, 1.0, noHorizon.ConfidenceScore)
+}
+
+func TestPredictWith(t *testing.T) {
+	history := repeat([]float64{2, 2, 2, 2, 2, 10, 12}, 4)
+
+	tests := []struct {
+		method Method
+		want   Method
+	}{
+		{MovingAverage, MovingAverage},
+		{HoltWinters, HoltWinters},
+		{Croston, Croston},
+		{Method("UNKNOWN"), MovingAverage},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.method), func(t *testing.T) {
+			forecast := PredictWith(tt.method, history, 7)
+			assert.Equal(t, tt.want, forecast.Method)
+			assert.Len(t, forecast.Daily, 7)
+		})
+	}
+
+	// Holt-Winters needs two full seasons; shorter histories use the moving average
+	assert.Equal(t, MovingAverage, PredictWith(HoltWinters, constant(4, 10), 7).Method)
+}
+
+func TestMovingAverageUsesRecentWindow(t *testing.T) {
+	// Older sales outside the 28-day window do not count
+	history := append(constant(100, 30), constant(6, movingAverageWindow)...)
+	m := movingAverage(history, 3)
+	assert.Equal(t, constant(6, 3), m.daily)
+	assert.Len(t, m.residuals, len(history)-1)
+}
+
+func TestCrostonRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		history  []float64
+		wantRate float64
+	}{
+		{"demand every day", constant(4, 10), 0.95 * 4},
+		{"demand every third day", repeat([]float64{0, 0, 6}, 8), 0.95 * 6 / 3},
+		{"no demand", constant(0, 10), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := croston(tt.history, 2)
+			assert.InDelta(t, tt.wantRate, m.daily[0], 1e-9)
+			assert.InDelta(t, tt.wantRate, m.daily[1], 1e-9)
+		})
+	}
+}
+
+func TestConfidenceScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     float64
+		halfWidth float64
+		want      float64
+	}{
+		{"no uncertainty", 10, 0, 1},
+		{"interval a quarter of the forecast", 100, 25, 0.75},
+		{"interval as wide as the forecast", 10, 10, 0},
+		{"interval wider than the forecast", 10, 30, 0},
+		{"nothing forecast", 0, 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, confidenceScore(tt.total, tt.halfWidth))
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package forecasting
+
+import "math"
+
+// movingAverage forecasts every day at the mean of the most recent window of days.
+func movingAverage(history []float64, horizon int) model {
+	window := movingAverageWindow
+	if len(history) < window {
+		window = len(history)
+	}
+	level := mean(history[len(history)-window:])
+
+	m := model{method: MovingAverage, daily: constant(level, horizon)}
+	for t := 1; t < len(history); t++ {
+		start := t - window
+		if start < 0 {
+			start = 0
+		}
+		m.residuals = append(m.residuals, history[t]-mean(history[start:t]))
+	}
+	return m
+}
+
+// holtWinters fits additive Holt-Winters with a damped trend and weekly seasonality, choosing the
+// smoothing parameters with the smallest one-step-ahead squared error over the history. Histories
+// shorter than two seasons fall back to the moving average.
+func holtWinters(history []float64, horizon int) model {
+	if len(history) < 2*SeasonLength {
+		return movingAverage(history, horizon)
+	}
+
+	var best *holtWintersFit
+	for _, alpha := range []float64{0.1, 0.2, 0.4, 0.6} {
+		for _, beta := range []float64{0, 0.05, 0.15} {
+			for _, gamma := range []float64{0.05, 0.15, 0.3} {
+				fit := fitHoltWinters(history, alpha, beta, gamma)
+				if best == nil || fit.sse < best.sse {
+					best = fit
+				}
+			}
+		}
+	}
+
+	m := model{method: HoltWinters, daily: make([]float64, horizon), residuals: best.residuals}
+	trend := 0.0
+	for h := 1; h <= horizon; h++ {
+		trend += math.Pow(holtWintersDamping, float64(h)) * best.trend
+		season := best.seasonal[(len(history)+h-1)%SeasonLength]
+		m.daily[h-1] = math.Max(0, best.level+trend+season)
+	}
+	return m
+}
+
+// holtWintersDamping flattens the trend over the horizon so a short run of growth is not extrapolated forever.
+const holtWintersDamping = 0.98
+
+type holtWintersFit struct {
+	level, trend float64
+	seasonal     []float64
+	residuals    []float64
+	sse          float64
+}
+
+func fitHoltWinters(history []float64, alpha, beta, gamma float64) *holtWintersFit {
+	first, second := mean(history[:SeasonLength]), mean(history[SeasonLength:2*SeasonLength])
+	fit := &holtWintersFit{
+		level:    first,
+		trend:    (second - first) / SeasonLength,
+		seasonal: make([]float64, SeasonLength),
+	}
+	for i := 0; i < SeasonLength; i++ {
+		fit.seasonal[i] = history[i] - first
+	}
+
+	for t := SeasonLength; t < len(history); t++ {
+		s := t % SeasonLength
+		predicted := fit.level + holtWintersDamping*fit.trend + fit.seasonal[s]
+		residual := history[t] - predicted
+		fit.residuals = append(fit.residuals, residual)
+		fit.sse += residual * residual
+
+		level := alpha*(history[t]-fit.seasonal[s]) + (1-alpha)*(fit.level+holtWintersDamping*fit.trend)
+		fit.trend = beta*(level-fit.level) + (1-beta)*holtWintersDamping*fit.trend
+		fit.seasonal[s] = gamma*(history[t]-level) + (1-gamma)*fit.seasonal[s]
+		fit.level = level
+	}
+	return fit
+}
+
+// crostonAlpha smooths both the demand sizes and the intervals between them.
+const crostonAlpha = 0.1
+
+// croston forecasts intermittent demand as a daily rate: smoothed demand size over smoothed interval
+// between demands, with the Syntetos-Boylan correction for the bias of the plain ratio.
+func croston(history []float64, horizon int) model {
+	m := model{method: Croston}
+	var size, interval float64
+	initialized := false
+	sinceLast := 1.0
+	for _, v := range history {
+		if initialized {
+			m.residuals = append(m.residuals, v-crostonRate(size, interval))
+		}
+		if v > 0 {
+			if !initialized {
+				size, interval = v, sinceLast
+				initialized = true
+			} else {
+				size = crostonAlpha*v + (1-crostonAlpha)*size
+				interval = crostonAlpha*sinceLast + (1-crostonAlpha)*interval
+			}
+			sinceLast = 1
+		} else {
+			sinceLast++
+		}
+	}
+	m.daily = constant(crostonRate(size, interval), horizon)
+	return m
+}
+
+func crostonRate(size, interval float64) float64 {
+	if interval <= 0 {
+		return 0
+	}
+	return (1 - crostonAlpha/2) * size / interval
+}
+
+func constant(v float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = v
+	}
+	return values
+}
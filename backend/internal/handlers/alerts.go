@@ -41,7 +41,7 @@ type AlertTriggeredPayload struct {
 
 // PutProductAlertSettings godoc
 // @Summary Configure alert thresholds for a product
-// @Description Configures low-stock, overstock, and expiry alert thresholds for a specific product
+// @Description Configures the reorder point, safety stock, max level, overstock and expiry alert thresholds for a product at one location, or for its total stock when no location is given
 // @Tags alerts
 // @Accept json
 // @Produce json
@@ -75,15 +75,34 @@ func PutProductAlertSettings(c *gin.Context) {
 		return
 	}
 
+	if req.LocationID != 0 {
+		var location domain.Location
+		if err := repository.DB.First(&location, req.LocationID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.Error(appErrors.NewAppError("Location not found", http.StatusNotFound, err))
+				return
+			}
+			c.Error(appErrors.NewAppError("Failed to fetch location", http.StatusInternalServerError, err))
+			return
+		}
+	}
+	if req.MaxStockLevel > 0 && req.MaxStockLevel < req.LowStockLevel {
+		c.Error(appErrors.NewAppError("maxStockLevel must not be below lowStockLevel", http.StatusBadRequest, nil))
+		return
+	}
+
 	settings := domain.ProductAlertSettings{
 		ProductID:       uint(productID),
+		LocationID:      req.LocationID,
 		LowStockLevel:   req.LowStockLevel,
+		SafetyStock:     req.SafetyStock,
+		MaxStockLevel:   req.MaxStockLevel,
 		OverStockLevel:  req.OverStockLevel,
 		ExpiryAlertDays: req.ExpiryAlertDays,
 	}
 
 	// Upsert: create if not exists, update if exists
-	if err := repository.DB.Where(domain.ProductAlertSettings{ProductID: uint(productID)}).Assign(settings).FirstOrCreate(&settings).Error; err != nil {
+	if err := repository.DB.Where("product_id = ? AND location_id = ?", productID, req.LocationID).Assign(settings).FirstOrCreate(&settings).Error; err != nil {
 		c.Error(appErrors.NewAppError("Failed to save product alert settings", http.StatusInternalServerError, err))
 		return
 	}
@@ -91,6 +110,47 @@ func PutProductAlertSettings(c *gin.Context) {
 	c.JSON(http.StatusOK, settings)
 }
 
+// ListProductAlertSettings godoc
+// @Summary List alert thresholds for a product
+// @Description Lists the product's thresholds per location; the entry with locationId 0 applies to its total stock
+// @Tags alerts
+// @Produce json
+// @Param productId path int true "Product ID"
+// @Success 200 {array} domain.ProductAlertSettings
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /alerts/products/{productId}/settings [get]
+func ListProductAlertSettings(c *gin.Context) {
+	var settings []domain.ProductAlertSettings
+	if err := repository.DB.Where("product_id = ?", c.Param("productId")).Order("location_id asc").Find(&settings).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch product alert settings", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, settings)
+}
+
+// DeleteProductAlertSettings godoc
+// @Summary Remove alert thresholds for a product at a location
+// @Tags alerts
+// @Param productId path int true "Product ID"
+// @Param locationId path int true "Location ID (0 for the product-wide thresholds)"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]interface{} "Settings not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /alerts/products/{productId}/settings/{locationId} [delete]
+func DeleteProductAlertSettings(c *gin.Context) {
+	result := repository.DB.Where("product_id = ? AND location_id = ?", c.Param("productId"), c.Param("locationId")).
+		Delete(&domain.ProductAlertSettings{})
+	if result.Error != nil {
+		c.Error(appErrors.NewAppError("Failed to delete product alert settings", http.StatusInternalServerError, result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(appErrors.NewAppError("Alert settings not found", http.StatusNotFound, nil))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 // ListAlerts godoc
 // @Summary Get a list of all active alerts
 // @Description Retrieves a list of all active stock-related alerts
@@ -100,6 +160,7 @@ func PutProductAlertSettings(c *gin.Context) {
 // @Param type query string false "Filter by alert type (LOW_STOCK, OUT_OF_STOCK, OVERSTOCK, EXPIRY_ALERT)"
 // @Param status query string false "Filter by alert status (ACTIVE, RESOLVED)"
 // @Param productId query int false "Filter by Product ID"
+// @Param locationId query int false "Filter by Location ID"
 // @Success 200 {array} domain.Alert
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
 // @Router /alerts [get]
@@ -126,6 +187,9 @@ func ListAlerts(c *gin.Context) {
 	if productID := c.Query("productId"); productID != "" {
 		db = db.Where("product_id = ?", productID)
 	}
+	if locationID := c.Query("locationId"); locationID != "" {
+		db = db.Where("location_id = ?", locationID)
+	}
 
 	if err := db.Find(&alerts).Error; err != nil {
 		c.Error(appErrors.NewAppError("Failed to fetch alerts", http.StatusInternalServerError, err))
@@ -262,17 +326,18 @@ func CheckAndTriggerAlerts() {
 	}
 }
 
-// CheckAndTriggerAlertsForProduct evaluates alert thresholds for a single product.
+// CheckAndTriggerAlertsForProduct evaluates the alert thresholds of a single product at every
+// location it has settings for.
 func CheckAndTriggerAlertsForProduct(productID uint) {
-	var settings domain.ProductAlertSettings
-	if err := repository.DB.Where("product_id = ?", productID).First(&settings).Error; err != nil {
-		if err != gorm.ErrRecordNotFound {
-			logrus.Errorf("Failed to fetch alert settings for product %d: %v", productID, err)
-		}
+	var settings []domain.ProductAlertSettings
+	if err := repository.DB.Where("product_id = ?", productID).Find(&settings).Error; err != nil {
+		logrus.Errorf("Failed to fetch alert settings for product %d: %v", productID, err)
 		return
 	}
 
-	checkAndTriggerAlertsForSettings(&settings)
+	for i := range settings {
+		checkAndTriggerAlertsForSettings(&settings[i])
+	}
 }
 
 func checkAndTriggerAlertsForSettings(s *domain.ProductAlertSettings) {
@@ -282,47 +347,73 @@ func checkAndTriggerAlertsForSettings(s *domain.ProductAlertSettings) {
 		return
 	}
 
+	// Location-level thresholds are measured against that location's own stock
+	var locationID *uint
+	where := ""
+	if s.LocationID != 0 {
+		var location domain.Location
+		if err := repository.DB.First(&location, s.LocationID).Error; err != nil {
+			logrus.Errorf("Failed to fetch location %d for alert check: %v", s.LocationID, err)
+			return
+		}
+		locationID = &s.LocationID
+		where = " at " + location.Name
+	}
+
 	// Get current quantity
-	var currentQuantity int
-	repository.DB.Model(&domain.Batch{}).Where("product_id = ?", s.ProductID).Select("sum(quantity)").Row().Scan(&currentQuantity)
+	currentQuantity, err := repository.GetLocationStock(repository.DB, s.ProductID, s.LocationID)
+	if err != nil {
+		logrus.Errorf("Failed to fetch stock for product %d: %v", s.ProductID, err)
+		return
+	}
 
 	// Low Stock Alert
-	if s.LowStockLevel > 0 && currentQuantity <= s.LowStockLevel {
-		triggerAlert(s.ProductID, "LOW_STOCK", fmt.Sprintf("Product %s is running low. Current quantity: %d", product.Name, currentQuantity), nil)
+	if reorderPoint := s.ReorderPoint(); reorderPoint > 0 && currentQuantity <= reorderPoint {
+		triggerAlert(s.ProductID, "LOW_STOCK", fmt.Sprintf("Product %s is running low%s. Current quantity: %g %s", product.Name, where, currentQuantity, product.UnitOfMeasure), nil, locationID)
 	}
 
 	// Out of Stock Alert
-	if currentQuantity == 0 {
-		triggerAlert(s.ProductID, "OUT_OF_STOCK", fmt.Sprintf("Product %s is out of stock.", product.Name), nil)
+	if currentQuantity <= 0 {
+		triggerAlert(s.ProductID, "OUT_OF_STOCK", fmt.Sprintf("Product %s is out of stock%s.", product.Name, where), nil, locationID)
 	}
 
 	// Overstock Alert
 	if s.OverStockLevel > 0 && currentQuantity >= s.OverStockLevel {
-		triggerAlert(s.ProductID, "OVERSTOCK", fmt.Sprintf("Product %s is overstocked. Current quantity: %d", product.Name, currentQuantity), nil)
+		triggerAlert(s.ProductID, "OVERSTOCK", fmt.Sprintf("Product %s is overstocked%s. Current quantity: %g %s", product.Name, where, currentQuantity, product.UnitOfMeasure), nil, locationID)
 	}
 
 	// Expiry Alert
 	if s.ExpiryAlertDays > 0 {
 		var expiringBatches []domain.Batch
 		expiryThreshold := time.Now().AddDate(0, 0, s.ExpiryAlertDays)
-		if err := repository.DB.Where("product_id = ? AND expiry_date IS NOT NULL AND expiry_date <= ?", s.ProductID, expiryThreshold).Find(&expiringBatches).Error; err != nil {
+		// Quarantined batches are already out of sale and waiting on a write-off
+		query := repository.DB.Where("product_id = ? AND expiry_date IS NOT NULL AND expiry_date <= ? AND quarantined_at IS NULL", s.ProductID, expiryThreshold)
+		if s.LocationID != 0 {
+			query = query.Where("location_id = ?", s.LocationID)
+		}
+		if err := query.Find(&expiringBatches).Error; err != nil {
 			logrus.Errorf("Failed to fetch expiring batches for product %d: %v", s.ProductID, err)
 			return
 		}
 
 		for _, batch := range expiringBatches {
-			triggerAlert(s.ProductID, "EXPIRY_ALERT", fmt.Sprintf("Batch %s of product %s is expiring soon on %s", batch.BatchNumber, product.Name, batch.ExpiryDate.Format("2006-01-02")), &batch.ID)
+			triggerAlert(s.ProductID, "EXPIRY_ALERT", fmt.Sprintf("Batch %s of product %s is expiring soon on %s", batch.BatchNumber, product.Name, batch.ExpiryDate.Format("2006-01-02")), &batch.ID, locationID)
 		}
 	}
 }
 
-func triggerAlert(productID uint, alertType, message string, batchID *uint) {
-	// Check if an active alert of this type already exists for this product/batch
+func triggerAlert(productID uint, alertType, message string, batchID *uint, locationID *uint) {
+	// Check if an active alert of this type already exists for this product/batch/location
 	var existingAlert domain.Alert
 	query := repository.DB.Where("product_id = ? AND type = ? AND status = ?", productID, alertType, "ACTIVE")
 	if batchID != nil {
 		query = query.Where("batch_id = ?", *batchID)
 	}
+	if locationID != nil {
+		query = query.Where("location_id = ?", *locationID)
+	} else {
+		query = query.Where("location_id IS NULL")
+	}
 
 	if err := query.First(&existingAlert).Error; err == nil {
 		// Alert already active, do not re-trigger
@@ -340,6 +431,7 @@ func triggerAlert(productID uint, alertType, message string, batchID *uint) {
 		TriggeredAt: time.Now(),
 		Status:      "ACTIVE",
 		BatchID:     batchID,
+		LocationID:  locationID,
 	}
 	if err := repository.DB.Create(&alert).Error; err != nil {
 		logrus.Errorf("Failed to create alert record: %v", err)
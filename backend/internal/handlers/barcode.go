@@ -7,7 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"inventory/backend/internal/domain"
 	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/requests"
 	"inventory/backend/internal/services"
 )
 
@@ -67,12 +69,12 @@ func (h *BarcodeHandler) GenerateBarcode(c *gin.Context) {
 
 // LookupProductByBarcode godoc
 // @Summary Lookup a product by barcode/UPC
-// @Description Retrieves product details by scanning its barcode or UPC
+// @Description Retrieves product details by scanning its barcode or UPC. Weight- or price-embedded scale barcodes are decoded using the configured patterns and return the embedded quantity and price under scaleBarcode.
 // @Tags barcodes
 // @Accept json
 // @Produce json
 // @Param barcode query string true "Barcode or UPC value"
-// @Success 200 {object} domain.Product
+// @Success 200 {object} services.BarcodeLookupResult
 // @Failure 400 {object} map[string]interface{} "Bad Request"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
@@ -84,7 +86,7 @@ func (h *BarcodeHandler) LookupProductByBarcode(c *gin.Context) {
 		return
 	}
 
-	product, err := h.barcodeService.LookupProductByBarcode(barcodeValue)
+	result, err := h.barcodeService.LookupProductByBarcode(barcodeValue)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.Error(appErrors.NewAppError("Product not found with provided barcode", http.StatusNotFound, err))
@@ -94,5 +96,133 @@ func (h *BarcodeHandler) LookupProductByBarcode(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	c.JSON(http.StatusOK, result)
+}
+
+// ListScalePatterns godoc
+// @Summary List scale barcode patterns
+// @Description Retrieves all configured weight- and price-embedded barcode patterns
+// @Tags barcodes
+// @Produce json
+// @Success 200 {array} domain.ScaleBarcodePattern
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /barcode/scale-patterns [get]
+func (h *BarcodeHandler) ListScalePatterns(c *gin.Context) {
+	patterns, err := h.barcodeService.ListScalePatterns()
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to list scale barcode patterns", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, patterns)
+}
+
+// CreateScalePattern godoc
+// @Summary Create a scale barcode pattern
+// @Description Configures how a GS1 variable-measure barcode (prefix 2x) encodes its PLU and weight or price
+// @Tags barcodes
+// @Accept json
+// @Produce json
+// @Param pattern body requests.ScaleBarcodePatternRequest true "Scale barcode pattern"
+// @Success 201 {object} domain.ScaleBarcodePattern
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Router /barcode/scale-patterns [post]
+func (h *BarcodeHandler) CreateScalePattern(c *gin.Context) {
+	var req requests.ScaleBarcodePatternRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+
+	pattern := domain.ScaleBarcodePattern{VerifyCheck: true, IsActive: true}
+	applyScalePatternRequest(&pattern, &req)
+
+	if err := h.barcodeService.CreateScalePattern(&pattern); err != nil {
+		c.Error(appErrors.NewAppError("Failed to create scale barcode pattern", http.StatusBadRequest, err))
+		return
+	}
+	c.JSON(http.StatusCreated, pattern)
+}
+
+// UpdateScalePattern godoc
+// @Summary Update a scale barcode pattern
+// @Tags barcodes
+// @Accept json
+// @Produce json
+// @Param id path int true "Pattern ID"
+// @Param pattern body requests.ScaleBarcodePatternRequest true "Scale barcode pattern"
+// @Success 200 {object} domain.ScaleBarcodePattern
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Not Found"
+// @Router /barcode/scale-patterns/{id} [put]
+func (h *BarcodeHandler) UpdateScalePattern(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid pattern ID", http.StatusBadRequest, err))
+		return
+	}
+
+	var req requests.ScaleBarcodePatternRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+
+	pattern, err := h.barcodeService.GetScalePattern(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Scale barcode pattern not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch scale barcode pattern", http.StatusInternalServerError, err))
+		return
+	}
+
+	applyScalePatternRequest(pattern, &req)
+
+	if err := h.barcodeService.UpdateScalePattern(pattern); err != nil {
+		c.Error(appErrors.NewAppError("Failed to update scale barcode pattern", http.StatusBadRequest, err))
+		return
+	}
+	c.JSON(http.StatusOK, pattern)
+}
+
+// DeleteScalePattern godoc
+// @Summary Delete a scale barcode pattern
+// @Tags barcodes
+// @Param id path int true "Pattern ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /barcode/scale-patterns/{id} [delete]
+func (h *BarcodeHandler) DeleteScalePattern(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid pattern ID", http.StatusBadRequest, err))
+		return
+	}
+
+	if err := h.barcodeService.DeleteScalePattern(uint(id)); err != nil {
+		c.Error(appErrors.NewAppError("Failed to delete scale barcode pattern", http.StatusInternalServerError, err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func applyScalePatternRequest(pattern *domain.ScaleBarcodePattern, req *requests.ScaleBarcodePatternRequest) {
+	pattern.Name = req.Name
+	pattern.Prefix = req.Prefix
+	pattern.Length = req.Length
+	pattern.PLUStart = req.PLUStart
+	pattern.PLULength = req.PLULength
+	pattern.ValueStart = req.ValueStart
+	pattern.ValueLength = req.ValueLength
+	pattern.ValueType = req.ValueType
+	pattern.ValueDecimals = req.ValueDecimals
+	pattern.Priority = req.Priority
+	if req.VerifyCheck != nil {
+		pattern.VerifyCheck = *req.VerifyCheck
+	}
+	if req.IsActive != nil {
+		pattern.IsActive = *req.IsActive
+	}
 }
@@ -0,0 +1,453 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+)
+
+// putAwayReceipt assigns a newly received batch to the requested bin, or to the suggested bin when
+// none is requested. Locations without bins keep the stock unassigned.
+func putAwayReceipt(tx *gorm.DB, batch *domain.Batch, binID *uint) error {
+	var bin *domain.Bin
+	if binID != nil {
+		locked, err := repository.LockBin(tx, *binID)
+		if err != nil {
+			return appErrors.NewAppError(fmt.Sprintf("Bin %d not found", *binID), http.StatusNotFound, err)
+		}
+		bin = locked
+	} else {
+		suggested, err := repository.SuggestPutawayBin(tx, batch.ProductID, batch.LocationID, batch.Quantity)
+		if err != nil {
+			return appErrors.NewAppError("Failed to suggest a putaway bin", http.StatusInternalServerError, err)
+		}
+		if suggested == nil {
+			return nil
+		}
+		if bin, err = repository.LockBin(tx, suggested.ID); err != nil {
+			return appErrors.NewAppError("Failed to lock putaway bin", http.StatusInternalServerError, err)
+		}
+	}
+	if err := repository.PutAwayBatch(tx, batch, bin); err != nil {
+		return appErrors.NewAppError(fmt.Sprintf("Cannot put batch %s away: %v", batch.BatchNumber, err), http.StatusBadRequest, err)
+	}
+	return nil
+}
+
+// loadLocationBin fetches a bin and checks it belongs to the location in the path.
+func loadLocationBin(c *gin.Context) (*domain.Bin, bool) {
+	var bin domain.Bin
+	if err := repository.DB.Where("location_id = ?", c.Param("id")).First(&bin, c.Param("binId")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Bin not found", http.StatusNotFound, err))
+			return nil, false
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch bin", http.StatusInternalServerError, err))
+		return nil, false
+	}
+	return &bin, true
+}
+
+// CreateBin godoc
+// @Summary Create a bin inside a location
+// @Description Creates a zone, aisle, shelf or bin. Its path is the parent's path followed by its code, e.g. A-03-2.
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Param id path int true "Location ID"
+// @Param bin body requests.BinCreateRequest true "Bin creation request"
+// @Success 201 {object} domain.Bin
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Location or parent bin not found"
+// @Failure 409 {object} map[string]interface{} "A bin with this path already exists"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /locations/{id}/bins [post]
+func CreateBin(c *gin.Context) {
+	var req requests.BinCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+
+	var location domain.Location
+	if err := repository.DB.First(&location, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Location not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch location", http.StatusInternalServerError, err))
+		return
+	}
+
+	code := strings.TrimSpace(req.Code)
+	bin := domain.Bin{
+		LocationID: location.ID,
+		ParentID:   req.ParentID,
+		Code:       code,
+		Path:       code,
+		Type:       req.Type,
+		Capacity:   req.Capacity,
+		IsActive:   true,
+	}
+	if bin.Type == "" {
+		bin.Type = domain.BinTypeBin
+	}
+	if req.ParentID != nil {
+		var parent domain.Bin
+		if err := repository.DB.Where("location_id = ?", location.ID).First(&parent, *req.ParentID).Error; err != nil {
+			c.Error(appErrors.NewAppError("Parent bin not found in this location", http.StatusNotFound, err))
+			return
+		}
+		bin.Path = parent.ChildPath(code)
+	}
+
+	var existing int64
+	repository.DB.Model(&domain.Bin{}).Where("location_id = ? AND path = ?", location.ID, bin.Path).Count(&existing)
+	if existing > 0 {
+		c.Error(appErrors.NewAppError(fmt.Sprintf("Bin %s already exists in this location", bin.Path), http.StatusConflict, nil))
+		return
+	}
+
+	if err := repository.DB.Create(&bin).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to create bin", http.StatusInternalServerError, err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, bin)
+}
+
+// ListBins godoc
+// @Summary List the bins of a location
+// @Description Lists the location's bins in walking order (by path, numeric codes by value)
+// @Tags locations
+// @Produce json
+// @Param id path int true "Location ID"
+// @Param type query string false "ZONE, AISLE, SHELF or BIN"
+// @Param parentId query int false "Only the direct children of this bin"
+// @Success 200 {array} domain.Bin
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /locations/{id}/bins [get]
+func ListBins(c *gin.Context) {
+	query := repository.DB.Where("location_id = ?", c.Param("id"))
+	if binType := c.Query("type"); binType != "" {
+		query = query.Where("type = ?", strings.ToUpper(binType))
+	}
+	if parentID := c.Query("parentId"); parentID != "" {
+		query = query.Where("parent_id = ?", parentID)
+	}
+
+	var bins []domain.Bin
+	if err := query.Find(&bins).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch bins", http.StatusInternalServerError, err))
+		return
+	}
+	repository.SortBinsByPath(bins)
+	c.JSON(http.StatusOK, bins)
+}
+
+// UpdateBin godoc
+// @Summary Update a bin
+// @Description Changes a bin's type, capacity or active flag. Inactive bins take no putaway or moves.
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Param id path int true "Location ID"
+// @Param binId path int true "Bin ID"
+// @Param bin body requests.BinUpdateRequest true "Bin update request"
+// @Success 200 {object} domain.Bin
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Bin not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /locations/{id}/bins/{binId} [put]
+func UpdateBin(c *gin.Context) {
+	var req requests.BinUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	bin, ok := loadLocationBin(c)
+	if !ok {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Type != "" {
+		updates["type"] = req.Type
+	}
+	if req.Capacity != nil {
+		updates["capacity"] = *req.Capacity
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if len(updates) > 0 {
+		if err := repository.DB.Model(bin).Updates(updates).Error; err != nil {
+			c.Error(appErrors.NewAppError("Failed to update bin", http.StatusInternalServerError, err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, bin)
+}
+
+// DeleteBin godoc
+// @Summary Delete a bin
+// @Description Deletes an empty bin that has no child bins
+// @Tags locations
+// @Param id path int true "Location ID"
+// @Param binId path int true "Bin ID"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]interface{} "Bin not found"
+// @Failure 409 {object} map[string]interface{} "Bin has child bins or holds stock"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /locations/{id}/bins/{binId} [delete]
+func DeleteBin(c *gin.Context) {
+	bin, ok := loadLocationBin(c)
+	if !ok {
+		return
+	}
+
+	var childCount int64
+	repository.DB.Model(&domain.Bin{}).Where("parent_id = ?", bin.ID).Count(&childCount)
+	if childCount > 0 {
+		c.Error(appErrors.NewAppError("Cannot delete bin: it has child bins", http.StatusConflict, nil))
+		return
+	}
+	held, err := repository.GetBinStock(repository.DB, bin.ID)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to check bin stock", http.StatusInternalServerError, err))
+		return
+	}
+	if held > 0 {
+		c.Error(appErrors.NewAppError("Cannot delete bin: it holds stock", http.StatusConflict, nil))
+		return
+	}
+
+	if err := repository.DB.Delete(bin).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to delete bin", http.StatusInternalServerError, err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetBinStock godoc
+// @Summary List the stock held in a bin
+// @Tags locations
+// @Produce json
+// @Param id path int true "Location ID"
+// @Param binId path int true "Bin ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{} "Bin not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /locations/{id}/bins/{binId}/stock [get]
+func GetBinStock(c *gin.Context) {
+	bin, ok := loadLocationBin(c)
+	if !ok {
+		return
+	}
+
+	var batches []domain.Batch
+	if err := repository.DB.Preload("Product").Where("bin_id = ? AND quantity > 0", bin.ID).
+		Order("expiry_date asc").Find(&batches).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch bin stock", http.StatusInternalServerError, err))
+		return
+	}
+	var held float64
+	for _, b := range batches {
+		held += b.Quantity
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bin":      bin,
+		"quantity": domain.RoundQuantity(held, domain.MaxQuantityPrecision),
+		"batches":  batches,
+	})
+}
+
+// GetPutawaySuggestion godoc
+// @Summary Suggest a bin to put stock away in
+// @Description Suggests a bin already holding the product with room for the quantity, otherwise the first empty bin in walking order. Receipts without a bin are put away here automatically.
+// @Tags locations
+// @Produce json
+// @Param id path int true "Location ID"
+// @Param productId query int true "Product ID"
+// @Param quantity query number true "Quantity to put away"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /locations/{id}/bins/putaway-suggestion [get]
+func GetPutawaySuggestion(c *gin.Context) {
+	locationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid location ID", http.StatusBadRequest, err))
+		return
+	}
+	productID, err := strconv.ParseUint(c.Query("productId"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("productId is required", http.StatusBadRequest, err))
+		return
+	}
+	quantity, err := strconv.ParseFloat(c.Query("quantity"), 64)
+	if err != nil || quantity <= 0 {
+		c.Error(appErrors.NewAppError("quantity must be greater than zero", http.StatusBadRequest, err))
+		return
+	}
+
+	bin, err := repository.SuggestPutawayBin(repository.DB, uint(productID), uint(locationID), quantity)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to suggest a putaway bin", http.StatusInternalServerError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"productId": productID,
+		"quantity":  quantity,
+		"bin":       bin, // Null when no bin has room
+	})
+}
+
+// MoveStockBetweenBins godoc
+// @Summary Move stock from one bin to another
+// @Description Moves a batch, or part of it, into another bin of the same location. Moving part of a batch splits it. Stock on hand at the location is unchanged.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param move body requests.BinMoveRequest true "Bin move request"
+// @Success 201 {object} domain.BinMove
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Batch or bin not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /inventory/bin-moves [post]
+func MoveStockBetweenBins(c *gin.Context) {
+	var req requests.BinMoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var move *domain.BinMove
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		var batch domain.Batch
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, req.BatchID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return appErrors.NewAppError("Batch not found", http.StatusNotFound, err)
+			}
+			return err
+		}
+		bin, err := repository.LockBin(tx, req.ToBinID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return appErrors.NewAppError("Bin not found", http.StatusNotFound, err)
+			}
+			return err
+		}
+		var product domain.Product
+		if err := tx.First(&product, batch.ProductID).Error; err != nil {
+			return err
+		}
+
+		quantity := req.Quantity
+		if quantity == 0 {
+			quantity = batch.Quantity
+		}
+		if !product.IsValidQuantity(quantity) {
+			return quantityPrecisionError(&product, quantity)
+		}
+
+		move, err = repository.MoveBatchToBin(tx, &product, &batch, bin, quantity, userID, req.Notes)
+		if err != nil {
+			if errors.Is(err, repository.ErrStockConflict) {
+				return appErrors.NewAppError(err.Error(), http.StatusConflict, err)
+			}
+			return appErrors.NewAppError(err.Error(), http.StatusBadRequest, err)
+		}
+		return nil
+	})
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(appErrors.NewAppError("Failed to move stock", http.StatusInternalServerError, err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, move)
+}
+
+// ListBinMoves godoc
+// @Summary List bin-to-bin moves
+// @Tags inventory
+// @Produce json
+// @Param productId query int false "Product ID"
+// @Param locationId query int false "Location ID"
+// @Success 200 {array} domain.BinMove
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /inventory/bin-moves [get]
+func ListBinMoves(c *gin.Context) {
+	query := repository.DB.Preload("Product")
+	if productID := c.Query("productId"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if locationID := c.Query("locationId"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+
+	var moves []domain.BinMove
+	if err := query.Order("moved_at desc").Limit(200).Find(&moves).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch bin moves", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, moves)
+}
+
+// CreatePickList godoc
+// @Summary Build a pick list
+// @Description Allocates the requested quantities to the location's batches, earliest expiry first, and lists them in bin-path order so the picker walks the location once. Stock not yet put away is listed last. Quantities the location cannot supply are reported as shortages.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param pickList body requests.PickListRequest true "Products and quantities to pick"
+// @Success 200 {object} repository.PickList
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /inventory/pick-lists [post]
+func CreatePickList(c *gin.Context) {
+	var req requests.PickListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+
+	quantities := make(map[uint]float64, len(req.Items))
+	for _, item := range req.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	pickList, err := repository.BuildPickList(repository.DB, req.LocationID, quantities)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("One or more products not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to build pick list", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, pickList)
+}
@@ -54,6 +54,39 @@ func (h *BulkHandler) GetProductImportTemplate(c *gin.Context) {
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
 // @Router /bulk/products/import [post]
 func (h *BulkHandler) UploadProductImport(c *gin.Context) {
+	h.queueImport(c, "BULK_IMPORT")
+}
+
+// GetSupplierPriceImportTemplate godoc
+// @Summary Download supplier price list template
+// @Description Downloads a CSV template for importing supplier catalog entries (cost, minimum order quantity, pack size, lead time and preferred flag per product and supplier)
+// @Tags bulk
+// @Produce text/csv
+// @Success 200 {file} text/csv "CSV template file"
+// @Router /bulk/supplier-prices/template [get]
+func (h *BulkHandler) GetSupplierPriceImportTemplate(c *gin.Context) {
+	templateHeaders := "SKU,SupplierName,SupplierSKU,UnitCost,MinimumOrderQuantity,PackSize,LeadTimeDays,Preferred\n"
+	c.Header("Content-Disposition", "attachment; filename=supplier_price_import_template.csv")
+	c.Data(http.StatusOK, "text/csv", []byte(templateHeaders))
+}
+
+// UploadSupplierPriceImport godoc
+// @Summary Upload a supplier price list
+// @Description Uploads a CSV price list that creates or updates supplier catalog entries for existing products and suppliers. Progress is tracked like a product import.
+// @Tags bulk
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file to upload"
+// @Success 202 {object} domain.Job "Price list import job started"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /bulk/supplier-prices/import [post]
+func (h *BulkHandler) UploadSupplierPriceImport(c *gin.Context) {
+	h.queueImport(c, "SUPPLIER_PRICE_IMPORT")
+}
+
+// queueImport stores the uploaded file and queues an import job of the given type.
+func (h *BulkHandler) queueImport(c *gin.Context, jobType string) {
 	file, err := c.FormFile("file")
 	if err != nil {
 		c.Error(appErrors.NewAppError("Failed to get file", http.StatusBadRequest, err))
@@ -84,7 +117,7 @@ func (h *BulkHandler) UploadProductImport(c *gin.Context) {
 	payloadBytes, _ := json.Marshal(payload)
 
 	job := &domain.Job{
-		Type:       "BULK_IMPORT",
+		Type:       jobType,
 		Status:     "QUEUED",
 		Payload:    string(payloadBytes),
 		MaxRetries: 3,
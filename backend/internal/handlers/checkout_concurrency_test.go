@@ -0,0 +1,285 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/handlers"
+	"inventory/backend/internal/middleware"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+	"inventory/backend/internal/services"
+)
+
+// setupConcurrencyDB opens a database that real concurrent transactions can share. It uses the
+// Postgres database in STRESS_DATABASE_URL when set, as CI does, so the row locks themselves are
+// exercised. Otherwise it falls back to a file-backed SQLite database whose transactions take the
+// write lock up front; that serializes whole transactions, so locally the tests only check that
+// the stock arithmetic adds up and cannot catch a missing lock.
+func setupConcurrencyDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+
+	var db *gorm.DB
+	var err error
+	if dsn := os.Getenv("STRESS_DATABASE_URL"); dsn != "" {
+		db, err = gorm.Open(postgres.Open(dsn), cfg)
+	} else {
+		path := filepath.Join(t.TempDir(), "stress.db")
+		db, err = gorm.Open(sqlite.Open("file:"+path+"?_txlock=immediate&_busy_timeout=30000&_journal_mode=WAL"), cfg)
+	}
+	require.NoError(t, err)
+
+	require.NoError(t, db.AutoMigrate(
+		&domain.User{},
+		&domain.Product{},
+		&domain.Batch{},
+		&domain.StockAdjustment{},
+		&domain.Transaction{},
+		&domain.LoyaltyAccount{},
+		&domain.Order{},
+		&domain.OrderItem{},
+		&domain.Return{},
+		&domain.ReturnItem{},
+		&domain.SystemSetting{},
+		&domain.Promotion{},
+		&domain.StockReservation{},
+		&domain.StockTransfer{},
+		&domain.SerialNumber{},
+		&domain.SerialNumberEvent{},
+	))
+	return db
+}
+
+func newCheckoutRouter(db *gorm.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	settingsService := services.NewSettingsService(repository.NewSettingsRepository(db))
+	salesHandler := handlers.NewSalesHandler(db, settingsService, nil)
+
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	})
+	r.POST("/sales/checkout", salesHandler.Checkout)
+	return r
+}
+
+// seedStock creates a product holding the given batch quantities at location 1.
+func seedStock(t *testing.T, db *gorm.DB, sku string, precision int, quantities ...float64) domain.Product {
+	t.Helper()
+	product := domain.Product{
+		Name:              "Stress " + sku,
+		SKU:               fmt.Sprintf("%s-%s", sku, t.Name()),
+		SellingPrice:      10,
+		PurchasePrice:     4,
+		Status:            "Active",
+		LocationID:        1,
+		QuantityPrecision: precision,
+	}
+	require.NoError(t, db.Create(&product).Error)
+	for i, qty := range quantities {
+		batch := domain.Batch{
+			ProductID:   product.ID,
+			LocationID:  1,
+			BatchNumber: fmt.Sprintf("%s-B%d", product.SKU, i+1),
+			Quantity:    qty,
+			UnitCost:    4,
+		}
+		require.NoError(t, db.Create(&batch).Error)
+	}
+	return product
+}
+
+func checkout(r *gin.Engine, items ...requests.CheckoutItem) int {
+	body, _ := json.Marshal(requests.CheckoutRequest{Items: items, PaymentMethod: "cash"})
+	req, _ := http.NewRequest(http.MethodPost, "/sales/checkout", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+// runConcurrently starts n workers at once and counts the HTTP status codes they return.
+func runConcurrently(n int, work func(i int) int) map[int]int {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	start := make(chan struct{})
+	codes := make(map[int]int)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			code := work(i)
+			mu.Lock()
+			codes[code]++
+			mu.Unlock()
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return codes
+}
+
+func batchTotals(t *testing.T, db *gorm.DB, productID uint) (total float64, negative int) {
+	t.Helper()
+	var batches []domain.Batch
+	require.NoError(t, db.Where("product_id = ?", productID).Find(&batches).Error)
+	for _, b := range batches {
+		total += b.Quantity
+		if b.Quantity < 0 {
+			negative++
+		}
+	}
+	return domain.RoundQuantity(total, domain.MaxQuantityPrecision), negative
+}
+
+func soldQuantity(t *testing.T, db *gorm.DB, productID uint) (float64, int64) {
+	t.Helper()
+	var sold float64
+	require.NoError(t, db.Model(&domain.OrderItem{}).Where("product_id = ?", productID).
+		Select("COALESCE(SUM(quantity), 0)").Scan(&sold).Error)
+	var adjustments int64
+	require.NoError(t, db.Model(&domain.StockAdjustment{}).
+		Where("product_id = ? AND type = ? AND reason_code = ?", productID, "STOCK_OUT", "SALE").
+		Count(&adjustments).Error)
+	return domain.RoundQuantity(sold, domain.MaxQuantityPrecision), adjustments
+}
+
+func TestConcurrentCheckoutDoesNotOversell(t *testing.T) {
+	db := setupConcurrencyDB(t)
+	r := newCheckoutRouter(db)
+	product := seedStock(t, db, "UNIT", 0, 10, 8, 7)
+
+	codes := runConcurrently(60, func(int) int {
+		return checkout(r, requests.CheckoutItem{ProductID: product.ID, Quantity: 1})
+	})
+
+	// Exactly the 25 units on hand are sold; every other attempt is refused
+	assert.Equal(t, 25, codes[http.StatusCreated])
+	assert.Equal(t, 35, codes[http.StatusBadRequest]+codes[http.StatusConflict])
+
+	remaining, negative := batchTotals(t, db, product.ID)
+	assert.Equal(t, 0.0, remaining)
+	assert.Zero(t, negative)
+
+	sold, adjustments := soldQuantity(t, db, product.ID)
+	assert.Equal(t, 25.0, sold)
+	assert.Equal(t, int64(codes[http.StatusCreated]), adjustments)
+}
+
+func TestConcurrentCheckoutFractionalQuantities(t *testing.T) {
+	db := setupConcurrencyDB(t)
+	r := newCheckoutRouter(db)
+	product := seedStock(t, db, "WEIGHED", 3, 4.5, 5.5)
+
+	codes := runConcurrently(20, func(int) int {
+		return checkout(r, requests.CheckoutItem{ProductID: product.ID, Quantity: 0.75})
+	})
+
+	// 13 x 0.75 kg fit into 10 kg, leaving 0.25 kg that no further sale can take
+	assert.Equal(t, 13, codes[http.StatusCreated])
+
+	remaining, negative := batchTotals(t, db, product.ID)
+	assert.Equal(t, 0.25, remaining)
+	assert.Zero(t, negative)
+
+	sold, _ := soldQuantity(t, db, product.ID)
+	assert.Equal(t, 9.75, sold)
+}
+
+func TestConcurrentMultiItemCheckoutsStayConsistent(t *testing.T) {
+	db := setupConcurrencyDB(t)
+	r := newCheckoutRouter(db)
+	apples := seedStock(t, db, "APPLE", 0, 15)
+	pears := seedStock(t, db, "PEAR", 0, 12)
+
+	// Carts list the same products in opposite orders, which deadlocks without ordered locking
+	codes := runConcurrently(40, func(i int) int {
+		if i%2 == 0 {
+			return checkout(r,
+				requests.CheckoutItem{ProductID: apples.ID, Quantity: 1},
+				requests.CheckoutItem{ProductID: pears.ID, Quantity: 1})
+		}
+		return checkout(r,
+			requests.CheckoutItem{ProductID: pears.ID, Quantity: 1},
+			requests.CheckoutItem{ProductID: apples.ID, Quantity: 1})
+	})
+
+	// Pears run out first; a cart either sells both items or neither
+	assert.Equal(t, 12, codes[http.StatusCreated])
+
+	applesLeft, applesNegative := batchTotals(t, db, apples.ID)
+	pearsLeft, pearsNegative := batchTotals(t, db, pears.ID)
+	assert.Equal(t, 3.0, applesLeft)
+	assert.Equal(t, 0.0, pearsLeft)
+	assert.Zero(t, applesNegative+pearsNegative)
+
+	applesSold, _ := soldQuantity(t, db, apples.ID)
+	pearsSold, _ := soldQuantity(t, db, pears.ID)
+	assert.Equal(t, 12.0, applesSold)
+	assert.Equal(t, 12.0, pearsSold)
+}
+
+func TestConcurrentCheckoutAndStockRemovalNoLostUpdates(t *testing.T) {
+	db := setupConcurrencyDB(t)
+	r := newCheckoutRouter(db)
+	product := seedStock(t, db, "MIXED", 0, 20, 20)
+
+	// Sales race against stock-take style removals drawing on the same batches
+	var removed float64
+	var mu sync.Mutex
+	codes := runConcurrently(50, func(i int) int {
+		if i%5 != 0 {
+			return checkout(r, requests.CheckoutItem{ProductID: product.ID, Quantity: 1})
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			// Removals take the product lock first, as stock-take approval does
+			locked, err := repository.LockProducts(tx, []uint{product.ID})
+			if err != nil {
+				return err
+			}
+			if len(locked) != 1 {
+				return gorm.ErrRecordNotFound
+			}
+			qty, _, err := repository.RemoveStockFEFO(tx, &locked[0], 1, 2)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			removed += qty
+			mu.Unlock()
+			return nil
+		})
+		if err != nil {
+			return http.StatusInternalServerError
+		}
+		return http.StatusOK
+	})
+
+	assert.Zero(t, codes[http.StatusInternalServerError])
+	remaining, negative := batchTotals(t, db, product.ID)
+	sold, _ := soldQuantity(t, db, product.ID)
+	assert.Zero(t, negative)
+	// Every unit is accounted for: nothing sold twice and no write overwrote another
+	assert.Equal(t, 40.0, domain.RoundQuantity(remaining+sold+removed, domain.MaxQuantityPrecision))
+	assert.Equal(t, float64(codes[http.StatusCreated]), sold)
+}
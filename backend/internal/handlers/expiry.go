@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+	"inventory/backend/internal/services"
+)
+
+type ExpiryHandler struct {
+	expiryService services.ExpiryService
+}
+
+func NewExpiryHandler(expiryService services.ExpiryService) *ExpiryHandler {
+	return &ExpiryHandler{expiryService: expiryService}
+}
+
+func expiryError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(appErrors.NewAppError(notFound, http.StatusNotFound, err))
+	case errors.Is(err, services.ErrWriteOffState), errors.Is(err, repository.ErrStockConflict):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusConflict, err))
+	case errors.Is(err, services.ErrWriteOffQuantity):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
+	default:
+		c.Error(appErrors.NewAppError("Expiry operation failed", http.StatusInternalServerError, err))
+	}
+}
+
+func parseExpiryID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid ID", http.StatusBadRequest, err))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ListExpiryMarkdownRules godoc
+// @Summary List expiry markdown rules
+// @Tags expiry
+// @Produce json
+// @Success 200 {array} domain.ExpiryMarkdownRule
+// @Router /inventory/expiry-rules [get]
+func (h *ExpiryHandler) ListExpiryMarkdownRules(c *gin.Context) {
+	rules, err := h.expiryService.ListRules()
+	if err != nil {
+		expiryError(c, err, "Rule not found")
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateExpiryMarkdownRule godoc
+// @Summary Create an expiry markdown rule
+// @Description Batches of matching products are marked down automatically once they are within daysBeforeExpiry of expiring. Tiers are built from several rules; the most urgent matching rule wins.
+// @Tags expiry
+// @Accept json
+// @Produce json
+// @Param rule body requests.ExpiryMarkdownRuleRequest true "Markdown rule"
+// @Success 201 {object} domain.ExpiryMarkdownRule
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Router /inventory/expiry-rules [post]
+func (h *ExpiryHandler) CreateExpiryMarkdownRule(c *gin.Context) {
+	var req requests.ExpiryMarkdownRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	rule, err := h.expiryService.CreateRule(&req)
+	if err != nil {
+		expiryError(c, err, "Rule not found")
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateExpiryMarkdownRule godoc
+// @Summary Update an expiry markdown rule
+// @Description Changes apply to markdowns created from the next run; running markdowns keep their discount.
+// @Tags expiry
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param rule body requests.ExpiryMarkdownRuleRequest true "Markdown rule"
+// @Success 200 {object} domain.ExpiryMarkdownRule
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Router /inventory/expiry-rules/{id} [put]
+func (h *ExpiryHandler) UpdateExpiryMarkdownRule(c *gin.Context) {
+	id, ok := parseExpiryID(c)
+	if !ok {
+		return
+	}
+	var req requests.ExpiryMarkdownRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	rule, err := h.expiryService.UpdateRule(id, &req)
+	if err != nil {
+		expiryError(c, err, "Rule not found")
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteExpiryMarkdownRule godoc
+// @Summary Delete an expiry markdown rule
+// @Description Deletes the rule and ends the markdowns it created.
+// @Tags expiry
+// @Param id path int true "Rule ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Router /inventory/expiry-rules/{id} [delete]
+func (h *ExpiryHandler) DeleteExpiryMarkdownRule(c *gin.Context) {
+	id, ok := parseExpiryID(c)
+	if !ok {
+		return
+	}
+	if err := h.expiryService.DeleteRule(id); err != nil {
+		expiryError(c, err, "Rule not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RunExpiryChecks godoc
+// @Summary Run expiry quarantine and markdowns now
+// @Description Quarantines expired batches (raising pending write-offs) and creates markdowns for batches entering a rule's window. The same checks run hourly.
+// @Tags expiry
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /inventory/expiry-rules/apply [post]
+func (h *ExpiryHandler) RunExpiryChecks(c *gin.Context) {
+	quarantined, err := h.expiryService.QuarantineExpiredBatches()
+	if err != nil {
+		expiryError(c, err, "Batch not found")
+		return
+	}
+	markdowns, err := h.expiryService.ApplyMarkdownRules()
+	if err != nil {
+		expiryError(c, err, "Batch not found")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"quarantinedBatches": quarantined, "markdownsCreated": markdowns})
+}
+
+// RequestStockWriteOff godoc
+// @Summary Request a stock write-off
+// @Description Raises a write-off of expired, damaged or spoiled stock from a batch. Stock only leaves the batch once a manager approves it.
+// @Tags expiry
+// @Accept json
+// @Produce json
+// @Param writeOff body requests.StockWriteOffRequest true "Write-off request"
+// @Success 201 {object} domain.StockWriteOff
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Batch not found"
+// @Router /inventory/write-offs [post]
+func (h *ExpiryHandler) RequestStockWriteOff(c *gin.Context) {
+	var req requests.StockWriteOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	writeOff, err := h.expiryService.RequestWriteOff(&req, userID)
+	if err != nil {
+		expiryError(c, err, "Batch not found")
+		return
+	}
+	c.JSON(http.StatusCreated, writeOff)
+}
+
+// ListStockWriteOffs godoc
+// @Summary List stock write-offs
+// @Tags expiry
+// @Produce json
+// @Param status query string false "PENDING, APPROVED or REJECTED"
+// @Success 200 {array} domain.StockWriteOff
+// @Router /inventory/write-offs [get]
+func (h *ExpiryHandler) ListStockWriteOffs(c *gin.Context) {
+	writeOffs, err := h.expiryService.ListWriteOffs(c.Query("status"))
+	if err != nil {
+		expiryError(c, err, "Write-off not found")
+		return
+	}
+	c.JSON(http.StatusOK, writeOffs)
+}
+
+// ApproveStockWriteOff godoc
+// @Summary Approve a stock write-off
+// @Description Removes the stock from its batch and posts a stock-out adjustment with the write-off's reason (e.g. EXPIRED), valued at the batch cost.
+// @Tags expiry
+// @Accept json
+// @Produce json
+// @Param id path int true "Write-off ID"
+// @Param review body requests.ReviewStockWriteOffRequest false "Review notes"
+// @Success 200 {object} domain.StockWriteOff
+// @Failure 404 {object} map[string]interface{} "Write-off not found"
+// @Failure 409 {object} map[string]interface{} "Write-off is not pending"
+// @Router /inventory/write-offs/{id}/approve [post]
+func (h *ExpiryHandler) ApproveStockWriteOff(c *gin.Context) {
+	h.reviewStockWriteOff(c, h.expiryService.ApproveWriteOff)
+}
+
+// RejectStockWriteOff godoc
+// @Summary Reject a stock write-off
+// @Tags expiry
+// @Accept json
+// @Produce json
+// @Param id path int true "Write-off ID"
+// @Param review body requests.ReviewStockWriteOffRequest false "Review notes"
+// @Success 200 {object} domain.StockWriteOff
+// @Failure 404 {object} map[string]interface{} "Write-off not found"
+// @Failure 409 {object} map[string]interface{} "Write-off is not pending"
+// @Router /inventory/write-offs/{id}/reject [post]
+func (h *ExpiryHandler) RejectStockWriteOff(c *gin.Context) {
+	h.reviewStockWriteOff(c, h.expiryService.RejectWriteOff)
+}
+
+func (h *ExpiryHandler) reviewStockWriteOff(c *gin.Context, review func(uint, *requests.ReviewStockWriteOffRequest, uint) (*domain.StockWriteOff, error)) {
+	id, ok := parseExpiryID(c)
+	if !ok {
+		return
+	}
+	var req requests.ReviewStockWriteOffRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+			return
+		}
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	writeOff, err := review(id, &req, userID)
+	if err != nil {
+		expiryError(c, err, "Write-off not found")
+		return
+	}
+	c.JSON(http.StatusOK, writeOff)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -41,6 +42,20 @@ func CreateStockTransfer(c *gin.Context) {
 		return
 	}
 
+	var product domain.Product
+	if err := repository.DB.First(&product, req.ProductID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Product not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch product", http.StatusInternalServerError, err))
+		return
+	}
+	if !product.IsValidQuantity(req.Quantity) {
+		c.Error(quantityPrecisionError(&product, req.Quantity))
+		return
+	}
+
 	// Get UserID from context
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -58,48 +73,112 @@ func CreateStockTransfer(c *gin.Context) {
 	}
 
 	err := repository.DB.Transaction(func(tx *gorm.DB) error {
-		// Create the stock transfer record
-		if err := tx.Create(&transfer).Error; err != nil {
-			return err
-		}
+		return repository.PostStockTransfer(tx, &product, &transfer, userID.(uint))
+	})
 
-		// Create stock adjustment for source location (stock out)
-		sourceAdjustment := domain.StockAdjustment{
-			ProductID:  req.ProductID,
-			LocationID: req.SourceLocationID,
-			Type:       "STOCK_OUT",
-			Quantity:   req.Quantity,
-			ReasonCode: "TRANSFER_OUT",
-			Notes:      "Stock transfer to location " + strconv.FormatUint(uint64(req.DestLocationID), 10),
-			AdjustedBy: userID.(uint),
-			AdjustedAt: time.Now(),
-		}
-		if err := tx.Create(&sourceAdjustment).Error; err != nil {
-			return err
+	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientTransferStock) {
+			c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
+		} else {
+			c.Error(appErrors.NewAppError("Failed to create stock transfer", http.StatusInternalServerError, err))
 		}
+		return
+	}
 
-		// Create stock adjustment for destination location (stock in)
-		destAdjustment := domain.StockAdjustment{
-			ProductID:  req.ProductID,
-			LocationID: req.DestLocationID,
-			Type:       "STOCK_IN",
-			Quantity:   req.Quantity,
-			ReasonCode: "TRANSFER_IN",
-			Notes:      "Stock transfer from location " + strconv.FormatUint(uint64(req.SourceLocationID), 10),
-			AdjustedBy: userID.(uint),
-			AdjustedAt: time.Now(),
-		}
-		if err := tx.Create(&destAdjustment).Error; err != nil {
-			return err
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// CompleteStockTransfer godoc
+// @Summary Receive a stock transfer
+// @Description Confirms a pending transfer arrived: moves the stock from the source batches (the transfer's batch first, then earliest expiry) into batches at the destination and marks it COMPLETED. Serialized products must list the units received.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Param receipt body requests.CompleteStockTransferRequest false "Serial numbers received"
+// @Success 200 {object} domain.StockTransfer
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Transfer not found"
+// @Failure 409 {object} map[string]interface{} "Transfer is not pending or the source no longer holds the stock"
+// @Router /inventory/transfers/{id}/complete [post]
+func CompleteStockTransfer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid transfer ID", http.StatusBadRequest, err))
+		return
+	}
+	var req requests.CompleteStockTransferRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+			return
 		}
+	}
+	serials, err := domain.NormalizeSerialNumbers(req.SerialNumbers)
+	if err != nil {
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
 
-		return nil
+	var transfer *domain.StockTransfer
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = repository.CompleteStockTransfer(tx, uint(id), serials, userID)
+		return err
 	})
-
 	if err != nil {
-		c.Error(appErrors.NewAppError("Failed to create stock transfer", http.StatusInternalServerError, err))
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.Error(appErrors.NewAppError("Stock transfer not found", http.StatusNotFound, err))
+		case errors.Is(err, repository.ErrTransferSerials):
+			c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
+		case errors.Is(err, repository.ErrTransferNotPending),
+			errors.Is(err, repository.ErrInsufficientTransferStock),
+			errors.Is(err, repository.ErrStockConflict):
+			c.Error(appErrors.NewAppError(err.Error(), http.StatusConflict, err))
+		default:
+			c.Error(appErrors.NewAppError("Failed to complete stock transfer", http.StatusInternalServerError, err))
+		}
 		return
 	}
+	c.JSON(http.StatusOK, transfer)
+}
 
-	c.JSON(http.StatusCreated, transfer)
+// ListStockTransfers godoc
+// @Summary List stock transfers
+// @Description Lists transfers newest first, e.g. rebalancing drafts awaiting approval with status=DRAFT&origin=REBALANCE
+// @Tags inventory
+// @Produce json
+// @Param status query string false "DRAFT, PENDING, COMPLETED or CANCELLED"
+// @Param origin query string false "MANUAL or REBALANCE"
+// @Param productId query int false "Product ID"
+// @Param locationId query int false "Source or destination location ID"
+// @Success 200 {array} domain.StockTransfer
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /inventory/transfers [get]
+func ListStockTransfers(c *gin.Context) {
+	query := repository.DB.Preload("Product").Preload("SourceLocation").Preload("DestLocation")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if origin := c.Query("origin"); origin != "" {
+		query = query.Where("origin = ?", origin)
+	}
+	if productID := c.Query("productId"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if locationID := c.Query("locationId"); locationID != "" {
+		query = query.Where("source_location_id = ? OR dest_location_id = ?", locationID, locationID)
+	}
+
+	var transfers []domain.StockTransfer
+	if err := query.Order("created_at desc").Limit(200).Find(&transfers).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch stock transfers", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, transfers)
 }
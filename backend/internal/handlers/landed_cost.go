@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+)
+
+func newLandedCostCharge(poID uint, req requests.LandedCostChargeRequest, userID uint) domain.LandedCostCharge {
+	return domain.LandedCostCharge{
+		PurchaseOrderID:  poID,
+		ChargeType:       req.ChargeType,
+		Description:      req.Description,
+		Reference:        req.Reference,
+		Amount:           domain.RoundMoney(req.Amount),
+		AllocationMethod: req.AllocationMethod,
+		Status:           domain.LandedCostStatusPending,
+		CreatedBy:        userID,
+	}
+}
+
+// CreateLandedCostCharge godoc
+// @Summary Add a landed-cost charge to a Purchase Order
+// @Description Records freight, duty, handling or similar costs for a Purchase Order. The charge is spread by value, quantity or weight of the order: each delivery takes its share as it is received (goods already received take theirs immediately) and the charge is fully allocated once the order is complete.
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Param charge body requests.LandedCostChargeRequest true "Charge"
+// @Success 201 {object} domain.LandedCostCharge
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Purchase Order not found"
+// @Failure 409 {object} map[string]interface{} "Purchase Order is cancelled"
+// @Router /replenishment/purchase-orders/{poId}/landed-costs [post]
+func CreateLandedCostCharge(c *gin.Context) {
+	var req requests.LandedCostChargeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+
+	authUserID, _ := c.Get("user_id")
+	userID, _ := authUserID.(uint)
+
+	var charge domain.LandedCostCharge
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		var po domain.PurchaseOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&po, c.Param("poId")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return appErrors.NewAppError("Purchase Order not found", http.StatusNotFound, err)
+			}
+			return appErrors.NewAppError("Failed to fetch purchase order", http.StatusInternalServerError, err)
+		}
+		if po.Status == "CANCELLED" {
+			return appErrors.NewAppError("Cannot add charges to a cancelled Purchase Order", http.StatusConflict, nil)
+		}
+
+		charge = newLandedCostCharge(po.ID, req, userID)
+		if err := tx.Create(&charge).Error; err != nil {
+			return appErrors.NewAppError("Failed to record landed cost charge", http.StatusInternalServerError, err)
+		}
+		if po.Status == "RECEIVED" || po.Status == "PARTIALLY_RECEIVED" {
+			if err := repository.AllocateReceivedLandedCost(tx, &charge); err != nil {
+				return appErrors.NewAppError(fmt.Sprintf("Failed to allocate charge: %v", err), http.StatusBadRequest, err)
+			}
+		}
+		return tx.Preload("Allocations").First(&charge, charge.ID).Error
+	})
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(appErrors.NewAppError("Failed to record landed cost charge", http.StatusInternalServerError, err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, charge)
+}
+
+// ListLandedCostCharges godoc
+// @Summary List landed-cost charges for a Purchase Order
+// @Description Retrieves the charges recorded against a Purchase Order with their allocation to received lines
+// @Tags replenishment
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Success 200 {array} domain.LandedCostCharge
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/purchase-orders/{poId}/landed-costs [get]
+func ListLandedCostCharges(c *gin.Context) {
+	var charges []domain.LandedCostCharge
+	if err := repository.DB.Preload("Allocations.Product").Where("purchase_order_id = ?", c.Param("poId")).
+		Order("id").Find(&charges).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch landed cost charges", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, charges)
+}
+
+// AllocateLandedCostCharge godoc
+// @Summary Allocate a pending landed-cost charge
+// @Description Allocates what is left of a pending charge across the goods received so far that do not carry a share of it yet, without waiting for the Purchase Order to be fully received
+// @Tags replenishment
+// @Produce json
+// @Param id path int true "Charge ID"
+// @Success 200 {object} domain.LandedCostCharge
+// @Failure 400 {object} map[string]interface{} "Nothing received to allocate to"
+// @Failure 404 {object} map[string]interface{} "Charge not found"
+// @Failure 409 {object} map[string]interface{} "Charge already allocated"
+// @Router /replenishment/landed-costs/{id}/allocate [post]
+func AllocateLandedCostCharge(c *gin.Context) {
+	var charge domain.LandedCostCharge
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&charge, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return appErrors.NewAppError("Landed cost charge not found", http.StatusNotFound, err)
+			}
+			return appErrors.NewAppError("Failed to fetch landed cost charge", http.StatusInternalServerError, err)
+		}
+		if charge.Status != domain.LandedCostStatusPending {
+			return appErrors.NewAppError("Landed cost charge is already allocated", http.StatusConflict, nil)
+		}
+		if err := repository.AllocateLandedCostCharge(tx, &charge, nil); err != nil {
+			return appErrors.NewAppError(fmt.Sprintf("Failed to allocate charge: %v", err), http.StatusBadRequest, err)
+		}
+		return tx.Preload("Allocations.Product").First(&charge, charge.ID).Error
+	})
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(appErrors.NewAppError("Failed to allocate landed cost charge", http.StatusInternalServerError, err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, charge)
+}
+
+// DeleteLandedCostCharge godoc
+// @Summary Delete a pending landed-cost charge
+// @Description Removes a charge that no received goods carry a share of yet
+// @Tags replenishment
+// @Param id path int true "Charge ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Charge not found"
+// @Failure 409 {object} map[string]interface{} "Charge already allocated"
+// @Router /replenishment/landed-costs/{id} [delete]
+func DeleteLandedCostCharge(c *gin.Context) {
+	var charge domain.LandedCostCharge
+	if err := repository.DB.First(&charge, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Landed cost charge not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch landed cost charge", http.StatusInternalServerError, err))
+		return
+	}
+	var allocations int64
+	if err := repository.DB.Model(&domain.LandedCostAllocation{}).Where("charge_id = ?", charge.ID).Count(&allocations).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch landed cost allocations", http.StatusInternalServerError, err))
+		return
+	}
+	if charge.Status != domain.LandedCostStatusPending || allocations > 0 {
+		c.Error(appErrors.NewAppError("Allocated charges cannot be deleted", http.StatusConflict, nil))
+		return
+	}
+	if err := repository.DB.Delete(&charge).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to delete landed cost charge", http.StatusInternalServerError, err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
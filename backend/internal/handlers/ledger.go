@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/requests"
+	"inventory/backend/internal/services"
+)
+
+type LedgerHandler struct {
+	ledgerService services.LedgerService
+}
+
+func NewLedgerHandler(ledgerService services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{ledgerService: ledgerService}
+}
+
+func ledgerError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(appErrors.NewAppError("Reconciliation not found", http.StatusNotFound, err))
+	case errors.Is(err, services.ErrReconciliationState), errors.Is(err, services.ErrUncoveredShortfall):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusConflict, err))
+	default:
+		c.Error(appErrors.NewAppError("Ledger reconciliation failed", http.StatusInternalServerError, err))
+	}
+}
+
+func parseReconciliationID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid reconciliation ID", http.StatusBadRequest, err))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// RunLedgerReconciliation godoc
+// @Summary Check stock ledger integrity
+// @Description Replays the stock adjustment ledger per product and location, compares it with batch quantities and reports discrepancies, negative batches and drifted quantity snapshots. Findings wait for manager approval before any correction is posted.
+// @Tags ledger
+// @Produce json
+// @Success 201 {object} domain.LedgerReconciliation
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /inventory/ledger/reconciliations [post]
+func (h *LedgerHandler) RunLedgerReconciliation(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	reconciliation, err := h.ledgerService.RunReconciliation(&userID)
+	if err != nil {
+		ledgerError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, reconciliation)
+}
+
+// ListLedgerReconciliations godoc
+// @Summary List ledger reconciliations
+// @Tags ledger
+// @Produce json
+// @Param status query string false "CLEAN, PENDING_APPROVAL, APPROVED or REJECTED"
+// @Success 200 {array} domain.LedgerReconciliation
+// @Router /inventory/ledger/reconciliations [get]
+func (h *LedgerHandler) ListLedgerReconciliations(c *gin.Context) {
+	reconciliations, err := h.ledgerService.ListReconciliations(c.Query("status"))
+	if err != nil {
+		ledgerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reconciliations)
+}
+
+// GetLedgerReconciliation godoc
+// @Summary Get a ledger reconciliation with its discrepancies
+// @Tags ledger
+// @Produce json
+// @Param id path int true "Reconciliation ID"
+// @Success 200 {object} domain.LedgerReconciliation
+// @Failure 404 {object} map[string]interface{} "Reconciliation not found"
+// @Router /inventory/ledger/reconciliations/{id} [get]
+func (h *LedgerHandler) GetLedgerReconciliation(c *gin.Context) {
+	id, ok := parseReconciliationID(c)
+	if !ok {
+		return
+	}
+	reconciliation, err := h.ledgerService.GetReconciliation(id)
+	if err != nil {
+		ledgerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reconciliation)
+}
+
+// ApproveLedgerReconciliation godoc
+// @Summary Approve ledger corrections
+// @Description Clears negative batches and posts LEDGER_CORRECTION adjustments that bring the ledger in line with batch quantities, for all discrepancies or the selected ones
+// @Tags ledger
+// @Accept json
+// @Produce json
+// @Param id path int true "Reconciliation ID"
+// @Param approval body requests.ApproveLedgerReconciliationRequest false "Discrepancies to correct"
+// @Success 200 {object} domain.LedgerReconciliation
+// @Failure 404 {object} map[string]interface{} "Reconciliation not found"
+// @Failure 409 {object} map[string]interface{} "Reconciliation is not awaiting approval, or a location's batches net below zero"
+// @Router /inventory/ledger/reconciliations/{id}/approve [post]
+func (h *LedgerHandler) ApproveLedgerReconciliation(c *gin.Context) {
+	id, ok := parseReconciliationID(c)
+	if !ok {
+		return
+	}
+	var req requests.ApproveLedgerReconciliationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+			return
+		}
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	reconciliation, err := h.ledgerService.ApproveReconciliation(id, &req, userID)
+	if err != nil {
+		ledgerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reconciliation)
+}
+
+// RejectLedgerReconciliation godoc
+// @Summary Reject ledger corrections
+// @Description Closes the reconciliation without changing stock
+// @Tags ledger
+// @Accept json
+// @Produce json
+// @Param id path int true "Reconciliation ID"
+// @Param rejection body requests.RejectLedgerReconciliationRequest false "Reason"
+// @Success 200 {object} domain.LedgerReconciliation
+// @Failure 404 {object} map[string]interface{} "Reconciliation not found"
+// @Failure 409 {object} map[string]interface{} "Reconciliation is not awaiting approval"
+// @Router /inventory/ledger/reconciliations/{id}/reject [post]
+func (h *LedgerHandler) RejectLedgerReconciliation(c *gin.Context) {
+	id, ok := parseReconciliationID(c)
+	if !ok {
+		return
+	}
+	var req requests.RejectLedgerReconciliationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+			return
+		}
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	reconciliation, err := h.ledgerService.RejectReconciliation(id, &req, userID)
+	if err != nil {
+		ledgerError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reconciliation)
+}
@@ -141,7 +141,7 @@ func UpdateLocation(c *gin.Context) {
 
 // DeleteLocation godoc
 // @Summary Delete a location
-// @Description Delete an inventory location by its ID. Cannot delete if products, batches, or stock adjustments are associated.
+// @Description Delete an inventory location by its ID. Cannot delete if products, batches, bins, or stock adjustments are associated.
 // @Tags locations
 // @Accept json
 // @Produce json
@@ -179,6 +179,14 @@ func DeleteLocation(c *gin.Context) {
 		return
 	}
 
+	// Check for bins
+	var binCount int64
+	repository.DB.Model(&domain.Bin{}).Where("location_id = ?", id).Count(&binCount)
+	if binCount > 0 {
+		c.Error(appErrors.NewAppError("Cannot delete location: bins are defined", http.StatusConflict, nil))
+		return
+	}
+
 	// Check for associated stock adjustments
 	var adjustmentCount int64
 	repository.DB.Model(&domain.StockAdjustment{}).Where("location_id = ?", id).Count(&adjustmentCount)
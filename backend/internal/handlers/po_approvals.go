@@ -0,0 +1,553 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+)
+
+// SubmitPurchaseOrder godoc
+// @Summary Submit a draft Purchase Order for approval
+// @Description Starts an approval round: every approval rule matching the order's value, supplier and product categories adds a sign-off step, signed off in level order. Orders no rule matches need one sign-off. Approvers of the first step are notified.
+// @Tags replenishment
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Success 200 {object} domain.PurchaseOrder
+// @Failure 400 {object} map[string]interface{} "Purchase Order is not a draft or has no items"
+// @Failure 404 {object} map[string]interface{} "Purchase Order not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/purchase-orders/{poId}/submit [post]
+func (h *ReplenishmentHandler) SubmitPurchaseOrder(c *gin.Context) {
+	var po domain.PurchaseOrder
+	var next *domain.POApproval
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPurchaseOrder(tx, c.Param("poId"), &po); err != nil {
+			return err
+		}
+		if po.Status != "DRAFT" {
+			return appErrors.NewAppError("Purchase Order is not in DRAFT state", http.StatusBadRequest, nil)
+		}
+		steps, err := submitPurchaseOrder(tx, &po)
+		if err != nil {
+			return err
+		}
+		next = &steps[0]
+		return nil
+	})
+	if err != nil {
+		respondApprovalError(c, err, "Failed to submit purchase order")
+		return
+	}
+
+	h.notifyApprovers(&po, next)
+	c.JSON(http.StatusOK, reloadPurchaseOrder(po.ID))
+}
+
+// ApprovePurchaseOrder godoc
+// @Summary Sign off the current approval step of a Purchase Order
+// @Description Approves the order's current step. The order's creator cannot approve it, and nobody can sign off two steps of the same round. Once the last step is approved the order becomes APPROVED; otherwise approvers of the next step are notified. Drafts are submitted first.
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Param decision body requests.PODecisionRequest false "Approval comments"
+// @Success 200 {object} domain.PurchaseOrder
+// @Failure 400 {object} map[string]interface{} "Purchase Order is not awaiting approval"
+// @Failure 403 {object} map[string]interface{} "User may not sign off this step"
+// @Failure 404 {object} map[string]interface{} "Purchase Order not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/purchase-orders/{poId}/approve [post]
+func (h *ReplenishmentHandler) ApprovePurchaseOrder(c *gin.Context) {
+	var req requests.PODecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+			return
+		}
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var po domain.PurchaseOrder
+	var next *domain.POApproval
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPurchaseOrder(tx, c.Param("poId"), &po); err != nil {
+			return err
+		}
+		if po.Status == "DRAFT" {
+			if _, err := submitPurchaseOrder(tx, &po); err != nil {
+				return err
+			}
+		} else if po.Status != "PENDING_APPROVAL" {
+			return appErrors.NewAppError(fmt.Sprintf("Purchase Order in %s status is not awaiting approval", po.Status), http.StatusBadRequest, nil)
+		}
+
+		step, err := decideApprovalStep(tx, &po, userID, domain.ApprovalStatusApproved, req.Comments)
+		if err != nil {
+			return err
+		}
+		if next, err = repository.CurrentApprovalStep(tx, po.ID); err != nil {
+			return appErrors.NewAppError("Failed to fetch next approval step", http.StatusInternalServerError, err)
+		}
+		if next != nil {
+			return nil
+		}
+		return tx.Model(&po).Updates(map[string]interface{}{
+			"Status":     "APPROVED",
+			"ApprovedBy": userID,
+			"ApprovedAt": *step.DecidedAt,
+		}).Error
+	})
+	if err != nil {
+		respondApprovalError(c, err, "Failed to approve purchase order")
+		return
+	}
+
+	if next != nil {
+		h.notifyApprovers(&po, next)
+	} else {
+		h.notifyCreator(&po, "PO_APPROVED", "Purchase Order Approved", fmt.Sprintf("Purchase Order #%d has been approved.", po.ID))
+	}
+	c.JSON(http.StatusOK, reloadPurchaseOrder(po.ID))
+}
+
+// RejectPurchaseOrder godoc
+// @Summary Reject a Purchase Order awaiting approval
+// @Description Rejects the order's current step, closes the rest of the round and returns the order to DRAFT so it can be revised and resubmitted. Comments are required. The creator is notified.
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Param decision body requests.PODecisionRequest true "Rejection comments"
+// @Success 200 {object} domain.PurchaseOrder
+// @Failure 400 {object} map[string]interface{} "Purchase Order is not awaiting approval or comments are missing"
+// @Failure 403 {object} map[string]interface{} "User may not sign off this step"
+// @Failure 404 {object} map[string]interface{} "Purchase Order not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/purchase-orders/{poId}/reject [post]
+func (h *ReplenishmentHandler) RejectPurchaseOrder(c *gin.Context) {
+	var req requests.PODecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	if req.Comments == "" {
+		c.Error(appErrors.NewAppError("Comments are required to reject a purchase order", http.StatusBadRequest, nil))
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var po domain.PurchaseOrder
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPurchaseOrder(tx, c.Param("poId"), &po); err != nil {
+			return err
+		}
+		if po.Status != "PENDING_APPROVAL" {
+			return appErrors.NewAppError(fmt.Sprintf("Purchase Order in %s status is not awaiting approval", po.Status), http.StatusBadRequest, nil)
+		}
+		if _, err := decideApprovalStep(tx, &po, userID, domain.ApprovalStatusRejected, req.Comments); err != nil {
+			return err
+		}
+		if err := repository.CancelPendingApprovals(tx, po.ID); err != nil {
+			return appErrors.NewAppError("Failed to close remaining approval steps", http.StatusInternalServerError, err)
+		}
+		return tx.Model(&po).Update("Status", "DRAFT").Error
+	})
+	if err != nil {
+		respondApprovalError(c, err, "Failed to reject purchase order")
+		return
+	}
+
+	h.notifyCreator(&po, "PO_REJECTED", "Purchase Order Rejected", fmt.Sprintf("Purchase Order #%d was rejected: %s", po.ID, req.Comments))
+	c.JSON(http.StatusOK, reloadPurchaseOrder(po.ID))
+}
+
+// ListPurchaseOrderApprovals godoc
+// @Summary Get the approval history of a Purchase Order
+// @Description Returns the sign-off steps of every submission of the order, with each decision, approver and comments.
+// @Tags replenishment
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Success 200 {array} domain.POApproval
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/purchase-orders/{poId}/approvals [get]
+func ListPurchaseOrderApprovals(c *gin.Context) {
+	var steps []domain.POApproval
+	if err := repository.DB.Preload("Rule").Preload("ApproverRole").
+		Where("purchase_order_id = ?", c.Param("poId")).
+		Order("round, level, id").Find(&steps).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch approval history", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, steps)
+}
+
+// ListMyPendingApprovals godoc
+// @Summary List Purchase Orders awaiting the current user's sign-off
+// @Description Returns the current approval step of each order awaiting approval that the user may sign off.
+// @Tags replenishment
+// @Produce json
+// @Success 200 {array} domain.POApproval
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/approvals/pending [get]
+func ListMyPendingApprovals(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	var user domain.User
+	if err := repository.DB.First(&user, userID).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch user", http.StatusInternalServerError, err))
+		return
+	}
+
+	var steps []domain.POApproval
+	if err := repository.DB.Preload("ApproverRole").
+		Joins("JOIN purchase_orders ON purchase_orders.id = po_approvals.purchase_order_id").
+		Where("po_approvals.status = ? AND purchase_orders.status = ? AND purchase_orders.created_by <> ?", domain.ApprovalStatusPending, "PENDING_APPROVAL", userID).
+		Order("po_approvals.purchase_order_id, po_approvals.round, po_approvals.level, po_approvals.id").
+		Find(&steps).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch pending approvals", http.StatusInternalServerError, err))
+		return
+	}
+
+	// Only each order's current step can be signed off
+	pending := make([]domain.POApproval, 0)
+	seen := make(map[uint]bool)
+	for _, step := range steps {
+		if seen[step.PurchaseOrderID] {
+			continue
+		}
+		seen[step.PurchaseOrderID] = true
+		if step.ApproverRoleID != nil && *step.ApproverRoleID != user.RoleID {
+			continue
+		}
+		decided, err := repository.HasDecidedApprovalRound(repository.DB, step.PurchaseOrderID, step.Round, userID)
+		if err != nil {
+			c.Error(appErrors.NewAppError("Failed to fetch approval history", http.StatusInternalServerError, err))
+			return
+		}
+		if !decided {
+			pending = append(pending, step)
+		}
+	}
+	c.JSON(http.StatusOK, pending)
+}
+
+// ListPOApprovalRules godoc
+// @Summary List purchase order approval rules
+// @Tags replenishment
+// @Produce json
+// @Success 200 {array} domain.POApprovalRule
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/approval-rules [get]
+func ListPOApprovalRules(c *gin.Context) {
+	var rules []domain.POApprovalRule
+	if err := repository.DB.Preload("Supplier").Preload("Category").Preload("ApproverRole").
+		Order("level, min_amount").Find(&rules).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch approval rules", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreatePOApprovalRule godoc
+// @Summary Create a purchase order approval rule
+// @Description Orders of at least minAmount, from the supplier and with a product in the category (when given), need sign-off from the approver role at the rule's level. Applies to orders submitted afterwards.
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param rule body requests.POApprovalRuleRequest true "Approval rule"
+// @Success 201 {object} domain.POApprovalRule
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/approval-rules [post]
+func CreatePOApprovalRule(c *gin.Context) {
+	var req requests.POApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	rule := domain.POApprovalRule{IsActive: true}
+	if !applyPOApprovalRuleRequest(c, &rule, &req) {
+		return
+	}
+	if err := repository.DB.Create(&rule).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to create approval rule", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdatePOApprovalRule godoc
+// @Summary Update a purchase order approval rule
+// @Description Orders already awaiting approval keep the steps they were submitted with.
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param rule body requests.POApprovalRuleRequest true "Approval rule"
+// @Success 200 {object} domain.POApprovalRule
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/approval-rules/{id} [put]
+func UpdatePOApprovalRule(c *gin.Context) {
+	var req requests.POApprovalRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	var rule domain.POApprovalRule
+	if err := repository.DB.First(&rule, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Approval rule not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch approval rule", http.StatusInternalServerError, err))
+		return
+	}
+	if !applyPOApprovalRuleRequest(c, &rule, &req) {
+		return
+	}
+	if err := repository.DB.Omit(clause.Associations).Save(&rule).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to update approval rule", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeletePOApprovalRule godoc
+// @Summary Delete a purchase order approval rule
+// @Description Orders already awaiting approval keep the steps they were submitted with.
+// @Tags replenishment
+// @Param id path int true "Rule ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/approval-rules/{id} [delete]
+func DeletePOApprovalRule(c *gin.Context) {
+	result := repository.DB.Delete(&domain.POApprovalRule{}, c.Param("id"))
+	if result.Error != nil {
+		c.Error(appErrors.NewAppError("Failed to delete approval rule", http.StatusInternalServerError, result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(appErrors.NewAppError("Approval rule not found", http.StatusNotFound, nil))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// applyPOApprovalRuleRequest copies the request onto the rule after checking the role, supplier and
+// category it refers to exist, writing the error response and returning false when they do not.
+func applyPOApprovalRuleRequest(c *gin.Context, rule *domain.POApprovalRule, req *requests.POApprovalRuleRequest) bool {
+	checks := []struct {
+		model interface{}
+		id    *uint
+		name  string
+	}{
+		{&domain.Role{}, &req.ApproverRoleID, "Approver role"},
+		{&domain.Supplier{}, req.SupplierID, "Supplier"},
+		{&domain.Category{}, req.CategoryID, "Category"},
+	}
+	for _, check := range checks {
+		if check.id == nil {
+			continue
+		}
+		if err := repository.DB.First(check.model, *check.id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.Error(appErrors.NewAppError(check.name+" not found", http.StatusBadRequest, err))
+				return false
+			}
+			c.Error(appErrors.NewAppError("Failed to validate approval rule", http.StatusInternalServerError, err))
+			return false
+		}
+	}
+
+	rule.Name = req.Name
+	rule.Level = req.Level
+	rule.MinAmount = req.MinAmount
+	rule.SupplierID = req.SupplierID
+	rule.CategoryID = req.CategoryID
+	rule.ApproverRoleID = req.ApproverRoleID
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return true
+}
+
+// lockPurchaseOrder locks the order and loads its items.
+func lockPurchaseOrder(tx *gorm.DB, poID string, po *domain.PurchaseOrder) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("PurchaseOrderItems").First(po, poID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return appErrors.NewAppError("Purchase Order not found", http.StatusNotFound, err)
+		}
+		return appErrors.NewAppError("Failed to fetch purchase order", http.StatusInternalServerError, err)
+	}
+	return nil
+}
+
+func submitPurchaseOrder(tx *gorm.DB, po *domain.PurchaseOrder) ([]domain.POApproval, error) {
+	if len(po.PurchaseOrderItems) == 0 {
+		return nil, appErrors.NewAppError("Cannot submit a purchase order without items", http.StatusBadRequest, nil)
+	}
+	steps, err := repository.SubmitPurchaseOrderForApproval(tx, po)
+	if err != nil {
+		return nil, appErrors.NewAppError("Failed to submit purchase order", http.StatusInternalServerError, err)
+	}
+	return steps, nil
+}
+
+// decideApprovalStep records the user's decision on the order's current step, after checking the user
+// did not create the order, has not already signed off this round and holds the step's role.
+func decideApprovalStep(tx *gorm.DB, po *domain.PurchaseOrder, userID uint, status, comments string) (*domain.POApproval, error) {
+	step, err := repository.CurrentApprovalStep(tx, po.ID)
+	if err != nil {
+		return nil, appErrors.NewAppError("Failed to fetch approval step", http.StatusInternalServerError, err)
+	}
+	if step == nil {
+		return nil, appErrors.NewAppError("Purchase Order has no approval step awaiting a decision", http.StatusBadRequest, nil)
+	}
+	if po.CreatedBy == userID {
+		return nil, appErrors.NewAppError("You cannot approve or reject a purchase order you created", http.StatusForbidden, nil)
+	}
+	decided, err := repository.HasDecidedApprovalRound(tx, po.ID, step.Round, userID)
+	if err != nil {
+		return nil, appErrors.NewAppError("Failed to fetch approval history", http.StatusInternalServerError, err)
+	}
+	if decided {
+		return nil, appErrors.NewAppError("You have already signed off this purchase order", http.StatusForbidden, nil)
+	}
+	if step.ApproverRoleID != nil {
+		var user domain.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return nil, appErrors.NewAppError("Failed to fetch user", http.StatusInternalServerError, err)
+		}
+		if user.RoleID != *step.ApproverRoleID {
+			roleName := fmt.Sprintf("#%d", *step.ApproverRoleID)
+			if step.ApproverRole != nil {
+				roleName = step.ApproverRole.Name
+			}
+			return nil, appErrors.NewAppError(fmt.Sprintf("This level %d step needs sign-off from the %s role", step.Level, roleName), http.StatusForbidden, nil)
+		}
+	}
+
+	now := time.Now()
+	step.Status = status
+	step.DecidedBy = &userID
+	step.DecidedAt = &now
+	step.Comments = comments
+	if err := tx.Model(step).Updates(map[string]interface{}{
+		"Status":    status,
+		"DecidedBy": userID,
+		"DecidedAt": now,
+		"Comments":  comments,
+	}).Error; err != nil {
+		return nil, appErrors.NewAppError("Failed to record approval decision", http.StatusInternalServerError, err)
+	}
+	return step, nil
+}
+
+func respondApprovalError(c *gin.Context, err error, message string) {
+	if appErr, ok := err.(*appErrors.AppError); ok {
+		c.Error(appErr)
+		return
+	}
+	c.Error(appErrors.NewAppError(message, http.StatusInternalServerError, err))
+}
+
+func reloadPurchaseOrder(poID uint) domain.PurchaseOrder {
+	var po domain.PurchaseOrder
+	repository.DB.Preload("Supplier").Preload("PurchaseOrderItems.Product").
+		Preload("Approvals", func(db *gorm.DB) *gorm.DB { return db.Order("round, level, id") }).
+		First(&po, poID)
+	return po
+}
+
+// notifyApprovers tells whoever can sign off the step that the order is waiting for them.
+func (h *ReplenishmentHandler) notifyApprovers(po *domain.PurchaseOrder, step *domain.POApproval) {
+	payload := gin.H{
+		"event": "PO_APPROVAL_REQUESTED",
+		"type":  "PURCHASE_ORDER",
+		"data":  gin.H{"purchaseOrderId": po.ID, "level": step.Level, "orderValue": step.OrderValue},
+	}
+	notification := domain.Notification{
+		Type:        "PO_APPROVAL_REQUESTED",
+		Title:       "Purchase Order Awaiting Approval",
+		Message:     fmt.Sprintf("Purchase Order #%d (%.2f) needs your level %d approval.", po.ID, step.OrderValue, step.Level),
+		Payload:     fmt.Sprintf(`{"purchaseOrderId":%d}`, po.ID),
+		TriggeredAt: time.Now(),
+	}
+
+	// Steps without a role can be signed off by anyone allowed to manage purchasing
+	var userIDs []uint
+	var err error
+	if step.ApproverRoleID == nil {
+		userIDs, err = repository.GetActiveUserIDsByPermission(repository.DB, "replenishment.write")
+	} else {
+		userIDs, err = repository.GetActiveUserIDsByRole(repository.DB, *step.ApproverRoleID)
+	}
+	if err != nil {
+		logrus.Errorf("Failed to find approvers of purchase order %d: %v", po.ID, err)
+		return
+	}
+	for _, userID := range userIDs {
+		// The creator and whoever decided an earlier level cannot approve this one
+		if userID == po.CreatedBy {
+			continue
+		}
+		decided, err := repository.HasDecidedApprovalRound(repository.DB, po.ID, step.Round, userID)
+		if err != nil {
+			logrus.Errorf("Failed to check earlier approvals of purchase order %d: %v", po.ID, err)
+			return
+		}
+		if decided {
+			continue
+		}
+		n := notification
+		n.UserID = userID
+		if err := h.NotificationRepo.CreateNotification(&n); err != nil {
+			logrus.Errorf("Failed to notify user %d of purchase order %d: %v", userID, po.ID, err)
+		}
+		h.Hub.SendToUser(userID, payload)
+	}
+}
+
+// notifyCreator tells the order's creator about the outcome of its approval.
+func (h *ReplenishmentHandler) notifyCreator(po *domain.PurchaseOrder, event, title, message string) {
+	if po.CreatedBy == 0 {
+		return
+	}
+	notification := domain.Notification{
+		UserID:      po.CreatedBy,
+		Type:        event,
+		Title:       title,
+		Message:     message,
+		Payload:     fmt.Sprintf(`{"purchaseOrderId":%d}`, po.ID),
+		TriggeredAt: time.Now(),
+	}
+	if err := h.NotificationRepo.CreateNotification(&notification); err != nil {
+		logrus.Errorf("Failed to notify creator of purchase order %d: %v", po.ID, err)
+	}
+	h.Hub.SendToUser(po.CreatedBy, gin.H{
+		"event": event,
+		"type":  "PURCHASE_ORDER",
+		"data":  gin.H{"purchaseOrderId": po.ID},
+	})
+}
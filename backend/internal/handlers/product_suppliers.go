@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+)
+
+// ListProductSuppliers godoc
+// @Summary List a product's suppliers
+// @Description Returns every supplier catalog entry for the product with its cost, minimum order quantity, pack size and lead time.
+// @Tags products
+// @Produce json
+// @Param productId path int true "Product ID"
+// @Success 200 {array} domain.ProductSupplier
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /products/{productId}/suppliers [get]
+func ListProductSuppliers(c *gin.Context) {
+	var entries []domain.ProductSupplier
+	if err := repository.DB.Preload("Supplier").Where("product_id = ?", c.Param("productId")).Order("is_preferred desc, unit_cost").Find(&entries).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch product suppliers", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// SaveProductSupplier godoc
+// @Summary Add or update a supplier's catalog entry for a product
+// @Description Marking the entry preferred clears the flag on the product's other suppliers.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param productId path int true "Product ID"
+// @Param supplierId path int true "Supplier ID"
+// @Param entry body requests.ProductSupplierRequest true "Catalog entry"
+// @Success 200 {object} domain.ProductSupplier
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product or supplier not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /products/{productId}/suppliers/{supplierId} [put]
+func SaveProductSupplier(c *gin.Context) {
+	var req requests.ProductSupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+
+	var product domain.Product
+	if err := repository.DB.First(&product, c.Param("productId")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Product not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch product", http.StatusInternalServerError, err))
+		return
+	}
+	var supplier domain.Supplier
+	if err := repository.DB.First(&supplier, c.Param("supplierId")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Supplier not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch supplier", http.StatusInternalServerError, err))
+		return
+	}
+
+	entry := domain.ProductSupplier{
+		ProductID:            product.ID,
+		SupplierID:           supplier.ID,
+		SupplierSKU:          req.SupplierSKU,
+		UnitCost:             req.UnitCost,
+		MinimumOrderQuantity: req.MinimumOrderQuantity,
+		PackSize:             req.PackSize,
+		LeadTimeDays:         domain.DefaultLeadTimeDays,
+		IsPreferred:          req.IsPreferred,
+	}
+	if req.LeadTimeDays != nil {
+		entry.LeadTimeDays = *req.LeadTimeDays
+	}
+	if err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		return repository.SaveProductSupplier(tx, &entry)
+	}); err != nil {
+		c.Error(appErrors.NewAppError("Failed to save product supplier", http.StatusInternalServerError, err))
+		return
+	}
+	entry.Supplier = supplier
+	c.JSON(http.StatusOK, entry)
+}
+
+// DeleteProductSupplier godoc
+// @Summary Remove a supplier from a product's catalog
+// @Tags products
+// @Param productId path int true "Product ID"
+// @Param supplierId path int true "Supplier ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Catalog entry not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /products/{productId}/suppliers/{supplierId} [delete]
+func DeleteProductSupplier(c *gin.Context) {
+	// Hard delete so the supplier can be added back without tripping the unique index
+	result := repository.DB.Unscoped().
+		Where("product_id = ? AND supplier_id = ?", c.Param("productId"), c.Param("supplierId")).
+		Delete(&domain.ProductSupplier{})
+	if result.Error != nil {
+		c.Error(appErrors.NewAppError("Failed to delete product supplier", http.StatusInternalServerError, result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(appErrors.NewAppError("Catalog entry not found", http.StatusNotFound, nil))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetBestProductSupplier godoc
+// @Summary Choose the supplier to order a product from
+// @Description Picks the preferred supplier, or else the one with the lowest cost for the quantity after minimums and pack rounding, shorter lead time breaking ties. With urgent=true the shortest lead time wins.
+// @Tags products
+// @Produce json
+// @Param productId path int true "Product ID"
+// @Param quantity query number true "Quantity needed"
+// @Param urgent query bool false "Prefer the shortest lead time"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product has no suppliers in its catalog"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /products/{productId}/suppliers/best [get]
+func GetBestProductSupplier(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid product ID", http.StatusBadRequest, err))
+		return
+	}
+	quantity, err := strconv.ParseFloat(c.Query("quantity"), 64)
+	if err != nil || quantity <= 0 {
+		c.Error(appErrors.NewAppError("quantity must be a positive number", http.StatusBadRequest, err))
+		return
+	}
+	urgent := c.Query("urgent") == "true"
+
+	catalog, err := repository.GetProductSuppliers(repository.DB.Preload("Supplier"), []uint{uint(productID)})
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch product suppliers", http.StatusInternalServerError, err))
+		return
+	}
+	best := domain.ChooseSupplier(catalog[uint(productID)], quantity, urgent)
+	if best == nil {
+		c.Error(appErrors.NewAppError("Product has no suppliers in its catalog", http.StatusNotFound, nil))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"supplier":      best,
+		"orderQuantity": best.OrderQuantity(quantity),
+		"orderCost":     best.OrderCost(quantity),
+	})
+}
+
+// ListSupplierCatalog godoc
+// @Summary List the products a supplier offers
+// @Tags suppliers
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {array} domain.ProductSupplier
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /suppliers/{id}/catalog [get]
+func ListSupplierCatalog(c *gin.Context) {
+	var entries []domain.ProductSupplier
+	if err := repository.DB.Preload("Product").Where("supplier_id = ?", c.Param("id")).Order("id").Find(&entries).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch supplier catalog", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -71,12 +71,30 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		ImageURLs:     req.ImageURLs,
 		Status:        "Active", // Default status
 		LocationID:    req.LocationID,
+
+		UnitOfMeasure:     req.UnitOfMeasure,
+		QuantityPrecision: req.QuantityPrecision,
+		PLUCode:           domain.NormalizePLU(req.PLUCode),
+		IsSerialized:      req.IsSerialized,
+		WarrantyDays:      req.WarrantyDays,
+		CostingMethod:     req.CostingMethod,
+		Weight:            req.Weight,
+	}
+	if product.IsSerialized && product.QuantityPrecision != 0 {
+		c.Error(appErrors.NewAppError("Serialized products must be tracked in whole units", http.StatusBadRequest, nil))
+		return
+	}
+	if product.UnitOfMeasure == "" {
+		product.UnitOfMeasure = "unit"
+	}
+	if product.CostingMethod == "" {
+		product.CostingMethod = domain.CostingMethodFIFO
 	}
 
 	if err := h.productRepo.CreateProduct(&product); err != nil {
-		// Check for unique constraint violation (e.g., SKU, BarcodeUPC)
+		// Check for unique constraint violation (e.g., SKU, BarcodeUPC, PLUCode)
 		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-			c.Error(appErrors.NewAppError("Product with this SKU or BarcodeUPC already exists", http.StatusConflict, err))
+			c.Error(appErrors.NewAppError("Product with this SKU, BarcodeUPC or PLU code already exists", http.StatusConflict, err))
 			return
 		}
 		c.Error(appErrors.NewAppError("Failed to create product", http.StatusInternalServerError, err))
@@ -323,9 +341,46 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		"Status":        req.Status,
 		"LocationID":    req.LocationID,
 	}
+	if req.UnitOfMeasure != "" {
+		updates["UnitOfMeasure"] = req.UnitOfMeasure
+	}
+	if req.QuantityPrecision != nil {
+		updates["QuantityPrecision"] = *req.QuantityPrecision
+	}
+	if req.PLUCode != "" {
+		updates["PLUCode"] = domain.NormalizePLU(req.PLUCode)
+	}
+	if req.IsSerialized != nil {
+		updates["IsSerialized"] = *req.IsSerialized
+	}
+	if req.WarrantyDays != nil {
+		updates["WarrantyDays"] = *req.WarrantyDays
+	}
+	if req.CostingMethod != "" {
+		updates["CostingMethod"] = req.CostingMethod
+	}
+	if req.Weight != nil {
+		updates["Weight"] = *req.Weight
+	}
+
+	isSerialized, precision := product.IsSerialized, product.QuantityPrecision
+	if req.IsSerialized != nil {
+		isSerialized = *req.IsSerialized
+	}
+	if req.QuantityPrecision != nil {
+		precision = *req.QuantityPrecision
+	}
+	if isSerialized && precision != 0 {
+		c.Error(appErrors.NewAppError("Serialized products must be tracked in whole units", http.StatusBadRequest, nil))
+		return
+	}
 
 	// Update fields using the repository method
 	if err := h.productRepo.UpdateProduct(&product, updates); err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			c.Error(appErrors.NewAppError("Another product already uses this BarcodeUPC or PLU code", http.StatusConflict, err))
+			return
+		}
 		c.Error(appErrors.NewAppError("Failed to update product", http.StatusInternalServerError, err))
 		return
 	}
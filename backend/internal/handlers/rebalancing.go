@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/requests"
+	"inventory/backend/internal/services"
+)
+
+type RebalancingHandler struct {
+	rebalancingService services.RebalancingService
+}
+
+func NewRebalancingHandler(rebalancingService services.RebalancingService) *RebalancingHandler {
+	return &RebalancingHandler{rebalancingService: rebalancingService}
+}
+
+// GenerateRebalancingDrafts godoc
+// @Summary Suggest inter-store rebalancing transfers
+// @Description Compares each location's stock with its min/max and forecast demand and drafts transfers from overstocked to short locations, shipping near-expiry batches first. Drafts move no stock until approved.
+// @Tags inventory
+// @Produce json
+// @Success 201 {array} domain.StockTransfer
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /inventory/transfers/rebalance [post]
+func (h *RebalancingHandler) GenerateRebalancingDrafts(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	drafts, err := h.rebalancingService.GenerateRebalancingDrafts(userID)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to generate rebalancing transfers", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusCreated, drafts)
+}
+
+// ApproveTransferDrafts godoc
+// @Summary Approve transfer drafts in bulk
+// @Description Posts each selected draft as a pending transfer. Drafts whose source no longer has the stock are reported as failed and left as drafts.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param drafts body requests.TransferDraftsRequest true "Drafts to approve"
+// @Success 200 {object} services.TransferApprovalResult
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Router /inventory/transfers/drafts/approve [post]
+func (h *RebalancingHandler) ApproveTransferDrafts(c *gin.Context) {
+	var req requests.TransferDraftsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, h.rebalancingService.ApproveDrafts(req.TransferIDs, userID))
+}
+
+// CancelTransferDrafts godoc
+// @Summary Cancel transfer drafts in bulk
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param drafts body requests.TransferDraftsRequest true "Drafts to cancel"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Router /inventory/transfers/drafts/cancel [post]
+func (h *RebalancingHandler) CancelTransferDrafts(c *gin.Context) {
+	var req requests.TransferDraftsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	cancelled, err := h.rebalancingService.CancelDrafts(req.TransferIDs)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to cancel transfer drafts", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cancelled": cancelled})
+}
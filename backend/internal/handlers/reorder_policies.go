@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+)
+
+// ListReorderPolicies godoc
+// @Summary List reorder policies
+// @Description Returns the reorder policy of each category and the default policy (no category).
+// @Tags replenishment
+// @Produce json
+// @Success 200 {array} domain.ReorderPolicy
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/policies [get]
+func ListReorderPolicies(c *gin.Context) {
+	var policies []domain.ReorderPolicy
+	if err := repository.DB.Preload("Category").Order("category_id IS NOT NULL, category_id").Find(&policies).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch reorder policies", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+// CreateReorderPolicy godoc
+// @Summary Create a reorder policy
+// @Description Sets how reorder suggestions are sized for a category, or for every product without a category policy when no category is given.
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param policy body requests.ReorderPolicyRequest true "Reorder policy"
+// @Success 201 {object} domain.ReorderPolicy
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Category not found"
+// @Failure 409 {object} map[string]interface{} "The category already has a policy"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/policies [post]
+func CreateReorderPolicy(c *gin.Context) {
+	var req requests.ReorderPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+
+	var policy domain.ReorderPolicy
+	if !applyReorderPolicyRequest(c, &policy, &req) {
+		return
+	}
+	if err := repository.DB.Create(&policy).Error; err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			c.Error(appErrors.NewAppError("A reorder policy already exists for this category", http.StatusConflict, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to create reorder policy", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusCreated, policy)
+}
+
+// UpdateReorderPolicy godoc
+// @Summary Update a reorder policy
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param id path int true "Policy ID"
+// @Param policy body requests.ReorderPolicyRequest true "Reorder policy"
+// @Success 200 {object} domain.ReorderPolicy
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Policy or category not found"
+// @Failure 409 {object} map[string]interface{} "The category already has a policy"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/policies/{id} [put]
+func UpdateReorderPolicy(c *gin.Context) {
+	var req requests.ReorderPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+
+	var policy domain.ReorderPolicy
+	if err := repository.DB.First(&policy, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Reorder policy not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch reorder policy", http.StatusInternalServerError, err))
+		return
+	}
+	if !applyReorderPolicyRequest(c, &policy, &req) {
+		return
+	}
+	if err := repository.DB.Omit("Category").Save(&policy).Error; err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
+			c.Error(appErrors.NewAppError("A reorder policy already exists for this category", http.StatusConflict, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to update reorder policy", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteReorderPolicy godoc
+// @Summary Delete a reorder policy
+// @Description The category falls back to the default policy.
+// @Tags replenishment
+// @Param id path int true "Policy ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Policy not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/policies/{id} [delete]
+func DeleteReorderPolicy(c *gin.Context) {
+	// Hard delete so the category can be given a new policy without tripping the unique index
+	result := repository.DB.Unscoped().Delete(&domain.ReorderPolicy{}, c.Param("id"))
+	if result.Error != nil {
+		c.Error(appErrors.NewAppError("Failed to delete reorder policy", http.StatusInternalServerError, result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.Error(appErrors.NewAppError("Reorder policy not found", http.StatusNotFound, nil))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// applyReorderPolicyRequest copies the request onto the policy and checks it, writing the error
+// response and returning false when the policy cannot be saved.
+func applyReorderPolicyRequest(c *gin.Context, policy *domain.ReorderPolicy, req *requests.ReorderPolicyRequest) bool {
+	policy.CategoryID = req.CategoryID
+	policy.PolicyType = req.PolicyType
+	policy.ServiceLevel = req.ServiceLevel
+	policy.OrderingCost = req.OrderingCost
+	policy.HoldingCostRate = req.HoldingCostRate
+	policy.ReviewPeriodDays = req.ReviewPeriodDays
+	policy.Category = nil
+	if err := policy.Validate(); err != nil {
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
+		return false
+	}
+
+	if policy.CategoryID != nil {
+		var category domain.Category
+		if err := repository.DB.First(&category, *policy.CategoryID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				c.Error(appErrors.NewAppError("Category not found", http.StatusNotFound, err))
+				return false
+			}
+			c.Error(appErrors.NewAppError("Failed to fetch category", http.StatusInternalServerError, err))
+			return false
+		}
+	}
+
+	// One policy per category, and one default
+	query := repository.DB.Model(&domain.ReorderPolicy{}).Where("id <> ?", policy.ID)
+	if policy.CategoryID != nil {
+		query = query.Where("category_id = ?", *policy.CategoryID)
+	} else {
+		query = query.Where("category_id IS NULL")
+	}
+	var existing int64
+	if err := query.Count(&existing).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to check existing reorder policies", http.StatusInternalServerError, err))
+		return false
+	}
+	if existing > 0 {
+		c.Error(appErrors.NewAppError("A reorder policy already exists for this category", http.StatusConflict, nil))
+		return false
+	}
+	return true
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
@@ -23,15 +26,19 @@ type ReplenishmentHandler struct {
 	Hub                  *websocket.Hub
 	NotificationRepo     repository.NotificationRepository
 	EmailService         services.EmailService
+	ConsolidationService services.POConsolidationService
+	DocumentService      services.PODocumentService
 }
 
-func NewReplenishmentHandler(forecastingService services.ForecastingService, replenishmentService services.ReplenishmentService, hub *websocket.Hub, notificationRepo repository.NotificationRepository, emailService services.EmailService) *ReplenishmentHandler {
+func NewReplenishmentHandler(forecastingService services.ForecastingService, replenishmentService services.ReplenishmentService, hub *websocket.Hub, notificationRepo repository.NotificationRepository, emailService services.EmailService, consolidationService services.POConsolidationService, documentService services.PODocumentService) *ReplenishmentHandler {
 	return &ReplenishmentHandler{
 		ForecastingService:   forecastingService,
 		ReplenishmentService: replenishmentService,
 		Hub:                  hub,
 		NotificationRepo:     notificationRepo,
 		EmailService:         emailService,
+		ConsolidationService: consolidationService,
+		DocumentService:      documentService,
 	}
 }
 
@@ -97,7 +104,7 @@ func GetDemandForecast(c *gin.Context) {
 
 // GetForecastDashboard godoc
 // @Summary Get forecasting dashboard data
-// @Description Retrieves aggregated forecasting data including top predicted demand and low stock warnings.
+// @Description Retrieves aggregated forecasting data including top predicted demand, low stock warnings, the products whose forecasts missed most (by WAPE) and accuracy by method.
 // @Tags replenishment
 // @Produce json
 // @Success 200 {object} map[string]interface{}
@@ -112,6 +119,80 @@ func (h *ReplenishmentHandler) GetForecastDashboard(c *gin.Context) {
 	c.JSON(http.StatusOK, dashboard)
 }
 
+// GetForecastAccuracy godoc
+// @Summary Get forecast accuracy
+// @Description Scores forecasts from the last 180 days whose period has ended against what actually sold: MAPE, WAPE and bias (positive = over-forecast), as fractions. Worst first.
+// @Tags replenishment
+// @Produce json
+// @Param groupBy query string false "product or method" default(product)
+// @Success 200 {array} services.ForecastAccuracy
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/forecast/accuracy [get]
+func (h *ReplenishmentHandler) GetForecastAccuracy(c *gin.Context) {
+	groupBy := c.DefaultQuery("groupBy", "product")
+	if groupBy != "product" && groupBy != "method" {
+		c.Error(appErrors.NewAppError("groupBy must be 'product' or 'method'", http.StatusBadRequest, nil))
+		return
+	}
+	accuracy, err := h.ForecastingService.GetForecastAccuracy(groupBy)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to get forecast accuracy", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, accuracy)
+}
+
+// EvaluateForecasts godoc
+// @Summary Score matured forecasts now
+// @Description Records actual sales against every forecast whose period has ended. The same job runs daily.
+// @Tags replenishment
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/forecast/accuracy/evaluate [post]
+func (h *ReplenishmentHandler) EvaluateForecasts(c *gin.Context) {
+	evaluated, err := h.ForecastingService.EvaluateForecasts()
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to evaluate forecasts", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"evaluated": evaluated})
+}
+
+// BacktestForecast godoc
+// @Summary Backtest forecasting for a product
+// @Description Replays forecasting over a past window: every horizonDays from the start date a forecast is made from the sales before that day and compared with what sold next. Accuracy is reported for automatic method selection (AUTO) and for each method forced.
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param backtest body requests.ForecastBacktestRequest true "Backtest window"
+// @Success 200 {object} services.BacktestResult
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/forecast/backtest [post]
+func (h *ReplenishmentHandler) BacktestForecast(c *gin.Context) {
+	var req requests.ForecastBacktestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	result, err := h.ForecastingService.Backtest(req.ProductID, req.StartDate, req.EndDate, req.HorizonDays)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidBacktest):
+			c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.Error(appErrors.NewAppError("Product not found", http.StatusNotFound, err))
+		default:
+			c.Error(appErrors.NewAppError("Failed to run backtest", http.StatusInternalServerError, err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // GenerateReorderSuggestions godoc
 // @Summary Generate reorder suggestions
 // @Description Triggers the generation of reorder suggestions based on current stock levels
@@ -159,6 +240,95 @@ func (h *ReplenishmentHandler) ListReorderSuggestions(c *gin.Context) {
 	c.JSON(http.StatusOK, suggestions)
 }
 
+// ApproveReorderSuggestion godoc
+// @Summary Approve a reorder suggestion
+// @Description Approved suggestions are consolidated into purchase orders by supplier and delivery location.
+// @Tags replenishment
+// @Produce json
+// @Param suggestionId path int true "Reorder Suggestion ID"
+// @Success 200 {object} domain.ReorderSuggestion
+// @Failure 400 {object} map[string]interface{} "Suggestion is not pending"
+// @Failure 404 {object} map[string]interface{} "Suggestion not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/suggestions/{suggestionId}/approve [post]
+func ApproveReorderSuggestion(c *gin.Context) {
+	reviewReorderSuggestion(c, "APPROVED")
+}
+
+// RejectReorderSuggestion godoc
+// @Summary Reject a reorder suggestion
+// @Tags replenishment
+// @Produce json
+// @Param suggestionId path int true "Reorder Suggestion ID"
+// @Success 200 {object} domain.ReorderSuggestion
+// @Failure 400 {object} map[string]interface{} "Suggestion is not pending or approved"
+// @Failure 404 {object} map[string]interface{} "Suggestion not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/suggestions/{suggestionId}/reject [post]
+func RejectReorderSuggestion(c *gin.Context) {
+	reviewReorderSuggestion(c, "REJECTED")
+}
+
+// reviewReorderSuggestion moves a suggestion to APPROVED or REJECTED. Pending suggestions can be either;
+// approved ones can still be rejected until they are ordered.
+func reviewReorderSuggestion(c *gin.Context, status string) {
+	var suggestion domain.ReorderSuggestion
+	err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&suggestion, c.Param("suggestionId")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return appErrors.NewAppError("Reorder suggestion not found", http.StatusNotFound, err)
+			}
+			return appErrors.NewAppError("Failed to fetch suggestion", http.StatusInternalServerError, err)
+		}
+		if suggestion.Status != "PENDING" && !(status == "REJECTED" && suggestion.Status == "APPROVED") {
+			return appErrors.NewAppError(fmt.Sprintf("Cannot mark a %s suggestion as %s", suggestion.Status, status), http.StatusBadRequest, nil)
+		}
+		suggestion.Status = status
+		return tx.Model(&suggestion).Update("Status", status).Error
+	})
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			c.Error(appErr)
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to update reorder suggestion", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, suggestion)
+}
+
+// ConsolidateReorderSuggestions godoc
+// @Summary Consolidate approved reorder suggestions into purchase orders
+// @Description Groups approved suggestions by supplier and delivery location into one purchase order each. Orders slightly short of the supplier's minimum order value or free-shipping threshold are topped up; orders far below the minimum are held. Orders within the supplier's auto-approve limit are approved straight away.
+// @Tags replenishment
+// @Produce json
+// @Param supplierId query int false "Only consolidate this supplier's suggestions"
+// @Success 200 {object} services.ConsolidationResult
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /replenishment/suggestions/consolidate [post]
+func (h *ReplenishmentHandler) ConsolidateReorderSuggestions(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	var supplierID uint64
+	if raw := c.Query("supplierId"); raw != "" {
+		var err error
+		if supplierID, err = strconv.ParseUint(raw, 10, 32); err != nil {
+			c.Error(appErrors.NewAppError("Invalid supplier ID", http.StatusBadRequest, err))
+			return
+		}
+	}
+
+	result, err := h.ConsolidationService.ConsolidateSuggestions(uint(supplierID), userID)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to consolidate reorder suggestions", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // CreatePOFromSuggestion godoc
 // @Summary Create a draft Purchase Order from a reorder suggestion
 // @Description Creates a draft Purchase Order based on a selected reorder suggestion
@@ -194,20 +364,26 @@ func CreatePOFromSuggestion(c *gin.Context) {
 			return appErrors.NewAppError("Failed to fetch suggestion", http.StatusInternalServerError, err)
 		}
 
-		if suggestion.Status != "PENDING" {
-			return appErrors.NewAppError("Suggestion is not in PENDING state", http.StatusBadRequest, nil)
+		if suggestion.Status != "PENDING" && suggestion.Status != "APPROVED" {
+			return appErrors.NewAppError("Suggestion is not in PENDING or APPROVED state", http.StatusBadRequest, nil)
 		}
 
+		unitPrice := suggestion.UnitCost
+		if unitPrice == 0 {
+			unitPrice = suggestion.Product.PurchasePrice
+		}
+		expected := time.Now().AddDate(0, 0, suggestion.LeadTimeDays)
 		po = domain.PurchaseOrder{
-			SupplierID: suggestion.SupplierID,
-			Status:     "DRAFT",
-			OrderDate:  time.Now(),
-			CreatedBy:  authUserID,
+			SupplierID:           suggestion.SupplierID,
+			Status:               "DRAFT",
+			OrderDate:            time.Now(),
+			ExpectedDeliveryDate: &expected,
+			CreatedBy:            authUserID,
 			PurchaseOrderItems: []domain.PurchaseOrderItem{
 				{
 					ProductID:       suggestion.ProductID,
 					OrderedQuantity: suggestion.SuggestedOrderQuantity,
-					UnitPrice:       suggestion.Product.PurchasePrice,
+					UnitPrice:       unitPrice,
 				},
 			},
 		}
@@ -216,7 +392,7 @@ func CreatePOFromSuggestion(c *gin.Context) {
 			return appErrors.NewAppError("Failed to create purchase order", http.StatusInternalServerError, err)
 		}
 
-		if err := tx.Model(&suggestion).Update("Status", "PO_CREATED").Error; err != nil {
+		if err := tx.Model(&suggestion).Updates(map[string]interface{}{"Status": "PO_CREATED", "PurchaseOrderID": po.ID}).Error; err != nil {
 			return appErrors.NewAppError("Failed to update suggestion status", http.StatusInternalServerError, err)
 		}
 		return nil
@@ -234,6 +410,14 @@ func CreatePOFromSuggestion(c *gin.Context) {
 	c.JSON(http.StatusCreated, po)
 }
 
+// poItemUnitPrice returns the price requested for a PO line, or the supplier's catalog price when none was given.
+func poItemUnitPrice(tx *gorm.DB, itemReq requests.POItemRequest, supplierID uint) (float64, error) {
+	if itemReq.UnitPrice > 0 {
+		return itemReq.UnitPrice, nil
+	}
+	return repository.CatalogUnitPrice(tx, itemReq.ProductID, supplierID)
+}
+
 // SendPurchaseOrder godoc
 // @Summary Send a Purchase Order to the supplier
 // @Description Marks an approved Purchase Order as SENT
@@ -263,7 +447,7 @@ func (h *ReplenishmentHandler) SendPurchaseOrder(c *gin.Context) {
 		return
 	}
 
-	if err := repository.DB.Model(&po).Update("Status", "SENT").Error; err != nil {
+	if err := repository.DB.Model(&po).Updates(map[string]interface{}{"Status": "SENT", "SentAt": time.Now()}).Error; err != nil {
 		c.Error(appErrors.NewAppError("Failed to mark purchase order as sent", http.StatusInternalServerError, err))
 		return
 	}
@@ -273,70 +457,47 @@ func (h *ReplenishmentHandler) SendPurchaseOrder(c *gin.Context) {
 	repository.DB.Preload("Supplier").Preload("PurchaseOrderItems.Product").First(&po, poID)
 
 	go func() {
-		if err := h.EmailService.SendPurchaseOrderEmail(po); err != nil {
+		pdf, err := h.DocumentService.GeneratePurchaseOrderPDF(po.ID)
+		if err != nil {
+			// Still send the order, just without the attachment
+			logrus.Errorf("Failed to generate PDF for purchase order %d: %v", po.ID, err)
+		}
+		if err := h.EmailService.SendPurchaseOrderEmail(po, pdf); err != nil {
 			// Log error but don't fail request
-			fmt.Printf("Failed to send PO email: %v\n", err)
+			logrus.Errorf("Failed to send purchase order %d email: %v", po.ID, err)
 		}
 	}()
 
 	c.JSON(http.StatusOK, po)
 }
 
-// ApprovePurchaseOrder godoc
-// @Summary Approve a draft Purchase Order
-// @Description Approves a draft Purchase Order, changing its status to APPROVED
+// DownloadPurchaseOrderPDF godoc
+// @Summary Download a Purchase Order as PDF
+// @Description Renders the order with the business details from settings, its lines, total and delivery location, and returns it. This is the document attached to the supplier email; the copy in object storage is written when the order is sent or changed, not on download.
 // @Tags replenishment
-// @Accept json
-// @Produce json
+// @Produce application/pdf
 // @Param poId path int true "Purchase Order ID"
-// @Success 200 {object} domain.PurchaseOrder
-// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Success 200 {file} file "Purchase order PDF"
 // @Failure 404 {object} map[string]interface{} "Purchase Order not found"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
-// @Router /purchase-orders/{poId}/approve [post]
-func ApprovePurchaseOrder(c *gin.Context) {
-	poID := c.Param("poId")
-	userIDVal, ok := c.Get("user_id")
-	if !ok {
-		c.Error(appErrors.NewAppError("Authenticated user not found", http.StatusUnauthorized, nil))
-		return
-	}
-	authUserID, ok := userIDVal.(uint)
-	if !ok {
-		c.Error(appErrors.NewAppError("Invalid user ID type in context", http.StatusInternalServerError, nil))
+// @Router /replenishment/purchase-orders/{poId}/pdf [get]
+func (h *ReplenishmentHandler) DownloadPurchaseOrderPDF(c *gin.Context) {
+	poID, err := strconv.ParseUint(c.Param("poId"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid purchase order ID", http.StatusBadRequest, err))
 		return
 	}
-
-	var po domain.PurchaseOrder
-	err := repository.DB.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&po, poID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return appErrors.NewAppError("Purchase Order not found", http.StatusNotFound, err)
-			}
-			return appErrors.NewAppError("Failed to fetch purchase order", http.StatusInternalServerError, err)
-		}
-
-		if po.Status != "DRAFT" {
-			return appErrors.NewAppError("Purchase Order is not in DRAFT state", http.StatusBadRequest, nil)
-		}
-
-		return tx.Model(&po).Updates(map[string]interface{}{
-			"Status":     "APPROVED",
-			"ApprovedBy": authUserID,
-			"ApprovedAt": time.Now(),
-		}).Error
-	})
-
+	pdf, err := h.DocumentService.RenderPurchaseOrderPDF(uint(poID))
 	if err != nil {
-		if appErr, ok := err.(*appErrors.AppError); ok {
-			c.Error(appErr)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.Error(appErrors.NewAppError("Purchase Order not found", http.StatusNotFound, err))
 			return
 		}
-		c.Error(appErrors.NewAppError("Failed to approve purchase order", http.StatusInternalServerError, err))
+		c.Error(appErrors.NewAppError("Failed to generate purchase order PDF", http.StatusInternalServerError, err))
 		return
 	}
-
-	c.JSON(http.StatusOK, po)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", pdf.FileName))
+	c.Data(http.StatusOK, "application/pdf", pdf.Content)
 }
 
 // GetPurchaseOrder godoc
@@ -353,7 +514,9 @@ func ApprovePurchaseOrder(c *gin.Context) {
 func GetPurchaseOrder(c *gin.Context) {
 	poID := c.Param("poId")
 	var po domain.PurchaseOrder
-	if err := repository.DB.Preload("Supplier").Preload("PurchaseOrderItems.Product").First(&po, poID).Error; err != nil {
+	if err := repository.DB.Preload("Supplier").Preload("PurchaseOrderItems.Product").
+		Preload("Approvals", func(db *gorm.DB) *gorm.DB { return db.Order("round, level, id") }).
+		First(&po, poID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.Error(appErrors.NewAppError("Purchase Order not found", http.StatusNotFound, err))
 			return
@@ -430,12 +593,21 @@ func UpdatePurchaseOrder(c *gin.Context) {
 			c.Error(appErrors.NewAppError("Failed to clear existing PO items", http.StatusInternalServerError, err))
 			return
 		}
+		supplierID := po.SupplierID
+		if req.SupplierID != 0 {
+			supplierID = req.SupplierID
+		}
 		for _, itemReq := range req.PurchaseOrderItems {
+			unitPrice, err := poItemUnitPrice(repository.DB, itemReq, supplierID)
+			if err != nil {
+				c.Error(appErrors.NewAppError("Failed to look up supplier price", http.StatusInternalServerError, err))
+				return
+			}
 			poItem := domain.PurchaseOrderItem{
 				PurchaseOrderID: po.ID,
 				ProductID:       itemReq.ProductID,
 				OrderedQuantity: itemReq.OrderedQuantity,
-				UnitPrice:       itemReq.UnitPrice,
+				UnitPrice:       unitPrice,
 			}
 			if err := repository.DB.Create(&poItem).Error; err != nil {
 				c.Error(appErrors.NewAppError("Failed to create PO item", http.StatusInternalServerError, err))
@@ -451,7 +623,7 @@ func UpdatePurchaseOrder(c *gin.Context) {
 
 // ReceivePurchaseOrder godoc
 // @Summary Record received goods for a Purchase Order
-// @Description Records received quantities for items in a Purchase Order and updates stock levels
+// @Description Records received quantities for items in a Purchase Order and updates stock levels. Landed-cost charges sent with the receipt are allocated to its lines.
 // @Tags replenishment
 // @Accept json
 // @Produce json
@@ -471,6 +643,9 @@ func ReceivePurchaseOrder(c *gin.Context) {
 		return
 	}
 
+	authUserID, _ := c.Get("user_id")
+	userID, _ := authUserID.(uint)
+
 	var po domain.PurchaseOrder
 	err := repository.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("PurchaseOrderItems").First(&po, poID).Error; err != nil {
@@ -480,10 +655,11 @@ func ReceivePurchaseOrder(c *gin.Context) {
 			return appErrors.NewAppError("Failed to fetch purchase order", http.StatusInternalServerError, err)
 		}
 
-		if po.Status != "APPROVED" && po.Status != "SENT" {
+		if po.Status != "APPROVED" && po.Status != "SENT" && po.Status != "PARTIALLY_RECEIVED" {
 			return appErrors.NewAppError(fmt.Sprintf("Cannot receive goods for Purchase Order in %s status", po.Status), http.StatusConflict, nil)
 		}
 
+		receiptBatchIDs := make([]uint, 0, len(req.Items))
 		for _, receivedItem := range req.Items {
 			var poItem domain.PurchaseOrderItem
 			if err := tx.First(&poItem, receivedItem.PurchaseOrderItemID).Error; err != nil {
@@ -494,24 +670,102 @@ func ReceivePurchaseOrder(c *gin.Context) {
 				return appErrors.NewAppError("Purchase Order Item does not belong to this Purchase Order", http.StatusBadRequest, nil)
 			}
 
-			if receivedItem.ReceivedQuantity > (poItem.OrderedQuantity - poItem.ReceivedQuantity) {
-				return appErrors.NewAppError(fmt.Sprintf("Received quantity %d for item %d exceeds remaining ordered quantity %d", receivedItem.ReceivedQuantity, poItem.ID, (poItem.OrderedQuantity-poItem.ReceivedQuantity)), http.StatusBadRequest, nil)
+			// Locked so the moving-average cost is not overwritten by a concurrent receipt or sale
+			var product domain.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, poItem.ProductID).Error; err != nil {
+				return appErrors.NewAppError(fmt.Sprintf("Product %d not found", poItem.ProductID), http.StatusNotFound, err)
+			}
+			if !product.IsValidQuantity(receivedItem.ReceivedQuantity) {
+				return quantityPrecisionError(&product, receivedItem.ReceivedQuantity)
+			}
+
+			serials, err := serialNumbersForQuantity(&product, receivedItem.SerialNumbers, receivedItem.ReceivedQuantity)
+			if err != nil {
+				return err
+			}
+
+			remaining := product.RoundQuantity(poItem.OrderedQuantity - poItem.ReceivedQuantity)
+			if receivedItem.ReceivedQuantity > remaining {
+				return appErrors.NewAppError(fmt.Sprintf("Received quantity %g for item %d exceeds remaining ordered quantity %g", receivedItem.ReceivedQuantity, poItem.ID, remaining), http.StatusBadRequest, nil)
 			}
 
-			poItem.ReceivedQuantity += receivedItem.ReceivedQuantity
+			poItem.ReceivedQuantity = product.RoundQuantity(poItem.ReceivedQuantity + receivedItem.ReceivedQuantity)
 			if err := tx.Save(&poItem).Error; err != nil {
 				return appErrors.NewAppError(fmt.Sprintf("Failed to update received quantity for PO item %d", poItem.ID), http.StatusInternalServerError, err)
 			}
 
+			// The PO line price becomes the cost of this layer, received at the order's delivery location
+			poItemID := poItem.ID
 			batch := domain.Batch{
-				ProductID:   poItem.ProductID,
-				BatchNumber: receivedItem.BatchNumber,
-				Quantity:    receivedItem.ReceivedQuantity,
-				ExpiryDate:  receivedItem.ExpiryDate,
+				ProductID:           poItem.ProductID,
+				LocationID:          po.DeliveryLocation(&product),
+				BatchNumber:         receivedItem.BatchNumber,
+				Quantity:            receivedItem.ReceivedQuantity,
+				ExpiryDate:          receivedItem.ExpiryDate,
+				UnitCost:            poItem.UnitPrice,
+				PurchaseOrderItemID: &poItemID,
+				ReceivedQuantity:    receivedItem.ReceivedQuantity,
+			}
+			if err := repository.RecordReceiptCost(tx, &product, batch.Quantity, batch.UnitCost); err != nil {
+				return appErrors.NewAppError(fmt.Sprintf("Failed to update cost for product %d", poItem.ProductID), http.StatusInternalServerError, err)
 			}
 			if err := tx.Create(&batch).Error; err != nil {
 				return appErrors.NewAppError(fmt.Sprintf("Failed to create batch for product %d", poItem.ProductID), http.StatusInternalServerError, err)
 			}
+			receiptBatchIDs = append(receiptBatchIDs, batch.ID)
+
+			// Put the receipt away into the chosen bin, or the suggested one when none is given
+			if err := putAwayReceipt(tx, &batch, receivedItem.BinID); err != nil {
+				return err
+			}
+
+			if len(serials) > 0 {
+				event := domain.SerialNumberEvent{EventType: "RECEIVED", ReferenceType: "PURCHASE_ORDER", ReferenceID: po.ID, UserID: userID}
+				if err := repository.RegisterSerialNumbers(tx, product.ID, serials, batch.ID, batch.LocationID, event); err != nil {
+					return appErrors.NewAppError(err.Error(), http.StatusConflict, err)
+				}
+			}
+		}
+
+		// Charges billed with this delivery are spread over this receipt's lines only
+		for _, chargeReq := range req.Charges {
+			charge := newLandedCostCharge(po.ID, chargeReq, userID)
+			if err := tx.Create(&charge).Error; err != nil {
+				return appErrors.NewAppError("Failed to record landed cost charge", http.StatusInternalServerError, err)
+			}
+			if err := repository.AllocateLandedCostCharge(tx, &charge, receiptBatchIDs); err != nil {
+				return appErrors.NewAppError(fmt.Sprintf("Failed to allocate %s charge: %v", charge.ChargeType, err), http.StatusBadRequest, err)
+			}
+		}
+
+		// Order-level charges take this delivery's share as it arrives
+		if err := repository.AllocatePendingLandedCosts(tx, po.ID); err != nil {
+			return appErrors.NewAppError("Failed to allocate landed costs", http.StatusInternalServerError, err)
+		}
+
+		// Record the receipt in the stock ledger at the final layer cost, including its landed costs
+		var receivedBatches []domain.Batch
+		if err := tx.Where("id IN ?", receiptBatchIDs).Find(&receivedBatches).Error; err != nil {
+			return appErrors.NewAppError("Failed to reload received batches", http.StatusInternalServerError, err)
+		}
+		for _, b := range receivedBatches {
+			unitCost := b.UnitCost
+			batchID := b.ID
+			receipt := domain.StockAdjustment{
+				ProductID:  b.ProductID,
+				LocationID: b.LocationID,
+				Type:       "STOCK_IN",
+				Quantity:   b.Quantity,
+				ReasonCode: "PURCHASE_RECEIPT",
+				Notes:      fmt.Sprintf("Received against PO #%d, batch %s", po.ID, b.BatchNumber),
+				AdjustedBy: userID,
+				AdjustedAt: time.Now(),
+				UnitCost:   &unitCost,
+				BatchID:    &batchID,
+			}
+			if err := tx.Create(&receipt).Error; err != nil {
+				return appErrors.NewAppError("Failed to record stock receipt", http.StatusInternalServerError, err)
+			}
 		}
 
 		var refreshedItems []domain.PurchaseOrderItem
@@ -521,7 +775,7 @@ func ReceivePurchaseOrder(c *gin.Context) {
 
 		allReceived := true
 		for _, item := range refreshedItems {
-			if item.OrderedQuantity != item.ReceivedQuantity {
+			if item.ReceivedQuantity < item.OrderedQuantity {
 				allReceived = false
 				break
 			}
@@ -573,13 +827,18 @@ func CancelPurchaseOrder(c *gin.Context) {
 		return
 	}
 
-	// Only allow cancellation if PO is in DRAFT or APPROVED status
-	if po.Status != "DRAFT" && po.Status != "APPROVED" {
+	// Only allow cancellation if PO is in DRAFT, PENDING_APPROVAL or APPROVED status
+	if po.Status != "DRAFT" && po.Status != "PENDING_APPROVAL" && po.Status != "APPROVED" {
 		c.Error(appErrors.NewAppError(fmt.Sprintf("Cannot cancel Purchase Order in %s status", po.Status), http.StatusConflict, nil))
 		return
 	}
 
-	if err := repository.DB.Model(&po).Update("Status", "CANCELLED").Error; err != nil {
+	if err := repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := repository.CancelPendingApprovals(tx, po.ID); err != nil {
+			return err
+		}
+		return tx.Model(&po).Update("Status", "CANCELLED").Error
+	}); err != nil {
 		c.Error(appErrors.NewAppError("Failed to cancel purchase order", http.StatusInternalServerError, err))
 		return
 	}
@@ -631,11 +890,15 @@ func (h *ReplenishmentHandler) CreatePurchaseOrder(c *gin.Context) {
 		}
 
 		for _, itemReq := range req.PurchaseOrderItems {
+			unitPrice, err := poItemUnitPrice(tx, itemReq, req.SupplierID)
+			if err != nil {
+				return appErrors.NewAppError("Failed to look up supplier price", http.StatusInternalServerError, err)
+			}
 			poItem := domain.PurchaseOrderItem{
 				PurchaseOrderID: po.ID,
 				ProductID:       itemReq.ProductID,
 				OrderedQuantity: itemReq.OrderedQuantity,
-				UnitPrice:       itemReq.UnitPrice,
+				UnitPrice:       unitPrice,
 			}
 			if err := tx.Create(&poItem).Error; err != nil {
 				return appErrors.NewAppError("Failed to create PO item", http.StatusInternalServerError, err)
@@ -682,10 +945,10 @@ type CreatePurchaseReturnRequest struct {
 	SupplierID      uint   `json:"supplier_id" binding:"required"`
 	Reason          string `json:"reason" binding:"required"`
 	Items           []struct {
-		ProductID uint   `json:"product_id" binding:"required"`
-		Quantity  int    `json:"quantity" binding:"required"`
-		BatchID   uint   `json:"batch_id" binding:"required"`
-		Reason    string `json:"reason"`
+		ProductID uint    `json:"product_id" binding:"required"`
+		Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+		BatchID   uint    `json:"batch_id" binding:"required"`
+		Reason    string  `json:"reason"`
 	} `json:"items" binding:"required"`
 }
 
@@ -737,13 +1000,24 @@ func (h *ReplenishmentHandler) CreatePurchaseReturn(c *gin.Context) {
 			if batch.ProductID != item.ProductID {
 				return appErrors.NewAppError("Batch does not match product", http.StatusBadRequest, nil)
 			}
+			var product domain.Product
+			if err := tx.First(&product, item.ProductID).Error; err != nil {
+				return appErrors.NewAppError(fmt.Sprintf("Product %d not found", item.ProductID), http.StatusNotFound, err)
+			}
+			if !product.IsValidQuantity(item.Quantity) {
+				return quantityPrecisionError(&product, item.Quantity)
+			}
 			if batch.Quantity < item.Quantity {
 				return appErrors.NewAppError(fmt.Sprintf("Insufficient quantity in batch %s", batch.BatchNumber), http.StatusBadRequest, nil)
 			}
 
 			// 2. Deduct Stock
-			batch.Quantity -= item.Quantity
-			if err := tx.Save(&batch).Error; err != nil {
+			previous := batch.Quantity
+			batch.Quantity = product.RoundQuantity(batch.Quantity - item.Quantity)
+			if err := repository.SaveBatchQuantity(tx, &batch, previous); err != nil {
+				if errors.Is(err, repository.ErrStockConflict) {
+					return appErrors.NewAppError(err.Error(), http.StatusConflict, err)
+				}
 				return appErrors.NewAppError("Failed to update batch quantity", http.StatusInternalServerError, err)
 			}
 
@@ -761,12 +1035,9 @@ func (h *ReplenishmentHandler) CreatePurchaseReturn(c *gin.Context) {
 			}
 
 			// 4. Create Stock Adjustment Record (STOCK_OUT)
-			// Need to fetch product location for record
-			var product domain.Product
-			tx.First(&product, item.ProductID) // Optimize: preload or fetch
-
-			// Calculate Refund Amount (using PurchasePrice)
-			totalRefundAmount += product.PurchasePrice * float64(item.Quantity)
+			// Calculate Refund Amount at the cost the returned batch was received at
+			unitCost := batch.LayerCost(&product)
+			totalRefundAmount += unitCost * item.Quantity
 
 			stockAdj := domain.StockAdjustment{
 				ProductID:   item.ProductID,
@@ -778,6 +1049,7 @@ func (h *ReplenishmentHandler) CreatePurchaseReturn(c *gin.Context) {
 				AdjustedBy:  userID,
 				AdjustedAt:  time.Now(),
 				NewQuantity: batch.Quantity, // Approx batch qty
+				UnitCost:    &unitCost,
 			}
 			if err := tx.Create(&stockAdj).Error; err != nil {
 				return appErrors.NewAppError("Failed to create stock adjustment log", http.StatusInternalServerError, err)
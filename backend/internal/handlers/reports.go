@@ -115,6 +115,113 @@ func (h *ReportHandler) ExportSalesTrendsReport(c *gin.Context) {
 	c.JSON(http.StatusAccepted, gin.H{"jobId": job.ID})
 }
 
+// GetInventoryValuationReport godoc
+// @Summary Get point-in-time inventory valuation
+// @Description Reconstructs stock quantity and value per product and location as of a past date from the stock ledger and batch costs.
+// @Tags reports
+// @Produce json
+// @Param asOf query string true "Valuation date (RFC3339 or YYYY-MM-DD, end of day)"
+// @Param locationId query int false "Location ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /reports/inventory-valuation [get]
+func (h *ReportHandler) GetInventoryValuationReport(c *gin.Context) {
+	asOf, err := parseAsOfDate(c.Query("asOf"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var locationID *uint
+	if locStr := c.Query("locationId"); locStr != "" {
+		id, err := strconv.ParseUint(locStr, 10, 32)
+		if err != nil {
+			c.Error(appErrors.NewAppError("Invalid locationId", http.StatusBadRequest, err))
+			return
+		}
+		val := uint(id)
+		locationID = &val
+	}
+
+	report, err := h.reportingService.GetInventoryValuationReport(asOf, locationID)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to get inventory valuation report", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ExportInventoryValuationReport godoc
+// @Summary Export point-in-time inventory valuation
+// @Description Queues a CSV or XLSX export of stock quantity and value per product and location as of a past date. Poll /reports/jobs/{jobId} and download when complete.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param format query string false "csv (default) or excel"
+// @Param request body requests.InventoryValuationReportRequest true "Valuation parameters"
+// @Success 202 {object} map[string]interface{} "Job ID"
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /reports/inventory-valuation/export [post]
+func (h *ReportHandler) ExportInventoryValuationReport(c *gin.Context) {
+	var req requests.InventoryValuationReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	if req.AsOf.After(time.Now()) {
+		c.Error(appErrors.NewAppError("asOf cannot be in the future", http.StatusBadRequest, nil))
+		return
+	}
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "excel" {
+		c.Error(appErrors.NewAppError("format must be 'csv' or 'excel'", http.StatusBadRequest, nil))
+		return
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to marshal request", http.StatusInternalServerError, err))
+		return
+	}
+
+	job := &domain.Job{
+		Type:       "inventory_valuation_" + format,
+		Status:     "QUEUED",
+		Payload:    string(payload),
+		MaxRetries: 3,
+	}
+
+	if err := h.jobRepo.CreateJob(job); err != nil {
+		c.Error(appErrors.NewAppError("Failed to create job", http.StatusInternalServerError, err))
+		return
+	}
+
+	if err := message_broker.Publish(c.Request.Context(), "inventory", "reporting", job); err != nil {
+		c.Error(appErrors.NewAppError("Failed to publish job", http.StatusInternalServerError, err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"jobId": job.ID})
+}
+
+// parseAsOfDate accepts RFC3339 or a plain date, which is taken as the end of that day.
+func parseAsOfDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, appErrors.NewAppError("asOf is required", http.StatusBadRequest, nil)
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, appErrors.NewAppError("Invalid asOf format. Use RFC3339 or YYYY-MM-DD", http.StatusBadRequest, err)
+	}
+	return day.Add(24*time.Hour - time.Nanosecond), nil
+}
+
 // GetReportJobStatus godoc
 // @Summary Get report job status
 // @Description Get the status of a report generation job.
@@ -334,6 +441,37 @@ func (h *ReportHandler) GetSupplierPerformanceReport(c *gin.Context) {
 	c.JSON(http.StatusOK, report)
 }
 
+// GetLandedCostReport godoc
+// @Summary Get landed cost report
+// @Description Freight, duty, handling and other landed costs allocated in the period, per supplier or per product, with their share of goods value.
+// @Tags reports
+// @Produce json
+// @Param startDate query string true "Start Date (RFC3339)"
+// @Param endDate query string true "End Date (RFC3339)"
+// @Param groupBy query string false "supplier (default) or product"
+// @Success 200 {array} repository.LandedCostSummary
+// @Router /reports/landed-cost [get]
+func (h *ReportHandler) GetLandedCostReport(c *gin.Context) {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	groupBy := c.DefaultQuery("groupBy", "supplier")
+	if groupBy != "supplier" && groupBy != "product" {
+		c.Error(appErrors.NewAppError("groupBy must be 'supplier' or 'product'", http.StatusBadRequest, nil))
+		return
+	}
+
+	report, err := h.reportingService.GetLandedCostReport(start, end, groupBy)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to get landed cost report", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 // GetHourlySalesHeatmap godoc
 // @Summary Get hourly sales heatmap
 // @Description Sales by hour and day of week.
@@ -551,6 +689,36 @@ func (h *ReportHandler) GetShrinkageReport(c *gin.Context) {
 	c.JSON(http.StatusOK, report)
 }
 
+// GetWasteReport godoc
+// @Summary Get waste report
+// @Description Stock written off as expired, damaged or spoiled, with its cost, per product, supplier or reason.
+// @Tags reports
+// @Produce json
+// @Param startDate query string true "Start Date (RFC3339)"
+// @Param endDate query string true "End Date (RFC3339)"
+// @Param groupBy query string false "product (default), supplier or reason"
+// @Success 200 {array} repository.WasteSummary
+// @Router /reports/waste [get]
+func (h *ReportHandler) GetWasteReport(c *gin.Context) {
+	start, end, err := parseDateRange(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	groupBy := c.DefaultQuery("groupBy", "product")
+	if groupBy != "product" && groupBy != "supplier" && groupBy != "reason" {
+		c.Error(appErrors.NewAppError("groupBy must be 'product', 'supplier' or 'reason'", http.StatusBadRequest, nil))
+		return
+	}
+
+	report, err := h.reportingService.GetWasteReport(start, end, groupBy)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to get waste report", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 // GetCustomerReturnAnalysisReport godoc
 // @Summary Get returns analysis
 // @Description Analysis of customer returns.
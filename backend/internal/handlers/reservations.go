@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+	"inventory/backend/internal/services"
+)
+
+// defaultReservationTTLMinutes applies when the reservation_ttl_minutes setting is missing.
+const defaultReservationTTLMinutes = 30
+
+type ReservationHandler struct {
+	DB       *gorm.DB
+	Settings services.SettingsService
+}
+
+func NewReservationHandler(db *gorm.DB, settings services.SettingsService) *ReservationHandler {
+	return &ReservationHandler{DB: db, Settings: settings}
+}
+
+func (h *ReservationHandler) reservationTTL() int {
+	if h.Settings == nil {
+		return defaultReservationTTLMinutes
+	}
+	val, err := h.Settings.GetSetting("reservation_ttl_minutes")
+	if err != nil {
+		return defaultReservationTTLMinutes
+	}
+	minutes, err := strconv.Atoi(val)
+	if err != nil || minutes < 0 {
+		return defaultReservationTTLMinutes
+	}
+	return minutes
+}
+
+// CreateStockReservation godoc
+// @Summary Reserve stock
+// @Description Allocates available stock at a location to a pending order, held cart or other commitment. Reservations lapse at their expiry time.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param reservation body requests.CreateStockReservationRequest true "Reservation"
+// @Success 201 {object} domain.StockReservation
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /reservations [post]
+func (h *ReservationHandler) CreateStockReservation(c *gin.Context) {
+	var req requests.CreateStockReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.Error(appErrors.NewAppError("Authenticated user not found", http.StatusUnauthorized, nil))
+		return
+	}
+
+	ttl := h.reservationTTL()
+	if req.ExpiresInMinutes != nil {
+		ttl = *req.ExpiresInMinutes
+	}
+
+	reservation := domain.StockReservation{
+		ProductID:  req.ProductID,
+		LocationID: req.LocationID,
+		Quantity:   req.Quantity,
+		Status:     domain.ReservationStatusActive,
+		SourceType: req.SourceType,
+		SourceRef:  req.SourceRef,
+		ReservedBy: userID.(uint),
+		Notes:      req.Notes,
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(time.Duration(ttl) * time.Minute)
+		reservation.ExpiresAt = &expiresAt
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the product so concurrent reservations see each other's holds
+		var product domain.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, req.ProductID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return appErrors.NewAppError("Product not found", http.StatusNotFound, err)
+			}
+			return appErrors.NewAppError("Failed to fetch product", http.StatusInternalServerError, err)
+		}
+		if !product.IsValidQuantity(req.Quantity) {
+			return quantityPrecisionError(&product, req.Quantity)
+		}
+
+		position, err := repository.GetStockPosition(tx, &product, req.LocationID, nil)
+		if err != nil {
+			return appErrors.NewAppError("Failed to calculate available stock", http.StatusInternalServerError, err)
+		}
+		if position.Available < req.Quantity {
+			return appErrors.NewAppError(fmt.Sprintf("Insufficient available stock for product '%s' at this location (Available: %g %s, Requested: %g %s)",
+				product.Name, position.Available, product.UnitOfMeasure, req.Quantity, product.UnitOfMeasure), http.StatusBadRequest, nil)
+		}
+
+		if err := tx.Create(&reservation).Error; err != nil {
+			return appErrors.NewAppError("Failed to create reservation", http.StatusInternalServerError, err)
+		}
+		return nil
+	})
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(appErrors.NewAppError("Failed to create reservation", http.StatusInternalServerError, err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// ListStockReservations godoc
+// @Summary List stock reservations
+// @Description Retrieves reservations, optionally filtered by product, location, status or source reference
+// @Tags reservations
+// @Produce json
+// @Param productId query int false "Product ID"
+// @Param locationId query int false "Location ID"
+// @Param status query string false "ACTIVE, CONSUMED, RELEASED or EXPIRED"
+// @Param sourceRef query string false "Source reference"
+// @Success 200 {array} domain.StockReservation
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /reservations [get]
+func (h *ReservationHandler) ListStockReservations(c *gin.Context) {
+	query := h.DB.Preload("Product").Preload("Location")
+	if productID := c.Query("productId"); productID != "" {
+		query = query.Where("product_id = ?", productID)
+	}
+	if locationID := c.Query("locationId"); locationID != "" {
+		query = query.Where("location_id = ?", locationID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if sourceRef := c.Query("sourceRef"); sourceRef != "" {
+		query = query.Where("source_ref = ?", sourceRef)
+	}
+
+	var reservations []domain.StockReservation
+	if err := query.Order("created_at desc").Limit(500).Find(&reservations).Error; err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch reservations", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, reservations)
+}
+
+// ReleaseStockReservation godoc
+// @Summary Release a stock reservation
+// @Description Returns reserved stock to the available pool
+// @Tags reservations
+// @Produce json
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} domain.StockReservation
+// @Failure 400 {object} map[string]interface{} "Reservation is not active"
+// @Failure 404 {object} map[string]interface{} "Reservation not found"
+// @Router /reservations/{id}/release [post]
+func (h *ReservationHandler) ReleaseStockReservation(c *gin.Context) {
+	var reservation domain.StockReservation
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reservation, c.Param("id")).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return appErrors.NewAppError("Reservation not found", http.StatusNotFound, err)
+			}
+			return appErrors.NewAppError("Failed to fetch reservation", http.StatusInternalServerError, err)
+		}
+		if reservation.Status != domain.ReservationStatusActive {
+			return appErrors.NewAppError("Reservation is not active", http.StatusBadRequest, nil)
+		}
+		if err := repository.CloseReservations(tx, []uint{reservation.ID}, domain.ReservationStatusReleased); err != nil {
+			return appErrors.NewAppError("Failed to release reservation", http.StatusInternalServerError, err)
+		}
+		return tx.First(&reservation, reservation.ID).Error
+	})
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(appErrors.NewAppError("Failed to release reservation", http.StatusInternalServerError, err))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// LocationAvailability is one location's stock position in an availability query.
+type LocationAvailability struct {
+	domain.StockPosition
+	LocationName string `json:"locationName"`
+	CanFulfil    bool   `json:"canFulfil"`
+}
+
+// GetStockAvailability godoc
+// @Summary Find where stock is available
+// @Description Answers "where can I get N of this product": lists available-to-promise stock per location, locations able to fulfil the full quantity first
+// @Tags reservations
+// @Produce json
+// @Param productId query int false "Product ID"
+// @Param sku query string false "Product SKU"
+// @Param quantity query number false "Quantity required (defaults to 1)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Router /inventory/availability [get]
+func (h *ReservationHandler) GetStockAvailability(c *gin.Context) {
+	var product domain.Product
+	query := h.DB
+	switch {
+	case c.Query("productId") != "":
+		query = query.Where("id = ?", c.Query("productId"))
+	case c.Query("sku") != "":
+		query = query.Where("sku = ?", c.Query("sku"))
+	default:
+		c.Error(appErrors.NewAppError("Either 'productId' or 'sku' is required", http.StatusBadRequest, nil))
+		return
+	}
+	if err := query.First(&product).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Product not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch product", http.StatusInternalServerError, err))
+		return
+	}
+
+	quantity := 1.0
+	if q := c.Query("quantity"); q != "" {
+		parsed, err := strconv.ParseFloat(q, 64)
+		if err != nil || parsed <= 0 {
+			c.Error(appErrors.NewAppError("Invalid quantity", http.StatusBadRequest, err))
+			return
+		}
+		quantity = parsed
+	}
+
+	positions, err := repository.GetStockPositions(h.DB, &product, 0, nil)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to calculate available stock", http.StatusInternalServerError, err))
+		return
+	}
+
+	locationIDs := make([]uint, 0, len(positions))
+	for _, p := range positions {
+		locationIDs = append(locationIDs, p.LocationID)
+	}
+	var locations []domain.Location
+	if len(locationIDs) > 0 {
+		if err := h.DB.Where("id IN ?", locationIDs).Find(&locations).Error; err != nil {
+			c.Error(appErrors.NewAppError("Failed to fetch locations", http.StatusInternalServerError, err))
+			return
+		}
+	}
+	locationNames := make(map[uint]string, len(locations))
+	for _, l := range locations {
+		locationNames[l.ID] = l.Name
+	}
+
+	var totalAvailable float64
+	results := make([]LocationAvailability, 0, len(positions))
+	for _, p := range positions {
+		totalAvailable += p.Available
+		results = append(results, LocationAvailability{
+			StockPosition: p,
+			LocationName:  locationNames[p.LocationID],
+			CanFulfil:     p.Available >= quantity,
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].CanFulfil != results[j].CanFulfil {
+			return results[i].CanFulfil
+		}
+		return results[i].Available > results[j].Available
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"productId":      product.ID,
+		"sku":            product.SKU,
+		"unitOfMeasure":  product.UnitOfMeasure,
+		"quantity":       quantity,
+		"totalAvailable": product.RoundQuantity(totalAvailable),
+		"locations":      results,
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"strconv"
 
@@ -34,10 +35,11 @@ func NewReturnHandler(db *gorm.DB, cfg *config.Config, settings services.Setting
 type ReturnRequest struct {
 	OrderNumber string `json:"order_number" binding:"required"`
 	Items       []struct {
-		OrderItemID uint   `json:"order_item_id" binding:"required"`
-		Quantity    int    `json:"quantity" binding:"required"`
-		Condition   string `json:"condition"`
-		Reason      string `json:"reason" binding:"required"`
+		OrderItemID   uint     `json:"order_item_id" binding:"required"`
+		Quantity      float64  `json:"quantity" binding:"required,gt=0"`
+		Condition     string   `json:"condition"`
+		Reason        string   `json:"reason" binding:"required"`
+		SerialNumbers []string `json:"serial_numbers"` // Required for serialized products
 	} `json:"items" binding:"required"`
 }
 
@@ -116,8 +118,20 @@ func (h *ReturnHandler) RequestReturn(c *gin.Context) {
 
 		// Map OrderItemID -> OrderItem
 		orderItemMap := make(map[uint]domain.OrderItem)
+		productIDs := make([]uint, 0, len(orderItems))
 		for _, oi := range orderItems {
 			orderItemMap[oi.ID] = oi
+			productIDs = append(productIDs, oi.ProductID)
+		}
+
+		// Products carry the quantity precision returns are validated against
+		var products []domain.Product
+		if err := tx.Unscoped().Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+			return fmt.Errorf("failed to fetch products: %w", err)
+		}
+		productMap := make(map[uint]*domain.Product, len(products))
+		for i := range products {
+			productMap[products[i].ID] = &products[i]
 		}
 
 		var totalRefundAmount float64
@@ -139,22 +153,53 @@ func (h *ReturnHandler) RequestReturn(c *gin.Context) {
 			}
 
 			// Validate quantity
-			if item.Quantity > (orderItem.Quantity - orderItem.ReturnedQty) {
+			product, ok := productMap[orderItem.ProductID]
+			if !ok {
+				return fmt.Errorf("product for order item %d not found", item.OrderItemID)
+			}
+			if !product.IsValidQuantity(item.Quantity) {
+				return fmt.Errorf("invalid return quantity %g for item %d: at most %d decimal places allowed", item.Quantity, item.OrderItemID, product.QuantityPrecision)
+			}
+			remaining := product.RoundQuantity(orderItem.Quantity - orderItem.ReturnedQty)
+			if item.Quantity > remaining {
 				return fmt.Errorf("invalid return quantity for item %d", item.OrderItemID)
 			}
 
+			// Serialized units must be ones sold on this line and not already returned
+			serials, err := domain.NormalizeSerialNumbers(item.SerialNumbers)
+			if err != nil {
+				return err
+			}
+			if orderItem.SerialNumbers != "" {
+				if float64(len(serials)) != item.Quantity {
+					return fmt.Errorf("item %d is serialized: %g serial numbers required, got %d", item.OrderItemID, item.Quantity, len(serials))
+				}
+				units, err := repository.LockSerialNumbers(tx, orderItem.ProductID, serials, domain.SerialStatusSold)
+				if err != nil {
+					return err
+				}
+				for _, u := range units {
+					if u.OrderItemID == nil || *u.OrderItemID != orderItem.ID {
+						return fmt.Errorf("serial number %s was not sold on order item %d", u.SerialNumber, orderItem.ID)
+					}
+				}
+			} else if len(serials) > 0 {
+				return fmt.Errorf("order item %d has no serial numbers", item.OrderItemID)
+			}
+
 			// Prepare Return Item
 			returnItems = append(returnItems, domain.ReturnItem{
-				ReturnID:    returnRecord.ID,
-				OrderItemID: orderItem.ID,
-				ProductID:   orderItem.ProductID,
-				Quantity:    item.Quantity,
-				Condition:   item.Condition,
-				Reason:      item.Reason,
+				ReturnID:      returnRecord.ID,
+				OrderItemID:   orderItem.ID,
+				ProductID:     orderItem.ProductID,
+				Quantity:      item.Quantity,
+				Condition:     item.Condition,
+				Reason:        item.Reason,
+				SerialNumbers: domain.JoinSerialNumbers(serials),
 			})
 
 			// Calculate refund amount for this item (including tax)
-			itemTotal := orderItem.UnitPrice * float64(item.Quantity)
+			itemTotal := orderItem.UnitPrice * item.Quantity
 			totalRefundAmount += itemTotal * (1 + taxRate/100.0)
 		}
 
@@ -247,7 +292,8 @@ func (h *ReturnHandler) ProcessReturn(c *gin.Context) {
 
 	err := h.DB.Transaction(func(tx *gorm.DB) error {
 		var returnRecord domain.Return
-		if err := tx.Preload("ReturnItems").First(&returnRecord, returnID).Error; err != nil {
+		// Locked so a return cannot be approved twice by concurrent requests
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("ReturnItems.OrderItem").First(&returnRecord, returnID).Error; err != nil {
 			return fmt.Errorf("return request not found")
 		}
 
@@ -282,8 +328,8 @@ func (h *ReturnHandler) ProcessReturn(c *gin.Context) {
 		// 1. Bulk Fetch Products
 		productMap := make(map[uint]domain.Product)
 		if len(productIDs) > 0 {
-			var products []domain.Product
-			if err := tx.Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+			products, err := repository.LockProducts(tx, productIDs)
+			if err != nil {
 				return fmt.Errorf("failed to fetch products: %w", err)
 			}
 			for _, p := range products {
@@ -295,6 +341,7 @@ func (h *ReturnHandler) ProcessReturn(c *gin.Context) {
 
 		// 2. Process Items
 		for _, item := range returnRecord.ReturnItems {
+			var restockBatch *domain.Batch
 			if item.Condition == "GOOD" {
 				product, ok := productMap[item.ProductID]
 				if !ok {
@@ -306,12 +353,23 @@ func (h *ReturnHandler) ProcessReturn(c *gin.Context) {
 				// Given returns are usually few items, individual batch query is acceptable IF we avoid the product query loop.
 				// We already optimized the product query.
 
+				// Returned goods go back at the cost they were sold at
+				unitCost := item.OrderItem.UnitCost
+				if unitCost == 0 {
+					unitCost = product.CurrentUnitCost()
+				}
+				if err := repository.RecordReceiptCost(tx, &product, item.Quantity, unitCost); err != nil {
+					return fmt.Errorf("failed to update product cost: %w", err)
+				}
+				productMap[product.ID] = product
+
 				var batch domain.Batch
-				// Try to find an existing batch for this product/location
-				if err := tx.Where("product_id = ? AND location_id = ?", item.ProductID, product.LocationID).Order("created_at desc").First(&batch).Error; err == nil {
-					batch.Quantity += item.Quantity
-					if err := tx.Save(&batch).Error; err != nil {
-						return fmt.Errorf("failed to update batch")
+				// Try to find an existing batch for this product/location in the same cost layer
+				if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("product_id = ? AND location_id = ? AND unit_cost = ?", item.ProductID, product.LocationID, unitCost).Order("created_at desc").First(&batch).Error; err == nil {
+					previous := batch.Quantity
+					batch.Quantity = product.RoundQuantity(batch.Quantity + item.Quantity)
+					if err := repository.SaveBatchQuantity(tx, &batch, previous); err != nil {
+						return fmt.Errorf("failed to update batch: %w", err)
 					}
 				} else {
 					// Create new batch
@@ -320,6 +378,7 @@ func (h *ReturnHandler) ProcessReturn(c *gin.Context) {
 						LocationID:  product.LocationID,
 						BatchNumber: fmt.Sprintf("RET-%d", returnRecord.ID),
 						Quantity:    item.Quantity,
+						UnitCost:    unitCost,
 						// Expiry?
 					}
 					if err := tx.Create(&batch).Error; err != nil {
@@ -338,7 +397,32 @@ func (h *ReturnHandler) ProcessReturn(c *gin.Context) {
 					AdjustedBy:  approverID,
 					AdjustedAt:  time.Now(),
 					NewQuantity: batch.Quantity, // Approximate
+					UnitCost:    &unitCost,
 				})
+				restockBatch = &batch
+			}
+
+			// Serialized units go back into stock when resaleable, otherwise they are flagged defective
+			if serials := domain.SplitSerialNumbers(item.SerialNumbers); len(serials) > 0 {
+				units, err := repository.LockSerialNumbers(tx, item.ProductID, serials, domain.SerialStatusSold)
+				if err != nil {
+					return err
+				}
+				updates := map[string]interface{}{"status": domain.SerialStatusDefective}
+				if restockBatch != nil {
+					updates = map[string]interface{}{
+						"status":              domain.SerialStatusInStock,
+						"batch_id":            restockBatch.ID,
+						"location_id":         restockBatch.LocationID,
+						"order_item_id":       nil,
+						"sold_at":             nil,
+						"warranty_expires_at": nil,
+					}
+				}
+				event := domain.SerialNumberEvent{EventType: "RETURNED", ReferenceType: "RETURN", ReferenceID: returnRecord.ID, Notes: item.Condition, UserID: approverID}
+				if err := repository.TransitionSerialNumbers(tx, units, updates, event); err != nil {
+					return fmt.Errorf("failed to update serial numbers")
+				}
 			}
 
 			// Update OrderItem returned quantity
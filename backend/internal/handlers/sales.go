@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -12,6 +13,7 @@ import (
 
 	"inventory/backend/internal/domain"
 	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
 	"inventory/backend/internal/requests"
 	"inventory/backend/internal/services"
 )
@@ -26,6 +28,27 @@ func NewSalesHandler(db *gorm.DB, settings services.SettingsService, reportingSe
 	return &SalesHandler{DB: db, Settings: settings, ReportingService: reportingService}
 }
 
+// scaleBarcodeLine decodes the scale barcode a checkout line was scanned from. The barcode must
+// carry the product's PLU and the quantity on the line, so a label cannot be charged against
+// another product or amount.
+func scaleBarcodeLine(patterns []domain.ScaleBarcodePattern, product *domain.Product, code string, quantity float64) (*domain.ScaleLine, error) {
+	for i := range patterns {
+		if !patterns[i].Matches(code) {
+			continue
+		}
+		decoded, err := patterns[i].Decode(code)
+		if err != nil || decoded.PLU != product.PLUCode {
+			continue
+		}
+		line := decoded.LineFor(product)
+		if line.Quantity != quantity {
+			return nil, fmt.Errorf("quantity %g for product '%s' does not match the %g on its scale barcode", quantity, product.Name, line.Quantity)
+		}
+		return &line, nil
+	}
+	return nil, fmt.Errorf("barcode %s is not a scale barcode for product '%s'", code, product.Name)
+}
+
 // Checkout godoc
 // @Summary Process a sales checkout
 // @Description Creates a new sale transaction and deducts stock atomically
@@ -35,6 +58,7 @@ func NewSalesHandler(db *gorm.DB, settings services.SettingsService, reportingSe
 // @Param checkout body requests.CheckoutRequest true "Checkout request"
 // @Success 201 {object} map[string]interface{} "Sale completed"
 // @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 409 {object} map[string]interface{} "Stock changed concurrently"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
 // @Router /sales/checkout [post]
 func (h *SalesHandler) Checkout(c *gin.Context) {
@@ -61,15 +85,15 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 	err := h.DB.Transaction(func(tx *gorm.DB) error {
 		// 1. Bulk Fetch Products
 		productIDs := make([]uint, len(req.Items))
-		itemMap := make(map[uint]int) // ProductID -> Quantity
+		itemMap := make(map[uint]float64) // ProductID -> Quantity
 		for i, item := range req.Items {
 			productIDs[i] = item.ProductID
 			itemMap[item.ProductID] = item.Quantity
 		}
 
-		var products []domain.Product
-		// Lock rows for update
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		// Lock the products, then their batches, so concurrent checkouts of the same items queue up
+		products, err := repository.LockProducts(tx, productIDs)
+		if err != nil {
 			return fmt.Errorf("failed to fetch products: %w", err)
 		}
 
@@ -83,8 +107,8 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 		}
 
 		// 2. Bulk Fetch Batches
-		var allBatches []domain.Batch
-		if err := tx.Where("product_id IN ? AND quantity > 0", productIDs).Order("expiry_date asc, created_at asc").Find(&allBatches).Error; err != nil {
+		allBatches, err := repository.LockBatches(tx, productIDs, 0)
+		if err != nil {
 			return fmt.Errorf("failed to fetch batches: %w", err)
 		}
 
@@ -96,9 +120,23 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 			batchesByProduct[allBatches[i].ProductID] = append(batchesByProduct[allBatches[i].ProductID], &allBatches[i])
 		}
 
+		// Stock held for this sale (e.g. a held cart) is available to it and consumed below
+		reservations, err := repository.LockActiveReservations(tx, req.ReservationIDs)
+		if err != nil {
+			return fmt.Errorf("failed to fetch reservations: %w", err)
+		}
+		reservationIDs := make([]uint, 0, len(reservations))
+		for _, r := range reservations {
+			if _, ok := itemMap[r.ProductID]; !ok {
+				return fmt.Errorf("reservation %d is for a product that is not in the cart", r.ID)
+			}
+			reservationIDs = append(reservationIDs, r.ID)
+		}
+
 		var totalAmount float64
 		var orderItems []domain.OrderItem
 		var stockAdjustments []domain.StockAdjustment
+		soldUnits := make(map[int][]domain.SerialNumber) // OrderItem index -> serialized units sold
 
 		// Fetch active promotions
 		var activePromotions []domain.Promotion
@@ -109,8 +147,27 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 			return fmt.Errorf("failed to fetch promotions: %w", err)
 		}
 
+		// Batch markdowns (e.g. for near-expiry stock) price only the units drawn from their batch
+		batchMarkdowns := make(map[uint]*domain.Promotion)
+		for i := range activePromotions {
+			if p := &activePromotions[i]; p.BatchID != nil {
+				batchMarkdowns[*p.BatchID] = p
+			}
+		}
+
 		var totalDiscountFromPromotions float64
 
+		// Scale patterns are only needed when a line was scanned from a scale label
+		var scalePatterns []domain.ScaleBarcodePattern
+		for _, item := range req.Items {
+			if item.Barcode != "" {
+				if scalePatterns, err = repository.NewBarcodeRepository(tx).GetActiveScalePatterns(); err != nil {
+					return fmt.Errorf("failed to fetch scale barcode patterns: %w", err)
+				}
+				break
+			}
+		}
+
 		// 3. Process Items
 		for _, item := range req.Items {
 			product, ok := productMap[item.ProductID]
@@ -118,42 +175,83 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 				return fmt.Errorf("product %d not found", item.ProductID)
 			}
 
+			if !product.IsValidQuantity(item.Quantity) {
+				return fmt.Errorf("invalid quantity %g for product '%s': at most %d decimal places allowed", item.Quantity, product.Name, product.QuantityPrecision)
+			}
+
+			var scaleLine *domain.ScaleLine
+			if item.Barcode != "" {
+				if scaleLine, err = scaleBarcodeLine(scalePatterns, &product, item.Barcode, item.Quantity); err != nil {
+					return err
+				}
+			}
+
 			requestedQty := item.Quantity
 			batches := batchesByProduct[item.ProductID]
 
 			// Calculate total available stock from fetched batches
-			var availableStock int
+			var availableStock float64
 			for _, b := range batches {
 				availableStock += b.Quantity
 			}
+			availableStock = product.RoundQuantity(availableStock)
+
+			// Stock reserved for other orders or committed to outbound transfers cannot be sold
+			position, err := repository.GetStockPosition(tx, &product, 0, reservationIDs)
+			if err != nil {
+				return fmt.Errorf("failed to calculate available stock: %w", err)
+			}
+			position.OnHand = availableStock
+			position.Quarantined = 0 // Locked batches already leave out quarantined stock
+			position.CalculateAvailable(product.QuantityPrecision)
+
+			if position.Available < requestedQty {
+				return fmt.Errorf("insufficient stock for product '%s' (Available: %g %s, Requested: %g %s)", product.Name, position.Available, product.UnitOfMeasure, requestedQty, product.UnitOfMeasure)
+			}
 
-			if availableStock < requestedQty {
-				return fmt.Errorf("insufficient stock for product '%s' (Available: %d, Requested: %d)", product.Name, availableStock, requestedQty)
+			serials, err := serialNumbersForQuantity(&product, item.SerialNumbers, item.Quantity)
+			if err != nil {
+				return err
 			}
 
 			// Deduct from batches
 			qtyToReduce := requestedQty
+			before := repository.SnapshotBatchQuantities(batches)
+
+			// Serialized units come out of the batch they were received into
+			if len(serials) > 0 {
+				units, err := repository.LockSerialNumbers(tx, product.ID, serials, domain.SerialStatusInStock)
+				if err != nil {
+					return err
+				}
+				_, unmatched := allocateSerialUnits(batches, units)
+				qtyToReduce = unmatched
+				soldUnits[len(orderItems)] = units
+			}
+
 			for _, batch := range batches {
 				if qtyToReduce <= 0 {
 					break
 				}
 
 				if batch.Quantity >= qtyToReduce {
-					batch.Quantity -= qtyToReduce
+					batch.Quantity = product.RoundQuantity(batch.Quantity - qtyToReduce)
 					qtyToReduce = 0
 				} else {
-					qtyToReduce -= batch.Quantity
+					qtyToReduce = product.RoundQuantity(qtyToReduce - batch.Quantity)
 					batch.Quantity = 0
 				}
+			}
 
-				// We need to save the batch updates.
-				// Since we are in a transaction, we can save them individually or collect them.
-				// For simplicity and safety, saving individually here is okay as it's in-memory modified.
-				if err := tx.Save(batch).Error; err != nil {
-					return fmt.Errorf("failed to update batch %s", batch.BatchNumber)
-				}
+			// Each batch is written once, and only if nobody changed it since it was read
+			if err := repository.SaveBatchQuantities(tx, batches, before); err != nil {
+				return err
 			}
 
+			// Cost of goods sold from the layers consumed
+			lineCost := product.IssueCost(repository.DrawnCostLayers(&product, batches, before))
+			unitCost := domain.UnitCostOf(lineCost, item.Quantity)
+
 			// Prepare Stock Adjustment
 			stockAdjustments = append(stockAdjustments, domain.StockAdjustment{
 				ProductID:        product.ID,
@@ -165,7 +263,8 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 				AdjustedBy:       userID,
 				AdjustedAt:       time.Now(),
 				PreviousQuantity: availableStock,
-				NewQuantity:      availableStock - item.Quantity,
+				NewQuantity:      product.RoundQuantity(availableStock - item.Quantity),
+				UnitCost:         &unitCost,
 			})
 
 			// Calculate Discount
@@ -175,6 +274,9 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 
 			for i := range activePromotions {
 				p := &activePromotions[i]
+				if p.BatchID != nil {
+					continue
+				}
 				score := -1
 
 				// Check matches
@@ -202,27 +304,45 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 
 			unitPrice := product.SellingPrice
 			if bestPromo != nil {
-				if bestPromo.DiscountType == "PERCENTAGE" {
-					discount := unitPrice * (bestPromo.DiscountValue / 100.0)
-					unitPrice -= discount
-				} else if bestPromo.DiscountType == "FIXED_AMOUNT" {
-					unitPrice -= bestPromo.DiscountValue
+				unitPrice = bestPromo.DiscountedPrice(unitPrice)
+			}
+
+			// Units taken from marked-down batches sell at the markdown price instead
+			var markdownQty, markdownTotal float64
+			for _, b := range batches {
+				markdown, ok := batchMarkdowns[b.ID]
+				if !ok {
+					continue
 				}
-				if unitPrice < 0 {
-					unitPrice = 0
+				if drawn := before[b.ID] - b.Quantity; drawn > 0 {
+					markdownQty += drawn
+					markdownTotal += drawn * markdown.DiscountedPrice(product.SellingPrice)
 				}
-				totalDiscountFromPromotions += (product.SellingPrice - unitPrice) * float64(item.Quantity)
+			}
+			if markdownQty > 0 {
+				unitPrice = (markdownTotal + unitPrice*(item.Quantity-markdownQty)) / item.Quantity
+			}
+			lineTotal := unitPrice * item.Quantity
+			if scaleLine != nil && scaleLine.FixedPrice {
+				// The scale already priced the package; its label is what the customer pays
+				unitPrice = scaleLine.UnitPrice
+				lineTotal = scaleLine.TotalPrice
+			} else if unitPrice < product.SellingPrice {
+				totalDiscountFromPromotions += (product.SellingPrice - unitPrice) * item.Quantity
 			}
 
 			// Accumulate total
-			totalAmount += unitPrice * float64(item.Quantity)
+			totalAmount += lineTotal
 
 			// Prepare Order Item (for later creation)
 			orderItems = append(orderItems, domain.OrderItem{
-				ProductID:  item.ProductID,
-				Quantity:   item.Quantity,
-				UnitPrice:  unitPrice,
-				TotalPrice: unitPrice * float64(item.Quantity),
+				ProductID:     item.ProductID,
+				Quantity:      item.Quantity,
+				UnitPrice:     unitPrice,
+				TotalPrice:    lineTotal,
+				SerialNumbers: domain.JoinSerialNumbers(serials),
+				UnitCost:      unitCost,
+				TotalCost:     lineCost,
 			})
 		}
 
@@ -346,7 +466,8 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 		// UserID corresponds to the Staff (Authenticated User)
 		// CustomerID corresponds to the Customer (if provided)
 
-		orderNumber := fmt.Sprintf("ORD-%d-%d", time.Now().Unix(), userID)
+		// Nanoseconds keep order numbers unique when one cashier completes several sales in a second
+		orderNumber := fmt.Sprintf("ORD-%d-%d", time.Now().UnixNano(), userID)
 		order := domain.Order{
 			OrderNumber:    orderNumber,
 			UserID:         userID,         // Staff
@@ -371,6 +492,27 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 			return fmt.Errorf("failed to create order items: %w", err)
 		}
 
+		if err := repository.CloseReservations(tx, reservationIDs, domain.ReservationStatusConsumed); err != nil {
+			return fmt.Errorf("failed to consume reservations: %w", err)
+		}
+
+		// Link sold serialized units to their order lines and start their warranty
+		for i, units := range soldUnits {
+			orderItem := orderItems[i]
+			updates := map[string]interface{}{
+				"status":        domain.SerialStatusSold,
+				"order_item_id": orderItem.ID,
+				"sold_at":       order.OrderDate,
+			}
+			if product := productMap[orderItem.ProductID]; product.WarrantyDays > 0 {
+				updates["warranty_expires_at"] = order.OrderDate.AddDate(0, 0, product.WarrantyDays)
+			}
+			event := domain.SerialNumberEvent{EventType: "SOLD", ReferenceType: "ORDER", ReferenceID: order.ID, Notes: orderNumber, UserID: userID, OccurredAt: order.OrderDate}
+			if err := repository.TransitionSerialNumbers(tx, units, updates, event); err != nil {
+				return fmt.Errorf("failed to update serial numbers: %w", err)
+			}
+		}
+
 		// 6. Create Transaction
 		saletransaction := domain.Transaction{
 			OrderID:              orderNumber,
@@ -389,6 +531,10 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 	})
 
 	if err != nil {
+		if errors.Is(err, repository.ErrStockConflict) {
+			c.Error(appErrors.NewAppError(err.Error(), http.StatusConflict, err))
+			return
+		}
 		c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
 		return
 	}
@@ -421,13 +567,15 @@ func (h *SalesHandler) Checkout(c *gin.Context) {
 // @Router /sales/products [get]
 func (h *SalesHandler) ListProducts(c *gin.Context) {
 	type ProductWithStock struct {
-		ID            uint    `json:"ID"`
-		Name          string  `json:"Name"`
-		SKU           string  `json:"SKU"`
-		SellingPrice  float64 `json:"SellingPrice"`
-		StockQuantity int     `json:"StockQuantity"`
-		CategoryID    uint    `json:"CategoryID"`
-		SubCategoryID uint    `json:"SubCategoryID"`
+		ID                uint    `json:"ID"`
+		Name              string  `json:"Name"`
+		SKU               string  `json:"SKU"`
+		SellingPrice      float64 `json:"SellingPrice"`
+		StockQuantity     float64 `json:"StockQuantity"`
+		UnitOfMeasure     string  `json:"UnitOfMeasure"`
+		QuantityPrecision int     `json:"QuantityPrecision"`
+		CategoryID        uint    `json:"CategoryID"`
+		SubCategoryID     uint    `json:"SubCategoryID"`
 	}
 
 	var results []ProductWithStock
@@ -435,10 +583,10 @@ func (h *SalesHandler) ListProducts(c *gin.Context) {
 	// Optimized query: Get products and sum their batch quantities
 	// Using LEFT JOIN to ensure products with 0 stock are also returned (with NULL sum -> 0)
 	query := h.DB.Table("products").
-		Select("products.id, products.name, products.sku, products.selling_price, products.category_id, products.sub_category_id, COALESCE(SUM(batches.quantity), 0) as stock_quantity").
+		Select("products.id, products.name, products.sku, products.selling_price, products.unit_of_measure, products.quantity_precision, products.category_id, products.sub_category_id, COALESCE(SUM(batches.quantity), 0) as stock_quantity").
 		Joins("LEFT JOIN batches ON batches.product_id = products.id").
 		Where("products.deleted_at IS NULL"). // Respect soft delete
-		Group("products.id, products.name, products.sku, products.selling_price, products.category_id, products.sub_category_id")
+		Group("products.id, products.name, products.sku, products.selling_price, products.unit_of_measure, products.quantity_precision, products.category_id, products.sub_category_id")
 
 	if err := query.Scan(&results).Error; err != nil {
 		c.Error(appErrors.NewAppError("Failed to fetch products", http.StatusInternalServerError, err))
@@ -526,12 +674,13 @@ type UserSummaryDTO struct {
 }
 
 type OrderItemDTO struct {
-	ID         uint           `json:"ID"`
-	ProductID  uint           `json:"ProductID"`
-	Product    ProductSummary `json:"Product"`
-	Quantity   int            `json:"Quantity"`
-	UnitPrice  float64        `json:"UnitPrice"`
-	TotalPrice float64        `json:"TotalPrice"`
+	ID            uint           `json:"ID"`
+	ProductID     uint           `json:"ProductID"`
+	Product       ProductSummary `json:"Product"`
+	Quantity      float64        `json:"Quantity"`
+	UnitPrice     float64        `json:"UnitPrice"`
+	TotalPrice    float64        `json:"TotalPrice"`
+	SerialNumbers []string       `json:"SerialNumbers,omitempty"`
 }
 
 type ProductSummary struct {
@@ -577,9 +726,10 @@ func (h *SalesHandler) ListAllOrders(c *gin.Context) {
 					Name: item.Product.Name,
 					SKU:  item.Product.SKU,
 				},
-				Quantity:   item.Quantity,
-				UnitPrice:  item.UnitPrice,
-				TotalPrice: item.TotalPrice,
+				Quantity:      item.Quantity,
+				UnitPrice:     item.UnitPrice,
+				TotalPrice:    item.TotalPrice,
+				SerialNumbers: domain.SplitSerialNumbers(item.SerialNumbers),
 			})
 		}
 
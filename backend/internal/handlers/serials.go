@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+)
+
+// SerialNumberHistory is a tracked unit with its full event history and warranty status.
+type SerialNumberHistory struct {
+	domain.SerialNumber
+	OrderNumber    string `json:"OrderNumber,omitempty"`
+	WarrantyActive bool   `json:"WarrantyActive"`
+}
+
+// GetSerialNumberHistory godoc
+// @Summary Look up a serial number or IMEI
+// @Description Returns every unit matching the serial number with its receipt, sale and return history and warranty status
+// @Tags serials
+// @Produce json
+// @Param serial path string true "Serial number or IMEI"
+// @Success 200 {array} SerialNumberHistory
+// @Failure 404 {object} map[string]interface{} "Serial number not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /serials/{serial} [get]
+func GetSerialNumberHistory(c *gin.Context) {
+	serial := c.Param("serial")
+
+	units, err := repository.GetSerialNumberHistory(repository.DB, serial)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to look up serial number", http.StatusInternalServerError, err))
+		return
+	}
+	if len(units) == 0 {
+		c.Error(appErrors.NewAppError("Serial number not found", http.StatusNotFound, nil))
+		return
+	}
+
+	now := time.Now()
+	history := make([]SerialNumberHistory, 0, len(units))
+	for _, u := range units {
+		entry := SerialNumberHistory{SerialNumber: u, WarrantyActive: u.WarrantyActive(now)}
+		if u.OrderItemID != nil {
+			var orderItem domain.OrderItem
+			if err := repository.DB.Preload("Order").First(&orderItem, *u.OrderItemID).Error; err == nil {
+				entry.OrderNumber = orderItem.Order.OrderNumber
+			}
+		}
+		history = append(history, entry)
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// ListProductSerialNumbers godoc
+// @Summary List serial numbers for a product
+// @Description Lists the tracked units of a serialized product, e.g. the IN_STOCK units to choose from at checkout
+// @Tags serials
+// @Produce json
+// @Param productId path int true "Product ID"
+// @Param status query string false "Filter by status (IN_STOCK, SOLD, DEFECTIVE, REMOVED)"
+// @Success 200 {array} domain.SerialNumber
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /products/{productId}/serials [get]
+func ListProductSerialNumbers(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 64)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid product ID", http.StatusBadRequest, err))
+		return
+	}
+
+	units, err := repository.ListProductSerialNumbers(repository.DB, uint(productID), c.Query("status"))
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to list serial numbers", http.StatusInternalServerError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, units)
+}
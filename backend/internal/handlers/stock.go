@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"inventory/backend/internal/domain"
 	appErrors "inventory/backend/internal/errors"
@@ -19,10 +21,66 @@ import (
 
 // StockAdjustedEventPayload defines the payload for stock adjustment events.
 type StockAdjustedEventPayload struct {
-	ProductID uint   `json:"productId"`
-	Quantity  int    `json:"quantity"`
-	Type      string `json:"type"`
-	Reason    string `json:"reason"`
+	ProductID uint    `json:"productId"`
+	Quantity  float64 `json:"quantity"`
+	Type      string  `json:"type"`
+	Reason    string  `json:"reason"`
+}
+
+// quantityPrecisionError builds the error returned when a quantity is finer than the product's precision allows.
+func quantityPrecisionError(product *domain.Product, quantity float64) *appErrors.AppError {
+	return appErrors.NewAppError(fmt.Sprintf("Invalid quantity %g for product '%s': at most %d decimal places allowed", quantity, product.Name, product.QuantityPrecision), http.StatusBadRequest, nil)
+}
+
+// serialNumbersForQuantity normalizes the serials supplied for a movement and checks that a
+// serialized product has exactly one serial per unit. Non-serialized products must not send any.
+func serialNumbersForQuantity(product *domain.Product, serials []string, quantity float64) ([]string, error) {
+	if !product.IsSerialized {
+		if len(serials) > 0 {
+			return nil, appErrors.NewAppError(fmt.Sprintf("Product '%s' is not serialized", product.Name), http.StatusBadRequest, nil)
+		}
+		return nil, nil
+	}
+
+	normalized, err := domain.NormalizeSerialNumbers(serials)
+	if err != nil {
+		return nil, appErrors.NewAppError(err.Error(), http.StatusBadRequest, err)
+	}
+	if float64(len(normalized)) != quantity {
+		return nil, appErrors.NewAppError(fmt.Sprintf("Product '%s' is serialized: %g serial numbers required, got %d", product.Name, quantity, len(normalized)), http.StatusBadRequest, nil)
+	}
+	return normalized, nil
+}
+
+// allocateSerialUnits deducts one unit per serial from the in-memory batch it was received into.
+// It returns the batches it changed and how many units could not be matched to a batch, which the
+// caller takes FIFO instead.
+func allocateSerialUnits(batches []*domain.Batch, units []domain.SerialNumber) ([]*domain.Batch, float64) {
+	byID := make(map[uint]*domain.Batch, len(batches))
+	for _, b := range batches {
+		byID[b.ID] = b
+	}
+
+	var touched []*domain.Batch
+	seen := make(map[uint]bool)
+	var unmatched float64
+	for _, u := range units {
+		if u.BatchID == nil {
+			unmatched++
+			continue
+		}
+		b, ok := byID[*u.BatchID]
+		if !ok || b.Quantity < 1 {
+			unmatched++
+			continue
+		}
+		b.Quantity--
+		if !seen[b.ID] {
+			seen[b.ID] = true
+			touched = append(touched, b)
+		}
+	}
+	return touched, unmatched
 }
 
 // CreateBatch godoc
@@ -61,16 +119,69 @@ func CreateBatch(c *gin.Context) {
 		return
 	}
 
+	if !product.IsValidQuantity(req.Quantity) {
+		c.Error(quantityPrecisionError(&product, req.Quantity))
+		return
+	}
+
+	serials, err := serialNumbersForQuantity(&product, req.SerialNumbers, req.Quantity)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	batch := domain.Batch{
 		ProductID:   uint(productID),
 		BatchNumber: req.BatchNumber,
 		Quantity:    req.Quantity,
 		ExpiryDate:  req.ExpiryDate,
 		LocationID:  product.LocationID, // Inherit from product's default location
+		UnitCost:    product.CurrentUnitCost(),
+	}
+	if req.UnitCost != nil {
+		batch.UnitCost = *req.UnitCost
 	}
 
-	if err := repository.DB.Create(&batch).Error; err != nil {
-		c.Error(appErrors.NewAppError("Failed to create batch", http.StatusInternalServerError, err))
+	userID, _ := c.Get("user_id")
+	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, product.ID).Error; err != nil {
+			return appErrors.NewAppError("Failed to lock product", http.StatusInternalServerError, err)
+		}
+		if err := repository.RecordReceiptCost(tx, &product, batch.Quantity, batch.UnitCost); err != nil {
+			return appErrors.NewAppError("Failed to update product cost", http.StatusInternalServerError, err)
+		}
+		if err := tx.Create(&batch).Error; err != nil {
+			return appErrors.NewAppError("Failed to create batch", http.StatusInternalServerError, err)
+		}
+		receipt := domain.StockAdjustment{
+			ProductID:  batch.ProductID,
+			LocationID: batch.LocationID,
+			Type:       "STOCK_IN",
+			Quantity:   batch.Quantity,
+			ReasonCode: "BATCH_RECEIPT",
+			Notes:      "Batch " + batch.BatchNumber,
+			AdjustedAt: time.Now(),
+			UnitCost:   &batch.UnitCost,
+		}
+		receipt.AdjustedBy, _ = userID.(uint)
+		if err := tx.Create(&receipt).Error; err != nil {
+			return appErrors.NewAppError("Failed to record stock receipt", http.StatusInternalServerError, err)
+		}
+		if len(serials) > 0 {
+			uid, _ := userID.(uint)
+			event := domain.SerialNumberEvent{EventType: "STOCK_IN", ReferenceType: "BATCH", ReferenceID: batch.ID, UserID: uid}
+			if err := repository.RegisterSerialNumbers(tx, product.ID, serials, batch.ID, batch.LocationID, event); err != nil {
+				return appErrors.NewAppError(err.Error(), http.StatusConflict, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(appErrors.NewAppError("Failed to create batch", http.StatusInternalServerError, err))
+		}
 		return
 	}
 
@@ -79,11 +190,12 @@ func CreateBatch(c *gin.Context) {
 
 // GetProductStock godoc
 // @Summary Get current stock levels for a product
-// @Description Retrieves current stock levels and batch breakdown for a specific product
+// @Description Retrieves on-hand, reserved, in-transit and available stock with the batch breakdown for a specific product
 // @Tags stock
 // @Accept json
 // @Produce json
 // @Param productId path int true "Product ID"
+// @Param locationId query int false "Location ID"
 // @Success 200 {object} map[string]interface{} "Product stock details"
 // @Failure 404 {object} map[string]interface{} "Product not found"
 // @Failure 500 {object} map[string]interface{} "Internal Server Error"
@@ -108,7 +220,7 @@ func GetProductStock(c *gin.Context) {
 	}
 
 	// Optimize total quantity calculation using DB aggregation
-	var totalQuantity int64
+	var totalQuantity float64
 	// Use a new session for aggregation to avoid polluting the base query or subsequent queries
 	if err := db.Session(&gorm.Session{}).Model(&domain.Batch{}).Select("COALESCE(SUM(quantity), 0)").Row().Scan(&totalQuantity); err != nil {
 		c.Error(appErrors.NewAppError("Failed to calculate total stock", http.StatusInternalServerError, err))
@@ -123,9 +235,31 @@ func GetProductStock(c *gin.Context) {
 		return
 	}
 
+	var locationID uint64
+	if loc := c.Query("locationId"); loc != "" {
+		locationID, _ = strconv.ParseUint(loc, 10, 64)
+	}
+	positions, err := repository.GetStockPositions(repository.DB, &product, uint(locationID), nil)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to calculate available stock", http.StatusInternalServerError, err))
+		return
+	}
+	total := domain.StockPosition{ProductID: product.ID, LocationID: uint(locationID), OnHand: totalQuantity}
+	for _, p := range positions {
+		total.Reserved += p.Reserved
+		total.InTransit += p.InTransit
+	}
+	total.CalculateAvailable(product.QuantityPrecision)
+
 	c.JSON(http.StatusOK, gin.H{
 		"productId":       product.ID,
 		"currentQuantity": totalQuantity,
+		"onHand":          total.OnHand,
+		"reserved":        total.Reserved,
+		"inTransit":       total.InTransit,
+		"available":       total.Available,
+		"unitOfMeasure":   product.UnitOfMeasure,
+		"locations":       positions,
 		"batches":         batches,
 	})
 }
@@ -183,13 +317,32 @@ func CreateStockAdjustment(c *gin.Context) {
 		return
 	}
 
+	if !product.IsValidQuantity(req.Quantity) {
+		c.Error(quantityPrecisionError(&product, req.Quantity))
+		return
+	}
+
+	serials, err := serialNumbersForQuantity(&product, req.SerialNumbers, req.Quantity)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	var adjustment domain.StockAdjustment
+	var removedUnits []domain.SerialNumber
 
 	// Start a database transaction
 	err = repository.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the product so concurrent adjustments and sales of it are applied one at a time
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+			return fmt.Errorf("failed to lock product: %w", err)
+		}
+
 		// Get current quantity before adjustment within the transaction
-		var currentQuantity int
-		tx.Model(&domain.Batch{}).Where("product_id = ?", productID).Select("sum(quantity)").Row().Scan(&currentQuantity)
+		currentQuantity, err := repository.GetLocationStock(tx, product.ID, 0)
+		if err != nil {
+			return fmt.Errorf("failed to calculate current stock: %w", err)
+		}
 
 		adjustment = domain.StockAdjustment{
 			ProductID:        uint(productID),
@@ -212,11 +365,22 @@ func CreateStockAdjustment(c *gin.Context) {
 				BatchNumber: "MANUAL-" + time.Now().Format("20060102150405"), // Unique batch number
 				Quantity:    req.Quantity,
 				ExpiryDate:  nil, // Manual stock-in might not have expiry
+				UnitCost:    product.CurrentUnitCost(),
+			}
+			adjustment.UnitCost = &batch.UnitCost
+			if err := repository.RecordReceiptCost(tx, &product, batch.Quantity, batch.UnitCost); err != nil {
+				return fmt.Errorf("failed to update product cost: %w", err)
 			}
 			if err := tx.Create(&batch).Error; err != nil {
 				return fmt.Errorf("failed to add stock for adjustment: %w", err)
 			}
-			adjustment.NewQuantity = currentQuantity + req.Quantity
+			if len(serials) > 0 {
+				event := domain.SerialNumberEvent{EventType: "STOCK_IN", ReferenceType: "ADJUSTMENT", ReferenceID: batch.ID, Notes: req.ReasonCode, UserID: userID}
+				if err := repository.RegisterSerialNumbers(tx, product.ID, serials, batch.ID, batch.LocationID, event); err != nil {
+					return appErrors.NewAppError(err.Error(), http.StatusConflict, err)
+				}
+			}
+			adjustment.NewQuantity = product.RoundQuantity(currentQuantity + req.Quantity)
 		} else if req.Type == "STOCK_OUT" {
 			// For simplicity, reduce from existing batches (FIFO/FEFO logic would be more complex)
 			// This is a basic implementation and needs refinement for production
@@ -225,38 +389,79 @@ func CreateStockAdjustment(c *gin.Context) {
 			}
 
 			quantityToReduce := req.Quantity
-			var batchesToUpdate []domain.Batch
-			// Fetch batches ordered by creation date (FIFO) or expiry date (FEFO)
-			if err := tx.Where("product_id = ? AND location_id = ?", productID, product.LocationID).Order("created_at asc").Find(&batchesToUpdate).Error; err != nil {
+			// Lock the location's batches, earliest expiry first
+			batchesToUpdate, err := repository.LockBatches(tx, []uint{product.ID}, product.LocationID)
+			if err != nil {
 				return fmt.Errorf("failed to fetch batches for adjustment: %w", err)
 			}
 
-			for _, b := range batchesToUpdate {
+			batchPtrs := make([]*domain.Batch, len(batchesToUpdate))
+			for i := range batchesToUpdate {
+				batchPtrs[i] = &batchesToUpdate[i]
+			}
+			before := repository.SnapshotBatchQuantities(batchPtrs)
+
+			// Serialized units come out of the batch they were received into
+			if len(serials) > 0 {
+				units, err := repository.LockSerialNumbers(tx, product.ID, serials, domain.SerialStatusInStock)
+				if err != nil {
+					return appErrors.NewAppError(err.Error(), http.StatusBadRequest, err)
+				}
+				_, unmatched := allocateSerialUnits(batchPtrs, units)
+				quantityToReduce = unmatched
+				removedUnits = units
+			}
+
+			for _, b := range batchPtrs {
 				if quantityToReduce == 0 {
 					break
 				}
+				if b.Quantity <= 0 {
+					continue
+				}
 				if b.Quantity >= quantityToReduce {
-					b.Quantity -= quantityToReduce
+					b.Quantity = product.RoundQuantity(b.Quantity - quantityToReduce)
 					quantityToReduce = 0
 				} else {
-					quantityToReduce -= b.Quantity
+					quantityToReduce = product.RoundQuantity(quantityToReduce - b.Quantity)
 					b.Quantity = 0
 				}
-				if err := tx.Save(&b).Error; err != nil {
-					return fmt.Errorf("failed to update batch quantity: %w", err)
-				}
 			}
-			adjustment.NewQuantity = currentQuantity - req.Quantity
+			// Stock held at other locations, or quarantined or expired, cannot be taken here
+			if quantityToReduce > 0 {
+				return appErrors.NewAppError(fmt.Sprintf("Not enough stock at the product's location (short by %g %s)", quantityToReduce, product.UnitOfMeasure), http.StatusBadRequest, nil)
+			}
+			if err := repository.SaveBatchQuantities(tx, batchPtrs, before); err != nil {
+				return err
+			}
+			unitCost := domain.UnitCostOf(product.IssueCost(repository.DrawnCostLayers(&product, batchPtrs, before)), req.Quantity)
+			adjustment.UnitCost = &unitCost
+			adjustment.NewQuantity = product.RoundQuantity(currentQuantity - req.Quantity)
 		}
 
 		if err := tx.Create(&adjustment).Error; err != nil {
 			return fmt.Errorf("failed to record stock adjustment: %w", err)
 		}
 
+		if len(removedUnits) > 0 {
+			event := domain.SerialNumberEvent{EventType: "REMOVED", ReferenceType: "ADJUSTMENT", ReferenceID: adjustment.ID, Notes: req.ReasonCode, UserID: userID}
+			if err := repository.TransitionSerialNumbers(tx, removedUnits, map[string]interface{}{"status": domain.SerialStatusRemoved}, event); err != nil {
+				return fmt.Errorf("failed to update serial numbers: %w", err)
+			}
+		}
+
 		return nil
 	})
 
 	if err != nil {
+		if appErr, ok := err.(*appErrors.AppError); ok {
+			c.Error(appErr)
+			return
+		}
+		if errors.Is(err, repository.ErrStockConflict) {
+			c.Error(appErrors.NewAppError(err.Error(), http.StatusConflict, err))
+			return
+		}
 		c.Error(appErrors.NewAppError("Stock adjustment failed", http.StatusInternalServerError, err))
 		return
 	}
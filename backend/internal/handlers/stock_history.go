@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+)
+
+// defaultCoverWindowDays is the sales window used for days-of-cover when none is given.
+const defaultCoverWindowDays = 30
+
+type StockHistoryHandler struct {
+	snapshots repository.SnapshotRepository
+	db        *gorm.DB
+}
+
+func NewStockHistoryHandler(snapshots repository.SnapshotRepository, db *gorm.DB) *StockHistoryHandler {
+	return &StockHistoryHandler{snapshots: snapshots, db: db}
+}
+
+func parseOptionalLocationID(c *gin.Context) (*uint, error) {
+	locStr := c.Query("locationId")
+	if locStr == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(locStr, 10, 32)
+	if err != nil {
+		return nil, appErrors.NewAppError("Invalid locationId", http.StatusBadRequest, err)
+	}
+	val := uint(id)
+	return &val, nil
+}
+
+func parseCoverWindow(c *gin.Context) (int, error) {
+	window, err := strconv.Atoi(c.DefaultQuery("window", strconv.Itoa(defaultCoverWindowDays)))
+	if err != nil || window < 1 || window > 365 {
+		return 0, appErrors.NewAppError("window must be between 1 and 365 days", http.StatusBadRequest, err)
+	}
+	return window, nil
+}
+
+// GetProductStockHistory godoc
+// @Summary Get daily stock-level history for a product
+// @Description Returns the nightly snapshot time series of on-hand quantity, value and movements, with average daily sales and days of cover over the trailing window.
+// @Tags stock
+// @Produce json
+// @Param productId path int true "Product ID"
+// @Param from query string false "Start date (YYYY-MM-DD, defaults to 90 days ago)"
+// @Param to query string false "End date (YYYY-MM-DD, defaults to today)"
+// @Param locationId query int false "Location ID"
+// @Param window query int false "Sales window for days of cover, in days (default 30)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Product not found"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /products/{productId}/stock-history [get]
+func (h *StockHistoryHandler) GetProductStockHistory(c *gin.Context) {
+	var product domain.Product
+	if err := h.db.First(&product, c.Param("productId")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Error(appErrors.NewAppError("Product not found", http.StatusNotFound, err))
+			return
+		}
+		c.Error(appErrors.NewAppError("Failed to fetch product", http.StatusInternalServerError, err))
+		return
+	}
+
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.Error(appErrors.NewAppError("Invalid 'to' date. Use YYYY-MM-DD", http.StatusBadRequest, err))
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -90)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.Error(appErrors.NewAppError("Invalid 'from' date. Use YYYY-MM-DD", http.StatusBadRequest, err))
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		c.Error(appErrors.NewAppError("'from' must be before 'to'", http.StatusBadRequest, nil))
+		return
+	}
+
+	locationID, err := parseOptionalLocationID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	window, err := parseCoverWindow(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	history, err := h.snapshots.GetStockHistory(product.ID, locationID, from, to)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to fetch stock history", http.StatusInternalServerError, err))
+		return
+	}
+
+	// Cover is measured from the last point in the series over the window ending there
+	var onHand float64
+	coverEnd := to
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		onHand = last.Quantity
+		coverEnd = last.Date
+	}
+	averageDailySales, err := h.snapshots.GetAverageDailySales(product.ID, locationID, coverEnd.AddDate(0, 0, -(window-1)), coverEnd)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to calculate sales rate", http.StatusInternalServerError, err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"productId":         product.ID,
+		"sku":               product.SKU,
+		"unitOfMeasure":     product.UnitOfMeasure,
+		"locationId":        locationID,
+		"from":              domain.SnapshotDay(from),
+		"to":                domain.SnapshotDay(to),
+		"history":           history,
+		"onHand":            onHand,
+		"averageDailySales": averageDailySales,
+		"daysOfCover":       domain.DaysOfCover(onHand, averageDailySales),
+		"coverWindowDays":   window,
+	})
+}
+
+// GetDaysOfCover godoc
+// @Summary List days of cover per product
+// @Description Measures each product's stock in the latest nightly snapshot against its average daily sales, lowest cover first. Products without sales in the window have no cover figure and are listed last.
+// @Tags stock
+// @Produce json
+// @Param locationId query int false "Location ID"
+// @Param window query int false "Sales window in days (default 30)"
+// @Param maxDays query number false "Only products with at most this many days of cover"
+// @Success 200 {array} repository.DaysOfCoverItem
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /inventory/days-of-cover [get]
+func (h *StockHistoryHandler) GetDaysOfCover(c *gin.Context) {
+	locationID, err := parseOptionalLocationID(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	window, err := parseCoverWindow(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	items, err := h.snapshots.GetDaysOfCover(locationID, window)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to calculate days of cover", http.StatusInternalServerError, err))
+		return
+	}
+
+	if maxStr := c.Query("maxDays"); maxStr != "" {
+		maxDays, err := strconv.ParseFloat(maxStr, 64)
+		if err != nil {
+			c.Error(appErrors.NewAppError("Invalid maxDays", http.StatusBadRequest, err))
+			return
+		}
+		filtered := items[:0]
+		for _, item := range items {
+			if item.DaysOfCover != nil && *item.DaysOfCover <= maxDays {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	c.JSON(http.StatusOK, items)
+}
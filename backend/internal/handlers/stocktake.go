@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/requests"
+	"inventory/backend/internal/services"
+)
+
+type StockTakeHandler struct {
+	stockTakeService services.StockTakeService
+}
+
+func NewStockTakeHandler(stockTakeService services.StockTakeService) *StockTakeHandler {
+	return &StockTakeHandler{stockTakeService: stockTakeService}
+}
+
+// stockTakeError maps service errors onto HTTP responses.
+func stockTakeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(appErrors.NewAppError("Stock take or product not found", http.StatusNotFound, err))
+	case errors.Is(err, services.ErrStockTakeState):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusConflict, err))
+	case errors.Is(err, services.ErrInvalidStockTake):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
+	default:
+		c.Error(appErrors.NewAppError("Stock take operation failed", http.StatusInternalServerError, err))
+	}
+}
+
+func parseStockTakeID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid stock take ID", http.StatusBadRequest, err))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func authenticatedUserID(c *gin.Context) (uint, bool) {
+	userIDVal, ok := c.Get("user_id")
+	if !ok {
+		c.Error(appErrors.NewAppError("Authenticated user not found", http.StatusUnauthorized, nil))
+		return 0, false
+	}
+	userID, ok := userIDVal.(uint)
+	if !ok {
+		c.Error(appErrors.NewAppError("Invalid user ID type in context", http.StatusInternalServerError, nil))
+		return 0, false
+	}
+	return userID, true
+}
+
+// CreateStockTake godoc
+// @Summary Open a stock-take session
+// @Description Opens a stock take scoped to a location and/or category and freezes the current system quantity of every product in scope
+// @Tags stock-takes
+// @Accept json
+// @Produce json
+// @Param session body requests.CreateStockTakeRequest true "Stock take scope"
+// @Success 201 {object} domain.StockTakeSession
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /stock-takes [post]
+func (h *StockTakeHandler) CreateStockTake(c *gin.Context) {
+	var req requests.CreateStockTakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.stockTakeService.CreateSession(&req, userID)
+	if err != nil {
+		stockTakeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, session)
+}
+
+// ListStockTakes godoc
+// @Summary List stock-take sessions
+// @Description Retrieves stock-take sessions, optionally filtered by status
+// @Tags stock-takes
+// @Produce json
+// @Param status query string false "OPEN, SUBMITTED, APPROVED or CANCELLED"
+// @Success 200 {array} domain.StockTakeSession
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /stock-takes [get]
+func (h *StockTakeHandler) ListStockTakes(c *gin.Context) {
+	sessions, err := h.stockTakeService.ListSessions(c.Query("status"))
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to list stock takes", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, sessions)
+}
+
+// GetStockTake godoc
+// @Summary Get a stock-take session
+// @Tags stock-takes
+// @Produce json
+// @Param id path int true "Stock take ID"
+// @Success 200 {object} domain.StockTakeSession
+// @Failure 404 {object} map[string]interface{} "Stock take not found"
+// @Router /stock-takes/{id} [get]
+func (h *StockTakeHandler) GetStockTake(c *gin.Context) {
+	id, ok := parseStockTakeID(c)
+	if !ok {
+		return
+	}
+	session, err := h.stockTakeService.GetSession(id)
+	if err != nil {
+		stockTakeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// GetStockTakeCountSheet godoc
+// @Summary Get the count sheet of a stock take
+// @Description Lists the products to count with the running counted quantity. System quantities are hidden for blind counts until the session is submitted.
+// @Tags stock-takes
+// @Produce json
+// @Param id path int true "Stock take ID"
+// @Success 200 {array} services.StockTakeCountSheetLine
+// @Failure 404 {object} map[string]interface{} "Stock take not found"
+// @Router /stock-takes/{id}/count-sheet [get]
+func (h *StockTakeHandler) GetStockTakeCountSheet(c *gin.Context) {
+	id, ok := parseStockTakeID(c)
+	if !ok {
+		return
+	}
+	sheet, err := h.stockTakeService.GetCountSheet(id)
+	if err != nil {
+		stockTakeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sheet)
+}
+
+// RecordStockTakeCount godoc
+// @Summary Record a count
+// @Description Adds a counted quantity by product ID or barcode scan. Counts from several counters accumulate; a scan without a quantity counts one unit, or the weight embedded in a scale barcode. SET mode replaces the running total.
+// @Tags stock-takes
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock take ID"
+// @Param count body requests.StockTakeCountRequest true "Count entry"
+// @Success 200 {object} services.StockTakeCountSheetLine
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Stock take or product not found"
+// @Failure 409 {object} map[string]interface{} "Stock take is not open"
+// @Router /stock-takes/{id}/counts [post]
+func (h *StockTakeHandler) RecordStockTakeCount(c *gin.Context) {
+	id, ok := parseStockTakeID(c)
+	if !ok {
+		return
+	}
+	var req requests.StockTakeCountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	line, err := h.stockTakeService.RecordCount(id, &req, userID)
+	if err != nil {
+		stockTakeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, line)
+}
+
+// SubmitStockTake godoc
+// @Summary Submit a stock take for review
+// @Description Closes counting so a manager can review variances
+// @Tags stock-takes
+// @Produce json
+// @Param id path int true "Stock take ID"
+// @Success 200 {object} domain.StockTakeSession
+// @Failure 404 {object} map[string]interface{} "Stock take not found"
+// @Failure 409 {object} map[string]interface{} "Stock take is not open"
+// @Router /stock-takes/{id}/submit [post]
+func (h *StockTakeHandler) SubmitStockTake(c *gin.Context) {
+	id, ok := parseStockTakeID(c)
+	if !ok {
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	session, err := h.stockTakeService.SubmitSession(id, userID)
+	if err != nil {
+		stockTakeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// GetStockTakeVariances godoc
+// @Summary Review stock-take variances
+// @Description Compares the frozen system quantity with the counted quantity for every line, with the cost value of each variance
+// @Tags stock-takes
+// @Produce json
+// @Param id path int true "Stock take ID"
+// @Success 200 {object} services.StockTakeVarianceReport
+// @Failure 404 {object} map[string]interface{} "Stock take not found"
+// @Router /stock-takes/{id}/variances [get]
+func (h *StockTakeHandler) GetStockTakeVariances(c *gin.Context) {
+	id, ok := parseStockTakeID(c)
+	if !ok {
+		return
+	}
+	report, err := h.stockTakeService.GetVarianceReport(id)
+	if err != nil {
+		stockTakeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ApproveStockTake godoc
+// @Summary Approve a stock take
+// @Description Posts a STOCK_TAKE_CORRECTION adjustment for every line with a variance in a single transaction
+// @Tags stock-takes
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock take ID"
+// @Param approval body requests.ApproveStockTakeRequest false "Approval options"
+// @Success 200 {object} services.StockTakeVarianceReport
+// @Failure 404 {object} map[string]interface{} "Stock take not found"
+// @Failure 409 {object} map[string]interface{} "Stock take is not submitted"
+// @Router /stock-takes/{id}/approve [post]
+func (h *StockTakeHandler) ApproveStockTake(c *gin.Context) {
+	id, ok := parseStockTakeID(c)
+	if !ok {
+		return
+	}
+	var req requests.ApproveStockTakeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+			return
+		}
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.stockTakeService.ApproveSession(id, &req, userID)
+	if err != nil {
+		stockTakeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CancelStockTake godoc
+// @Summary Cancel a stock take
+// @Tags stock-takes
+// @Produce json
+// @Param id path int true "Stock take ID"
+// @Success 200 {object} domain.StockTakeSession
+// @Failure 404 {object} map[string]interface{} "Stock take not found"
+// @Failure 409 {object} map[string]interface{} "Stock take is already closed"
+// @Router /stock-takes/{id}/cancel [post]
+func (h *StockTakeHandler) CancelStockTake(c *gin.Context) {
+	id, ok := parseStockTakeID(c)
+	if !ok {
+		return
+	}
+	session, err := h.stockTakeService.CancelSession(id)
+	if err != nil {
+		stockTakeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// GetCycleCountPlan godoc
+// @Summary Get the cycle-count plan
+// @Description Classifies products by ABC consumption value and lists those due for a count, A-class first. Classes are stored only when sessions are generated.
+// @Tags stock-takes
+// @Produce json
+// @Success 200 {array} services.CycleCountPlanItem
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /stock-takes/cycle-count/plan [get]
+func (h *StockTakeHandler) GetCycleCountPlan(c *gin.Context) {
+	plan, err := h.stockTakeService.GetCycleCountPlan()
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to build cycle-count plan", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// RunCycleCount godoc
+// @Summary Generate cycle-count sessions
+// @Description Opens one cycle-count session per location for the products currently due and stores their ABC classes
+// @Tags stock-takes
+// @Produce json
+// @Success 201 {array} domain.StockTakeSession
+// @Failure 500 {object} map[string]interface{} "Internal Server Error"
+// @Router /stock-takes/cycle-count/run [post]
+func (h *StockTakeHandler) RunCycleCount(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	sessions, err := h.stockTakeService.GenerateCycleCountSessions(userID)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to generate cycle-count sessions", http.StatusInternalServerError, err))
+		return
+	}
+	c.JSON(http.StatusCreated, sessions)
+}
@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/requests"
+	"inventory/backend/internal/services"
+)
+
+type InvoiceHandler struct {
+	invoiceService services.InvoiceService
+}
+
+func NewInvoiceHandler(invoiceService services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{invoiceService: invoiceService}
+}
+
+func invoiceError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(appErrors.NewAppError(notFound, http.StatusNotFound, err))
+	case errors.Is(err, services.ErrInvoiceState), errors.Is(err, services.ErrInvoiceMismatch),
+		errors.Is(err, services.ErrDuplicateInvoice), errors.Is(err, services.ErrDuplicateToleranceRule):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusConflict, err))
+	case errors.Is(err, services.ErrInvalidInvoice):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
+	default:
+		c.Error(appErrors.NewAppError("Invoice operation failed", http.StatusInternalServerError, err))
+	}
+}
+
+func parseInvoiceID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid ID", http.StatusBadRequest, err))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ListSupplierInvoices godoc
+// @Summary List supplier invoices
+// @Tags payables
+// @Produce json
+// @Param status query string false "MATCHED, EXCEPTION, APPROVED, PARTIALLY_PAID or PAID"
+// @Param supplierId query int false "Filter by Supplier ID"
+// @Success 200 {array} domain.SupplierInvoice
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Router /payables/invoices [get]
+func (h *InvoiceHandler) ListSupplierInvoices(c *gin.Context) {
+	var supplierID uint64
+	if raw := c.Query("supplierId"); raw != "" {
+		var err error
+		if supplierID, err = strconv.ParseUint(raw, 10, 32); err != nil {
+			c.Error(appErrors.NewAppError("Invalid supplier ID", http.StatusBadRequest, err))
+			return
+		}
+	}
+	invoices, err := h.invoiceService.ListInvoices(c.Query("status"), uint(supplierID))
+	if err != nil {
+		invoiceError(c, err, "Invoice not found")
+		return
+	}
+	c.JSON(http.StatusOK, invoices)
+}
+
+// GetSupplierInvoice godoc
+// @Summary Get a supplier invoice with its matched lines
+// @Tags payables
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} domain.SupplierInvoice
+// @Failure 404 {object} map[string]interface{} "Invoice not found"
+// @Router /payables/invoices/{id} [get]
+func (h *InvoiceHandler) GetSupplierInvoice(c *gin.Context) {
+	id, ok := parseInvoiceID(c, "id")
+	if !ok {
+		return
+	}
+	invoice, err := h.invoiceService.GetInvoice(id)
+	if err != nil {
+		invoiceError(c, err, "Invoice not found")
+		return
+	}
+	c.JSON(http.StatusOK, invoice)
+}
+
+// CreateSupplierInvoice godoc
+// @Summary Record a supplier invoice
+// @Description Records the supplier's invoice against a purchase order and matches every line against the ordered unit price and the quantity received but not yet invoiced, within the supplier's tolerance rule. Invoices with mismatches are created with status EXCEPTION.
+// @Tags payables
+// @Accept json
+// @Produce json
+// @Param invoice body requests.SupplierInvoiceRequest true "Supplier invoice"
+// @Success 201 {object} domain.SupplierInvoice
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Purchase order not found"
+// @Failure 409 {object} map[string]interface{} "Invoice already recorded"
+// @Router /payables/invoices [post]
+func (h *InvoiceHandler) CreateSupplierInvoice(c *gin.Context) {
+	var req requests.SupplierInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	invoice, err := h.invoiceService.CreateInvoice(&req, userID)
+	if err != nil {
+		invoiceError(c, err, "Purchase order not found")
+		return
+	}
+	c.JSON(http.StatusCreated, invoice)
+}
+
+// RematchSupplierInvoice godoc
+// @Summary Match a supplier invoice again
+// @Description Re-runs the three-way match, e.g. after the rest of the goods were received. Resolutions are kept for lines whose mismatch did not change.
+// @Tags payables
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} domain.SupplierInvoice
+// @Failure 404 {object} map[string]interface{} "Invoice not found"
+// @Failure 409 {object} map[string]interface{} "Invoice is already approved"
+// @Router /payables/invoices/{id}/match [post]
+func (h *InvoiceHandler) RematchSupplierInvoice(c *gin.Context) {
+	id, ok := parseInvoiceID(c, "id")
+	if !ok {
+		return
+	}
+	invoice, err := h.invoiceService.RematchInvoice(id)
+	if err != nil {
+		invoiceError(c, err, "Invoice not found")
+		return
+	}
+	c.JSON(http.StatusOK, invoice)
+}
+
+// ResolveSupplierInvoiceLine godoc
+// @Summary Resolve a mismatched invoice line
+// @Description Accepts a quantity or price mismatch with a note, e.g. after the supplier agreed a credit note. The invoice can be approved once no unresolved mismatch is left.
+// @Tags payables
+// @Accept json
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Param lineId path int true "Invoice line ID"
+// @Param resolution body requests.ResolveInvoiceLineRequest true "Resolution"
+// @Success 200 {object} domain.SupplierInvoice
+// @Failure 404 {object} map[string]interface{} "Invoice line not found"
+// @Failure 409 {object} map[string]interface{} "Line has no unresolved mismatch"
+// @Router /payables/invoices/{id}/lines/{lineId}/resolve [post]
+func (h *InvoiceHandler) ResolveSupplierInvoiceLine(c *gin.Context) {
+	id, ok := parseInvoiceID(c, "id")
+	if !ok {
+		return
+	}
+	lineID, ok := parseInvoiceID(c, "lineId")
+	if !ok {
+		return
+	}
+	var req requests.ResolveInvoiceLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	invoice, err := h.invoiceService.ResolveLine(id, lineID, &req, userID)
+	if err != nil {
+		invoiceError(c, err, "Invoice line not found")
+		return
+	}
+	c.JSON(http.StatusOK, invoice)
+}
+
+// ApproveSupplierInvoice godoc
+// @Summary Approve a supplier invoice for payment
+// @Description Matches the invoice once more against the latest receipts and approves it. Invoices with unresolved mismatches are refused.
+// @Tags payables
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Success 200 {object} domain.SupplierInvoice
+// @Failure 404 {object} map[string]interface{} "Invoice not found"
+// @Failure 409 {object} map[string]interface{} "Unresolved mismatches or invoice already approved"
+// @Router /payables/invoices/{id}/approve [post]
+func (h *InvoiceHandler) ApproveSupplierInvoice(c *gin.Context) {
+	id, ok := parseInvoiceID(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	invoice, err := h.invoiceService.ApproveInvoice(id, userID)
+	if err != nil {
+		invoiceError(c, err, "Invoice not found")
+		return
+	}
+	c.JSON(http.StatusOK, invoice)
+}
+
+// RecordSupplierInvoicePayment godoc
+// @Summary Record a payment against an approved invoice
+// @Tags payables
+// @Accept json
+// @Produce json
+// @Param id path int true "Invoice ID"
+// @Param payment body requests.InvoicePaymentRequest true "Payment"
+// @Success 200 {object} domain.SupplierInvoice
+// @Failure 400 {object} map[string]interface{} "Payment exceeds the outstanding amount"
+// @Failure 404 {object} map[string]interface{} "Invoice not found"
+// @Failure 409 {object} map[string]interface{} "Invoice is not approved"
+// @Router /payables/invoices/{id}/payments [post]
+func (h *InvoiceHandler) RecordSupplierInvoicePayment(c *gin.Context) {
+	id, ok := parseInvoiceID(c, "id")
+	if !ok {
+		return
+	}
+	var req requests.InvoicePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	invoice, err := h.invoiceService.RecordPayment(id, &req)
+	if err != nil {
+		invoiceError(c, err, "Invoice not found")
+		return
+	}
+	c.JSON(http.StatusOK, invoice)
+}
+
+// DeleteSupplierInvoice godoc
+// @Summary Delete an unapproved supplier invoice
+// @Tags payables
+// @Param id path int true "Invoice ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Invoice not found"
+// @Failure 409 {object} map[string]interface{} "Invoice is already approved"
+// @Router /payables/invoices/{id} [delete]
+func (h *InvoiceHandler) DeleteSupplierInvoice(c *gin.Context) {
+	id, ok := parseInvoiceID(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.invoiceService.DeleteInvoice(id); err != nil {
+		invoiceError(c, err, "Invoice not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListInvoiceToleranceRules godoc
+// @Summary List invoice tolerance rules
+// @Tags payables
+// @Produce json
+// @Success 200 {array} domain.InvoiceToleranceRule
+// @Router /payables/tolerance-rules [get]
+func (h *InvoiceHandler) ListInvoiceToleranceRules(c *gin.Context) {
+	rules, err := h.invoiceService.ListToleranceRules()
+	if err != nil {
+		invoiceError(c, err, "Rule not found")
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateInvoiceToleranceRule godoc
+// @Summary Create an invoice tolerance rule
+// @Description A line's unit price may differ from the ordered price by pricePercent or priceAmount, whichever allows more; its quantity may exceed what was received and not yet invoiced by quantityPercent. The rule without a supplier applies to every other supplier.
+// @Tags payables
+// @Accept json
+// @Produce json
+// @Param rule body requests.InvoiceToleranceRuleRequest true "Tolerance rule"
+// @Success 201 {object} domain.InvoiceToleranceRule
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 409 {object} map[string]interface{} "Rule already exists"
+// @Router /payables/tolerance-rules [post]
+func (h *InvoiceHandler) CreateInvoiceToleranceRule(c *gin.Context) {
+	var req requests.InvoiceToleranceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	rule, err := h.invoiceService.CreateToleranceRule(&req)
+	if err != nil {
+		invoiceError(c, err, "Rule not found")
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateInvoiceToleranceRule godoc
+// @Summary Update an invoice tolerance rule
+// @Description Applies to invoices matched from now on.
+// @Tags payables
+// @Accept json
+// @Produce json
+// @Param id path int true "Rule ID"
+// @Param rule body requests.InvoiceToleranceRuleRequest true "Tolerance rule"
+// @Success 200 {object} domain.InvoiceToleranceRule
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Failure 409 {object} map[string]interface{} "Rule already exists"
+// @Router /payables/tolerance-rules/{id} [put]
+func (h *InvoiceHandler) UpdateInvoiceToleranceRule(c *gin.Context) {
+	id, ok := parseInvoiceID(c, "id")
+	if !ok {
+		return
+	}
+	var req requests.InvoiceToleranceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	rule, err := h.invoiceService.UpdateToleranceRule(id, &req)
+	if err != nil {
+		invoiceError(c, err, "Rule not found")
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteInvoiceToleranceRule godoc
+// @Summary Delete an invoice tolerance rule
+// @Tags payables
+// @Param id path int true "Rule ID"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Rule not found"
+// @Router /payables/tolerance-rules/{id} [delete]
+func (h *InvoiceHandler) DeleteInvoiceToleranceRule(c *gin.Context) {
+	id, ok := parseInvoiceID(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.invoiceService.DeleteToleranceRule(id); err != nil {
+		invoiceError(c, err, "Rule not found")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetAPAgingReport godoc
+// @Summary Accounts-payable aging report
+// @Description Unpaid supplier invoice balances by supplier, bucketed by days past due: current, 1-30, 31-60, 61-90 and over 90.
+// @Tags payables
+// @Produce json
+// @Param asOf query string false "RFC3339 or YYYY-MM-DD; defaults to today"
+// @Success 200 {object} services.APAgingReport
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Router /payables/aging [get]
+func (h *InvoiceHandler) GetAPAgingReport(c *gin.Context) {
+	asOf := time.Now()
+	if raw := c.Query("asOf"); raw != "" {
+		var err error
+		if asOf, err = parseAsOfDate(raw); err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	report, err := h.invoiceService.APAging(asOf)
+	if err != nil {
+		invoiceError(c, err, "Invoice not found")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
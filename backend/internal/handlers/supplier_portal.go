@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"inventory/backend/internal/domain"
+	appErrors "inventory/backend/internal/errors"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+	"inventory/backend/internal/services"
+	"inventory/backend/internal/websocket"
+)
+
+type SupplierPortalHandler struct {
+	portalService    services.SupplierPortalService
+	documentService  services.PODocumentService
+	hub              *websocket.Hub
+	notificationRepo repository.NotificationRepository
+}
+
+func NewSupplierPortalHandler(portalService services.SupplierPortalService, documentService services.PODocumentService, hub *websocket.Hub, notificationRepo repository.NotificationRepository) *SupplierPortalHandler {
+	return &SupplierPortalHandler{
+		portalService:    portalService,
+		documentService:  documentService,
+		hub:              hub,
+		notificationRepo: notificationRepo,
+	}
+}
+
+func supplierPortalError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.Error(appErrors.NewAppError(notFound, http.StatusNotFound, err))
+	case errors.Is(err, services.ErrSupplierNotLinked):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusForbidden, err))
+	case errors.Is(err, services.ErrPortalOrderState), errors.Is(err, services.ErrDuplicateShipment),
+		errors.Is(err, services.ErrDuplicateSupplierUser):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusConflict, err))
+	case errors.Is(err, services.ErrInvalidPortalRequest):
+		c.Error(appErrors.NewAppError(err.Error(), http.StatusBadRequest, err))
+	default:
+		c.Error(appErrors.NewAppError("Supplier portal operation failed", http.StatusInternalServerError, err))
+	}
+}
+
+func parsePortalID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Invalid ID", http.StatusBadRequest, err))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// portalUser returns the authenticated portal user and the supplier they act for.
+func (h *SupplierPortalHandler) portalUser(c *gin.Context) (userID, supplierID uint, ok bool) {
+	userID, ok = authenticatedUserID(c)
+	if !ok {
+		return 0, 0, false
+	}
+	supplierID, err := h.portalService.SupplierIDForUser(userID)
+	if err != nil {
+		supplierPortalError(c, err, "User not found")
+		return 0, 0, false
+	}
+	return userID, supplierID, true
+}
+
+// notifyBuyers tells purchasing staff about a supplier's response to an order.
+func (h *SupplierPortalHandler) notifyBuyers(poID uint, event, title, message string) {
+	notification := domain.Notification{
+		Type:        event,
+		Title:       title,
+		Message:     message,
+		Payload:     fmt.Sprintf(`{"purchaseOrderId":%d}`, poID),
+		TriggeredAt: time.Now(),
+	}
+	if err := h.notificationRepo.CreateNotificationsForPermission("replenishment.write", notification); err != nil {
+		logrus.Errorf("Failed to notify buyers of purchase order %d: %v", poID, err)
+	}
+	h.hub.BroadcastToPermission("replenishment.write", gin.H{
+		"event": event,
+		"type":  "PURCHASE_ORDER",
+		"data":  gin.H{"purchaseOrderId": poID},
+	})
+}
+
+// ListPortalPurchaseOrders godoc
+// @Summary List the supplier's purchase orders
+// @Description Lists the orders sent to the signed-in supplier user's company, with ordered, shipped and received quantities. Orders still being drafted or approved are not shown.
+// @Tags supplier-portal
+// @Produce json
+// @Param status query string false "SENT, PARTIALLY_RECEIVED, RECEIVED or CANCELLED"
+// @Success 200 {array} services.SupplierPortalOrder
+// @Failure 403 {object} map[string]interface{} "User is not linked to a supplier"
+// @Router /supplier-portal/purchase-orders [get]
+func (h *SupplierPortalHandler) ListPortalPurchaseOrders(c *gin.Context) {
+	_, supplierID, ok := h.portalUser(c)
+	if !ok {
+		return
+	}
+	orders, err := h.portalService.ListPurchaseOrders(supplierID, c.Query("status"))
+	if err != nil {
+		supplierPortalError(c, err, "Purchase Order not found")
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetPortalPurchaseOrder godoc
+// @Summary Get one of the supplier's purchase orders
+// @Description Returns the order with its lines and every change proposal made on it.
+// @Tags supplier-portal
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Success 200 {object} services.SupplierPortalOrder
+// @Failure 404 {object} map[string]interface{} "Purchase Order not found"
+// @Router /supplier-portal/purchase-orders/{poId} [get]
+func (h *SupplierPortalHandler) GetPortalPurchaseOrder(c *gin.Context) {
+	_, supplierID, ok := h.portalUser(c)
+	if !ok {
+		return
+	}
+	poID, ok := parsePortalID(c, "poId")
+	if !ok {
+		return
+	}
+	order, err := h.portalService.GetPurchaseOrder(supplierID, poID)
+	if err != nil {
+		supplierPortalError(c, err, "Purchase Order not found")
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// DownloadPortalPurchaseOrderPDF godoc
+// @Summary Download one of the supplier's purchase orders as PDF
+// @Tags supplier-portal
+// @Produce application/pdf
+// @Param poId path int true "Purchase Order ID"
+// @Success 200 {file} file "Purchase order PDF"
+// @Failure 404 {object} map[string]interface{} "Purchase Order not found"
+// @Router /supplier-portal/purchase-orders/{poId}/pdf [get]
+func (h *SupplierPortalHandler) DownloadPortalPurchaseOrderPDF(c *gin.Context) {
+	_, supplierID, ok := h.portalUser(c)
+	if !ok {
+		return
+	}
+	poID, ok := parsePortalID(c, "poId")
+	if !ok {
+		return
+	}
+	// Only orders sent to the supplier can be downloaded
+	if _, err := h.portalService.GetPurchaseOrder(supplierID, poID); err != nil {
+		supplierPortalError(c, err, "Purchase Order not found")
+		return
+	}
+	pdf, err := h.documentService.RenderPurchaseOrderPDF(poID)
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to generate purchase order PDF", http.StatusInternalServerError, err))
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", pdf.FileName))
+	c.Data(http.StatusOK, "application/pdf", pdf.Content)
+}
+
+// ConfirmPortalPurchaseOrder godoc
+// @Summary Confirm a purchase order as sent
+// @Description Commits the supplier to the quantities and expected delivery date on the order. To change either, propose changes instead.
+// @Tags supplier-portal
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Success 200 {object} services.SupplierPortalOrder
+// @Failure 404 {object} map[string]interface{} "Purchase Order not found"
+// @Failure 409 {object} map[string]interface{} "Already confirmed or changes awaiting review"
+// @Router /supplier-portal/purchase-orders/{poId}/confirm [post]
+func (h *SupplierPortalHandler) ConfirmPortalPurchaseOrder(c *gin.Context) {
+	_, supplierID, ok := h.portalUser(c)
+	if !ok {
+		return
+	}
+	poID, ok := parsePortalID(c, "poId")
+	if !ok {
+		return
+	}
+	if err := h.portalService.ConfirmPurchaseOrder(supplierID, poID); err != nil {
+		supplierPortalError(c, err, "Purchase Order not found")
+		return
+	}
+	h.notifyBuyers(poID, "PO_CONFIRMED", "Purchase Order Confirmed",
+		fmt.Sprintf("The supplier confirmed Purchase Order #%d.", poID))
+
+	order, err := h.portalService.GetPurchaseOrder(supplierID, poID)
+	if err != nil {
+		supplierPortalError(c, err, "Purchase Order not found")
+		return
+	}
+	c.JSON(http.StatusOK, order)
+}
+
+// ProposePurchaseOrderChanges godoc
+// @Summary Propose changed quantities or delivery date
+// @Description Proposes lower quantities for some lines (0 when a line cannot be supplied), a new delivery date, or both. Quantities cannot exceed what was ordered. Purchasing accepts or rejects the proposal; until then the order cannot be confirmed or shipped against.
+// @Tags supplier-portal
+// @Accept json
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Param proposal body requests.POChangeProposalRequest true "Proposed changes"
+// @Success 201 {object} domain.POChangeProposal
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Purchase Order not found"
+// @Failure 409 {object} map[string]interface{} "Already confirmed or changes awaiting review"
+// @Router /supplier-portal/purchase-orders/{poId}/propose-changes [post]
+func (h *SupplierPortalHandler) ProposePurchaseOrderChanges(c *gin.Context) {
+	var req requests.POChangeProposalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	userID, supplierID, ok := h.portalUser(c)
+	if !ok {
+		return
+	}
+	poID, ok := parsePortalID(c, "poId")
+	if !ok {
+		return
+	}
+	proposal, err := h.portalService.ProposeChanges(supplierID, poID, &req, userID)
+	if err != nil {
+		supplierPortalError(c, err, "Purchase Order not found")
+		return
+	}
+	h.notifyBuyers(poID, "PO_CHANGES_PROPOSED", "Supplier Proposed Changes",
+		fmt.Sprintf("The supplier proposed changes to Purchase Order #%d that need your review.", poID))
+	c.JSON(http.StatusCreated, proposal)
+}
+
+// SubmitAdvanceShippingNotice godoc
+// @Summary Submit an advance shipping notice
+// @Description Announces a shipment against the order with the batch number, and expiry date where the goods expire, of every line. The quantities shipped on all notices may not exceed the ordered quantities.
+// @Tags supplier-portal
+// @Accept json
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Param notice body requests.AdvanceShippingNoticeRequest true "Shipping notice"
+// @Success 201 {object} domain.AdvanceShippingNotice
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Purchase Order not found"
+// @Failure 409 {object} map[string]interface{} "Shipment already submitted or the order cannot be shipped"
+// @Router /supplier-portal/purchase-orders/{poId}/asns [post]
+func (h *SupplierPortalHandler) SubmitAdvanceShippingNotice(c *gin.Context) {
+	var req requests.AdvanceShippingNoticeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	userID, supplierID, ok := h.portalUser(c)
+	if !ok {
+		return
+	}
+	poID, ok := parsePortalID(c, "poId")
+	if !ok {
+		return
+	}
+	notice, err := h.portalService.SubmitShippingNotice(supplierID, poID, &req, userID)
+	if err != nil {
+		supplierPortalError(c, err, "Purchase Order not found")
+		return
+	}
+	h.notifyBuyers(poID, "PO_SHIPPED", "Shipment Announced",
+		fmt.Sprintf("The supplier shipped %s against Purchase Order #%d.", notice.ShipmentNumber, poID))
+	c.JSON(http.StatusCreated, notice)
+}
+
+// ListPortalShippingNotices godoc
+// @Summary List the supplier's advance shipping notices
+// @Tags supplier-portal
+// @Produce json
+// @Param poId query int false "Filter by Purchase Order ID"
+// @Success 200 {array} domain.AdvanceShippingNotice
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Router /supplier-portal/asns [get]
+func (h *SupplierPortalHandler) ListPortalShippingNotices(c *gin.Context) {
+	_, supplierID, ok := h.portalUser(c)
+	if !ok {
+		return
+	}
+	var poID uint64
+	if raw := c.Query("poId"); raw != "" {
+		var err error
+		if poID, err = strconv.ParseUint(raw, 10, 32); err != nil {
+			c.Error(appErrors.NewAppError("Invalid purchase order ID", http.StatusBadRequest, err))
+			return
+		}
+	}
+	notices, err := h.portalService.ListShippingNotices(supplierID, uint(poID))
+	if err != nil {
+		supplierPortalError(c, err, "Shipping notice not found")
+		return
+	}
+	c.JSON(http.StatusOK, notices)
+}
+
+// GetPortalScorecard godoc
+// @Summary Get the supplier's performance scorecard
+// @Description Rates the orders of the last days: how many were confirmed and how fast, on-time delivery against the promised date, lead time, fill rate, shipping notice coverage and how many invoices matched.
+// @Tags supplier-portal
+// @Produce json
+// @Param days query int false "Days to look back (default 90)"
+// @Success 200 {object} services.SupplierPortalScorecard
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Router /supplier-portal/scorecard [get]
+func (h *SupplierPortalHandler) GetPortalScorecard(c *gin.Context) {
+	_, supplierID, ok := h.portalUser(c)
+	if !ok {
+		return
+	}
+	days, err := strconv.Atoi(c.DefaultQuery("days", "90"))
+	if err != nil || days < 1 || days > 730 {
+		c.Error(appErrors.NewAppError("days must be between 1 and 730", http.StatusBadRequest, err))
+		return
+	}
+	scorecard, err := h.portalService.Scorecard(supplierID, days)
+	if err != nil {
+		supplierPortalError(c, err, "Supplier not found")
+		return
+	}
+	c.JSON(http.StatusOK, scorecard)
+}
+
+// ListPOChangeProposals godoc
+// @Summary List a purchase order's change proposals
+// @Tags replenishment
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Success 200 {array} domain.POChangeProposal
+// @Router /replenishment/purchase-orders/{poId}/change-proposals [get]
+func (h *SupplierPortalHandler) ListPOChangeProposals(c *gin.Context) {
+	poID, ok := parsePortalID(c, "poId")
+	if !ok {
+		return
+	}
+	proposals, err := h.portalService.ListChangeProposals(poID)
+	if err != nil {
+		supplierPortalError(c, err, "Purchase Order not found")
+		return
+	}
+	c.JSON(http.StatusOK, proposals)
+}
+
+// AcceptPOChangeProposal godoc
+// @Summary Accept a supplier's change proposal
+// @Description Applies the proposed quantities and delivery date to the order, which then counts as confirmed by the supplier.
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Param proposalId path int true "Change Proposal ID"
+// @Param decision body requests.PODecisionRequest false "Comments"
+// @Success 200 {object} domain.POChangeProposal
+// @Failure 404 {object} map[string]interface{} "Change proposal not found"
+// @Failure 409 {object} map[string]interface{} "Proposal already reviewed or the order has moved on"
+// @Router /replenishment/purchase-orders/{poId}/change-proposals/{proposalId}/accept [post]
+func (h *SupplierPortalHandler) AcceptPOChangeProposal(c *gin.Context) {
+	h.reviewChangeProposal(c, true)
+}
+
+// RejectPOChangeProposal godoc
+// @Summary Reject a supplier's change proposal
+// @Description Keeps the order as sent. The supplier can then confirm it or propose other changes.
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Param proposalId path int true "Change Proposal ID"
+// @Param decision body requests.PODecisionRequest true "Reason for rejecting"
+// @Success 200 {object} domain.POChangeProposal
+// @Failure 400 {object} map[string]interface{} "Comments are required"
+// @Failure 404 {object} map[string]interface{} "Change proposal not found"
+// @Failure 409 {object} map[string]interface{} "Proposal already reviewed"
+// @Router /replenishment/purchase-orders/{poId}/change-proposals/{proposalId}/reject [post]
+func (h *SupplierPortalHandler) RejectPOChangeProposal(c *gin.Context) {
+	h.reviewChangeProposal(c, false)
+}
+
+func (h *SupplierPortalHandler) reviewChangeProposal(c *gin.Context, accept bool) {
+	var req requests.PODecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+			return
+		}
+	}
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+	poID, ok := parsePortalID(c, "poId")
+	if !ok {
+		return
+	}
+	proposalID, ok := parsePortalID(c, "proposalId")
+	if !ok {
+		return
+	}
+
+	var proposal *domain.POChangeProposal
+	var err error
+	if accept {
+		proposal, err = h.portalService.AcceptChangeProposal(poID, proposalID, userID, req.Comments)
+	} else {
+		proposal, err = h.portalService.RejectChangeProposal(poID, proposalID, userID, req.Comments)
+	}
+	if err != nil {
+		supplierPortalError(c, err, "Change proposal not found")
+		return
+	}
+	if accept {
+		// The stored document follows the order's accepted quantities and dates
+		go func() {
+			if _, err := h.documentService.GeneratePurchaseOrderPDF(poID); err != nil {
+				logrus.Errorf("Failed to regenerate PDF for purchase order %d: %v", poID, err)
+			}
+		}()
+	}
+	c.JSON(http.StatusOK, proposal)
+}
+
+// ListPOShippingNotices godoc
+// @Summary List a purchase order's advance shipping notices
+// @Description Shows what the supplier has dispatched, with batch numbers and expiry dates, ahead of receiving.
+// @Tags replenishment
+// @Produce json
+// @Param poId path int true "Purchase Order ID"
+// @Success 200 {array} domain.AdvanceShippingNotice
+// @Router /replenishment/purchase-orders/{poId}/asns [get]
+func (h *SupplierPortalHandler) ListPOShippingNotices(c *gin.Context) {
+	poID, ok := parsePortalID(c, "poId")
+	if !ok {
+		return
+	}
+	notices, err := h.portalService.ListShippingNotices(0, poID)
+	if err != nil {
+		supplierPortalError(c, err, "Purchase Order not found")
+		return
+	}
+	c.JSON(http.StatusOK, notices)
+}
+
+// ListSupplierUsers godoc
+// @Summary List a supplier's portal users
+// @Tags suppliers
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {array} domain.User
+// @Router /suppliers/{id}/users [get]
+func (h *SupplierPortalHandler) ListSupplierUsers(c *gin.Context) {
+	supplierID, ok := parsePortalID(c, "id")
+	if !ok {
+		return
+	}
+	users, err := h.portalService.ListSupplierUsers(supplierID)
+	if err != nil {
+		supplierPortalError(c, err, "Supplier not found")
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// CreateSupplierUser godoc
+// @Summary Create a supplier portal user
+// @Description Creates an active login with the Supplier role for the supplier. It can only reach the supplier portal, and only this supplier's orders.
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Param user body requests.SupplierUserRequest true "Supplier user"
+// @Success 201 {object} domain.User
+// @Failure 400 {object} map[string]interface{} "Bad Request"
+// @Failure 404 {object} map[string]interface{} "Supplier not found"
+// @Failure 409 {object} map[string]interface{} "Username, email or phone number taken"
+// @Router /suppliers/{id}/users [post]
+func (h *SupplierPortalHandler) CreateSupplierUser(c *gin.Context) {
+	supplierID, ok := parsePortalID(c, "id")
+	if !ok {
+		return
+	}
+	var req requests.SupplierUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(appErrors.NewAppError("Invalid request payload", http.StatusBadRequest, err))
+		return
+	}
+	user, err := h.portalService.CreateSupplierUser(supplierID, &req)
+	if err != nil {
+		supplierPortalError(c, err, "Supplier not found")
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
@@ -5,6 +5,7 @@ import (
 	appErrors "inventory/backend/internal/errors"
 	"inventory/backend/internal/repository"
 	"inventory/backend/internal/requests"
+	"inventory/backend/internal/services"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,14 +15,16 @@ import (
 )
 
 type SupplierHandler struct {
-	supplierRepo *repository.SupplierRepository
-	db           *gorm.DB // Keep db for now for existing functions
+	supplierRepo    *repository.SupplierRepository
+	db              *gorm.DB // Keep db for now for existing functions
+	leadTimeService services.LeadTimeService
 }
 
-func NewSupplierHandler(supplierRepo *repository.SupplierRepository, db *gorm.DB) *SupplierHandler {
+func NewSupplierHandler(supplierRepo *repository.SupplierRepository, db *gorm.DB, leadTimeService services.LeadTimeService) *SupplierHandler {
 	return &SupplierHandler{
-		supplierRepo: supplierRepo,
-		db:           db,
+		supplierRepo:    supplierRepo,
+		db:              db,
+		leadTimeService: leadTimeService,
 	}
 }
 
@@ -50,6 +53,9 @@ func (h *SupplierHandler) CreateSupplier(c *gin.Context) {
 		Email:         req.Email,
 		Phone:         req.Phone,
 		Address:       req.Address,
+		MinimumOrderValue:     req.MinimumOrderValue,
+		FreeShippingThreshold: req.FreeShippingThreshold,
+		AutoApproveLimit:      req.AutoApproveLimit,
 	}
 
 	if err := h.supplierRepo.CreateSupplier(&supplier); err != nil {
@@ -170,6 +176,15 @@ func (h *SupplierHandler) UpdateSupplier(c *gin.Context) {
 		"Phone":         req.Phone,
 		"Address":       req.Address,
 	}
+	if req.MinimumOrderValue != nil {
+		updates["MinimumOrderValue"] = *req.MinimumOrderValue
+	}
+	if req.FreeShippingThreshold != nil {
+		updates["FreeShippingThreshold"] = *req.FreeShippingThreshold
+	}
+	if req.AutoApproveLimit != nil {
+		updates["AutoApproveLimit"] = *req.AutoApproveLimit
+	}
 
 	if err := h.supplierRepo.UpdateSupplier(&supplier, updates); err != nil {
 		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
@@ -225,7 +240,7 @@ func (h *SupplierHandler) DeleteSupplier(c *gin.Context) {
 
 // GetSupplierPerformanceReport godoc
 // @Summary Get supplier performance report
-// @Description Generates a mock report on supplier performance (e.g., on-time delivery, quality).
+// @Description Reports on-time delivery and lead-time statistics (mean, variance, spread) learned from the supplier's received purchase orders, overall and per product.
 // @Tags suppliers
 // @Accept json
 // @Produce json
@@ -257,11 +272,24 @@ func (h *SupplierHandler) GetSupplierPerformanceReport(c *gin.Context) {
 		return
 	}
 
+	leadTime, err := h.leadTimeService.SupplierLeadTime(uint(id))
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to get supplier lead times", http.StatusInternalServerError, err))
+		return
+	}
+	productLeadTimes, err := h.leadTimeService.ProductLeadTimes(uint(id))
+	if err != nil {
+		c.Error(appErrors.NewAppError("Failed to get supplier lead times", http.StatusInternalServerError, err))
+		return
+	}
+
 	performanceData := gin.H{
 		"supplierId":         supplier.ID,
 		"supplierName":       supplier.Name,
 		"averageLeadTimeDays": averageLeadTime,
 		"onTimeDeliveryRate":   onTimeDeliveryRate,
+		"leadTime":            leadTime,
+		"productLeadTimes":    productLeadTimes,
 	}
 
 	c.JSON(http.StatusOK, performanceData)
@@ -96,3 +96,26 @@ func ManagerOnly() gin.HandlerFunc {
 func StaffOnly() gin.HandlerFunc {
 	return AuthorizeMiddleware("Staff")
 }
+
+// RestrictRoleToPaths confines users of a role to the routes under the given path prefixes. Other roles
+// pass through. It guards routes that check no permission, so a restricted role cannot reach them.
+func RestrictRoleToPaths(role string, allowedPrefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userRole, exists := c.Get("role")
+		if !exists || userRole != role {
+			c.Next()
+			return
+		}
+
+		path := c.FullPath()
+		for _, prefix := range allowedPrefixes {
+			if strings.HasPrefix(path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		c.Error(appErrors.NewAppError("Insufficient permissions", http.StatusForbidden, nil))
+		c.Abort()
+	}
+}
@@ -9,6 +9,13 @@ type BarcodeRepository interface {
 	GetProductBySKU(sku string) (*domain.Product, error)
 	GetProductByID(id uint64) (*domain.Product, error)
 	GetProductByBarcode(barcode string) (*domain.Product, error)
+	GetProductByPLU(plu string) (*domain.Product, error)
+	GetActiveScalePatterns() ([]domain.ScaleBarcodePattern, error)
+	ListScalePatterns() ([]domain.ScaleBarcodePattern, error)
+	GetScalePatternByID(id uint) (*domain.ScaleBarcodePattern, error)
+	CreateScalePattern(pattern *domain.ScaleBarcodePattern) error
+	UpdateScalePattern(pattern *domain.ScaleBarcodePattern) error
+	DeleteScalePattern(id uint) error
 }
 
 type barcodeRepository struct {
@@ -42,3 +49,47 @@ func (r *barcodeRepository) GetProductByBarcode(barcode string) (*domain.Product
 	}
 	return &product, nil
 }
+
+func (r *barcodeRepository) GetProductByPLU(plu string) (*domain.Product, error) {
+	var product domain.Product
+	if err := r.db.Where("plu_code = ?", plu).First(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *barcodeRepository) GetActiveScalePatterns() ([]domain.ScaleBarcodePattern, error) {
+	var patterns []domain.ScaleBarcodePattern
+	if err := r.db.Where("is_active = ?", true).Order("priority desc, length(prefix) desc, id asc").Find(&patterns).Error; err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+func (r *barcodeRepository) ListScalePatterns() ([]domain.ScaleBarcodePattern, error) {
+	var patterns []domain.ScaleBarcodePattern
+	if err := r.db.Order("priority desc, id asc").Find(&patterns).Error; err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+func (r *barcodeRepository) GetScalePatternByID(id uint) (*domain.ScaleBarcodePattern, error) {
+	var pattern domain.ScaleBarcodePattern
+	if err := r.db.First(&pattern, id).Error; err != nil {
+		return nil, err
+	}
+	return &pattern, nil
+}
+
+func (r *barcodeRepository) CreateScalePattern(pattern *domain.ScaleBarcodePattern) error {
+	return r.db.Create(pattern).Error
+}
+
+func (r *barcodeRepository) UpdateScalePattern(pattern *domain.ScaleBarcodePattern) error {
+	return r.db.Save(pattern).Error
+}
+
+func (r *barcodeRepository) DeleteScalePattern(id uint) error {
+	return r.db.Delete(&domain.ScaleBarcodePattern{}, id).Error
+}
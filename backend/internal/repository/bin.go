@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrBinFull is returned when a bin's capacity cannot take the quantity put into it.
+var ErrBinFull = errors.New("bin does not have enough capacity")
+
+// GetBinStock returns the quantity held in a bin across all products.
+func GetBinStock(tx *gorm.DB, binID uint) (float64, error) {
+	var qty float64
+	err := tx.Model(&domain.Batch{}).
+		Where("bin_id = ? AND quantity > 0", binID).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&qty).Error
+	return qty, err
+}
+
+// SortBinsByPath orders bins in walking order.
+func SortBinsByPath(bins []domain.Bin) {
+	sort.SliceStable(bins, func(i, j int) bool {
+		return domain.CompareBinPaths(bins[i].Path, bins[j].Path) < 0
+	})
+}
+
+// SuggestPutawayBin picks the bin a receipt of the product should go to: a bin at the location
+// already holding the product, fullest first, then the first empty leaf bin in walking order.
+// Both must be active with room for the quantity. It returns nil when no bin qualifies, in which
+// case the stock stays unassigned in the location.
+func SuggestPutawayBin(tx *gorm.DB, productID, locationID uint, quantity float64) (*domain.Bin, error) {
+	type binStock struct {
+		BinID    uint
+		Quantity float64
+	}
+	var holding []binStock
+	if err := tx.Model(&domain.Batch{}).
+		Select("bin_id, SUM(quantity) AS quantity").
+		Where("product_id = ? AND location_id = ? AND bin_id IS NOT NULL AND quantity > 0", productID, locationID).
+		Group("bin_id").
+		Order("quantity desc").
+		Scan(&holding).Error; err != nil {
+		return nil, err
+	}
+	for _, h := range holding {
+		var bin domain.Bin
+		if err := tx.Where("id = ? AND is_active = ?", h.BinID, true).First(&bin).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		held, err := GetBinStock(tx, bin.ID)
+		if err != nil {
+			return nil, err
+		}
+		if bin.HasRoomFor(held, quantity) {
+			return &bin, nil
+		}
+	}
+
+	// Empty leaf bins: no active children and no stock of any product
+	var empty []domain.Bin
+	if err := tx.Where("location_id = ? AND is_active = ?", locationID, true).
+		Where("NOT EXISTS (SELECT 1 FROM bins c WHERE c.parent_id = bins.id AND c.deleted_at IS NULL)").
+		Where("NOT EXISTS (SELECT 1 FROM batches b WHERE b.bin_id = bins.id AND b.quantity > 0 AND b.deleted_at IS NULL)").
+		Find(&empty).Error; err != nil {
+		return nil, err
+	}
+	SortBinsByPath(empty)
+	for i := range empty {
+		if empty[i].HasRoomFor(0, quantity) {
+			return &empty[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// PutAwayBatch assigns a batch to a bin of its location, checking the bin's capacity.
+func PutAwayBatch(tx *gorm.DB, batch *domain.Batch, bin *domain.Bin) error {
+	if bin.LocationID != batch.LocationID {
+		return fmt.Errorf("bin %s is not in the batch's location", bin.Path)
+	}
+	if !bin.IsActive {
+		return fmt.Errorf("bin %s is inactive", bin.Path)
+	}
+	held, err := GetBinStock(tx, bin.ID)
+	if err != nil {
+		return err
+	}
+	if batch.BinID != nil && *batch.BinID == bin.ID {
+		held -= batch.Quantity
+	}
+	if !bin.HasRoomFor(held, batch.Quantity) {
+		return fmt.Errorf("%w: %s holds %g of %g", ErrBinFull, bin.Path, held, bin.Capacity)
+	}
+	binID := bin.ID
+	if err := tx.Model(batch).Update("bin_id", binID).Error; err != nil {
+		return err
+	}
+	batch.BinID = &binID
+	return nil
+}
+
+// MoveBatchToBin moves quantity of a batch into another bin of the same location. Moving the whole
+// batch reassigns it; moving part splits off a new batch with SplitBatch. The batch must be locked
+// by the caller. Serialized units cannot be split off without naming them, so partial moves of
+// serialized products are refused.
+func MoveBatchToBin(tx *gorm.DB, product *domain.Product, batch *domain.Batch, to *domain.Bin, quantity float64, userID uint, notes string) (*domain.BinMove, error) {
+	if quantity <= 0 || quantity > batch.Quantity {
+		return nil, fmt.Errorf("quantity must be between 0 and the %g held in batch %s", batch.Quantity, batch.BatchNumber)
+	}
+	if batch.BinID != nil && *batch.BinID == to.ID {
+		return nil, fmt.Errorf("batch %s is already in bin %s", batch.BatchNumber, to.Path)
+	}
+
+	move := &domain.BinMove{
+		ProductID:   batch.ProductID,
+		LocationID:  batch.LocationID,
+		FromBatchID: batch.ID,
+		ToBatchID:   batch.ID,
+		FromBinID:   batch.BinID,
+		ToBinID:     to.ID,
+		Quantity:    quantity,
+		MovedBy:     userID,
+		MovedAt:     time.Now(),
+		Notes:       notes,
+	}
+
+	if quantity == batch.Quantity {
+		if err := PutAwayBatch(tx, batch, to); err != nil {
+			return nil, err
+		}
+	} else {
+		if product.IsSerialized {
+			return nil, fmt.Errorf("serialized batch %s can only be moved whole", batch.BatchNumber)
+		}
+		split, err := SplitBatch(tx, product, batch, quantity, batch.LocationID)
+		if err != nil {
+			return nil, err
+		}
+		if err := PutAwayBatch(tx, split, to); err != nil {
+			return nil, err
+		}
+		move.ToBatchID = split.ID
+	}
+
+	if err := tx.Create(move).Error; err != nil {
+		return nil, err
+	}
+	return move, nil
+}
+
+// PickListLine is one stop on a pick walk: take Quantity of the product from the batch in the bin.
+type PickListLine struct {
+	BinID         *uint      `json:"binId"`
+	BinPath       string     `json:"binPath"` // Empty for stock not put away yet
+	ProductID     uint       `json:"productId"`
+	SKU           string     `json:"sku"`
+	ProductName   string     `json:"productName"`
+	BatchID       uint       `json:"batchId"`
+	BatchNumber   string     `json:"batchNumber"`
+	ExpiryDate    *time.Time `json:"expiryDate"`
+	Quantity      float64    `json:"quantity"`
+	UnitOfMeasure string     `json:"unitOfMeasure"`
+}
+
+// PickShortage is requested quantity the location cannot supply.
+type PickShortage struct {
+	ProductID uint    `json:"productId"`
+	SKU       string  `json:"sku"`
+	Requested float64 `json:"requested"`
+	Short     float64 `json:"short"`
+}
+
+// PickList is a walk through a location's bins that collects the requested stock.
+type PickList struct {
+	LocationID uint           `json:"locationId"`
+	Lines      []PickListLine `json:"lines"`
+	Shortages  []PickShortage `json:"shortages"`
+}
+
+// BuildPickList allocates each requested quantity to the location's batches earliest expiry first
+// and returns the lines in bin-path order, with unassigned stock last. It does not move stock.
+func BuildPickList(tx *gorm.DB, locationID uint, quantities map[uint]float64) (*PickList, error) {
+	pickList := &PickList{LocationID: locationID, Lines: []PickListLine{}, Shortages: []PickShortage{}}
+
+	productIDs := make([]uint, 0, len(quantities))
+	for id := range quantities {
+		productIDs = append(productIDs, id)
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
+	var products []domain.Product
+	if err := tx.Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	if len(products) != len(productIDs) {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	for i := range products {
+		product := &products[i]
+		var batches []domain.Batch
+		if err := tx.Preload("Bin").
+			Where("product_id = ? AND location_id = ? AND quantity > 0", product.ID, locationID).
+			Order("expiry_date asc, created_at asc, id asc").
+			Find(&batches).Error; err != nil {
+			return nil, err
+		}
+
+		remaining := quantities[product.ID]
+		for _, b := range batches {
+			if remaining <= 0 {
+				break
+			}
+			take := remaining
+			if b.Quantity < take {
+				take = b.Quantity
+			}
+			remaining = product.RoundQuantity(remaining - take)
+
+			line := PickListLine{
+				BinID:         b.BinID,
+				ProductID:     product.ID,
+				SKU:           product.SKU,
+				ProductName:   product.Name,
+				BatchID:       b.ID,
+				BatchNumber:   b.BatchNumber,
+				ExpiryDate:    b.ExpiryDate,
+				Quantity:      take,
+				UnitOfMeasure: product.UnitOfMeasure,
+			}
+			if b.Bin != nil {
+				line.BinPath = b.Bin.Path
+			}
+			pickList.Lines = append(pickList.Lines, line)
+		}
+		if remaining > 0 {
+			pickList.Shortages = append(pickList.Shortages, PickShortage{
+				ProductID: product.ID,
+				SKU:       product.SKU,
+				Requested: quantities[product.ID],
+				Short:     remaining,
+			})
+		}
+	}
+
+	sort.SliceStable(pickList.Lines, func(i, j int) bool {
+		a, b := pickList.Lines[i], pickList.Lines[j]
+		if (a.BinPath == "") != (b.BinPath == "") {
+			return b.BinPath == ""
+		}
+		if c := domain.CompareBinPaths(a.BinPath, b.BinPath); c != 0 {
+			return c < 0
+		}
+		return a.SKU < b.SKU
+	})
+	return pickList, nil
+}
+
+// LockBin loads and locks a bin so capacity checks and moves into it are serialized.
+func LockBin(tx *gorm.DB, binID uint) (*domain.Bin, error) {
+	var bin domain.Bin
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bin, binID).Error; err != nil {
+		return nil, err
+	}
+	return &bin, nil
+}
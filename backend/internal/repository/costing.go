@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// RecordReceiptCost folds received stock into the product's moving-average cost. It must be
+// called before the new batch is created so the on-hand quantity excludes the receipt.
+func RecordReceiptCost(tx *gorm.DB, product *domain.Product, quantity, unitCost float64) error {
+	onHand, err := GetLocationStock(tx, product.ID, 0)
+	if err != nil {
+		return err
+	}
+	averageCost := domain.MovingAverageCost(onHand, product.AverageCost, quantity, unitCost)
+	if averageCost == product.AverageCost {
+		return nil
+	}
+	if err := tx.Model(&domain.Product{}).Where("id = ?", product.ID).Update("average_cost", averageCost).Error; err != nil {
+		return err
+	}
+	product.AverageCost = averageCost
+	return nil
+}
+
+// SnapshotBatchQuantities records batch quantities before a deduction so the layers drawn can be
+// worked out afterwards with DrawnCostLayers.
+func SnapshotBatchQuantities(batches []*domain.Batch) map[uint]float64 {
+	before := make(map[uint]float64, len(batches))
+	for _, b := range batches {
+		before[b.ID] = b.Quantity
+	}
+	return before
+}
+
+// DrawnCostLayers returns the quantity taken from each batch since the snapshot, at the batch's cost.
+func DrawnCostLayers(product *domain.Product, batches []*domain.Batch, before map[uint]float64) []domain.CostLayerDraw {
+	var draws []domain.CostLayerDraw
+	for _, b := range batches {
+		taken := product.RoundQuantity(before[b.ID] - b.Quantity)
+		if taken <= 0 {
+			continue
+		}
+		draws = append(draws, domain.CostLayerDraw{BatchID: b.ID, Quantity: taken, UnitCost: b.LayerCost(product)})
+	}
+	return draws
+}
@@ -47,6 +47,8 @@ func InitDB(cfg *config.Config) {
 
 	AutoMigrate()
 	ensureBarcodeUniqueIndex()
+	ensurePLUUniqueIndex()
+	ensureDefaultReorderPolicyUniqueIndex()
 
 	ensureDefaultAlertSubscriptions()
 
@@ -86,6 +88,19 @@ func AutoMigrate() {
 		&domain.ProductAlertSettings{},
 
 		&domain.StockTransfer{},
+		&domain.ExpiryMarkdownRule{},
+		&domain.StockWriteOff{},
+		&domain.ProductSupplier{},
+		&domain.ReorderPolicy{},
+		&domain.POApprovalRule{},
+		&domain.POApproval{},
+		&domain.SupplierInvoice{},
+		&domain.SupplierInvoiceLine{},
+		&domain.InvoiceToleranceRule{},
+		&domain.POChangeProposal{},
+		&domain.POChangeProposalLine{},
+		&domain.AdvanceShippingNotice{},
+		&domain.AdvanceShippingNoticeLine{},
 
 		&domain.Transaction{},
 
@@ -111,6 +126,20 @@ func AutoMigrate() {
 		&domain.CashDrawerSession{},
 		&domain.CashDrop{},
 		&domain.Promotion{},
+		&domain.ScaleBarcodePattern{},
+		&domain.SerialNumber{},
+		&domain.SerialNumberEvent{},
+		&domain.StockTakeSession{},
+		&domain.StockTakeLine{},
+		&domain.StockTakeCount{},
+		&domain.StockReservation{},
+		&domain.LandedCostCharge{},
+		&domain.LandedCostAllocation{},
+		&domain.InventorySnapshot{},
+		&domain.LedgerReconciliation{},
+		&domain.LedgerDiscrepancy{},
+		&domain.Bin{},
+		&domain.BinMove{},
 	)
 
 	if err != nil {
@@ -123,6 +152,7 @@ func AutoMigrate() {
 
 	// Fix schema first (drop legacy columns) before seeding
 	fixRolePermissionsSchema()
+	fixProductAlertSettingsSchema()
 	seedData()
 }
 
@@ -139,6 +169,18 @@ func fixRolePermissionsSchema() {
 	}
 }
 
+func fixProductAlertSettingsSchema() {
+	if DB == nil {
+		return
+	}
+	// Alert settings used to be unique per product. They are now unique per product and location,
+	// so the old single-column unique index must go or a second location cannot be configured.
+	query := `DROP INDEX IF EXISTS idx_product_alert_settings_product_id;`
+	if err := DB.Exec(query).Error; err != nil {
+		logrus.Warnf("Failed to drop legacy unique index on product_alert_settings: %v", err)
+	}
+}
+
 func seedData() {
 	permMap := seedPermissions()
 	seedRoles(permMap)
@@ -163,9 +205,25 @@ func seedPermissions() map[string]domain.Permission {
 		{Name: "suppliers.write", Group: "Inventory", Description: "Manage suppliers"},
 		// Barcode
 		{Name: "barcode.read", Group: "Inventory", Description: "Lookup barcodes"},
+		{Name: "barcode.manage", Group: "Inventory", Description: "Configure scale barcode patterns"},
+		// Stock Take
+		{Name: "stocktake.count", Group: "Inventory", Description: "Enter counts in stock-take sessions"},
+		{Name: "stocktake.manage", Group: "Inventory", Description: "Create, review and approve stock-take sessions"},
+		// Stock movement and integrity
+		{Name: "inventory.reserve", Group: "Inventory", Description: "Reserve and release stock for orders and held carts"},
+		{Name: "ledger.reconcile", Group: "Inventory", Description: "Run and review stock ledger reconciliations"},
+		{Name: "ledger.approve", Group: "Inventory", Description: "Approve correcting adjustments from ledger reconciliations"},
+		{Name: "transfers.approve", Group: "Inventory", Description: "Approve or cancel rebalancing transfer drafts"},
+		{Name: "expiry.manage", Group: "Inventory", Description: "Configure expiry markdown rules and run expiry checks"},
+		{Name: "writeoffs.approve", Group: "Inventory", Description: "Approve or reject stock write-offs"},
 		// Replenishment
 		{Name: "replenishment.read", Group: "Inventory", Description: "View forecasts/suggestions"},
 		{Name: "replenishment.write", Group: "Inventory", Description: "Generate forecasts and manage POs"},
+		{Name: "approvals.manage", Group: "Inventory", Description: "Configure purchase order approval rules"},
+		// Payables
+		{Name: "invoices.read", Group: "Financial", Description: "View supplier invoices and the AP aging report"},
+		{Name: "invoices.write", Group: "Financial", Description: "Record and match supplier invoices"},
+		{Name: "invoices.approve", Group: "Financial", Description: "Resolve invoice mismatches, approve invoices, record payments and set tolerance rules"},
 		// CRM
 		{Name: "customers.read", Group: "CRM", Description: "View customers"},
 		{Name: "customers.write", Group: "CRM", Description: "Manage customers"},
@@ -192,6 +250,8 @@ func seedPermissions() map[string]domain.Permission {
 		{Name: "users.manage", Group: "Access Control", Description: "Manage users"},
 		{Name: "roles.view", Group: "Access Control", Description: "View roles"},
 		{Name: "roles.manage", Group: "Access Control", Description: "Manage roles"},
+		// Supplier Portal
+		{Name: "portal.supplier", Group: "Supplier Portal", Description: "Confirm purchase orders, propose changes, submit shipping notices and view the scorecard as a supplier"},
 		// Alerts
 		{Name: "alerts.view", Group: "Inventory", Description: "View alerts"},
 		{Name: "alerts.manage", Group: "Inventory", Description: "Resolve alerts"},
@@ -225,6 +285,7 @@ func seedRoles(permMap map[string]domain.Permission) {
 		{Name: "Manager", Description: "Store management access", IsSystem: true},
 		{Name: "Staff", Description: "Standard employee access", IsSystem: true},
 		{Name: "Customer", Description: "Customer portal access", IsSystem: true},
+		{Name: "Supplier", Description: "Supplier portal access", IsSystem: true},
 	}
 
 	for _, r := range roles {
@@ -248,8 +309,13 @@ func seedRoles(permMap map[string]domain.Permission) {
 				permMap["categories.read"], permMap["categories.write"],
 				permMap["locations.read"], permMap["locations.write"],
 				permMap["suppliers.read"], permMap["suppliers.write"],
-				permMap["barcode.read"],
+				permMap["barcode.read"], permMap["barcode.manage"],
+				permMap["stocktake.count"], permMap["stocktake.manage"],
+				permMap["inventory.reserve"],
+				permMap["ledger.reconcile"], permMap["ledger.approve"],
+				permMap["transfers.approve"], permMap["expiry.manage"], permMap["writeoffs.approve"],
 				permMap["replenishment.read"], permMap["replenishment.write"],
+				permMap["invoices.read"], permMap["invoices.write"], permMap["invoices.approve"],
 				permMap["customers.read"], permMap["customers.write"],
 				permMap["loyalty.read"], permMap["loyalty.write"],
 				permMap["pos.access"],
@@ -271,6 +337,8 @@ func seedRoles(permMap map[string]domain.Permission) {
 				permMap["locations.read"],
 				permMap["suppliers.read"],
 				permMap["barcode.read"],
+				permMap["stocktake.count"],
+				permMap["inventory.reserve"],
 				permMap["customers.read"],
 				permMap["pos.access"],
 				permMap["orders.read"],
@@ -280,6 +348,8 @@ func seedRoles(permMap map[string]domain.Permission) {
 				permMap["inventory.view"], permMap["crm.view"], permMap["pos.view"],
 				permMap["notifications.read"], permMap["notifications.write"],
 			)
+		case "Supplier":
+			permsToAssign = append(permsToAssign, permMap["portal.supplier"])
 		}
 
 		if len(permsToAssign) > 0 {
@@ -293,6 +363,10 @@ func seedRoles(permMap map[string]domain.Permission) {
 func seedSettings() {
 	settings := []domain.SystemSetting{
 		{Key: "business_name", Value: "Quantify Business", Group: "General", Type: "string"},
+		{Key: "business_address", Value: "", Group: "General", Type: "string", Description: "Business address printed on purchase orders"},
+		{Key: "business_phone", Value: "", Group: "General", Type: "string", Description: "Business phone number printed on purchase orders"},
+		{Key: "business_email", Value: "", Group: "General", Type: "string", Description: "Business email address printed on purchase orders"},
+		{Key: "business_tax_id", Value: "", Group: "General", Type: "string", Description: "Tax or VAT registration number printed on purchase orders"},
 		{Key: "currency_symbol", Value: "$", Group: "General", Type: "string"},
 		{Key: "timezone", Value: "UTC", Group: "General", Type: "string"},
 		{Key: "return_window_days", Value: "30", Group: "Policy", Type: "number", Description: "Number of days allowing returns after purchase"},
@@ -305,6 +379,17 @@ func seedSettings() {
 		{Key: "loyalty_tier_platinum", Value: "10000", Group: "Loyalty", Type: "number", Description: "Points required for Platinum tier"},
 		// Tax Settings
 		{Key: "tax_rate_percentage", Value: "0", Group: "Financial", Type: "number", Description: "Default tax rate percentage"},
+		{Key: "invoice_payment_terms_days", Value: "30", Group: "Financial", Type: "number", Description: "Days after the invoice date a supplier invoice falls due when it has no due date"},
+		// Cycle Count Settings
+		{Key: "cycle_count_enabled", Value: "true", Group: "Inventory", Type: "boolean", Description: "Generate scheduled cycle-count sessions from the ABC plan"},
+		{Key: "cycle_count_interval_a_days", Value: "30", Group: "Inventory", Type: "number", Description: "Days between counts of A-class products"},
+		{Key: "cycle_count_interval_b_days", Value: "90", Group: "Inventory", Type: "number", Description: "Days between counts of B-class products"},
+		{Key: "cycle_count_interval_c_days", Value: "180", Group: "Inventory", Type: "number", Description: "Days between counts of C-class products"},
+		{Key: "cycle_count_max_items", Value: "50", Group: "Inventory", Type: "number", Description: "Maximum products per scheduled cycle-count run"},
+		{Key: "abc_class_a_threshold", Value: "80", Group: "Inventory", Type: "number", Description: "Cumulative consumption value percentage covered by A-class products"},
+		{Key: "abc_class_b_threshold", Value: "95", Group: "Inventory", Type: "number", Description: "Cumulative consumption value percentage covered by A- and B-class products"},
+		{Key: "po_consolidation_enabled", Value: "true", Group: "Inventory", Type: "boolean", Description: "Consolidate approved reorder suggestions into purchase orders daily"},
+		{Key: "reservation_ttl_minutes", Value: "30", Group: "Inventory", Type: "number", Description: "Minutes before a stock reservation lapses unless another expiry is given (0 = no expiry)"},
 	}
 
 	for _, s := range settings {
@@ -394,6 +479,40 @@ WHERE barcode_upc IS NOT NULL AND barcode_upc <> '';
 	}
 }
 
+// ensurePLUUniqueIndex keeps scale barcodes unambiguous: a PLU may belong to one live product only.
+// Products without a PLU are left out.
+func ensurePLUUniqueIndex() {
+	if DB == nil {
+		return
+	}
+
+	createPartialIndex := `
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_plu_code_unique
+ON products (plu_code)
+WHERE plu_code <> '' AND deleted_at IS NULL;
+`
+	if err := DB.Exec(createPartialIndex).Error; err != nil {
+		logrus.Errorf("Failed to ensure unique PLU index (resolve duplicate PLU codes first): %v", err)
+	}
+}
+
+// ensureDefaultReorderPolicyUniqueIndex allows a single default reorder policy. The unique index on
+// category_id treats NULLs as distinct, so it does not stop a second policy without a category.
+func ensureDefaultReorderPolicyUniqueIndex() {
+	if DB == nil {
+		return
+	}
+
+	createPartialIndex := `
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reorder_policies_default_unique
+ON reorder_policies ((category_id IS NULL))
+WHERE category_id IS NULL;
+`
+	if err := DB.Exec(createPartialIndex).Error; err != nil {
+		logrus.Errorf("Failed to ensure unique default reorder policy index (remove duplicate default policies first): %v", err)
+	}
+}
+
 // CloseDB closes the database connection.
 
 func CloseDB() {
@@ -15,6 +15,11 @@ type ForecastingRepository interface {
 	GetProductsBatch(offset, limit int) ([]domain.Product, error)
 	GetTopForecasts(limit int) ([]ForecastDashboardItem, error)
 	GetLowStockPredictions(limit int) ([]ForecastDashboardItem, error)
+	GetSalesBetween(productID uint, start, end time.Time) ([]domain.StockAdjustment, error)
+	GetSalesTotal(productID uint, start, end time.Time) (float64, error)
+	GetUnevaluatedForecasts(generatedBefore time.Time) ([]domain.DemandForecast, error)
+	SaveForecastEvaluation(forecast *domain.DemandForecast) error
+	GetEvaluatedForecasts(generatedSince time.Time) ([]domain.DemandForecast, error)
 }
 
 type forecastingRepository struct {
@@ -74,7 +79,7 @@ type ForecastDashboardItem struct {
 	ProductID       uint
 	ProductName     string
 	PredictedDemand int
-	CurrentStock    int
+	CurrentStock    float64
 }
 
 func (r *forecastingRepository) GetTopForecasts(limit int) ([]ForecastDashboardItem, error) {
@@ -114,3 +119,48 @@ func (r *forecastingRepository) GetLowStockPredictions(limit int) ([]ForecastDas
 
 	return items, err
 }
+
+func (r *forecastingRepository) GetSalesBetween(productID uint, start, end time.Time) ([]domain.StockAdjustment, error) {
+	var sales []domain.StockAdjustment
+	err := r.db.Where("product_id = ? AND type = ? AND reason_code = ?", productID, "STOCK_OUT", "SALE").
+		Where("adjusted_at >= ? AND adjusted_at < ?", start, end).
+		Order("adjusted_at ASC").
+		Find(&sales).Error
+	return sales, err
+}
+
+func (r *forecastingRepository) GetSalesTotal(productID uint, start, end time.Time) (float64, error) {
+	var total float64
+	err := r.db.Model(&domain.StockAdjustment{}).
+		Where("product_id = ? AND type = ? AND reason_code = ?", productID, "STOCK_OUT", "SALE").
+		Where("adjusted_at >= ? AND adjusted_at < ?", start, end).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// GetUnevaluatedForecasts returns forecasts not yet scored against actual sales, generated before the given time.
+func (r *forecastingRepository) GetUnevaluatedForecasts(generatedBefore time.Time) ([]domain.DemandForecast, error) {
+	var forecasts []domain.DemandForecast
+	err := r.db.Where("evaluated_at IS NULL AND generated_at < ?", generatedBefore).
+		Order("generated_at ASC").
+		Find(&forecasts).Error
+	return forecasts, err
+}
+
+func (r *forecastingRepository) SaveForecastEvaluation(forecast *domain.DemandForecast) error {
+	return r.db.Model(forecast).Updates(map[string]interface{}{
+		"actual_demand": forecast.ActualDemand,
+		"evaluated_at":  forecast.EvaluatedAt,
+	}).Error
+}
+
+// GetEvaluatedForecasts returns scored forecasts generated since the given time, with their products.
+func (r *forecastingRepository) GetEvaluatedForecasts(generatedSince time.Time) ([]domain.DemandForecast, error) {
+	var forecasts []domain.DemandForecast
+	err := r.db.Preload("Product").
+		Where("evaluated_at IS NOT NULL AND generated_at >= ?", generatedSince).
+		Order("generated_at ASC").
+		Find(&forecasts).Error
+	return forecasts, err
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"inventory/backend/internal/domain"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// landedCostOrder is a purchase order's lines as seen by landed cost allocation.
+type landedCostOrder struct {
+	itemsByID     map[uint]domain.PurchaseOrderItem
+	itemIDs       []uint
+	ordered       []domain.LandedCostBasis // One per line, as ordered
+	fullyReceived bool
+}
+
+func loadLandedCostOrder(tx *gorm.DB, purchaseOrderID uint) (*landedCostOrder, error) {
+	var poItems []domain.PurchaseOrderItem
+	if err := tx.Preload("Product").Where("purchase_order_id = ?", purchaseOrderID).Find(&poItems).Error; err != nil {
+		return nil, err
+	}
+	if len(poItems) == 0 {
+		return nil, fmt.Errorf("purchase order %d has no items", purchaseOrderID)
+	}
+	order := &landedCostOrder{
+		itemsByID:     make(map[uint]domain.PurchaseOrderItem, len(poItems)),
+		fullyReceived: true,
+	}
+	for _, item := range poItems {
+		order.itemsByID[item.ID] = item
+		order.itemIDs = append(order.itemIDs, item.ID)
+		order.ordered = append(order.ordered, domain.LandedCostBasis{
+			Quantity: item.OrderedQuantity,
+			Value:    item.OrderedQuantity * item.UnitPrice,
+			Weight:   item.OrderedQuantity * item.Product.Weight,
+		})
+		if item.ReceivedQuantity < item.OrderedQuantity {
+			order.fullyReceived = false
+		}
+	}
+	return order, nil
+}
+
+// unallocatedBatches locks the batches received against the order that do not carry a share of
+// the charge yet, restricted to batchIDs when given, and returns them with their allocation basis.
+func (o *landedCostOrder) unallocatedBatches(tx *gorm.DB, chargeID uint, batchIDs []uint) ([]domain.Batch, []domain.LandedCostBasis, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Product").
+		Where("purchase_order_item_id IN ?", o.itemIDs).
+		Where("id NOT IN (?)", tx.Model(&domain.LandedCostAllocation{}).Select("batch_id").Where("charge_id = ?", chargeID))
+	if len(batchIDs) > 0 {
+		query = query.Where("id IN ?", batchIDs)
+	}
+	var batches []domain.Batch
+	if err := query.Order("id").Find(&batches).Error; err != nil {
+		return nil, nil, err
+	}
+
+	bases := make([]domain.LandedCostBasis, len(batches))
+	for i, b := range batches {
+		qty := b.ReceivedQuantity
+		if qty <= 0 {
+			qty = b.Quantity
+		}
+		bases[i] = domain.LandedCostBasis{
+			Quantity: qty,
+			Value:    qty * o.itemsByID[*b.PurchaseOrderItemID].UnitPrice,
+			Weight:   qty * b.Product.Weight,
+		}
+	}
+	return batches, bases, nil
+}
+
+// unallocatedAmount is the part of the charge not yet spread over any batch.
+func unallocatedAmount(tx *gorm.DB, charge *domain.LandedCostCharge) (float64, error) {
+	var allocated float64
+	err := tx.Model(&domain.LandedCostAllocation{}).
+		Where("charge_id = ?", charge.ID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&allocated).Error
+	return domain.RoundMoney(charge.Amount - allocated), err
+}
+
+// AllocateLandedCostCharge spreads what is left of a pending charge over received batches of its
+// purchase order that do not carry a share of it yet, and marks the charge allocated. When batchIDs
+// is empty every such batch received against the order so far takes part.
+func AllocateLandedCostCharge(tx *gorm.DB, charge *domain.LandedCostCharge, batchIDs []uint) error {
+	if charge.Status != domain.LandedCostStatusPending {
+		return fmt.Errorf("landed cost charge %d is already allocated", charge.ID)
+	}
+	order, err := loadLandedCostOrder(tx, charge.PurchaseOrderID)
+	if err != nil {
+		return err
+	}
+	batches, bases, err := order.unallocatedBatches(tx, charge.ID, batchIDs)
+	if err != nil {
+		return err
+	}
+	if len(batches) == 0 {
+		return fmt.Errorf("no goods have been received against purchase order %d", charge.PurchaseOrderID)
+	}
+	remaining, err := unallocatedAmount(tx, charge)
+	if err != nil {
+		return err
+	}
+	shares, err := domain.AllocateLandedCost(remaining, charge.AllocationMethod, bases)
+	if err != nil {
+		return err
+	}
+	if err := applyLandedCostShares(tx, charge, batches, bases, shares); err != nil {
+		return err
+	}
+	return markLandedCostAllocated(tx, charge)
+}
+
+// AllocateReceivedLandedCost gives the batches received since the charge was last allocated their
+// share of it, measured against the order as placed, so each delivery carries its part of an
+// order-level charge as it arrives. Once every line has been received the last batches take the
+// remainder and the charge is marked allocated. Errors wrapping domain.ErrLandedCostBasis mean the
+// charge cannot be spread by its method; nothing has been written then.
+func AllocateReceivedLandedCost(tx *gorm.DB, charge *domain.LandedCostCharge) error {
+	if charge.Status != domain.LandedCostStatusPending {
+		return fmt.Errorf("landed cost charge %d is already allocated", charge.ID)
+	}
+	order, err := loadLandedCostOrder(tx, charge.PurchaseOrderID)
+	if err != nil {
+		return err
+	}
+	batches, bases, err := order.unallocatedBatches(tx, charge.ID, nil)
+	if err != nil {
+		return err
+	}
+	if len(batches) == 0 {
+		if order.fullyReceived {
+			return markLandedCostAllocated(tx, charge)
+		}
+		return nil
+	}
+	remaining, err := unallocatedAmount(tx, charge)
+	if err != nil {
+		return err
+	}
+
+	var shares []float64
+	if order.fullyReceived {
+		shares, err = domain.AllocateLandedCost(remaining, charge.AllocationMethod, bases)
+	} else {
+		shares, err = domain.AllocateLandedCostShare(charge.Amount, charge.AllocationMethod, order.ordered, bases)
+	}
+	if err != nil {
+		return err
+	}
+	// Rounding must never take a partial delivery past the charge
+	for i := range shares {
+		shares[i] = math.Min(shares[i], remaining)
+		remaining = domain.RoundMoney(remaining - shares[i])
+	}
+	if err := applyLandedCostShares(tx, charge, batches, bases, shares); err != nil {
+		return err
+	}
+	if order.fullyReceived {
+		return markLandedCostAllocated(tx, charge)
+	}
+	return nil
+}
+
+// AllocatePendingLandedCosts brings every pending charge on a purchase order up to date with what
+// has been received. It runs with each receipt. A charge that cannot be spread by its method is
+// left pending and logged rather than holding up the receipt.
+func AllocatePendingLandedCosts(tx *gorm.DB, purchaseOrderID uint) error {
+	var charges []domain.LandedCostCharge
+	if err := tx.Where("purchase_order_id = ? AND status = ?", purchaseOrderID, domain.LandedCostStatusPending).
+		Order("id").Find(&charges).Error; err != nil {
+		return err
+	}
+	for i := range charges {
+		err := AllocateReceivedLandedCost(tx, &charges[i])
+		if errors.Is(err, domain.ErrLandedCostBasis) {
+			logrus.Warnf("Landed cost charge %d on purchase order %d left pending: %v", charges[i].ID, purchaseOrderID, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyLandedCostShares records each batch's share of the charge and adds it to the batch's unit
+// cost. Products' moving-average cost, and the receipt in the stock ledger, are raised by the share
+// still on hand, so valuations replayed from the ledger include it.
+func applyLandedCostShares(tx *gorm.DB, charge *domain.LandedCostCharge, batches []domain.Batch, bases []domain.LandedCostBasis, shares []float64) error {
+	onHandUplift := make(map[uint]float64)
+	products := make(map[uint]domain.Product)
+	for i, b := range batches {
+		unitAmount := domain.UnitCostOf(shares[i], bases[i].Quantity)
+		allocation := domain.LandedCostAllocation{
+			ChargeID:            charge.ID,
+			PurchaseOrderItemID: *b.PurchaseOrderItemID,
+			ProductID:           b.ProductID,
+			BatchID:             b.ID,
+			Quantity:            bases[i].Quantity,
+			BaseValue:           domain.RoundMoney(bases[i].Value),
+			Amount:              shares[i],
+			UnitAmount:          unitAmount,
+		}
+		if err := tx.Create(&allocation).Error; err != nil {
+			return err
+		}
+		if unitAmount == 0 {
+			continue
+		}
+
+		if err := tx.Model(&domain.Batch{}).Where("id = ?", b.ID).Updates(map[string]interface{}{
+			"unit_cost":   roundUnitCost(b.UnitCost + unitAmount),
+			"landed_cost": roundUnitCost(b.LandedCost + unitAmount),
+		}).Error; err != nil {
+			return err
+		}
+		if b.Quantity > 0 {
+			onHandUplift[b.ProductID] += unitAmount * b.Quantity
+			products[b.ProductID] = b.Product
+			if err := tx.Model(&domain.StockAdjustment{}).
+				Where("batch_id = ? AND reason_code = ? AND quantity > 0", b.ID, "PURCHASE_RECEIPT").
+				Update("unit_cost", gorm.Expr("COALESCE(unit_cost, 0) + ? / quantity", unitAmount*b.Quantity)).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for productID, uplift := range onHandUplift {
+		product := products[productID]
+		onHand, err := GetLocationStock(tx, productID, 0)
+		if err != nil {
+			return err
+		}
+		if onHand <= 0 {
+			continue
+		}
+		averageCost := roundUnitCost(product.AverageCost + uplift/onHand)
+		if err := tx.Model(&domain.Product{}).Where("id = ?", productID).Update("average_cost", averageCost).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func markLandedCostAllocated(tx *gorm.DB, charge *domain.LandedCostCharge) error {
+	now := time.Now()
+	charge.Status = domain.LandedCostStatusAllocated
+	charge.AllocatedAt = &now
+	return tx.Model(charge).Updates(map[string]interface{}{"status": charge.Status, "allocated_at": now}).Error
+}
+
+func roundUnitCost(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LeadTimeSample is one product line of a purchase order that has been received in full.
+type LeadTimeSample struct {
+	PurchaseOrderID    uint
+	SupplierID         uint
+	ProductID          uint
+	OrderDate          time.Time
+	ActualDeliveryDate time.Time
+}
+
+// GetLeadTimeSamples returns the lines of purchase orders received since the given time, optionally
+// limited to one supplier (0 = all suppliers).
+func GetLeadTimeSamples(tx *gorm.DB, supplierID uint, since time.Time) ([]LeadTimeSample, error) {
+	query := tx.Table("purchase_order_items").
+		Select("purchase_orders.id AS purchase_order_id, purchase_orders.supplier_id, purchase_order_items.product_id, purchase_orders.order_date, purchase_orders.actual_delivery_date").
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id").
+		Where("purchase_orders.status = ? AND purchase_orders.actual_delivery_date IS NOT NULL AND purchase_orders.actual_delivery_date >= ?", "RECEIVED", since).
+		Where("purchase_orders.deleted_at IS NULL AND purchase_order_items.deleted_at IS NULL")
+	if supplierID != 0 {
+		query = query.Where("purchase_orders.supplier_id = ?", supplierID)
+	}
+	var samples []LeadTimeSample
+	err := query.Order("purchase_orders.id").Scan(&samples).Error
+	return samples, err
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"fmt"
+
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubmitPurchaseOrderForApproval starts a new approval round for a draft order: it creates a step for
+// every approval rule the order matches and moves the order to PENDING_APPROVAL. The order's items
+// must be loaded.
+func SubmitPurchaseOrderForApproval(tx *gorm.DB, po *domain.PurchaseOrder) ([]domain.POApproval, error) {
+	var rules []domain.POApprovalRule
+	if err := tx.Where("is_active = ?", true).Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch approval rules: %w", err)
+	}
+
+	productIDs := make([]uint, 0, len(po.PurchaseOrderItems))
+	for _, item := range po.PurchaseOrderItems {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	var categoryIDs []uint
+	if len(productIDs) > 0 {
+		if err := tx.Model(&domain.Product{}).Where("id IN ?", productIDs).Distinct().Pluck("category_id", &categoryIDs).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch product categories: %w", err)
+		}
+	}
+	categories := make(map[uint]bool, len(categoryIDs))
+	for _, id := range categoryIDs {
+		categories[id] = true
+	}
+
+	var lastRound int
+	if err := tx.Model(&domain.POApproval{}).Where("purchase_order_id = ?", po.ID).
+		Select("COALESCE(MAX(round), 0)").Scan(&lastRound).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch previous approval rounds: %w", err)
+	}
+
+	steps := domain.BuildApprovalChain(rules, po.OrderValue(), po.SupplierID, categories, lastRound+1)
+	for i := range steps {
+		steps[i].PurchaseOrderID = po.ID
+	}
+	if err := tx.Create(&steps).Error; err != nil {
+		return nil, fmt.Errorf("failed to create approval steps: %w", err)
+	}
+	if err := tx.Model(po).Update("Status", "PENDING_APPROVAL").Error; err != nil {
+		return nil, fmt.Errorf("failed to submit purchase order: %w", err)
+	}
+	return steps, nil
+}
+
+// CurrentApprovalStep locks and returns the order's first undecided step, or nil when none is left.
+func CurrentApprovalStep(tx *gorm.DB, poID uint) (*domain.POApproval, error) {
+	var steps []domain.POApproval
+	err := tx.Preload("ApproverRole").Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("purchase_order_id = ? AND status = ?", poID, domain.ApprovalStatusPending).
+		Order("round, level, id").Limit(1).Find(&steps).Error
+	if err != nil || len(steps) == 0 {
+		return nil, err
+	}
+	return &steps[0], nil
+}
+
+// HasDecidedApprovalRound reports whether the user already approved or rejected a step in the order's round.
+func HasDecidedApprovalRound(tx *gorm.DB, poID uint, round int, userID uint) (bool, error) {
+	var count int64
+	err := tx.Model(&domain.POApproval{}).
+		Where("purchase_order_id = ? AND round = ? AND decided_by = ?", poID, round, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CancelPendingApprovals closes the order's undecided steps, e.g. after a rejection or cancellation.
+func CancelPendingApprovals(tx *gorm.DB, poID uint) error {
+	return tx.Model(&domain.POApproval{}).
+		Where("purchase_order_id = ? AND status = ?", poID, domain.ApprovalStatusPending).
+		Update("status", domain.ApprovalStatusCancelled).Error
+}
+
+// GetActiveUserIDsByRole returns the active users holding the role.
+func GetActiveUserIDsByRole(tx *gorm.DB, roleID uint) ([]uint, error) {
+	var userIDs []uint
+	err := tx.Model(&domain.User{}).Where("role_id = ? AND is_active = ?", roleID, true).Pluck("id", &userIDs).Error
+	return userIDs, err
+}
+
+// GetActiveUserIDsByPermission returns the active users whose role grants the permission.
+func GetActiveUserIDsByPermission(tx *gorm.DB, permission string) ([]uint, error) {
+	var userIDs []uint
+	err := tx.Model(&domain.User{}).
+		Joins("JOIN role_permissions ON role_permissions.role_id = users.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("permissions.name = ? AND users.is_active = ?", permission, true).
+		Distinct().Pluck("users.id", &userIDs).Error
+	return userIDs, err
+}
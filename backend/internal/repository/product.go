@@ -75,6 +75,10 @@ func (r *ProductRepository) GetProductBySKU(sku string) (*domain.Product, error)
 	return &product, nil
 }
 
+func (r *ProductRepository) GetBySKUs(skus []string, products *[]domain.Product) error {
+	return r.db.Where("sku IN ?", skus).Find(products).Error
+}
+
 func (r *ProductRepository) GetProductByBarcode(barcode string) (*domain.Product, error) {
 	var product domain.Product
 	if err := r.db.Where("barcode_upc = ?", barcode).First(&product).Error; err != nil {
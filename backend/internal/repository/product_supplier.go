@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"errors"
+
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// GetProductSuppliers returns the supplier catalog entries for the given products, keyed by product.
+func GetProductSuppliers(tx *gorm.DB, productIDs []uint) (map[uint][]domain.ProductSupplier, error) {
+	var entries []domain.ProductSupplier
+	if err := tx.Where("product_id IN ?", productIDs).Order("id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	catalog := make(map[uint][]domain.ProductSupplier)
+	for _, e := range entries {
+		catalog[e.ProductID] = append(catalog[e.ProductID], e)
+	}
+	return catalog, nil
+}
+
+// GetProductSupplier returns a supplier's catalog entry for a product, or nil when the supplier does not list it.
+func GetProductSupplier(tx *gorm.DB, productID, supplierID uint) (*domain.ProductSupplier, error) {
+	var entry domain.ProductSupplier
+	if err := tx.Where("product_id = ? AND supplier_id = ?", productID, supplierID).First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// CatalogUnitPrice returns what the supplier charges for one unit of the product: its catalog cost,
+// or the product's purchase price when the supplier has no catalog entry for it.
+func CatalogUnitPrice(tx *gorm.DB, productID, supplierID uint) (float64, error) {
+	entry, err := GetProductSupplier(tx, productID, supplierID)
+	if err != nil {
+		return 0, err
+	}
+	if entry != nil {
+		return entry.UnitCost, nil
+	}
+	var product domain.Product
+	if err := tx.Select("id", "purchase_price").First(&product, productID).Error; err != nil {
+		return 0, err
+	}
+	return product.PurchasePrice, nil
+}
+
+// SaveProductSupplier creates or updates the catalog entry for entry's product and supplier. Marking
+// an entry preferred clears the flag on the product's other suppliers.
+func SaveProductSupplier(tx *gorm.DB, entry *domain.ProductSupplier) error {
+	existing, err := GetProductSupplier(tx, entry.ProductID, entry.SupplierID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		entry.ID = existing.ID
+		entry.CreatedAt = existing.CreatedAt
+	}
+	if err := tx.Save(entry).Error; err != nil {
+		return err
+	}
+	if entry.IsPreferred {
+		return tx.Model(&domain.ProductSupplier{}).
+			Where("product_id = ? AND id <> ?", entry.ProductID, entry.ID).
+			Update("is_preferred", false).Error
+	}
+	return nil
+}
@@ -2,22 +2,38 @@ package repository
 
 import (
 	"inventory/backend/internal/domain"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type ReplenishmentRepository interface {
-	GetProductStock(productID uint) (int, error)
+	GetProductStock(productID uint) (float64, error)
 	GetAllProductAlertSettings() ([]domain.ProductAlertSettings, error)
 	GetPendingSuggestion(productID uint) (*domain.ReorderSuggestion, error)
 	GetPendingPO(productID uint) (*domain.PurchaseOrder, error)
 	CreateReorderSuggestion(suggestion *domain.ReorderSuggestion) error
 	GetSupplierForProduct(productID uint) (*domain.Supplier, error)
-	GetStockLevels(productIDs []uint) (map[uint]int, error)
-	GetPendingSuggestionsMap(productIDs []uint) (map[uint]bool, error)
-	GetPendingPOsMap(productIDs []uint) (map[uint]bool, error)
+	GetStockLevels(productIDs []uint) (map[uint]float64, error)
+	GetLocationStockLevels(productIDs []uint) (map[StockKey]float64, error)
+	GetPendingSuggestionsMap(productIDs []uint) (map[StockKey]bool, error)
+	GetPendingPOsMap(productIDs []uint) (map[StockKey]bool, error)
+	GetProductSuppliersMap(productIDs []uint) (map[uint][]domain.ProductSupplier, error)
+	GetSalesSince(productIDs []uint, since time.Time) ([]domain.StockAdjustment, error)
+	GetReorderPolicies() ([]domain.ReorderPolicy, error)
+	GetLastSuggestionTimes(productIDs []uint) (map[StockKey]time.Time, error)
 }
 
+// StockKey identifies a product's stock at one location; LocationID 0 stands for all locations.
+type StockKey struct {
+	ProductID  uint
+	LocationID uint
+}
+
+// openPurchaseOrderStatuses are the purchase order statuses whose goods are still to come. Products on
+// such an order are not suggested again.
+var openPurchaseOrderStatuses = []string{"DRAFT", "PENDING_APPROVAL", "APPROVED", "SENT", "PARTIALLY_RECEIVED"}
+
 type replenishmentRepository struct {
 	db *gorm.DB
 }
@@ -26,13 +42,13 @@ func NewReplenishmentRepository(db *gorm.DB) ReplenishmentRepository {
 	return &replenishmentRepository{db: db}
 }
 
-func (r *replenishmentRepository) GetProductStock(productID uint) (int, error) {
-	var totalStock int64
+func (r *replenishmentRepository) GetProductStock(productID uint) (float64, error) {
+	var totalStock float64
 	err := r.db.Model(&domain.Batch{}).
 		Where("product_id = ?", productID).
 		Select("COALESCE(SUM(quantity), 0)").
 		Scan(&totalStock).Error
-	return int(totalStock), err
+	return totalStock, err
 }
 
 func (r *replenishmentRepository) GetAllProductAlertSettings() ([]domain.ProductAlertSettings, error) {
@@ -60,7 +76,7 @@ func (r *replenishmentRepository) GetPendingPO(productID uint) (*domain.Purchase
 	var po domain.PurchaseOrder
 	err := r.db.Joins("JOIN purchase_order_items ON purchase_order_items.purchase_order_id = purchase_orders.id").
 		Where("purchase_order_items.product_id = ?", productID).
-		Where("purchase_orders.status IN ?", []string{"DRAFT", "APPROVED", "SENT", "PARTIALLY_RECEIVED"}).
+		Where("purchase_orders.status IN ?", openPurchaseOrderStatuses).
 		First(&po).Error
 
 	if err != nil {
@@ -84,10 +100,10 @@ func (r *replenishmentRepository) GetSupplierForProduct(productID uint) (*domain
 	return &product.Supplier, nil
 }
 
-func (r *replenishmentRepository) GetStockLevels(productIDs []uint) (map[uint]int, error) {
+func (r *replenishmentRepository) GetStockLevels(productIDs []uint) (map[uint]float64, error) {
 	type Result struct {
 		ProductID uint
-		Total     int
+		Total     float64
 	}
 	var results []Result
 	err := r.db.Model(&domain.Batch{}).
@@ -100,45 +116,119 @@ func (r *replenishmentRepository) GetStockLevels(productIDs []uint) (map[uint]in
 		return nil, err
 	}
 
-	stockMap := make(map[uint]int)
+	stockMap := make(map[uint]float64)
 	for _, res := range results {
 		stockMap[res.ProductID] = res.Total
 	}
 	return stockMap, nil
 }
 
-func (r *replenishmentRepository) GetPendingSuggestionsMap(productIDs []uint) (map[uint]bool, error) {
+func (r *replenishmentRepository) GetLocationStockLevels(productIDs []uint) (map[StockKey]float64, error) {
+	type Result struct {
+		ProductID  uint
+		LocationID uint
+		Total      float64
+	}
+	var results []Result
+	err := r.db.Model(&domain.Batch{}).
+		Where("product_id IN ?", productIDs).
+		Select("product_id, location_id, COALESCE(SUM(quantity), 0) as total").
+		Group("product_id, location_id").
+		Scan(&results).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	stockMap := make(map[StockKey]float64)
+	for _, res := range results {
+		stockMap[StockKey{ProductID: res.ProductID, LocationID: res.LocationID}] = res.Total
+	}
+	return stockMap, nil
+}
+
+func (r *replenishmentRepository) GetPendingSuggestionsMap(productIDs []uint) (map[StockKey]bool, error) {
 	var suggestions []domain.ReorderSuggestion
 	err := r.db.Where("product_id IN ? AND status = ?", productIDs, "PENDING").
-		Select("product_id").
+		Select("product_id, location_id").
 		Find(&suggestions).Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	pendingMap := make(map[uint]bool)
+	pendingMap := make(map[StockKey]bool)
 	for _, s := range suggestions {
-		pendingMap[s.ProductID] = true
+		pendingMap[StockKey{ProductID: s.ProductID, LocationID: s.LocationID}] = true
 	}
 	return pendingMap, nil
 }
 
-func (r *replenishmentRepository) GetPendingPOsMap(productIDs []uint) (map[uint]bool, error) {
-	var poItems []domain.PurchaseOrderItem
-	err := r.db.Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id").
+// GetPendingPOsMap reports which products are on open purchase orders, by delivery location (an order's
+// own location, else the product's default) and for all locations together (LocationID 0).
+func (r *replenishmentRepository) GetPendingPOsMap(productIDs []uint) (map[StockKey]bool, error) {
+	type Result struct {
+		ProductID  uint
+		LocationID uint
+	}
+	var results []Result
+	err := r.db.Model(&domain.PurchaseOrderItem{}).
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id").
+		Joins("JOIN products ON products.id = purchase_order_items.product_id").
 		Where("purchase_order_items.product_id IN ?", productIDs).
-		Where("purchase_orders.status IN ?", []string{"DRAFT", "APPROVED", "SENT", "PARTIALLY_RECEIVED"}).
-		Select("purchase_order_items.product_id").
-		Find(&poItems).Error
+		Where("purchase_orders.status IN ?", openPurchaseOrderStatuses).
+		Where("purchase_orders.deleted_at IS NULL").
+		Select("purchase_order_items.product_id, CASE WHEN purchase_orders.location_id <> 0 THEN purchase_orders.location_id ELSE products.location_id END AS location_id").
+		Scan(&results).Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	pendingMap := make(map[uint]bool)
-	for _, item := range poItems {
-		pendingMap[item.ProductID] = true
+	pendingMap := make(map[StockKey]bool)
+	for _, res := range results {
+		pendingMap[StockKey{ProductID: res.ProductID}] = true
+		pendingMap[StockKey{ProductID: res.ProductID, LocationID: res.LocationID}] = true
 	}
 	return pendingMap, nil
 }
+
+func (r *replenishmentRepository) GetProductSuppliersMap(productIDs []uint) (map[uint][]domain.ProductSupplier, error) {
+	return GetProductSuppliers(r.db, productIDs)
+}
+
+func (r *replenishmentRepository) GetSalesSince(productIDs []uint, since time.Time) ([]domain.StockAdjustment, error) {
+	var sales []domain.StockAdjustment
+	err := r.db.Select("product_id, location_id, quantity, adjusted_at").
+		Where("product_id IN ? AND type = ? AND reason_code = ? AND adjusted_at >= ?", productIDs, "STOCK_OUT", "SALE", since).
+		Find(&sales).Error
+	return sales, err
+}
+
+func (r *replenishmentRepository) GetReorderPolicies() ([]domain.ReorderPolicy, error) {
+	var policies []domain.ReorderPolicy
+	err := r.db.Find(&policies).Error
+	return policies, err
+}
+
+// GetLastSuggestionTimes returns when each product was last suggested for reordering at each location,
+// whatever became of the suggestion.
+func (r *replenishmentRepository) GetLastSuggestionTimes(productIDs []uint) (map[StockKey]time.Time, error) {
+	var suggestions []domain.ReorderSuggestion
+	err := r.db.Where("product_id IN ?", productIDs).
+		Select("product_id, location_id, suggested_at").
+		Find(&suggestions).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	lastMap := make(map[StockKey]time.Time)
+	for _, s := range suggestions {
+		key := StockKey{ProductID: s.ProductID, LocationID: s.LocationID}
+		if s.SuggestedAt.After(lastMap[key]) {
+			lastMap[key] = s.SuggestedAt
+		}
+	}
+	return lastMap, nil
+}
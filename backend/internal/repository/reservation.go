@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type locationQuantity struct {
+	LocationID uint
+	Quantity   float64
+}
+
+// activeReservations scopes a query to reservations that still hold stock.
+func activeReservations(tx *gorm.DB, now time.Time) *gorm.DB {
+	return tx.Model(&domain.StockReservation{}).
+		Where("status = ? AND (expires_at IS NULL OR expires_at > ?)", domain.ReservationStatusActive, now)
+}
+
+func sumByLocation(query *gorm.DB, column string) ([]locationQuantity, error) {
+	var rows []locationQuantity
+	err := query.Select(column + " as location_id, COALESCE(SUM(quantity), 0) as quantity").
+		Group(column).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// GetStockPositions returns on-hand, reserved, in-transit, quarantined and available stock for a product per
+// location. A zero locationID covers every location holding or owing stock. Reservations listed in
+// excludeReservationIDs are treated as released, so a checkout can use the stock held for it.
+func GetStockPositions(tx *gorm.DB, product *domain.Product, locationID uint, excludeReservationIDs []uint) ([]domain.StockPosition, error) {
+	batches := tx.Model(&domain.Batch{}).Where("product_id = ?", product.ID)
+	quarantinedBatches := tx.Model(&domain.Batch{}).Where("product_id = ? AND quarantined_at IS NOT NULL", product.ID)
+	reservations := activeReservations(tx, time.Now()).Where("product_id = ?", product.ID)
+	// Transfers post their adjustments up front but leave the batches at the source until
+	// CompleteStockTransfer moves them on receipt, so pending transfers are counted against the
+	// source location and drop out once completed.
+	transfers := tx.Model(&domain.StockTransfer{}).Where("product_id = ? AND status = ?", product.ID, "PENDING")
+	if locationID != 0 {
+		batches = batches.Where("location_id = ?", locationID)
+		quarantinedBatches = quarantinedBatches.Where("location_id = ?", locationID)
+		reservations = reservations.Where("location_id = ?", locationID)
+		transfers = transfers.Where("source_location_id = ?", locationID)
+	}
+	if len(excludeReservationIDs) > 0 {
+		reservations = reservations.Where("id NOT IN ?", excludeReservationIDs)
+	}
+
+	onHand, err := sumByLocation(batches, "location_id")
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := sumByLocation(reservations, "location_id")
+	if err != nil {
+		return nil, err
+	}
+	inTransit, err := sumByLocation(transfers, "source_location_id")
+	if err != nil {
+		return nil, err
+	}
+	quarantined, err := sumByLocation(quarantinedBatches, "location_id")
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[uint]*domain.StockPosition)
+	position := func(locID uint) *domain.StockPosition {
+		if p, ok := positions[locID]; ok {
+			return p
+		}
+		p := &domain.StockPosition{ProductID: product.ID, LocationID: locID}
+		positions[locID] = p
+		return p
+	}
+	if locationID != 0 {
+		position(locationID)
+	}
+	for _, row := range onHand {
+		position(row.LocationID).OnHand += row.Quantity
+	}
+	for _, row := range reserved {
+		position(row.LocationID).Reserved += row.Quantity
+	}
+	for _, row := range inTransit {
+		position(row.LocationID).InTransit += row.Quantity
+	}
+	for _, row := range quarantined {
+		position(row.LocationID).Quarantined += row.Quantity
+	}
+
+	result := make([]domain.StockPosition, 0, len(positions))
+	for _, p := range positions {
+		p.CalculateAvailable(product.QuantityPrecision)
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LocationID < result[j].LocationID
+	})
+	return result, nil
+}
+
+// GetStockPosition returns the stock position of a product at a location, or summed across all
+// locations when locationID is 0.
+func GetStockPosition(tx *gorm.DB, product *domain.Product, locationID uint, excludeReservationIDs []uint) (domain.StockPosition, error) {
+	total := domain.StockPosition{ProductID: product.ID, LocationID: locationID}
+	positions, err := GetStockPositions(tx, product, locationID, excludeReservationIDs)
+	if err != nil {
+		return total, err
+	}
+	for _, p := range positions {
+		total.OnHand += p.OnHand
+		total.Reserved += p.Reserved
+		total.InTransit += p.InTransit
+		total.Quarantined += p.Quarantined
+	}
+	total.CalculateAvailable(product.QuantityPrecision)
+	return total, nil
+}
+
+// LockActiveReservations loads and locks the given reservations, skipping any that are no longer active.
+func LockActiveReservations(tx *gorm.DB, ids []uint) ([]domain.StockReservation, error) {
+	var reservations []domain.StockReservation
+	if len(ids) == 0 {
+		return reservations, nil
+	}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ? AND status = ? AND (expires_at IS NULL OR expires_at > ?)", ids, domain.ReservationStatusActive, time.Now()).
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// CloseReservations moves reservations to a final status (CONSUMED or RELEASED).
+func CloseReservations(tx *gorm.DB, ids []uint, status string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Model(&domain.StockReservation{}).
+		Where("id IN ? AND status = ?", ids, domain.ReservationStatusActive).
+		Updates(map[string]interface{}{"status": status, "released_at": time.Now()}).Error
+}
+
+// ExpireStockReservations marks lapsed reservations as EXPIRED. Availability already ignores them;
+// this keeps the status column accurate for reporting.
+func ExpireStockReservations(db *gorm.DB) (int64, error) {
+	result := db.Model(&domain.StockReservation{}).
+		Where("status = ? AND expires_at IS NOT NULL AND expires_at <= ?", domain.ReservationStatusActive, time.Now()).
+		Updates(map[string]interface{}{"status": domain.ReservationStatusExpired, "released_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RegisterSerialNumbers creates IN_STOCK records for serialized units received into a batch and
+// records the given event for each. Serials that were previously removed are brought back into stock.
+func RegisterSerialNumbers(tx *gorm.DB, productID uint, serials []string, batchID uint, locationID uint, event domain.SerialNumberEvent) error {
+	var existing []domain.SerialNumber
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND serial_number IN ?", productID, serials).
+		Find(&existing).Error; err != nil {
+		return err
+	}
+	existingMap := make(map[string]domain.SerialNumber, len(existing))
+	for _, s := range existing {
+		if s.Status != domain.SerialStatusRemoved {
+			return fmt.Errorf("serial number %s is already registered (%s)", s.SerialNumber, s.Status)
+		}
+		existingMap[s.SerialNumber] = s
+	}
+
+	for _, serial := range serials {
+		unit, ok := existingMap[serial]
+		if !ok {
+			unit = domain.SerialNumber{ProductID: productID, SerialNumber: serial}
+		}
+		unit.BatchID = &batchID
+		unit.LocationID = locationID
+		unit.Status = domain.SerialStatusInStock
+		unit.OrderItemID = nil
+		unit.SoldAt = nil
+		unit.WarrantyExpiresAt = nil
+		if err := tx.Save(&unit).Error; err != nil {
+			return err
+		}
+		if err := recordSerialEvent(tx, unit.ID, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LockSerialNumbers fetches the product's serials with a row lock and verifies that every one
+// exists and is in the expected status.
+func LockSerialNumbers(tx *gorm.DB, productID uint, serials []string, status string) ([]domain.SerialNumber, error) {
+	var units []domain.SerialNumber
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND serial_number IN ?", productID, serials).
+		Find(&units).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]domain.SerialNumber, len(units))
+	for _, u := range units {
+		found[u.SerialNumber] = u
+	}
+	for _, serial := range serials {
+		u, ok := found[serial]
+		if !ok {
+			return nil, fmt.Errorf("serial number %s not found for product %d", serial, productID)
+		}
+		if u.Status != status {
+			return nil, fmt.Errorf("serial number %s is %s, expected %s", serial, u.Status, status)
+		}
+	}
+	return units, nil
+}
+
+// TransitionSerialNumbers applies the updates to the given units and records the event for each.
+func TransitionSerialNumbers(tx *gorm.DB, units []domain.SerialNumber, updates map[string]interface{}, event domain.SerialNumberEvent) error {
+	for i := range units {
+		if err := tx.Model(&units[i]).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := recordSerialEvent(tx, units[i].ID, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func recordSerialEvent(tx *gorm.DB, serialID uint, event domain.SerialNumberEvent) error {
+	event.ID = 0
+	event.SerialNumberID = serialID
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	return tx.Create(&event).Error
+}
+
+// GetSerialNumberHistory returns every unit matching the serial (across products) with its events.
+func GetSerialNumberHistory(db *gorm.DB, serial string) ([]domain.SerialNumber, error) {
+	var units []domain.SerialNumber
+	err := db.Preload("Product").
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("occurred_at asc, id asc") }).
+		Where("serial_number = ?", serial).
+		Find(&units).Error
+	return units, err
+}
+
+// ListProductSerialNumbers returns a product's units, optionally filtered by status.
+func ListProductSerialNumbers(db *gorm.DB, productID uint, status string) ([]domain.SerialNumber, error) {
+	var units []domain.SerialNumber
+	query := db.Where("product_id = ?", productID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("serial_number asc").Find(&units).Error
+	return units, err
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// DaysOfCoverItem is a product's current stock measured against its recent sales rate.
+type DaysOfCoverItem struct {
+	ProductID         uint     `json:"productId"`
+	SKU               string   `json:"sku"`
+	ProductName       string   `json:"productName"`
+	OnHand            float64  `json:"onHand"`
+	AverageDailySales float64  `json:"averageDailySales"`
+	DaysOfCover       *float64 `json:"daysOfCover"` // Nil when the product has not sold in the window
+}
+
+type SnapshotRepository interface {
+	CaptureDailySnapshot(day time.Time) (int, error)
+	GetStockHistory(productID uint, locationID *uint, from, to time.Time) ([]domain.StockLevelPoint, error)
+	GetAverageDailySales(productID uint, locationID *uint, from, to time.Time) (float64, error)
+	GetDaysOfCover(locationID *uint, window int) ([]DaysOfCoverItem, error)
+}
+
+type snapshotRepository struct {
+	db *gorm.DB
+}
+
+func NewSnapshotRepository(db *gorm.DB) SnapshotRepository {
+	return &snapshotRepository{db: db}
+}
+
+type snapshotKey struct {
+	productID  uint
+	locationID uint
+}
+
+// CaptureDailySnapshot records closing stock and the day's movements for every product and
+// location that holds stock or moved on the given day. Running it again for the same day
+// replaces that day's rows.
+func (r *snapshotRepository) CaptureDailySnapshot(day time.Time) (int, error) {
+	snapshotDate := domain.SnapshotDay(day)
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	var balances []struct {
+		ProductID  uint
+		LocationID uint
+		Quantity   float64
+		Value      float64
+	}
+	// AVERAGE products are valued at the moving average, others at each layer's cost
+	if err := r.db.Table("batches b").
+		Select(`b.product_id, b.location_id, SUM(b.quantity) AS quantity,
+			SUM(b.quantity * CASE
+				WHEN p.costing_method = ? AND p.average_cost > 0 THEN p.average_cost
+				WHEN b.unit_cost > 0 THEN b.unit_cost
+				ELSE p.purchase_price END) AS value`, domain.CostingMethodAverage).
+		Joins("JOIN products p ON p.id = b.product_id").
+		Where("b.deleted_at IS NULL AND p.deleted_at IS NULL").
+		Group("b.product_id, b.location_id").
+		Having("SUM(b.quantity) <> 0").
+		Scan(&balances).Error; err != nil {
+		return 0, err
+	}
+
+	var movements []struct {
+		ProductID    uint
+		LocationID   uint
+		QuantityIn   float64
+		QuantityOut  float64
+		QuantitySold float64
+	}
+	if err := r.db.Model(&domain.StockAdjustment{}).
+		Select(`product_id, location_id,
+			SUM(CASE WHEN type = 'STOCK_IN' THEN quantity ELSE 0 END) AS quantity_in,
+			SUM(CASE WHEN type = 'STOCK_OUT' THEN quantity ELSE 0 END) AS quantity_out,
+			SUM(CASE WHEN type = 'STOCK_OUT' AND reason_code = 'SALE' THEN quantity ELSE 0 END) AS quantity_sold`).
+		Where("adjusted_at >= ? AND adjusted_at < ?", dayStart, dayEnd).
+		Group("product_id, location_id").
+		Scan(&movements).Error; err != nil {
+		return 0, err
+	}
+
+	snapshots := make(map[snapshotKey]*domain.InventorySnapshot)
+	var order []snapshotKey
+	entry := func(productID, locationID uint) *domain.InventorySnapshot {
+		key := snapshotKey{productID, locationID}
+		if s, ok := snapshots[key]; ok {
+			return s
+		}
+		s := &domain.InventorySnapshot{SnapshotDate: snapshotDate, ProductID: productID, LocationID: locationID}
+		snapshots[key] = s
+		order = append(order, key)
+		return s
+	}
+	for _, b := range balances {
+		s := entry(b.ProductID, b.LocationID)
+		s.Quantity = b.Quantity
+		s.Value = domain.RoundMoney(b.Value)
+	}
+	for _, m := range movements {
+		s := entry(m.ProductID, m.LocationID)
+		s.QuantityIn = m.QuantityIn
+		s.QuantityOut = m.QuantityOut
+		s.QuantitySold = m.QuantitySold
+	}
+
+	rows := make([]domain.InventorySnapshot, 0, len(order))
+	for _, key := range order {
+		rows = append(rows, *snapshots[key])
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("snapshot_date = ?", snapshotDate).Delete(&domain.InventorySnapshot{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, 500).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// GetStockHistory returns one point per snapshot day in the range, summed across locations unless
+// a location is given. Days on which a snapshot ran but the product held no stock report zero.
+func (r *snapshotRepository) GetStockHistory(productID uint, locationID *uint, from, to time.Time) ([]domain.StockLevelPoint, error) {
+	from, to = domain.SnapshotDay(from), domain.SnapshotDay(to)
+
+	query := r.db.Model(&domain.InventorySnapshot{}).
+		Select(`snapshot_date AS date, SUM(quantity) AS quantity, SUM(value) AS value,
+			SUM(quantity_in) AS quantity_in, SUM(quantity_out) AS quantity_out, SUM(quantity_sold) AS quantity_sold`).
+		Where("product_id = ? AND snapshot_date BETWEEN ? AND ?", productID, from, to)
+	if locationID != nil {
+		query = query.Where("location_id = ?", *locationID)
+	}
+	var points []domain.StockLevelPoint
+	if err := query.Group("snapshot_date").Order("snapshot_date").Scan(&points).Error; err != nil {
+		return nil, err
+	}
+
+	var days []time.Time
+	if err := r.db.Model(&domain.InventorySnapshot{}).
+		Where("snapshot_date BETWEEN ? AND ?", from, to).
+		Distinct("snapshot_date").Order("snapshot_date").
+		Pluck("snapshot_date", &days).Error; err != nil {
+		return nil, err
+	}
+
+	byDay := make(map[time.Time]domain.StockLevelPoint, len(points))
+	for _, p := range points {
+		p.Value = domain.RoundMoney(p.Value)
+		byDay[domain.SnapshotDay(p.Date)] = p
+	}
+	history := make([]domain.StockLevelPoint, 0, len(days))
+	for _, d := range days {
+		day := domain.SnapshotDay(d)
+		if p, ok := byDay[day]; ok {
+			p.Date = day
+			history = append(history, p)
+			continue
+		}
+		history = append(history, domain.StockLevelPoint{Date: day})
+	}
+	return history, nil
+}
+
+// snapshotDayCount is the number of days in the range a snapshot was captured for. Days the job
+// did not run have no sales recorded, so rates are taken over the captured days only.
+func (r *snapshotRepository) snapshotDayCount(from, to time.Time) (int64, error) {
+	var days int64
+	err := r.db.Model(&domain.InventorySnapshot{}).
+		Where("snapshot_date BETWEEN ? AND ?", from, to).
+		Distinct("snapshot_date").Count(&days).Error
+	return days, err
+}
+
+// GetAverageDailySales averages snapshot sales over the days in the range that were captured.
+func (r *snapshotRepository) GetAverageDailySales(productID uint, locationID *uint, from, to time.Time) (float64, error) {
+	from, to = domain.SnapshotDay(from), domain.SnapshotDay(to)
+	days, err := r.snapshotDayCount(from, to)
+	if err != nil || days == 0 {
+		return 0, err
+	}
+
+	var sold float64
+	query := r.db.Model(&domain.InventorySnapshot{}).
+		Select("COALESCE(SUM(quantity_sold), 0)").
+		Where("product_id = ? AND snapshot_date BETWEEN ? AND ?", productID, from, to)
+	if locationID != nil {
+		query = query.Where("location_id = ?", *locationID)
+	}
+	if err := query.Scan(&sold).Error; err != nil {
+		return 0, err
+	}
+	return sold / float64(days), nil
+}
+
+// GetDaysOfCover measures each product's stock in the latest snapshot against its average daily
+// sales over the captured days among the preceding window days, lowest cover first.
+func (r *snapshotRepository) GetDaysOfCover(locationID *uint, window int) ([]DaysOfCoverItem, error) {
+	var latestDays []time.Time
+	if err := r.db.Model(&domain.InventorySnapshot{}).Order("snapshot_date desc").
+		Limit(1).Pluck("snapshot_date", &latestDays).Error; err != nil {
+		return nil, err
+	}
+	if len(latestDays) == 0 {
+		return []DaysOfCoverItem{}, nil
+	}
+	latest := domain.SnapshotDay(latestDays[0])
+	windowStart := latest.AddDate(0, 0, -(window - 1))
+	days, err := r.snapshotDayCount(windowStart, latest)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.db.Table("inventory_snapshots s").
+		Select(`s.product_id, p.sku, p.name AS product_name,
+			SUM(CASE WHEN s.snapshot_date = ? THEN s.quantity ELSE 0 END) AS on_hand,
+			SUM(s.quantity_sold) AS sold`, latest).
+		Joins("JOIN products p ON p.id = s.product_id").
+		Where("s.deleted_at IS NULL AND p.deleted_at IS NULL").
+		Where("s.snapshot_date BETWEEN ? AND ?", windowStart, latest)
+	if locationID != nil {
+		query = query.Where("s.location_id = ?", *locationID)
+	}
+
+	var rows []struct {
+		ProductID   uint
+		SKU         string
+		ProductName string
+		OnHand      float64
+		Sold        float64
+	}
+	if err := query.Group("s.product_id, p.sku, p.name").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]DaysOfCoverItem, 0, len(rows))
+	for _, row := range rows {
+		averageDailySales := row.Sold / float64(days)
+		items = append(items, DaysOfCoverItem{
+			ProductID:         row.ProductID,
+			SKU:               row.SKU,
+			ProductName:       row.ProductName,
+			OnHand:            row.OnHand,
+			AverageDailySales: averageDailySales,
+			DaysOfCover:       domain.DaysOfCover(row.OnHand, averageDailySales),
+		})
+	}
+	// Lowest cover first; products without sales last
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].DaysOfCover, items[j].DaysOfCover
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return *a < *b
+	})
+	return items, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"time"
+
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetLocationStock returns the on-hand quantity of a product at a location. A zero locationID
+// sums across all locations.
+func GetLocationStock(tx *gorm.DB, productID uint, locationID uint) (float64, error) {
+	var qty float64
+	query := tx.Model(&domain.Batch{}).Where("product_id = ?", productID)
+	if locationID != 0 {
+		query = query.Where("location_id = ?", locationID)
+	}
+	err := query.Select("COALESCE(SUM(quantity), 0)").Scan(&qty).Error
+	return qty, err
+}
+
+// AddStock receives quantity into a new batch (cost layer) at the location, updating the
+// product's moving-average cost.
+func AddStock(tx *gorm.DB, product *domain.Product, locationID uint, quantity, unitCost float64, batchNumber string, expiry *time.Time) (*domain.Batch, error) {
+	if err := RecordReceiptCost(tx, product, quantity, unitCost); err != nil {
+		return nil, err
+	}
+	batch := &domain.Batch{
+		ProductID:   product.ID,
+		LocationID:  locationID,
+		BatchNumber: batchNumber,
+		Quantity:    quantity,
+		ExpiryDate:  expiry,
+		UnitCost:    unitCost,
+	}
+	if err := tx.Create(batch).Error; err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// SplitBatch takes quantity out of a locked batch into a new batch at the location with the same
+// number, expiry, cost and purchase order line. The new batch carries its proportional share of the
+// received quantity, so landed cost allocated across both batches later counts each unit once.
+func SplitBatch(tx *gorm.DB, product *domain.Product, batch *domain.Batch, quantity float64, locationID uint) (*domain.Batch, error) {
+	previous := batch.Quantity
+	receivedShare := 0.0
+	if previous > 0 {
+		receivedShare = domain.RoundQuantity(batch.ReceivedQuantity*quantity/previous, domain.MaxQuantityPrecision)
+	}
+	batch.Quantity = product.RoundQuantity(batch.Quantity - quantity)
+	if err := SaveBatchQuantity(tx, batch, previous); err != nil {
+		return nil, err
+	}
+	if receivedShare > 0 {
+		batch.ReceivedQuantity = domain.RoundQuantity(batch.ReceivedQuantity-receivedShare, domain.MaxQuantityPrecision)
+		if err := tx.Model(batch).Update("received_quantity", batch.ReceivedQuantity).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	split := &domain.Batch{
+		ProductID:           batch.ProductID,
+		LocationID:          locationID,
+		BatchNumber:         batch.BatchNumber,
+		Quantity:            quantity,
+		ExpiryDate:          batch.ExpiryDate,
+		UnitCost:            batch.UnitCost,
+		PurchaseOrderItemID: batch.PurchaseOrderItemID,
+		ReceivedQuantity:    receivedShare,
+		LandedCost:          batch.LandedCost,
+	}
+	if err := tx.Create(split).Error; err != nil {
+		return nil, err
+	}
+	return split, nil
+}
+
+// RemoveStockFEFO deducts up to quantity from the product's batches at the location, earliest
+// expiry first, locking the batches it reads. It returns the quantity actually removed, which is
+// less than requested when the location does not hold enough stock, and its cost.
+func RemoveStockFEFO(tx *gorm.DB, product *domain.Product, locationID uint, quantity float64) (float64, float64, error) {
+	batches, err := LockBatches(tx, []uint{product.ID}, locationID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	remaining := quantity
+	var draws []domain.CostLayerDraw
+	for i := range batches {
+		if remaining <= 0 {
+			break
+		}
+		b := &batches[i]
+		take := remaining
+		if b.Quantity < take {
+			take = b.Quantity
+		}
+		previous := b.Quantity
+		b.Quantity = product.RoundQuantity(b.Quantity - take)
+		remaining = product.RoundQuantity(remaining - take)
+		if err := SaveBatchQuantity(tx, b, previous); err != nil {
+			return 0, 0, err
+		}
+		draws = append(draws, domain.CostLayerDraw{BatchID: b.ID, Quantity: take, UnitCost: b.LayerCost(product)})
+	}
+	return product.RoundQuantity(quantity - remaining), product.IssueCost(draws), nil
+}
+
+// GetLedgerBalance replays the stock adjustments of a product at a location and returns the net
+// quantity the ledger says should be on hand.
+func GetLedgerBalance(tx *gorm.DB, productID uint, locationID uint) (float64, error) {
+	var qty float64
+	err := tx.Model(&domain.StockAdjustment{}).
+		Where("product_id = ? AND location_id = ?", productID, locationID).
+		Select("COALESCE(SUM(CASE WHEN type = 'STOCK_IN' THEN quantity ELSE -quantity END), 0)").
+		Scan(&qty).Error
+	return qty, err
+}
+
+// NormalizeNegativeBatches zeroes the product's negative batches at the location and takes the
+// shortfall from its positive batches, earliest expiry first, so the location total is unchanged.
+// When the location's batches net to less than zero the shortfall cannot be absorbed without
+// creating stock, so the batches are left as they are and the uncovered quantity is returned.
+func NormalizeNegativeBatches(tx *gorm.DB, product *domain.Product, locationID uint) (float64, error) {
+	var batches []domain.Batch
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND location_id = ? AND quantity <> 0", product.ID, locationID).
+		Order("expiry_date asc, created_at asc").
+		Find(&batches).Error; err != nil {
+		return 0, err
+	}
+
+	var total float64
+	for _, b := range batches {
+		total += b.Quantity
+	}
+	if uncovered := product.RoundQuantity(-total); uncovered > 0 {
+		return uncovered, nil
+	}
+
+	var shortfall float64
+	for i := range batches {
+		b := &batches[i]
+		if b.Quantity >= 0 {
+			continue
+		}
+		shortfall -= b.Quantity
+		b.Quantity = 0
+		if err := tx.Model(b).Update("quantity", 0).Error; err != nil {
+			return 0, err
+		}
+	}
+	for i := range batches {
+		if shortfall <= 0 {
+			break
+		}
+		b := &batches[i]
+		if b.Quantity <= 0 {
+			continue
+		}
+		take := shortfall
+		if b.Quantity < take {
+			take = b.Quantity
+		}
+		b.Quantity = product.RoundQuantity(b.Quantity - take)
+		shortfall = product.RoundQuantity(shortfall - take)
+		if err := tx.Model(b).Update("quantity", b.Quantity).Error; err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStockConflict is returned when a batch no longer holds the quantity it was read with, i.e.
+// another transaction changed it in between. Callers should roll back and let the client retry.
+var ErrStockConflict = errors.New("stock changed concurrently, please retry")
+
+// LockProducts loads and locks the products for the rest of the transaction. Rows are locked in
+// ID order so that two transactions touching the same products cannot deadlock.
+func LockProducts(tx *gorm.DB, ids []uint) ([]domain.Product, error) {
+	var products []domain.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", ids).
+		Order("id asc").
+		Find(&products).Error
+	return products, err
+}
+
+// LockBatches loads and locks the in-stock batches of the products, earliest expiry first. A zero
+// locationID spans all locations. Quarantined and expired batches are left out; they only leave
+// stock through an approved write-off, even before the expiry job has quarantined them.
+func LockBatches(tx *gorm.DB, productIDs []uint, locationID uint) ([]domain.Batch, error) {
+	var batches []domain.Batch
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id IN ? AND quantity > 0 AND quarantined_at IS NULL", productIDs).
+		Where("expiry_date IS NULL OR expiry_date > ?", time.Now())
+	if locationID != 0 {
+		query = query.Where("location_id = ?", locationID)
+	}
+	err := query.Order("expiry_date asc, created_at asc, id asc").Find(&batches).Error
+	return batches, err
+}
+
+// SaveBatchQuantity writes the batch's new quantity, but only while the stored quantity is still
+// the previous one the caller read. Under row locks this always holds; it also keeps stock from
+// being lost or oversold on databases that ignore locking clauses.
+func SaveBatchQuantity(tx *gorm.DB, batch *domain.Batch, previous float64) error {
+	if batch.Quantity < 0 {
+		return fmt.Errorf("batch %s would go negative (%g)", batch.BatchNumber, batch.Quantity)
+	}
+	result := tx.Model(&domain.Batch{}).
+		Where("id = ? AND quantity = ?", batch.ID, previous).
+		Update("quantity", batch.Quantity)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: batch %s", ErrStockConflict, batch.BatchNumber)
+	}
+	return nil
+}
+
+// SaveBatchQuantities writes every batch whose quantity changed since the snapshot taken with
+// SnapshotBatchQuantities.
+func SaveBatchQuantities(tx *gorm.DB, batches []*domain.Batch, before map[uint]float64) error {
+	for _, b := range batches {
+		previous, ok := before[b.ID]
+		if !ok || previous == b.Quantity {
+			continue
+		}
+		if err := SaveBatchQuantity(tx, b, previous); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"inventory/backend/internal/domain"
+)
+
+var (
+	// ErrInsufficientTransferStock is returned when the source location cannot cover a transfer.
+	ErrInsufficientTransferStock = errors.New("insufficient available stock at source location")
+	// ErrTransferNotPending is returned when completing a transfer that is not in transit.
+	ErrTransferNotPending = errors.New("only pending transfers can be completed")
+	// ErrTransferSerials is returned when the serial numbers given for a transfer do not match it.
+	ErrTransferSerials = errors.New("serial numbers do not match the transfer")
+)
+
+// PostStockTransfer commits a new or draft transfer: it checks the source location's available
+// stock under a product lock, marks the transfer PENDING and posts the out and in adjustments.
+func PostStockTransfer(tx *gorm.DB, product *domain.Product, transfer *domain.StockTransfer, userID uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(product, transfer.ProductID).Error; err != nil {
+		return err
+	}
+
+	// Only stock that is not already reserved or in transit can be sent
+	position, err := GetStockPosition(tx, product, transfer.SourceLocationID, nil)
+	if err != nil {
+		return err
+	}
+	if position.Available < transfer.Quantity {
+		return fmt.Errorf("%w (Available: %g %s, Requested: %g %s)", ErrInsufficientTransferStock,
+			position.Available, product.UnitOfMeasure, transfer.Quantity, product.UnitOfMeasure)
+	}
+
+	transfer.Status = "PENDING"
+	if transfer.ID == 0 {
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+	} else if err := tx.Save(transfer).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	adjustments := []domain.StockAdjustment{
+		{
+			ProductID:  transfer.ProductID,
+			LocationID: transfer.SourceLocationID,
+			Type:       "STOCK_OUT",
+			Quantity:   transfer.Quantity,
+			ReasonCode: "TRANSFER_OUT",
+			Notes:      "Stock transfer to location " + strconv.FormatUint(uint64(transfer.DestLocationID), 10),
+			AdjustedBy: userID,
+			AdjustedAt: now,
+		},
+		{
+			ProductID:  transfer.ProductID,
+			LocationID: transfer.DestLocationID,
+			Type:       "STOCK_IN",
+			Quantity:   transfer.Quantity,
+			ReasonCode: "TRANSFER_IN",
+			Notes:      "Stock transfer from location " + strconv.FormatUint(uint64(transfer.SourceLocationID), 10),
+			AdjustedBy: userID,
+			AdjustedAt: now,
+		},
+	}
+	return tx.Create(&adjustments).Error
+}
+
+// GetPendingTransferQuantities returns, per product and location, the quantity of pending transfers
+// whose adjustments are posted but whose batches have not moved yet: positive at the source, which
+// still holds the stock, and negative at the destination, which has not received it. A zero
+// productID or locationID spans all of them.
+func GetPendingTransferQuantities(tx *gorm.DB, productID uint, locationID uint) (map[StockKey]float64, error) {
+	var transfers []domain.StockTransfer
+	query := tx.Select("product_id, source_location_id, dest_location_id, quantity").Where("status = ?", "PENDING")
+	if productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if locationID != 0 {
+		query = query.Where("source_location_id = ? OR dest_location_id = ?", locationID, locationID)
+	}
+	if err := query.Find(&transfers).Error; err != nil {
+		return nil, err
+	}
+	pending := make(map[StockKey]float64)
+	for _, t := range transfers {
+		pending[StockKey{ProductID: t.ProductID, LocationID: t.SourceLocationID}] += t.Quantity
+		pending[StockKey{ProductID: t.ProductID, LocationID: t.DestLocationID}] -= t.Quantity
+	}
+	return pending, nil
+}
+
+// CompleteStockTransfer receives a pending transfer at its destination. The quantity leaves the
+// source location's batches, the transfer's own batch first and then earliest expiry, and arrives in
+// matching batches at the destination: a batch sent whole is relocated, part of one is split off
+// with SplitBatch. The adjustments were posted when the transfer was sent, so none are added here.
+// Serialized products name the units that arrived; they are taken from the batches they belong to.
+func CompleteStockTransfer(tx *gorm.DB, transferID uint, serials []string, userID uint) (*domain.StockTransfer, error) {
+	var transfer domain.StockTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, transferID).Error; err != nil {
+		return nil, err
+	}
+	if transfer.Status != "PENDING" {
+		return nil, fmt.Errorf("%w (transfer %d is %s)", ErrTransferNotPending, transfer.ID, transfer.Status)
+	}
+	products, err := LockProducts(tx, []uint{transfer.ProductID})
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	product := &products[0]
+
+	batches, err := LockBatches(tx, []uint{product.ID}, transfer.SourceLocationID)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.BatchID != nil {
+		for i := range batches {
+			if batches[i].ID == *transfer.BatchID {
+				chosen := batches[i]
+				copy(batches[1:i+1], batches[:i])
+				batches[0] = chosen
+				break
+			}
+		}
+	}
+
+	takes, unitsByBatch, err := transferDraws(tx, product, &transfer, batches, serials)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range batches {
+		b := &batches[i]
+		take, ok := takes[b.ID]
+		if !ok {
+			continue
+		}
+		destBatchID := b.ID
+		if take == b.Quantity {
+			result := tx.Model(&domain.Batch{}).
+				Where("id = ? AND quantity = ?", b.ID, b.Quantity).
+				Updates(map[string]interface{}{"location_id": transfer.DestLocationID, "bin_id": nil})
+			if result.Error != nil {
+				return nil, result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil, fmt.Errorf("%w: batch %s", ErrStockConflict, b.BatchNumber)
+			}
+		} else {
+			split, err := SplitBatch(tx, product, b, take, transfer.DestLocationID)
+			if err != nil {
+				return nil, err
+			}
+			destBatchID = split.ID
+		}
+		if units := unitsByBatch[b.ID]; len(units) > 0 {
+			event := domain.SerialNumberEvent{
+				EventType:     "TRANSFERRED",
+				ReferenceType: "TRANSFER",
+				ReferenceID:   transfer.ID,
+				Notes:         "Received at location " + strconv.FormatUint(uint64(transfer.DestLocationID), 10),
+				UserID:        userID,
+			}
+			updates := map[string]interface{}{"batch_id": destBatchID, "location_id": transfer.DestLocationID}
+			if err := TransitionSerialNumbers(tx, units, updates, event); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	now := time.Now()
+	transfer.Status = "COMPLETED"
+	transfer.ReceivedBy = &userID
+	transfer.ReceivedAt = &now
+	if err := tx.Model(&transfer).Updates(map[string]interface{}{
+		"status":      transfer.Status,
+		"received_by": userID,
+		"received_at": now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// transferDraws decides how much of each locked source batch a transfer takes. Serialized products
+// take one unit per named serial from the batch it was received into; others take the batches in
+// order until the transfer quantity is covered.
+func transferDraws(tx *gorm.DB, product *domain.Product, transfer *domain.StockTransfer, batches []domain.Batch, serials []string) (map[uint]float64, map[uint][]domain.SerialNumber, error) {
+	takes := make(map[uint]float64)
+	if !product.IsSerialized {
+		if len(serials) > 0 {
+			return nil, nil, fmt.Errorf("%w: product %s is not serialized", ErrTransferSerials, product.Name)
+		}
+		remaining := transfer.Quantity
+		for _, b := range batches {
+			if remaining <= 0 {
+				break
+			}
+			take := remaining
+			if b.Quantity < take {
+				take = b.Quantity
+			}
+			takes[b.ID] = take
+			remaining = product.RoundQuantity(remaining - take)
+		}
+		if remaining > 0 {
+			return nil, nil, fmt.Errorf("%w (short by %g %s)", ErrInsufficientTransferStock, remaining, product.UnitOfMeasure)
+		}
+		return takes, nil, nil
+	}
+
+	if float64(len(serials)) != transfer.Quantity {
+		return nil, nil, fmt.Errorf("%w: %g serial numbers required, got %d", ErrTransferSerials, transfer.Quantity, len(serials))
+	}
+	units, err := LockSerialNumbers(tx, product.ID, serials, domain.SerialStatusInStock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrTransferSerials, err)
+	}
+	available := make(map[uint]float64, len(batches))
+	for _, b := range batches {
+		available[b.ID] = b.Quantity
+	}
+	unitsByBatch := make(map[uint][]domain.SerialNumber)
+	for _, u := range units {
+		if u.LocationID != transfer.SourceLocationID || u.BatchID == nil {
+			return nil, nil, fmt.Errorf("%w: %s is not in stock at the source location", ErrTransferSerials, u.SerialNumber)
+		}
+		if takes[*u.BatchID] >= available[*u.BatchID] {
+			return nil, nil, fmt.Errorf("%w: batch of %s holds no more units to send", ErrInsufficientTransferStock, u.SerialNumber)
+		}
+		takes[*u.BatchID]++
+		unitsByBatch[*u.BatchID] = append(unitsByBatch[*u.BatchID], u)
+	}
+	return takes, unitsByBatch, nil
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"time"
+
+	"inventory/backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type StockTakeRepository interface {
+	GetSession(id uint) (*domain.StockTakeSession, error)
+	ListSessions(status string) ([]domain.StockTakeSession, error)
+	GetLines(sessionID uint) ([]domain.StockTakeLine, error)
+	GetCounterCounts(sessionID uint) (map[uint]int64, error)
+	GetProductsInScope(tx *gorm.DB, locationID *uint, categoryID *uint) ([]domain.Product, error)
+	GetConsumptionValues(since time.Time) (map[uint]float64, error)
+	GetLastCountDates() (map[uint]time.Time, error)
+	GetProductsInOpenSessions() (map[uint]bool, error)
+	UpdateABCClasses(classes map[uint]string) error
+}
+
+type stockTakeRepository struct {
+	db *gorm.DB
+}
+
+func NewStockTakeRepository(db *gorm.DB) StockTakeRepository {
+	return &stockTakeRepository{db: db}
+}
+
+func (r *stockTakeRepository) GetSession(id uint) (*domain.StockTakeSession, error) {
+	var session domain.StockTakeSession
+	if err := r.db.Preload("Location").Preload("Category").First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *stockTakeRepository) ListSessions(status string) ([]domain.StockTakeSession, error) {
+	var sessions []domain.StockTakeSession
+	query := r.db.Preload("Location").Preload("Category").Order("created_at desc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *stockTakeRepository) GetLines(sessionID uint) ([]domain.StockTakeLine, error) {
+	var lines []domain.StockTakeLine
+	err := r.db.Preload("Product").Where("session_id = ?", sessionID).Order("id asc").Find(&lines).Error
+	return lines, err
+}
+
+// GetCounterCounts returns, per line, how many distinct users recorded counts.
+func (r *stockTakeRepository) GetCounterCounts(sessionID uint) (map[uint]int64, error) {
+	var rows []struct {
+		LineID   uint
+		Counters int64
+	}
+	err := r.db.Model(&domain.StockTakeCount{}).
+		Select("line_id, COUNT(DISTINCT counted_by) as counters").
+		Where("session_id = ?", sessionID).
+		Group("line_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		result[row.LineID] = row.Counters
+	}
+	return result, nil
+}
+
+// GetProductsInScope returns the active products a session covers. With a location scope this
+// includes products homed at the location as well as any holding stock there.
+func (r *stockTakeRepository) GetProductsInScope(tx *gorm.DB, locationID *uint, categoryID *uint) ([]domain.Product, error) {
+	var products []domain.Product
+	query := tx.Where("status <> ?", "Archived")
+	if locationID != nil {
+		query = query.Where("location_id = ? OR id IN (?)", *locationID,
+			tx.Model(&domain.Batch{}).Select("product_id").Where("location_id = ? AND quantity > 0", *locationID))
+	}
+	if categoryID != nil {
+		query = query.Where("category_id = ?", *categoryID)
+	}
+	err := query.Order("id asc").Find(&products).Error
+	return products, err
+}
+
+// GetConsumptionValues returns the cost value of units sold per product since the given time.
+func (r *stockTakeRepository) GetConsumptionValues(since time.Time) (map[uint]float64, error) {
+	var rows []struct {
+		ProductID uint
+		Value     float64
+	}
+	err := r.db.Table("stock_adjustments").
+		Select("stock_adjustments.product_id, COALESCE(SUM(stock_adjustments.quantity * COALESCE(stock_adjustments.unit_cost, products.purchase_price)), 0) as value").
+		Joins("JOIN products ON products.id = stock_adjustments.product_id").
+		Where("stock_adjustments.type = ? AND stock_adjustments.reason_code = ?", "STOCK_OUT", "SALE").
+		Where("stock_adjustments.adjusted_at >= ?", since).
+		Where("stock_adjustments.deleted_at IS NULL").
+		Group("stock_adjustments.product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		result[row.ProductID] = row.Value
+	}
+	return result, nil
+}
+
+// GetLastCountDates returns when each product was last counted in an approved session.
+func (r *stockTakeRepository) GetLastCountDates() (map[uint]time.Time, error) {
+	var rows []struct {
+		ProductID  uint
+		ApprovedAt time.Time
+	}
+	err := r.db.Table("stock_take_lines").
+		Select("stock_take_lines.product_id, stock_take_sessions.approved_at").
+		Joins("JOIN stock_take_sessions ON stock_take_sessions.id = stock_take_lines.session_id").
+		Where("stock_take_sessions.status = ? AND stock_take_lines.counted_quantity IS NOT NULL", domain.StockTakeStatusApproved).
+		Where("stock_take_lines.deleted_at IS NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		if last, ok := result[row.ProductID]; !ok || row.ApprovedAt.After(last) {
+			result[row.ProductID] = row.ApprovedAt
+		}
+	}
+	return result, nil
+}
+
+// GetProductsInOpenSessions returns the products already being counted in an open or submitted session.
+func (r *stockTakeRepository) GetProductsInOpenSessions() (map[uint]bool, error) {
+	var productIDs []uint
+	err := r.db.Table("stock_take_lines").
+		Joins("JOIN stock_take_sessions ON stock_take_sessions.id = stock_take_lines.session_id").
+		Where("stock_take_sessions.status IN ?", []string{domain.StockTakeStatusOpen, domain.StockTakeStatusSubmitted}).
+		Where("stock_take_lines.deleted_at IS NULL AND stock_take_sessions.deleted_at IS NULL").
+		Distinct().
+		Pluck("stock_take_lines.product_id", &productIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[uint]bool, len(productIDs))
+	for _, id := range productIDs {
+		result[id] = true
+	}
+	return result, nil
+}
+
+func (r *stockTakeRepository) UpdateABCClasses(classes map[uint]string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for productID, class := range classes {
+			if err := tx.Model(&domain.Product{}).Where("id = ?", productID).Update("abc_class", class).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -81,6 +81,11 @@ func (r *SupplierRepository) GetByNames(names []string, suppliers *[]domain.Supp
 	return r.db.Where("name IN ?", names).Find(suppliers).Error
 }
 
+// GetByNamesIgnoreCase fetches the suppliers whose names match any of the given lower-case names.
+func (r *SupplierRepository) GetByNamesIgnoreCase(names []string, suppliers *[]domain.Supplier) error {
+	return r.db.Where("LOWER(name) IN ?", names).Find(suppliers).Error
+}
+
 func (r *SupplierRepository) GetSupplierByName(name string) (*domain.Supplier, error) {
 
 	var supplier domain.Supplier
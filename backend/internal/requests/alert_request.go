@@ -2,9 +2,12 @@ package requests
 
 // ProductAlertSettingsRequest represents the request body for configuring product alert thresholds.
 type ProductAlertSettingsRequest struct {
-	LowStockLevel   int `json:"lowStockLevel"`
-	OverStockLevel  int `json:"overStockLevel"`
-	ExpiryAlertDays int `json:"expiryAlertDays"`
+	LocationID      uint    `json:"locationId"` // Omit for thresholds on the product's total stock
+	LowStockLevel   float64 `json:"lowStockLevel" binding:"gte=0"`
+	SafetyStock     float64 `json:"safetyStock" binding:"gte=0"`
+	MaxStockLevel   float64 `json:"maxStockLevel" binding:"gte=0"`
+	OverStockLevel  float64 `json:"overStockLevel" binding:"gte=0"`
+	ExpiryAlertDays int     `json:"expiryAlertDays"`
 }
 
 // UserNotificationSettingsRequest represents the request body for configuring user notification preferences.
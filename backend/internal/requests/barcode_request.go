@@ -0,0 +1,17 @@
+package requests
+
+// ScaleBarcodePatternRequest represents the request body for creating or updating a scale barcode pattern.
+type ScaleBarcodePatternRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Prefix        string `json:"prefix" binding:"required,numeric,max=3"`
+	Length        int    `json:"length" binding:"required,min=8,max=14"`
+	PLUStart      int    `json:"pluStart" binding:"min=0"`
+	PLULength     int    `json:"pluLength" binding:"required,min=1"`
+	ValueStart    int    `json:"valueStart" binding:"min=0"`
+	ValueLength   int    `json:"valueLength" binding:"required,min=1"`
+	ValueType     string `json:"valueType" binding:"required,oneof=WEIGHT PRICE"`
+	ValueDecimals int    `json:"valueDecimals" binding:"min=0,max=4"`
+	VerifyCheck   *bool  `json:"verifyCheck"`
+	Priority      int    `json:"priority"`
+	IsActive      *bool  `json:"isActive"`
+}
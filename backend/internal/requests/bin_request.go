@@ -0,0 +1,36 @@
+package requests
+
+// BinCreateRequest represents the request body for creating a zone, aisle, shelf or bin.
+type BinCreateRequest struct {
+	Code     string  `json:"code" binding:"required,max=20,excludes=-"`
+	ParentID *uint   `json:"parentId"` // Omit for a top-level zone
+	Type     string  `json:"type" binding:"omitempty,oneof=ZONE AISLE SHELF BIN"`
+	Capacity float64 `json:"capacity" binding:"gte=0"`
+}
+
+// BinUpdateRequest represents the request body for updating a bin.
+type BinUpdateRequest struct {
+	Type     string   `json:"type" binding:"omitempty,oneof=ZONE AISLE SHELF BIN"`
+	Capacity *float64 `json:"capacity" binding:"omitempty,gte=0"`
+	IsActive *bool    `json:"isActive"`
+}
+
+// BinMoveRequest moves stock of a batch into another bin of the same location.
+type BinMoveRequest struct {
+	BatchID  uint    `json:"batchId" binding:"required"`
+	ToBinID  uint    `json:"toBinId" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"omitempty,gt=0"` // Omit to move the whole batch
+	Notes    string  `json:"notes"`
+}
+
+// PickListItem is a product and quantity to collect.
+type PickListItem struct {
+	ProductID uint    `json:"productId" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// PickListRequest represents the request body for building a pick list at a location.
+type PickListRequest struct {
+	LocationID uint           `json:"locationId" binding:"required"`
+	Items      []PickListItem `json:"items" binding:"required,min=1,dive"`
+}
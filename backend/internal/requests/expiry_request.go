@@ -0,0 +1,24 @@
+package requests
+
+// ExpiryMarkdownRuleRequest represents the request body for creating or replacing an expiry markdown rule.
+type ExpiryMarkdownRuleRequest struct {
+	Name             string  `json:"name" binding:"required"`
+	DaysBeforeExpiry int     `json:"daysBeforeExpiry" binding:"required,gt=0"`
+	DiscountType     string  `json:"discountType" binding:"required,oneof=PERCENTAGE FIXED_AMOUNT"`
+	DiscountValue    float64 `json:"discountValue" binding:"required,gt=0"`
+	CategoryID       *uint   `json:"categoryId"` // Omit to cover every perishable product
+	IsActive         *bool   `json:"isActive"`
+}
+
+// StockWriteOffRequest represents the request body for asking to write off stock from a batch.
+type StockWriteOffRequest struct {
+	BatchID    uint    `json:"batchId" binding:"required"`
+	Quantity   float64 `json:"quantity" binding:"omitempty,gt=0"` // Omit to write off the whole batch
+	ReasonCode string  `json:"reasonCode" binding:"required,oneof=EXPIRED DAMAGED_GOODS SPOILAGE"`
+	Notes      string  `json:"notes"`
+}
+
+// ReviewStockWriteOffRequest represents the request body for approving or rejecting a write-off.
+type ReviewStockWriteOffRequest struct {
+	Notes string `json:"notes"`
+}
@@ -0,0 +1,40 @@
+package requests
+
+import "time"
+
+// SupplierInvoiceRequest represents the request body for recording a supplier invoice against a purchase order.
+type SupplierInvoiceRequest struct {
+	PurchaseOrderID uint                         `json:"purchaseOrderId" binding:"required"`
+	InvoiceNumber   string                       `json:"invoiceNumber" binding:"required"`
+	InvoiceDate     time.Time                    `json:"invoiceDate" binding:"required"`
+	DueDate         *time.Time                   `json:"dueDate"` // Omit to apply the default payment terms
+	TaxAmount       float64                      `json:"taxAmount" binding:"gte=0"`
+	Notes           string                       `json:"notes"`
+	Lines           []SupplierInvoiceLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// SupplierInvoiceLineRequest bills a quantity of one purchase order line.
+type SupplierInvoiceLineRequest struct {
+	PurchaseOrderItemID uint    `json:"purchaseOrderItemId" binding:"required"`
+	Quantity            float64 `json:"quantity" binding:"required,gt=0"`
+	UnitPrice           float64 `json:"unitPrice" binding:"gte=0"`
+}
+
+// ResolveInvoiceLineRequest represents the request body for accepting a mismatched invoice line.
+type ResolveInvoiceLineRequest struct {
+	Note string `json:"note" binding:"required"` // Why the mismatch is acceptable, e.g. a credit note was agreed
+}
+
+// InvoicePaymentRequest represents the request body for recording a payment against an approved invoice.
+type InvoicePaymentRequest struct {
+	Amount float64    `json:"amount" binding:"required,gt=0"`
+	PaidAt *time.Time `json:"paidAt"` // Omit for now
+}
+
+// InvoiceToleranceRuleRequest represents the request body for creating or replacing an invoice tolerance rule.
+type InvoiceToleranceRuleRequest struct {
+	SupplierID      *uint   `json:"supplierId"` // Omit for the default rule
+	PricePercent    float64 `json:"pricePercent" binding:"gte=0"`
+	PriceAmount     float64 `json:"priceAmount" binding:"gte=0"`
+	QuantityPercent float64 `json:"quantityPercent" binding:"gte=0"`
+}
@@ -0,0 +1,13 @@
+package requests
+
+// ApproveLedgerReconciliationRequest represents the request body for approving a ledger reconciliation.
+type ApproveLedgerReconciliationRequest struct {
+	// DiscrepancyIDs limits the corrections to these rows; the rest are ignored. Empty corrects all.
+	DiscrepancyIDs []uint `json:"discrepancyIds"`
+	Notes          string `json:"notes"`
+}
+
+// RejectLedgerReconciliationRequest represents the request body for rejecting a ledger reconciliation.
+type RejectLedgerReconciliationRequest struct {
+	Notes string `json:"notes"`
+}
@@ -15,22 +15,41 @@ type ProductCreateRequest struct {
 	ImageURLs     string  `json:"imageUrls"`
 	Status        string  `json:"status"`
 	LocationID    uint    `json:"locationId" binding:"required"`
+	// UnitOfMeasure and QuantityPrecision enable weighed/measured goods (e.g., "kg" with precision 3).
+	UnitOfMeasure     string `json:"unitOfMeasure"`
+	QuantityPrecision int    `json:"quantityPrecision" binding:"min=0,max=3"`
+	// PLUCode links the product to the PLU embedded in scale barcodes.
+	PLUCode string `json:"pluCode" binding:"omitempty,numeric"`
+	// IsSerialized requires serial/IMEI capture on receipt and selection at checkout.
+	IsSerialized bool `json:"isSerialized"`
+	WarrantyDays int  `json:"warrantyDays" binding:"min=0"`
+	// CostingMethod is FIFO (default) or AVERAGE.
+	CostingMethod string `json:"costingMethod" binding:"omitempty,oneof=FIFO AVERAGE"`
+	// Weight is the shipping weight of one unit in kilograms.
+	Weight float64 `json:"weight" binding:"min=0"`
 }
 
 // ProductUpdateRequest represents the request body for updating an existing product.
 type ProductUpdateRequest struct {
-	Name          string  `json:"name"`
-	Description   string  `json:"description"`
-	CategoryID    uint    `json:"categoryId"`
-	SubCategoryID uint    `json:"subCategoryId"`
-	SupplierID    uint    `json:"supplierId"`
-	Brand         string  `json:"brand"`
-	PurchasePrice float64 `json:"purchasePrice"`
-	SellingPrice  float64 `json:"sellingPrice"`
-	BarcodeUPC    string  `json:"barcodeUpc"`
-	ImageURLs     string  `json:"imageUrls"` // Comma-separated or JSON array string
-	Status        string  `json:"status"`
-	LocationID    uint    `json:"locationId"`
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	CategoryID        uint     `json:"categoryId"`
+	SubCategoryID     uint     `json:"subCategoryId"`
+	SupplierID        uint     `json:"supplierId"`
+	Brand             string   `json:"brand"`
+	PurchasePrice     float64  `json:"purchasePrice"`
+	SellingPrice      float64  `json:"sellingPrice"`
+	BarcodeUPC        string   `json:"barcodeUpc"`
+	ImageURLs         string   `json:"imageUrls"` // Comma-separated or JSON array string
+	Status            string   `json:"status"`
+	LocationID        uint     `json:"locationId"`
+	UnitOfMeasure     string   `json:"unitOfMeasure"`
+	QuantityPrecision *int     `json:"quantityPrecision" binding:"omitempty,min=0,max=3"`
+	PLUCode           string   `json:"pluCode" binding:"omitempty,numeric"`
+	IsSerialized      *bool    `json:"isSerialized"`
+	WarrantyDays      *int     `json:"warrantyDays" binding:"omitempty,min=0"`
+	CostingMethod     string   `json:"costingMethod" binding:"omitempty,oneof=FIFO AVERAGE"`
+	Weight            *float64 `json:"weight" binding:"omitempty,min=0"`
 }
 
 // ProductArchiveRequest represents the request body for archiving a product.
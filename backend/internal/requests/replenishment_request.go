@@ -8,6 +8,14 @@ type ForecastGenerationRequest struct {
 	ProductID    *uint `json:"productId"` // Optional, for specific product forecast
 }
 
+// ForecastBacktestRequest represents the request body for replaying forecasts over a past window.
+type ForecastBacktestRequest struct {
+	ProductID   uint      `json:"productId" binding:"required"`
+	StartDate   time.Time `json:"startDate" binding:"required"`
+	EndDate     time.Time `json:"endDate" binding:"required"`
+	HorizonDays int       `json:"horizonDays" binding:"required,gt=0,lte=365"` // Length of each replayed forecast; runs start this many days apart
+}
+
 // CreatePOFromSuggestionRequest represents the request body for creating a PO from a suggestion.
 type CreatePOFromSuggestionRequest struct {
 	SuggestionID uint `json:"suggestionId" binding:"required"`
@@ -21,7 +29,7 @@ type ApprovePORequest struct {
 // UpdatePORequest represents the request body for updating a purchase order.
 type UpdatePORequest struct {
 	SupplierID           uint            `json:"supplierId,omitempty"`
-	Status               string          `json:"status,omitempty" binding:"omitempty,oneof=DRAFT APPROVED SENT RECEIVED CANCELLED"`
+	Status               string          `json:"status,omitempty" binding:"omitempty,oneof=DRAFT CANCELLED"` // Approval goes through the approval workflow
 	OrderDate            *time.Time      `json:"orderDate,omitempty"`
 	ExpectedDeliveryDate *time.Time      `json:"expectedDeliveryDate,omitempty"`
 	PurchaseOrderItems   []POItemRequest `json:"items,omitempty"`
@@ -30,21 +38,25 @@ type UpdatePORequest struct {
 // POItemRequest represents an item within a purchase order update request.
 type POItemRequest struct {
 	ProductID       uint    `json:"productId" binding:"required"`
-	OrderedQuantity int     `json:"orderedQuantity" binding:"required,gt=0"`
-	UnitPrice       float64 `json:"unitPrice" binding:"required,gt=0"`
+	OrderedQuantity float64 `json:"orderedQuantity" binding:"required,gt=0"`
+	UnitPrice       float64 `json:"unitPrice" binding:"omitempty,gt=0"` // Omit to use the supplier's catalog price
 }
 
 // ReceivePORequest represents the request body for receiving goods for a purchase order.
 type ReceivePORequest struct {
 	Items []ReceivePOItemRequest `json:"items" binding:"required,min=1"`
+	// Charges are landed costs billed with this delivery; they are allocated to this receipt's lines only.
+	Charges []LandedCostChargeRequest `json:"charges" binding:"omitempty,dive"`
 }
 
 // ReceivePOItemRequest represents an item being received for a purchase order.
 type ReceivePOItemRequest struct {
 	PurchaseOrderItemID uint       `json:"purchaseOrderItemId" binding:"required"`
-	ReceivedQuantity    int        `json:"receivedQuantity" binding:"required,gt=0"`
+	ReceivedQuantity    float64    `json:"receivedQuantity" binding:"required,gt=0"`
 	BatchNumber         string     `json:"batchNumber" binding:"required"`
 	ExpiryDate          *time.Time `json:"expiryDate"`
+	SerialNumbers       []string   `json:"serialNumbers"` // Required for serialized products, one per unit
+	BinID               *uint      `json:"binId"`         // Putaway bin; omit to use the suggested bin
 }
 
 // CreatePORequest represents the request body for creating a new purchase order.
@@ -54,3 +66,38 @@ type CreatePORequest struct {
 	ExpectedDeliveryDate *time.Time      `json:"expectedDeliveryDate"`
 	PurchaseOrderItems   []POItemRequest `json:"items" binding:"required,min=1"`
 }
+
+// LandedCostChargeRequest represents a freight, duty or handling charge on a purchase order or receipt.
+type LandedCostChargeRequest struct {
+	ChargeType       string  `json:"chargeType" binding:"required,oneof=FREIGHT DUTY HANDLING INSURANCE OTHER"`
+	Description      string  `json:"description"`
+	Reference        string  `json:"reference"`
+	Amount           float64 `json:"amount" binding:"required,gt=0"`
+	AllocationMethod string  `json:"allocationMethod" binding:"required,oneof=VALUE QUANTITY WEIGHT"`
+}
+
+// ReorderPolicyRequest represents the request body for creating or updating a reorder policy.
+type ReorderPolicyRequest struct {
+	CategoryID       *uint   `json:"categoryId"` // Omit for the default policy
+	PolicyType       string  `json:"policyType" binding:"required,oneof=MIN_MAX EOQ PERIODIC_REVIEW"`
+	ServiceLevel     float64 `json:"serviceLevel" binding:"required,gte=0.5,lt=1"`
+	OrderingCost     float64 `json:"orderingCost" binding:"gte=0"`
+	HoldingCostRate  float64 `json:"holdingCostRate" binding:"gte=0"`
+	ReviewPeriodDays int     `json:"reviewPeriodDays" binding:"gte=0"`
+}
+
+// PODecisionRequest represents the request body for approving or rejecting a purchase order approval step.
+type PODecisionRequest struct {
+	Comments string `json:"comments" binding:"max=2000"` // Required when rejecting
+}
+
+// POApprovalRuleRequest represents the request body for creating or updating a purchase order approval rule.
+type POApprovalRuleRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	Level          int     `json:"level" binding:"required,gte=1"`
+	MinAmount      float64 `json:"minAmount" binding:"gte=0"`
+	SupplierID     *uint   `json:"supplierId"` // Omit for any supplier
+	CategoryID     *uint   `json:"categoryId"` // Omit for any category
+	ApproverRoleID uint    `json:"approverRoleId" binding:"required"`
+	IsActive       *bool   `json:"isActive"`
+}
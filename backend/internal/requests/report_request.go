@@ -27,3 +27,9 @@ type ProfitMarginReportRequest struct {
 	CategoryID *uint     `json:"categoryId"`
 	LocationID *uint     `json:"locationId"`
 }
+
+// InventoryValuationReportRequest represents parameters for the point-in-time inventory valuation export.
+type InventoryValuationReportRequest struct {
+	AsOf       time.Time `json:"asOf" binding:"required"`
+	LocationID *uint     `json:"locationId"`
+}
@@ -0,0 +1,13 @@
+package requests
+
+// CreateStockReservationRequest represents the request body for reserving stock at a location.
+type CreateStockReservationRequest struct {
+	ProductID  uint    `json:"productId" binding:"required"`
+	LocationID uint    `json:"locationId" binding:"required"`
+	Quantity   float64 `json:"quantity" binding:"required,gt=0"`
+	SourceType string  `json:"sourceType" binding:"required,oneof=ORDER CART MANUAL"`
+	SourceRef  string  `json:"sourceRef"` // e.g. external order number or cart ID
+	// ExpiresInMinutes overrides the reservation_ttl_minutes setting; 0 holds until released.
+	ExpiresInMinutes *int   `json:"expiresInMinutes" binding:"omitempty,gte=0"`
+	Notes            string `json:"notes"`
+}
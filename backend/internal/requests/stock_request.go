@@ -4,21 +4,32 @@ import "time"
 
 // StockInBatchRequest represents the request body for adding new stock with batch information.
 type StockInBatchRequest struct {
-	Quantity    int        `json:"quantity" binding:"required,gt=0"`
+	Quantity    float64    `json:"quantity" binding:"required,gt=0"`
 	BatchNumber string     `json:"batchNumber" binding:"required"`
 	ExpiryDate  *time.Time `json:"expiryDate"` // Optional for non-perishable
+	// UnitCost is the receipt cost per unit; defaults to the product's current cost.
+	UnitCost *float64 `json:"unitCost" binding:"omitempty,gte=0"`
+	// SerialNumbers is required for serialized products, one per unit.
+	SerialNumbers []string `json:"serialNumbers"`
 }
 
 // StockAdjustmentRequest represents the request body for performing a manual stock adjustment.
 type StockAdjustmentRequest struct {
-	Type       string `json:"type" binding:"required,oneof=STOCK_IN STOCK_OUT"` // "STOCK_IN" or "STOCK_OUT"
-	Quantity   int    `json:"quantity" binding:"required,gt=0"`
-	ReasonCode string `json:"reasonCode" binding:"required"`
-	Notes      string `json:"notes"`
+	Type       string  `json:"type" binding:"required,oneof=STOCK_IN STOCK_OUT"` // "STOCK_IN" or "STOCK_OUT"
+	Quantity   float64 `json:"quantity" binding:"required,gt=0"`
+	ReasonCode string  `json:"reasonCode" binding:"required"`
+	Notes      string  `json:"notes"`
+	// SerialNumbers identifies the units added or removed for serialized products.
+	SerialNumbers []string `json:"serialNumbers"`
 }
 type CheckoutItem struct {
-	ProductID uint `json:"productId" binding:"required"`
-	Quantity  int  `json:"quantity" binding:"required,min=1"`
+	ProductID uint    `json:"productId" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"required,gt=0"` // Fractional for weighed/measured goods
+	// SerialNumbers selects the exact units sold for serialized products.
+	SerialNumbers []string `json:"serialNumbers"`
+	// Barcode is the scale barcode the line was scanned from, if any. Price-embedded labels are
+	// charged the printed price instead of the selling price.
+	Barcode string `json:"barcode"`
 }
 
 type CheckoutRequest struct {
@@ -26,4 +37,6 @@ type CheckoutRequest struct {
 	CustomerID     *uint          `json:"customerId"`
 	PaymentMethod  string         `json:"paymentMethod" binding:"required"`
 	PointsToRedeem int            `json:"pointsToRedeem"` // Optional points to redeem
+	// ReservationIDs are the holds placed for this sale (e.g. a held cart); they are consumed on checkout.
+	ReservationIDs []uint `json:"reservationIds"`
 }
@@ -1,8 +1,18 @@
 package requests
 
 type StockTransferRequest struct {
-	ProductID        uint `json:"productId" binding:"required"`
-	SourceLocationID uint `json:"sourceLocationId" binding:"required"`
-	DestLocationID   uint `json:"destLocationId" binding:"required,nefield=SourceLocationID"`
-	Quantity         int  `json:"quantity" binding:"required,gt=0"`
+	ProductID        uint    `json:"productId" binding:"required"`
+	SourceLocationID uint    `json:"sourceLocationId" binding:"required"`
+	DestLocationID   uint    `json:"destLocationId" binding:"required,nefield=SourceLocationID"`
+	Quantity         float64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// TransferDraftsRequest selects transfer drafts for bulk approval or cancellation.
+type TransferDraftsRequest struct {
+	TransferIDs []uint `json:"transferIds" binding:"required,min=1"`
+}
+
+// CompleteStockTransferRequest confirms a pending transfer arrived at its destination.
+type CompleteStockTransferRequest struct {
+	SerialNumbers []string `json:"serialNumbers"` // Units received; required for serialized products
 }
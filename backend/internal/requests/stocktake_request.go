@@ -0,0 +1,24 @@
+package requests
+
+// CreateStockTakeRequest represents the request body for opening a stock-take session.
+type CreateStockTakeRequest struct {
+	Name       string `json:"name" binding:"required"`
+	LocationID *uint  `json:"locationId"`
+	CategoryID *uint  `json:"categoryId"`
+	BlindCount *bool  `json:"blindCount"` // Defaults to true
+	Notes      string `json:"notes"`
+}
+
+// StockTakeCountRequest records a count. Either ProductID or Barcode identifies the product;
+// scans without a quantity count one unit (or the weight embedded in a scale barcode).
+type StockTakeCountRequest struct {
+	ProductID *uint    `json:"productId"`
+	Barcode   string   `json:"barcode"`
+	Quantity  *float64 `json:"quantity" binding:"omitempty,gte=0"`
+	Mode      string   `json:"mode" binding:"omitempty,oneof=INCREMENT SET"` // Defaults to INCREMENT
+}
+
+// ApproveStockTakeRequest represents the request body for approving a stock-take session.
+type ApproveStockTakeRequest struct {
+	TreatUncountedAsZero bool `json:"treatUncountedAsZero"` // Otherwise uncounted lines are left unchanged
+}
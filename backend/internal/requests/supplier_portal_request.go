@@ -0,0 +1,45 @@
+package requests
+
+import "time"
+
+// POChangeProposalRequest represents the request body for a supplier proposing changes to a sent purchase order.
+type POChangeProposalRequest struct {
+	ProposedDeliveryDate *time.Time                    `json:"proposedDeliveryDate"` // Omit to keep the expected delivery date
+	Notes                string                        `json:"notes"`
+	Lines                []POChangeProposalLineRequest `json:"lines" binding:"dive"`
+}
+
+// POChangeProposalLineRequest proposes a new quantity for one purchase order line.
+type POChangeProposalLineRequest struct {
+	PurchaseOrderItemID uint    `json:"purchaseOrderItemId" binding:"required"`
+	Quantity            float64 `json:"quantity" binding:"gte=0"` // At most the ordered quantity; 0 = the line cannot be supplied
+}
+
+// AdvanceShippingNoticeRequest represents the request body for a supplier announcing a shipment.
+type AdvanceShippingNoticeRequest struct {
+	ShipmentNumber      string                             `json:"shipmentNumber" binding:"required"`
+	ShippedAt           *time.Time                         `json:"shippedAt"` // Omit for now
+	ExpectedArrivalDate *time.Time                         `json:"expectedArrivalDate"`
+	Carrier             string                             `json:"carrier"`
+	TrackingNumber      string                             `json:"trackingNumber"`
+	Notes               string                             `json:"notes"`
+	Lines               []AdvanceShippingNoticeLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// AdvanceShippingNoticeLineRequest is a quantity of one purchase order line shipped from a single batch.
+type AdvanceShippingNoticeLineRequest struct {
+	PurchaseOrderItemID uint       `json:"purchaseOrderItemId" binding:"required"`
+	Quantity            float64    `json:"quantity" binding:"required,gt=0"`
+	BatchNumber         string     `json:"batchNumber" binding:"required"`
+	ExpiryDate          *time.Time `json:"expiryDate"`
+}
+
+// SupplierUserRequest represents the request body for creating a supplier portal login.
+type SupplierUserRequest struct {
+	Username    string `json:"username" binding:"required,min=3,max=50"`
+	Password    string `json:"password" binding:"required,min=6"`
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	Email       string `json:"email" binding:"required,email"`
+	PhoneNumber string `json:"phoneNumber" binding:"required"`
+}
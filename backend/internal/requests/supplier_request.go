@@ -7,6 +7,9 @@ type SupplierCreateRequest struct {
 	Email       string `json:"email"`
 	Phone       string `json:"phone"`
 	Address     string `json:"address"`
+	MinimumOrderValue     float64 `json:"minimumOrderValue" binding:"gte=0"`
+	FreeShippingThreshold float64 `json:"freeShippingThreshold" binding:"gte=0"`
+	AutoApproveLimit      float64 `json:"autoApproveLimit" binding:"gte=0"`
 }
 
 // SupplierUpdateRequest represents the request body for updating an existing supplier.
@@ -16,4 +19,17 @@ type SupplierUpdateRequest struct {
 	Email       string `json:"email"`
 	Phone       string `json:"phone"`
 	Address     string `json:"address"`
+	MinimumOrderValue     *float64 `json:"minimumOrderValue" binding:"omitempty,gte=0"`
+	FreeShippingThreshold *float64 `json:"freeShippingThreshold" binding:"omitempty,gte=0"`
+	AutoApproveLimit      *float64 `json:"autoApproveLimit" binding:"omitempty,gte=0"`
+}
+
+// ProductSupplierRequest represents the request body for adding or updating a supplier's catalog entry for a product.
+type ProductSupplierRequest struct {
+	SupplierSKU          string  `json:"supplierSku"`
+	UnitCost             float64 `json:"unitCost" binding:"required,gt=0"`
+	MinimumOrderQuantity float64 `json:"minimumOrderQuantity" binding:"gte=0"`
+	PackSize             float64 `json:"packSize" binding:"gte=0"`
+	LeadTimeDays         *int    `json:"leadTimeDays" binding:"omitempty,gte=0"` // Omit for the default of 7 days
+	IsPreferred          bool    `json:"isPreferred"`
 }
@@ -55,6 +55,8 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 	replenishmentRepo := repository.NewReplenishmentRepository(db)
 	settingsRepo := repository.NewSettingsRepository(db)
 	roleRepo := repository.NewRoleRepository(db, repository.GetClient())
+	stockTakeRepo := repository.NewStockTakeRepository(db)
+	snapshotRepo := repository.NewSnapshotRepository(db)
 
 	// Initialize services
 	paymentService := services.NewPaymentService(cfg, paymentRepo)
@@ -66,16 +68,22 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 	integrationService := services.NewIntegrationService()
 	reportingService := services.NewReportingService(reportsRepo, minioUploader, jobRepo, hub, cfg)
 	searchService := services.NewSearchService(db, searchRepo, productRepo, userRepo, supplierRepo, categoryRepo)
-	replenishmentService := services.NewReplenishmentService(replenishmentRepo)
+	leadTimeService := services.NewLeadTimeService(db)
+	replenishmentService := services.NewReplenishmentService(replenishmentRepo, leadTimeService)
 	roleService := services.NewRoleService(roleRepo)
+	stockTakeService := services.NewStockTakeService(stockTakeRepo, db, settingsService, barcodeService)
+	ledgerService := services.NewLedgerService(db)
+	rebalancingService := services.NewRebalancingService(db)
+	expiryService := services.NewExpiryService(db)
+	invoiceService := services.NewInvoiceService(db, settingsService)
 
 	// Initialize handlers
 	productHandler := handlers.NewProductHandler(productRepo, db)
 	categoryHandler := handlers.NewCategoryHandler(categoryRepo, db)
-	supplierHandler := handlers.NewSupplierHandler(supplierRepo, db)
+	supplierHandler := handlers.NewSupplierHandler(supplierRepo, db, leadTimeService)
 	paymentHandler := handlers.NewPaymentHandler(paymentService)
 	emailService := services.NewEmailService(cfg)
-	replenishmentHandler := handlers.NewReplenishmentHandler(forecastingService, replenishmentService, hub, notificationRepo, emailService)
+	replenishmentHandler := handlers.NewReplenishmentHandler(forecastingService, replenishmentService, hub, notificationRepo, emailService, services.NewPOConsolidationService(db), services.NewPODocumentService(db, minioUploader, settingsService))
 	barcodeHandler := handlers.NewBarcodeHandler(barcodeService)
 	crmHandler := handlers.NewCRMHandler(crmService)
 	timeTrackingHandler := handlers.NewTimeTrackingHandler(timeTrackingService)
@@ -90,6 +98,14 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 	roleHandler := handlers.NewRoleHandler(roleService)
 	returnHandler := handlers.NewReturnHandler(db, cfg, settingsService, hub, notificationRepo, reportingService)
 	promotionHandler := handlers.NewPromotionHandler(db)
+	stockTakeHandler := handlers.NewStockTakeHandler(stockTakeService)
+	reservationHandler := handlers.NewReservationHandler(db, settingsService)
+	stockHistoryHandler := handlers.NewStockHistoryHandler(snapshotRepo, db)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService)
+	rebalancingHandler := handlers.NewRebalancingHandler(rebalancingService)
+	expiryHandler := handlers.NewExpiryHandler(expiryService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+	supplierPortalHandler := handlers.NewSupplierPortalHandler(services.NewSupplierPortalService(db), services.NewPODocumentService(db, minioUploader, settingsService), hub, notificationRepo)
 
 	// Public routes (no tenant middleware)
 	publicRoutes := r.Group("/")
@@ -134,6 +150,8 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 		// Auth middleware will be applied to all routes in this group
 		api.Use(middleware.AuthMiddleware())
 		api.Use(middleware.CSRFMiddleware())
+		// Supplier portal users only reach the portal and their own session
+		api.Use(middleware.RestrictRoleToPaths(services.SupplierRoleName, "/api/v1/supplier-portal/", "/api/v1/users/refresh-token", "/api/v1/users/logout"))
 
 		// Jobs (Manager/Admin)
 		jobs := api.Group("/jobs")
@@ -159,6 +177,31 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 			products.POST("/:productId/stock/batches", middleware.RequirePermission(roleRepo, "products.write"), handlers.CreateBatch)
 			products.POST("/:productId/stock/adjustments", middleware.RequirePermission(roleRepo, "products.write"), handlers.CreateStockAdjustment)
 			products.GET("/:productId/history", middleware.RequirePermission(roleRepo, "products.read"), handlers.ListStockHistory)
+			products.GET("/:productId/stock-history", middleware.RequirePermission(roleRepo, "products.read"), stockHistoryHandler.GetProductStockHistory)
+			products.GET("/:productId/suppliers", middleware.RequirePermission(roleRepo, "products.read"), handlers.ListProductSuppliers)
+			products.GET("/:productId/suppliers/best", middleware.RequirePermission(roleRepo, "products.read"), handlers.GetBestProductSupplier)
+			products.PUT("/:productId/suppliers/:supplierId", middleware.RequirePermission(roleRepo, "suppliers.write"), handlers.SaveProductSupplier)
+			products.DELETE("/:productId/suppliers/:supplierId", middleware.RequirePermission(roleRepo, "suppliers.write"), handlers.DeleteProductSupplier)
+			products.GET("/:productId/serials", middleware.RequirePermission(roleRepo, "products.read"), handlers.ListProductSerialNumbers)
+		}
+
+		// Serial numbers
+		api.GET("/serials/:serial", middleware.RequirePermission(roleRepo, "products.read"), handlers.GetSerialNumberHistory)
+
+		// Stock takes
+		stockTakes := api.Group("/stock-takes")
+		{
+			stockTakes.POST("", middleware.RequirePermission(roleRepo, "stocktake.manage"), stockTakeHandler.CreateStockTake)
+			stockTakes.GET("", middleware.RequirePermission(roleRepo, "stocktake.count"), stockTakeHandler.ListStockTakes)
+			stockTakes.GET("/cycle-count/plan", middleware.RequirePermission(roleRepo, "stocktake.manage"), stockTakeHandler.GetCycleCountPlan)
+			stockTakes.POST("/cycle-count/run", middleware.RequirePermission(roleRepo, "stocktake.manage"), stockTakeHandler.RunCycleCount)
+			stockTakes.GET("/:id", middleware.RequirePermission(roleRepo, "stocktake.count"), stockTakeHandler.GetStockTake)
+			stockTakes.GET("/:id/count-sheet", middleware.RequirePermission(roleRepo, "stocktake.count"), stockTakeHandler.GetStockTakeCountSheet)
+			stockTakes.POST("/:id/counts", middleware.RequirePermission(roleRepo, "stocktake.count"), stockTakeHandler.RecordStockTakeCount)
+			stockTakes.POST("/:id/submit", middleware.RequirePermission(roleRepo, "stocktake.count"), stockTakeHandler.SubmitStockTake)
+			stockTakes.GET("/:id/variances", middleware.RequirePermission(roleRepo, "stocktake.manage"), stockTakeHandler.GetStockTakeVariances)
+			stockTakes.POST("/:id/approve", middleware.RequirePermission(roleRepo, "stocktake.manage"), stockTakeHandler.ApproveStockTake)
+			stockTakes.POST("/:id/cancel", middleware.RequirePermission(roleRepo, "stocktake.manage"), stockTakeHandler.CancelStockTake)
 		}
 
 		// Promotions
@@ -199,7 +242,10 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 			suppliers.GET("/name/:name", middleware.RequirePermission(roleRepo, "suppliers.read"), supplierHandler.GetSupplierByName)
 			suppliers.PUT("/:id", middleware.RequirePermission(roleRepo, "suppliers.write"), supplierHandler.UpdateSupplier)
 			suppliers.DELETE("/:id", middleware.RequirePermission(roleRepo, "suppliers.write"), supplierHandler.DeleteSupplier)
+			suppliers.GET("/:id/catalog", middleware.RequirePermission(roleRepo, "suppliers.read"), handlers.ListSupplierCatalog)
 			suppliers.GET("/:id/performance", middleware.RequirePermission(roleRepo, "reports.financial"), supplierHandler.GetSupplierPerformanceReport)
+			suppliers.GET("/:id/users", middleware.RequirePermission(roleRepo, "users.view"), supplierPortalHandler.ListSupplierUsers)
+			suppliers.POST("/:id/users", middleware.RequirePermission(roleRepo, "users.manage"), supplierPortalHandler.CreateSupplierUser)
 		}
 
 		// Locations
@@ -210,6 +256,12 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 			locations.GET("/:id", middleware.RequirePermission(roleRepo, "locations.read"), handlers.GetLocation)
 			locations.PUT("/:id", middleware.RequirePermission(roleRepo, "locations.write"), handlers.UpdateLocation)
 			locations.DELETE("/:id", middleware.RequirePermission(roleRepo, "locations.write"), handlers.DeleteLocation)
+			locations.POST("/:id/bins", middleware.RequirePermission(roleRepo, "locations.write"), handlers.CreateBin)
+			locations.GET("/:id/bins", middleware.RequirePermission(roleRepo, "locations.read"), handlers.ListBins)
+			locations.GET("/:id/bins/putaway-suggestion", middleware.RequirePermission(roleRepo, "locations.read"), handlers.GetPutawaySuggestion)
+			locations.PUT("/:id/bins/:binId", middleware.RequirePermission(roleRepo, "locations.write"), handlers.UpdateBin)
+			locations.DELETE("/:id/bins/:binId", middleware.RequirePermission(roleRepo, "locations.write"), handlers.DeleteBin)
+			locations.GET("/:id/bins/:binId/stock", middleware.RequirePermission(roleRepo, "locations.read"), handlers.GetBinStock)
 		}
 
 		// Barcode
@@ -217,29 +269,91 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 		{
 			barcode.GET("/lookup", middleware.RequirePermission(roleRepo, "barcode.read"), barcodeHandler.LookupProductByBarcode)
 			barcode.GET("/generate", middleware.RequirePermission(roleRepo, "barcode.read"), barcodeHandler.GenerateBarcode)
+			barcode.GET("/scale-patterns", middleware.RequirePermission(roleRepo, "barcode.read"), barcodeHandler.ListScalePatterns)
+			barcode.POST("/scale-patterns", middleware.RequirePermission(roleRepo, "barcode.manage"), barcodeHandler.CreateScalePattern)
+			barcode.PUT("/scale-patterns/:id", middleware.RequirePermission(roleRepo, "barcode.manage"), barcodeHandler.UpdateScalePattern)
+			barcode.DELETE("/scale-patterns/:id", middleware.RequirePermission(roleRepo, "barcode.manage"), barcodeHandler.DeleteScalePattern)
 		}
 
 		// Replenishment
 		replenishment := api.Group("/replenishment")
 		{
 			replenishment.POST("/forecast/generate", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.GenerateDemandForecast)
+			replenishment.GET("/forecast/accuracy", middleware.RequirePermission(roleRepo, "replenishment.read"), replenishmentHandler.GetForecastAccuracy)
+			replenishment.POST("/forecast/accuracy/evaluate", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.EvaluateForecasts)
+			replenishment.POST("/forecast/backtest", middleware.RequirePermission(roleRepo, "replenishment.read"), replenishmentHandler.BacktestForecast)
 			replenishment.GET("/forecast/:forecastId", middleware.RequirePermission(roleRepo, "replenishment.read"), handlers.GetDemandForecast)
 			replenishment.GET("/dashboard", middleware.RequirePermission(roleRepo, "replenishment.read"), replenishmentHandler.GetForecastDashboard)
 			replenishment.POST("/suggestions/generate", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.GenerateReorderSuggestions)
 			replenishment.GET("/suggestions", middleware.RequirePermission(roleRepo, "replenishment.read"), replenishmentHandler.ListReorderSuggestions)
+			replenishment.GET("/policies", middleware.RequirePermission(roleRepo, "replenishment.read"), handlers.ListReorderPolicies)
+			replenishment.POST("/policies", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.CreateReorderPolicy)
+			replenishment.PUT("/policies/:id", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.UpdateReorderPolicy)
+			replenishment.DELETE("/policies/:id", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.DeleteReorderPolicy)
+			replenishment.POST("/suggestions/consolidate", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.ConsolidateReorderSuggestions)
+			replenishment.POST("/suggestions/:suggestionId/approve", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.ApproveReorderSuggestion)
+			replenishment.POST("/suggestions/:suggestionId/reject", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.RejectReorderSuggestion)
 			replenishment.POST("/suggestions/:suggestionId/create-po", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.CreatePOFromSuggestion)
-			replenishment.POST("/purchase-orders/:poId/approve", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.ApprovePurchaseOrder)
+			replenishment.POST("/purchase-orders/:poId/submit", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.SubmitPurchaseOrder)
+			replenishment.POST("/purchase-orders/:poId/approve", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.ApprovePurchaseOrder)
+			replenishment.POST("/purchase-orders/:poId/reject", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.RejectPurchaseOrder)
+			replenishment.GET("/purchase-orders/:poId/approvals", middleware.RequirePermission(roleRepo, "replenishment.read"), handlers.ListPurchaseOrderApprovals)
+			replenishment.GET("/approvals/pending", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.ListMyPendingApprovals)
+			replenishment.GET("/approval-rules", middleware.RequirePermission(roleRepo, "replenishment.read"), handlers.ListPOApprovalRules)
+			replenishment.POST("/approval-rules", middleware.RequirePermission(roleRepo, "approvals.manage"), handlers.CreatePOApprovalRule)
+			replenishment.PUT("/approval-rules/:id", middleware.RequirePermission(roleRepo, "approvals.manage"), handlers.UpdatePOApprovalRule)
+			replenishment.DELETE("/approval-rules/:id", middleware.RequirePermission(roleRepo, "approvals.manage"), handlers.DeletePOApprovalRule)
 			replenishment.POST("/purchase-orders/:poId/send", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.SendPurchaseOrder)
+			replenishment.GET("/purchase-orders/:poId/pdf", middleware.RequirePermission(roleRepo, "replenishment.read"), replenishmentHandler.DownloadPurchaseOrderPDF)
 			replenishment.GET("/purchase-orders/:poId", middleware.RequirePermission(roleRepo, "replenishment.read"), handlers.GetPurchaseOrder)
 			replenishment.PUT("/purchase-orders/:poId", middleware.RequirePermission(roleRepo, "inventory.write"), handlers.UpdatePurchaseOrder)
 			replenishment.POST("/purchase-orders/:poId/receive", middleware.RequirePermission(roleRepo, "inventory.write"), handlers.ReceivePurchaseOrder)
 			replenishment.POST("/purchase-orders/:poId/cancel", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.CancelPurchaseOrder)
+			replenishment.GET("/purchase-orders/:poId/change-proposals", middleware.RequirePermission(roleRepo, "replenishment.read"), supplierPortalHandler.ListPOChangeProposals)
+			replenishment.POST("/purchase-orders/:poId/change-proposals/:proposalId/accept", middleware.RequirePermission(roleRepo, "replenishment.write"), supplierPortalHandler.AcceptPOChangeProposal)
+			replenishment.POST("/purchase-orders/:poId/change-proposals/:proposalId/reject", middleware.RequirePermission(roleRepo, "replenishment.write"), supplierPortalHandler.RejectPOChangeProposal)
+			replenishment.GET("/purchase-orders/:poId/asns", middleware.RequirePermission(roleRepo, "replenishment.read"), supplierPortalHandler.ListPOShippingNotices)
+			replenishment.POST("/purchase-orders/:poId/landed-costs", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.CreateLandedCostCharge)
+			replenishment.GET("/purchase-orders/:poId/landed-costs", middleware.RequirePermission(roleRepo, "replenishment.read"), handlers.ListLandedCostCharges)
+			replenishment.POST("/landed-costs/:id/allocate", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.AllocateLandedCostCharge)
+			replenishment.DELETE("/landed-costs/:id", middleware.RequirePermission(roleRepo, "replenishment.write"), handlers.DeleteLandedCostCharge)
 			replenishment.POST("/purchase-orders", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.CreatePurchaseOrder)
 			replenishment.GET("/purchase-orders", middleware.RequirePermission(roleRepo, "replenishment.read"), replenishmentHandler.ListPurchaseOrders)
 			replenishment.POST("/returns", middleware.RequirePermission(roleRepo, "replenishment.write"), replenishmentHandler.CreatePurchaseReturn)
 			replenishment.GET("/returns", middleware.RequirePermission(roleRepo, "replenishment.read"), replenishmentHandler.ListPurchaseReturns)
 		}
 
+		// Payables (Supplier invoices, three-way matching and AP aging)
+		payables := api.Group("/payables")
+		{
+			payables.GET("/invoices", middleware.RequirePermission(roleRepo, "invoices.read"), invoiceHandler.ListSupplierInvoices)
+			payables.POST("/invoices", middleware.RequirePermission(roleRepo, "invoices.write"), invoiceHandler.CreateSupplierInvoice)
+			payables.GET("/invoices/:id", middleware.RequirePermission(roleRepo, "invoices.read"), invoiceHandler.GetSupplierInvoice)
+			payables.DELETE("/invoices/:id", middleware.RequirePermission(roleRepo, "invoices.write"), invoiceHandler.DeleteSupplierInvoice)
+			payables.POST("/invoices/:id/match", middleware.RequirePermission(roleRepo, "invoices.write"), invoiceHandler.RematchSupplierInvoice)
+			payables.POST("/invoices/:id/lines/:lineId/resolve", middleware.RequirePermission(roleRepo, "invoices.approve"), invoiceHandler.ResolveSupplierInvoiceLine)
+			payables.POST("/invoices/:id/approve", middleware.RequirePermission(roleRepo, "invoices.approve"), invoiceHandler.ApproveSupplierInvoice)
+			payables.POST("/invoices/:id/payments", middleware.RequirePermission(roleRepo, "invoices.approve"), invoiceHandler.RecordSupplierInvoicePayment)
+			payables.GET("/tolerance-rules", middleware.RequirePermission(roleRepo, "invoices.read"), invoiceHandler.ListInvoiceToleranceRules)
+			payables.POST("/tolerance-rules", middleware.RequirePermission(roleRepo, "invoices.approve"), invoiceHandler.CreateInvoiceToleranceRule)
+			payables.PUT("/tolerance-rules/:id", middleware.RequirePermission(roleRepo, "invoices.approve"), invoiceHandler.UpdateInvoiceToleranceRule)
+			payables.DELETE("/tolerance-rules/:id", middleware.RequirePermission(roleRepo, "invoices.approve"), invoiceHandler.DeleteInvoiceToleranceRule)
+			payables.GET("/aging", middleware.RequirePermission(roleRepo, "invoices.read"), invoiceHandler.GetAPAgingReport)
+		}
+
+		// Supplier portal
+		supplierPortal := api.Group("/supplier-portal")
+		{
+			supplierPortal.GET("/purchase-orders", middleware.RequirePermission(roleRepo, "portal.supplier"), supplierPortalHandler.ListPortalPurchaseOrders)
+			supplierPortal.GET("/purchase-orders/:poId", middleware.RequirePermission(roleRepo, "portal.supplier"), supplierPortalHandler.GetPortalPurchaseOrder)
+			supplierPortal.GET("/purchase-orders/:poId/pdf", middleware.RequirePermission(roleRepo, "portal.supplier"), supplierPortalHandler.DownloadPortalPurchaseOrderPDF)
+			supplierPortal.POST("/purchase-orders/:poId/confirm", middleware.RequirePermission(roleRepo, "portal.supplier"), supplierPortalHandler.ConfirmPortalPurchaseOrder)
+			supplierPortal.POST("/purchase-orders/:poId/propose-changes", middleware.RequirePermission(roleRepo, "portal.supplier"), supplierPortalHandler.ProposePurchaseOrderChanges)
+			supplierPortal.POST("/purchase-orders/:poId/asns", middleware.RequirePermission(roleRepo, "portal.supplier"), supplierPortalHandler.SubmitAdvanceShippingNotice)
+			supplierPortal.GET("/asns", middleware.RequirePermission(roleRepo, "portal.supplier"), supplierPortalHandler.ListPortalShippingNotices)
+			supplierPortal.GET("/scorecard", middleware.RequirePermission(roleRepo, "portal.supplier"), supplierPortalHandler.GetPortalScorecard)
+		}
+
 		// Sales
 		sales := api.Group("/sales")
 		{
@@ -273,6 +387,9 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 			reports.GET("/stock-aging", middleware.RequirePermission(roleRepo, "reports.inventory"), reportHandler.GetStockAgingReport)
 			reports.GET("/dead-stock", middleware.RequirePermission(roleRepo, "reports.inventory"), reportHandler.GetDeadStockReport)
 			reports.GET("/supplier-performance", middleware.RequirePermission(roleRepo, "reports.financial"), reportHandler.GetSupplierPerformanceReport)
+			reports.GET("/landed-cost", middleware.RequirePermission(roleRepo, "reports.financial"), reportHandler.GetLandedCostReport)
+			reports.GET("/inventory-valuation", middleware.RequirePermission(roleRepo, "reports.financial"), reportHandler.GetInventoryValuationReport)
+			reports.POST("/inventory-valuation/export", middleware.RequirePermission(roleRepo, "reports.financial"), reportHandler.ExportInventoryValuationReport)
 			reports.GET("/heatmap", middleware.RequirePermission(roleRepo, "reports.sales"), reportHandler.GetHourlySalesHeatmap)
 			reports.GET("/employee-sales", middleware.RequirePermission(roleRepo, "reports.sales"), reportHandler.GetSalesByEmployeeReport)
 			reports.GET("/category-drilldown", middleware.RequirePermission(roleRepo, "reports.sales"), reportHandler.GetCategoryDrillDownReport)
@@ -284,6 +401,7 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 			// Additional Business Intelligence Reports
 			reports.GET("/customer-insights", middleware.RequirePermission(roleRepo, "reports.sales"), reportHandler.GetCustomerInsightsReport)
 			reports.GET("/shrinkage", middleware.RequirePermission(roleRepo, "reports.inventory"), reportHandler.GetShrinkageReport)
+			reports.GET("/waste", middleware.RequirePermission(roleRepo, "reports.inventory"), reportHandler.GetWasteReport)
 			reports.GET("/returns-analysis", middleware.RequirePermission(roleRepo, "reports.sales"), reportHandler.GetCustomerReturnAnalysisReport)
 			reports.GET("/basket-analysis", middleware.RequirePermission(roleRepo, "reports.sales"), reportHandler.GetBasketAnalysisReport)
 			reports.GET("/product-performance", middleware.RequirePermission(roleRepo, "reports.sales"), reportHandler.GetProductPerformanceAnalytics)
@@ -296,6 +414,8 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 			alerts.GET("/:alertId", middleware.RequirePermission(roleRepo, "alerts.view"), handlers.GetAlert)
 			alerts.PATCH("/:alertId/resolve", middleware.RequirePermission(roleRepo, "alerts.manage"), handlers.ResolveAlert)
 			alerts.PUT("/products/:productId/settings", middleware.RequirePermission(roleRepo, "alerts.manage"), handlers.PutProductAlertSettings)
+			alerts.GET("/products/:productId/settings", middleware.RequirePermission(roleRepo, "alerts.view"), handlers.ListProductAlertSettings)
+			alerts.DELETE("/products/:productId/settings/:locationId", middleware.RequirePermission(roleRepo, "alerts.manage"), handlers.DeleteProductAlertSettings)
 			alerts.PUT("/users/:userId/notification-settings", middleware.RequirePermission(roleRepo, "settings.manage"), handlers.PutUserNotificationSettings)
 			alerts.POST("/check", func(c *gin.Context) {
 				handlers.CheckAndTriggerAlerts()
@@ -318,15 +438,49 @@ func SetupRouter(cfg *config.Config, hub *websocket.Hub, jobRepo *repository.Job
 			bulk.POST("/products/import", middleware.RequirePermission(roleRepo, "bulk.import"), bulkHandler.UploadProductImport)
 			bulk.GET("/products/import/:jobId/status", middleware.RequirePermission(roleRepo, "bulk.import"), bulkHandler.GetBulkImportStatus)
 			bulk.POST("/products/import/:jobId/confirm", middleware.RequirePermission(roleRepo, "bulk.import"), bulkHandler.ConfirmBulkImport)
+			bulk.GET("/supplier-prices/template", middleware.RequirePermission(roleRepo, "bulk.import"), bulkHandler.GetSupplierPriceImportTemplate)
+			bulk.POST("/supplier-prices/import", middleware.RequirePermission(roleRepo, "bulk.import"), bulkHandler.UploadSupplierPriceImport)
 			bulk.GET("/products/export", middleware.RequirePermission(roleRepo, "bulk.export"), bulkHandler.ExportProducts)
 			bulk.GET("/jobs", middleware.RequirePermission(roleRepo, "bulk.import"), bulkHandler.ListBulkJobs)
 			bulk.GET("/files/:bucket/:object", middleware.RequirePermission(roleRepo, "bulk.export"), bulkHandler.DownloadFile)
 		}
 
-		// Inventory (Transfers)
+		// Inventory (Transfers and rebalancing, availability, expiry and write-offs, ledger integrity, bins and picking)
 		inventory := api.Group("/inventory")
 		{
 			inventory.POST("/transfers", middleware.RequirePermission(roleRepo, "products.write"), handlers.CreateStockTransfer)
+			inventory.GET("/transfers", middleware.RequirePermission(roleRepo, "inventory.read"), handlers.ListStockTransfers)
+			inventory.POST("/transfers/:id/complete", middleware.RequirePermission(roleRepo, "products.write"), handlers.CompleteStockTransfer)
+			inventory.POST("/transfers/rebalance", middleware.RequirePermission(roleRepo, "replenishment.write"), rebalancingHandler.GenerateRebalancingDrafts)
+			inventory.POST("/transfers/drafts/approve", middleware.RequirePermission(roleRepo, "transfers.approve"), rebalancingHandler.ApproveTransferDrafts)
+			inventory.POST("/transfers/drafts/cancel", middleware.RequirePermission(roleRepo, "transfers.approve"), rebalancingHandler.CancelTransferDrafts)
+			inventory.GET("/availability", middleware.RequirePermission(roleRepo, "products.read"), reservationHandler.GetStockAvailability)
+			inventory.GET("/days-of-cover", middleware.RequirePermission(roleRepo, "products.read"), stockHistoryHandler.GetDaysOfCover)
+			inventory.GET("/expiry-rules", middleware.RequirePermission(roleRepo, "inventory.read"), expiryHandler.ListExpiryMarkdownRules)
+			inventory.POST("/expiry-rules", middleware.RequirePermission(roleRepo, "expiry.manage"), expiryHandler.CreateExpiryMarkdownRule)
+			inventory.POST("/expiry-rules/apply", middleware.RequirePermission(roleRepo, "expiry.manage"), expiryHandler.RunExpiryChecks)
+			inventory.PUT("/expiry-rules/:id", middleware.RequirePermission(roleRepo, "expiry.manage"), expiryHandler.UpdateExpiryMarkdownRule)
+			inventory.DELETE("/expiry-rules/:id", middleware.RequirePermission(roleRepo, "expiry.manage"), expiryHandler.DeleteExpiryMarkdownRule)
+			inventory.POST("/write-offs", middleware.RequirePermission(roleRepo, "inventory.write"), expiryHandler.RequestStockWriteOff)
+			inventory.GET("/write-offs", middleware.RequirePermission(roleRepo, "inventory.read"), expiryHandler.ListStockWriteOffs)
+			inventory.POST("/write-offs/:id/approve", middleware.RequirePermission(roleRepo, "writeoffs.approve"), expiryHandler.ApproveStockWriteOff)
+			inventory.POST("/write-offs/:id/reject", middleware.RequirePermission(roleRepo, "writeoffs.approve"), expiryHandler.RejectStockWriteOff)
+			inventory.POST("/bin-moves", middleware.RequirePermission(roleRepo, "inventory.write"), handlers.MoveStockBetweenBins)
+			inventory.GET("/bin-moves", middleware.RequirePermission(roleRepo, "inventory.read"), handlers.ListBinMoves)
+			inventory.POST("/pick-lists", middleware.RequirePermission(roleRepo, "inventory.read"), handlers.CreatePickList)
+			inventory.POST("/ledger/reconciliations", middleware.RequirePermission(roleRepo, "ledger.reconcile"), ledgerHandler.RunLedgerReconciliation)
+			inventory.GET("/ledger/reconciliations", middleware.RequirePermission(roleRepo, "ledger.reconcile"), ledgerHandler.ListLedgerReconciliations)
+			inventory.GET("/ledger/reconciliations/:id", middleware.RequirePermission(roleRepo, "ledger.reconcile"), ledgerHandler.GetLedgerReconciliation)
+			inventory.POST("/ledger/reconciliations/:id/approve", middleware.RequirePermission(roleRepo, "ledger.approve"), ledgerHandler.ApproveLedgerReconciliation)
+			inventory.POST("/ledger/reconciliations/:id/reject", middleware.RequirePermission(roleRepo, "ledger.approve"), ledgerHandler.RejectLedgerReconciliation)
+		}
+
+		// Stock reservations
+		reservations := api.Group("/reservations")
+		{
+			reservations.POST("", middleware.RequirePermission(roleRepo, "inventory.reserve"), reservationHandler.CreateStockReservation)
+			reservations.GET("", middleware.RequirePermission(roleRepo, "products.read"), reservationHandler.ListStockReservations)
+			reservations.POST("/:id/release", middleware.RequirePermission(roleRepo, "inventory.reserve"), reservationHandler.ReleaseStockReservation)
 		}
 
 		// Users - Protected mainly by users.manage
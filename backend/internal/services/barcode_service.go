@@ -2,17 +2,44 @@ package services
 
 import (
 	"bytes"
+	"errors"
 	"image/png"
 
 	"github.com/boombuler/barcode"
 	"github.com/boombuler/barcode/code128"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 	"inventory/backend/internal/domain"
 	"inventory/backend/internal/repository"
 )
 
 type BarcodeService interface {
 	GenerateBarcode(sku string, productID uint64) (*bytes.Buffer, error)
-	LookupProductByBarcode(barcodeValue string) (*domain.Product, error)
+	LookupProductByBarcode(barcodeValue string) (*BarcodeLookupResult, error)
+	ListScalePatterns() ([]domain.ScaleBarcodePattern, error)
+	GetScalePattern(id uint) (*domain.ScaleBarcodePattern, error)
+	CreateScalePattern(pattern *domain.ScaleBarcodePattern) error
+	UpdateScalePattern(pattern *domain.ScaleBarcodePattern) error
+	DeleteScalePattern(id uint) error
+}
+
+// BarcodeLookupResult is the product resolved from a scan. The product fields are
+// embedded so exact-match lookups keep their original response shape.
+type BarcodeLookupResult struct {
+	*domain.Product
+	ScaleBarcode *ScaleBarcodeInfo `json:"scaleBarcode,omitempty"`
+}
+
+// ScaleBarcodeInfo carries the values decoded from a weight- or price-embedded barcode
+// so the POS can add the line with the right quantity and price. The POS sends the barcode
+// back with the checkout line, where price-embedded labels are charged their printed price.
+type ScaleBarcodeInfo struct {
+	PatternID  uint    `json:"patternId"`
+	PLU        string  `json:"plu"`
+	ValueType  string  `json:"valueType"`
+	Quantity   float64 `json:"quantity"`
+	UnitPrice  float64 `json:"unitPrice"`
+	TotalPrice float64 `json:"totalPrice"`
 }
 
 type barcodeService struct {
@@ -62,6 +89,86 @@ func (s *barcodeService) GenerateBarcode(sku string, productID uint64) (*bytes.B
 	return &buf, nil
 }
 
-func (s *barcodeService) LookupProductByBarcode(barcodeValue string) (*domain.Product, error) {
-	return s.repo.GetProductByBarcode(barcodeValue)
+// LookupProductByBarcode resolves a scanned value by exact SKU/UPC match first and falls
+// back to the configured scale barcode patterns. gorm.ErrRecordNotFound is returned when
+// neither resolves a product.
+func (s *barcodeService) LookupProductByBarcode(barcodeValue string) (*BarcodeLookupResult, error) {
+	product, err := s.repo.GetProductByBarcode(barcodeValue)
+	if err == nil {
+		return &BarcodeLookupResult{Product: product}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	patterns, err := s.repo.GetActiveScalePatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range patterns {
+		pattern := &patterns[i]
+		if !pattern.Matches(barcodeValue) {
+			continue
+		}
+		decoded, err := pattern.Decode(barcodeValue)
+		if err != nil {
+			logrus.Debugf("Scale pattern %s rejected barcode %s: %v", pattern.Name, barcodeValue, err)
+			continue
+		}
+
+		product, err := s.repo.GetProductByPLU(decoded.PLU)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		return &BarcodeLookupResult{
+			Product:      product,
+			ScaleBarcode: buildScaleBarcodeInfo(pattern, decoded, product),
+		}, nil
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+// buildScaleBarcodeInfo derives the cart line from the embedded value (see ScaleBarcodeValue.LineFor).
+func buildScaleBarcodeInfo(pattern *domain.ScaleBarcodePattern, decoded *domain.ScaleBarcodeValue, product *domain.Product) *ScaleBarcodeInfo {
+	line := decoded.LineFor(product)
+	return &ScaleBarcodeInfo{
+		PatternID:  pattern.ID,
+		PLU:        decoded.PLU,
+		ValueType:  decoded.ValueType,
+		Quantity:   line.Quantity,
+		UnitPrice:  line.UnitPrice,
+		TotalPrice: line.TotalPrice,
+	}
+}
+
+func (s *barcodeService) ListScalePatterns() ([]domain.ScaleBarcodePattern, error) {
+	return s.repo.ListScalePatterns()
+}
+
+func (s *barcodeService) GetScalePattern(id uint) (*domain.ScaleBarcodePattern, error) {
+	return s.repo.GetScalePatternByID(id)
+}
+
+func (s *barcodeService) CreateScalePattern(pattern *domain.ScaleBarcodePattern) error {
+	if err := pattern.Validate(); err != nil {
+		return err
+	}
+	return s.repo.CreateScalePattern(pattern)
+}
+
+func (s *barcodeService) UpdateScalePattern(pattern *domain.ScaleBarcodePattern) error {
+	if err := pattern.Validate(); err != nil {
+		return err
+	}
+	return s.repo.UpdateScalePattern(pattern)
+}
+
+func (s *barcodeService) DeleteScalePattern(id uint) error {
+	return s.repo.DeleteScalePattern(id)
 }
@@ -12,14 +12,16 @@ import (
 
 // BulkImportService handles the logic of processing a bulk import file.
 type BulkImportService struct {
+	productRepo  *repository.ProductRepository
 	categoryRepo *repository.CategoryRepository
 	supplierRepo *repository.SupplierRepository
 	locationRepo *repository.LocationRepository
 }
 
 // NewBulkImportService creates a new BulkImportService.
-func NewBulkImportService(categoryRepo *repository.CategoryRepository, supplierRepo *repository.SupplierRepository, locationRepo *repository.LocationRepository) *BulkImportService {
+func NewBulkImportService(productRepo *repository.ProductRepository, categoryRepo *repository.CategoryRepository, supplierRepo *repository.SupplierRepository, locationRepo *repository.LocationRepository) *BulkImportService {
 	return &BulkImportService{
+		productRepo:  productRepo,
 		categoryRepo: categoryRepo,
 		supplierRepo: supplierRepo,
 		locationRepo: locationRepo,
@@ -305,3 +307,138 @@ func (s *BulkImportService) buildOptimizedCache(file io.ReadSeeker) (*entityCach
 
 	return cache, nil
 }
+
+// SupplierPriceImportResult holds the result of validating a supplier price list.
+type SupplierPriceImportResult struct {
+	TotalRecords   int                      `json:"totalRecords"`
+	ValidRecords   int                      `json:"validRecords"`
+	InvalidRecords int                      `json:"invalidRecords"`
+	Errors         []string                 `json:"errors"`
+	ValidEntries   []domain.ProductSupplier `json:"validEntries"`
+}
+
+// ProcessSupplierPriceImport reads a supplier price list CSV and produces a validation result. Each row
+// is a catalog entry for an existing product (by SKU) from an existing supplier (by name).
+func (s *BulkImportService) ProcessSupplierPriceImport(file io.Reader) (*SupplierPriceImportResult, error) {
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price list: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("failed to read header row: %w", io.EOF)
+	}
+	rows = rows[1:]
+
+	// Look up only the products and suppliers the file references
+	skuSet := make(map[string]bool)
+	supplierSet := make(map[string]bool)
+	for _, row := range rows {
+		if len(row) >= 2 {
+			skuSet[strings.TrimSpace(row[0])] = true
+			supplierSet[strings.ToLower(strings.TrimSpace(row[1]))] = true
+		}
+	}
+	productIDs := make(map[string]uint)
+	if len(skuSet) > 0 {
+		var products []domain.Product
+		if err := s.productRepo.GetBySKUs(mapKeys(skuSet), &products); err != nil {
+			return nil, fmt.Errorf("failed to fetch products: %w", err)
+		}
+		for _, p := range products {
+			productIDs[p.SKU] = p.ID
+		}
+	}
+	supplierIDs := make(map[string]uint)
+	if len(supplierSet) > 0 {
+		var suppliers []domain.Supplier
+		if err := s.supplierRepo.GetByNamesIgnoreCase(mapKeys(supplierSet), &suppliers); err != nil {
+			return nil, fmt.Errorf("failed to fetch suppliers: %w", err)
+		}
+		for _, sup := range suppliers {
+			supplierIDs[strings.ToLower(sup.Name)] = sup.ID
+		}
+	}
+
+	result := &SupplierPriceImportResult{}
+	seen := make(map[[2]uint]bool)
+	for i, row := range rows {
+		result.TotalRecords++
+		entry, validationErrors := parseSupplierPriceRow(row, productIDs, supplierIDs)
+		if entry != nil && seen[[2]uint{entry.ProductID, entry.SupplierID}] {
+			validationErrors = append(validationErrors, "duplicate SKU and supplier")
+		}
+		if len(validationErrors) > 0 {
+			result.InvalidRecords++
+			for _, e := range validationErrors {
+				result.Errors = append(result.Errors, fmt.Sprintf("Row %d: %s", i+2, e))
+			}
+			continue
+		}
+		seen[[2]uint{entry.ProductID, entry.SupplierID}] = true
+		result.ValidRecords++
+		result.ValidEntries = append(result.ValidEntries, *entry)
+	}
+	return result, nil
+}
+
+// parseSupplierPriceRow validates a single price list row and maps it to a catalog entry.
+// Columns: SKU, SupplierName, SupplierSKU, UnitCost, MinimumOrderQuantity, PackSize, LeadTimeDays, Preferred.
+func parseSupplierPriceRow(row []string, productIDs, supplierIDs map[string]uint) (*domain.ProductSupplier, []string) {
+	if len(row) != 8 {
+		return nil, []string{"invalid number of columns"}
+	}
+	for i := range row {
+		row[i] = strings.TrimSpace(row[i])
+	}
+
+	var errors []string
+	entry := &domain.ProductSupplier{
+		SupplierSKU:  row[2],
+		LeadTimeDays: domain.DefaultLeadTimeDays,
+	}
+	var ok bool
+	if entry.ProductID, ok = productIDs[row[0]]; !ok {
+		errors = append(errors, fmt.Sprintf("unknown product SKU '%s'", row[0]))
+	}
+	if entry.SupplierID, ok = supplierIDs[strings.ToLower(row[1])]; !ok {
+		errors = append(errors, fmt.Sprintf("unknown supplier '%s'", row[1]))
+	}
+
+	var err error
+	if entry.UnitCost, err = strconv.ParseFloat(row[3], 64); err != nil || entry.UnitCost <= 0 {
+		errors = append(errors, "UnitCost must be a positive number")
+	}
+	if row[4] != "" {
+		if entry.MinimumOrderQuantity, err = strconv.ParseFloat(row[4], 64); err != nil || entry.MinimumOrderQuantity < 0 {
+			errors = append(errors, "MinimumOrderQuantity must be zero or more")
+		}
+	}
+	if row[5] != "" {
+		if entry.PackSize, err = strconv.ParseFloat(row[5], 64); err != nil || entry.PackSize < 0 {
+			errors = append(errors, "PackSize must be zero or more")
+		}
+	}
+	if row[6] != "" {
+		if entry.LeadTimeDays, err = strconv.Atoi(row[6]); err != nil || entry.LeadTimeDays < 0 {
+			errors = append(errors, "LeadTimeDays must be a whole number of days")
+		}
+	}
+	if row[7] != "" {
+		if entry.IsPreferred, err = strconv.ParseBool(row[7]); err != nil {
+			errors = append(errors, "Preferred must be true or false")
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+	return entry, nil
+}
+
+func mapKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -1,15 +1,20 @@
 package services
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
 	"inventory/backend/internal/config"
 	"inventory/backend/internal/domain"
+	"mime/multipart"
 	"net/smtp"
+	"net/textproto"
 	"strings"
 )
 
 type EmailService interface {
-	SendPurchaseOrderEmail(po domain.PurchaseOrder) error
+	// SendPurchaseOrderEmail emails the order to its supplier, with the PDF attached when one is given.
+	SendPurchaseOrderEmail(po domain.PurchaseOrder, pdf *PurchaseOrderDocument) error
 }
 
 type emailService struct {
@@ -20,7 +25,7 @@ func NewEmailService(cfg *config.Config) EmailService {
 	return &emailService{cfg: cfg}
 }
 
-func (s *emailService) SendPurchaseOrderEmail(po domain.PurchaseOrder) error {
+func (s *emailService) SendPurchaseOrderEmail(po domain.PurchaseOrder, pdf *PurchaseOrderDocument) error {
 	if s.cfg.SMTPHost == "" {
 		return fmt.Errorf("SMTP host not configured")
 	}
@@ -43,7 +48,11 @@ func (s *emailService) SendPurchaseOrderEmail(po domain.PurchaseOrder) error {
 		if productName == "" {
 			productName = fmt.Sprintf("Product ID %d", item.ProductID)
 		}
-		body.WriteString(fmt.Sprintf("- %s (SKU: %s): %d units @ $%.2f\n", productName, item.Product.SKU, item.OrderedQuantity, item.UnitPrice))
+		unit := item.Product.UnitOfMeasure
+		if unit == "" {
+			unit = "units"
+		}
+		body.WriteString(fmt.Sprintf("- %s (SKU: %s): %g %s @ $%.2f\n", productName, item.Product.SKU, item.OrderedQuantity, unit, item.UnitPrice))
 	}
 
 	body.WriteString("\nExpected Delivery: ")
@@ -56,10 +65,10 @@ func (s *emailService) SendPurchaseOrderEmail(po domain.PurchaseOrder) error {
 	body.WriteString("\n\nThank you,\nQuantify Inventory Team")
 
 	// Construct Message
-	msg := []byte(fmt.Sprintf("To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"\r\n"+
-		"%s\r\n", to[0], subject, body.String()))
+	msg, err := buildPurchaseOrderMessage(s.cfg.SMTPSender, to[0], subject, body.String(), pdf)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
 
 	// Send Email
 	auth := smtp.PlainAuth("", s.cfg.SMTPUser, s.cfg.SMTPPass, s.cfg.SMTPHost)
@@ -71,3 +80,52 @@ func (s *emailService) SendPurchaseOrderEmail(po domain.PurchaseOrder) error {
 
 	return nil
 }
+
+// buildPurchaseOrderMessage builds a plain-text message, or a multipart/mixed one carrying the PDF.
+func buildPurchaseOrderMessage(from, to, subject, body string, pdf *PurchaseOrderDocument) ([]byte, error) {
+	var msg bytes.Buffer
+	msg.WriteString(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\n", from, to, subject))
+	if pdf == nil || len(pdf.Content) == 0 {
+		msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n\r\n")
+		msg.WriteString(body + "\r\n")
+		return msg.Bytes(), nil
+	}
+
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+	msg.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\r\n\r\n", writer.Boundary()))
+
+	text, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=\"utf-8\""}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := text.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n") + "\r\n")); err != nil {
+		return nil, err
+	}
+
+	attachment, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {fmt.Sprintf("application/pdf; name=%q", pdf.FileName)},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", pdf.FileName)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// RFC 2045 limits encoded lines to 76 characters
+	encoded := base64.StdEncoding.EncodeToString(pdf.Content)
+	for len(encoded) > 76 {
+		if _, err := attachment.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return nil, err
+		}
+		encoded = encoded[76:]
+	}
+	if _, err := attachment.Write([]byte(encoded + "\r\n")); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	msg.Write(parts.Bytes())
+	return msg.Bytes(), nil
+}
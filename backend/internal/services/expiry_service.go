@@ -0,0 +1,410 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrWriteOffState is returned when reviewing a write-off that is no longer pending.
+	ErrWriteOffState = errors.New("write-off is not pending")
+	// ErrWriteOffQuantity is returned when a write-off asks for more than its batch holds.
+	ErrWriteOffQuantity = errors.New("invalid write-off quantity")
+)
+
+type ExpiryService interface {
+	ListRules() ([]domain.ExpiryMarkdownRule, error)
+	CreateRule(req *requests.ExpiryMarkdownRuleRequest) (*domain.ExpiryMarkdownRule, error)
+	UpdateRule(id uint, req *requests.ExpiryMarkdownRuleRequest) (*domain.ExpiryMarkdownRule, error)
+	DeleteRule(id uint) error
+	ApplyMarkdownRules() (int, error)
+	QuarantineExpiredBatches() (int, error)
+	RequestWriteOff(req *requests.StockWriteOffRequest, userID uint) (*domain.StockWriteOff, error)
+	ListWriteOffs(status string) ([]domain.StockWriteOff, error)
+	ApproveWriteOff(id uint, req *requests.ReviewStockWriteOffRequest, userID uint) (*domain.StockWriteOff, error)
+	RejectWriteOff(id uint, req *requests.ReviewStockWriteOffRequest, userID uint) (*domain.StockWriteOff, error)
+}
+
+type expiryService struct {
+	db *gorm.DB
+}
+
+func NewExpiryService(db *gorm.DB) ExpiryService {
+	return &expiryService{db: db}
+}
+
+func (s *expiryService) ListRules() ([]domain.ExpiryMarkdownRule, error) {
+	var rules []domain.ExpiryMarkdownRule
+	if err := s.db.Preload("Category").Order("days_before_expiry desc, id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *expiryService) CreateRule(req *requests.ExpiryMarkdownRuleRequest) (*domain.ExpiryMarkdownRule, error) {
+	rule := domain.ExpiryMarkdownRule{IsActive: true}
+	applyRuleRequest(&rule, req)
+	if err := s.db.Create(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *expiryService) UpdateRule(id uint, req *requests.ExpiryMarkdownRuleRequest) (*domain.ExpiryMarkdownRule, error) {
+	var rule domain.ExpiryMarkdownRule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	applyRuleRequest(&rule, req)
+	if err := s.db.Save(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteRule removes the rule and ends the markdowns it is still running.
+func (s *expiryService) DeleteRule(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&domain.ExpiryMarkdownRule{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return endMarkdowns(tx, "markdown_rule_id = ?", id)
+	})
+}
+
+func applyRuleRequest(rule *domain.ExpiryMarkdownRule, req *requests.ExpiryMarkdownRuleRequest) {
+	rule.Name = req.Name
+	rule.DaysBeforeExpiry = req.DaysBeforeExpiry
+	rule.DiscountType = req.DiscountType
+	rule.DiscountValue = req.DiscountValue
+	rule.CategoryID = req.CategoryID
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+}
+
+// endMarkdowns deactivates the running promotions matching the condition.
+func endMarkdowns(tx *gorm.DB, query string, args ...interface{}) error {
+	return tx.Model(&domain.Promotion{}).Where(query, args...).
+		Where("is_active = ?", true).
+		Updates(map[string]interface{}{"is_active": false, "end_date": time.Now()}).Error
+}
+
+// ApplyMarkdownRules creates a batch markdown promotion for every sellable batch that has entered
+// a rule's window, running until the batch expires. A batch moving into a steeper tier has its
+// previous rule markdown replaced; markdowns set up by hand are left alone.
+func (s *expiryService) ApplyMarkdownRules() (int, error) {
+	var rules []domain.ExpiryMarkdownRule
+	if err := s.db.Where("is_active = ?", true).Order("days_before_expiry asc, id asc").Find(&rules).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch markdown rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return 0, nil
+	}
+	longest := 0
+	for _, rule := range rules {
+		if rule.DaysBeforeExpiry > longest {
+			longest = rule.DaysBeforeExpiry
+		}
+	}
+
+	now := time.Now()
+	var batches []domain.Batch
+	if err := s.db.Preload("Product").
+		Where("quantity > 0 AND quarantined_at IS NULL AND expiry_date > ? AND expiry_date <= ?", now, now.AddDate(0, 0, longest)).
+		Find(&batches).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch expiring batches: %w", err)
+	}
+
+	created := 0
+	for i := range batches {
+		batch := &batches[i]
+		var rule *domain.ExpiryMarkdownRule
+		for j := range rules {
+			// Rules are ordered most urgent first
+			if rules[j].Matches(&batch.Product, *batch.ExpiryDate, now) {
+				rule = &rules[j]
+				break
+			}
+		}
+		if rule == nil {
+			continue
+		}
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var current []domain.Promotion
+			if err := tx.Where("batch_id = ? AND is_active = ? AND end_date >= ?", batch.ID, true, now).
+				Find(&current).Error; err != nil {
+				return err
+			}
+			for _, promotion := range current {
+				if promotion.MarkdownRuleID == nil || *promotion.MarkdownRuleID == rule.ID {
+					return nil // Manual markdown or already in this tier
+				}
+			}
+			if len(current) > 0 {
+				if err := endMarkdowns(tx, "batch_id = ?", batch.ID); err != nil {
+					return err
+				}
+			}
+
+			productID, batchID, ruleID := batch.ProductID, batch.ID, rule.ID
+			markdown := domain.Promotion{
+				Name:           fmt.Sprintf("%s: %s batch %s", rule.Name, batch.Product.Name, batch.BatchNumber),
+				Description:    fmt.Sprintf("Expiry markdown for batch %s, expiring %s", batch.BatchNumber, batch.ExpiryDate.Format("2006-01-02")),
+				DiscountType:   rule.DiscountType,
+				DiscountValue:  rule.DiscountValue,
+				StartDate:      now,
+				EndDate:        *batch.ExpiryDate,
+				IsActive:       true,
+				ProductID:      &productID,
+				BatchID:        &batchID,
+				MarkdownRuleID: &ruleID,
+			}
+			if err := tx.Create(&markdown).Error; err != nil {
+				return err
+			}
+			created++
+			return nil
+		})
+		if err != nil {
+			return created, fmt.Errorf("failed to mark down batch %d: %w", batch.ID, err)
+		}
+	}
+	return created, nil
+}
+
+// QuarantineExpiredBatches takes expired batches out of sale, ends their markdowns and raises a
+// pending EXPIRED write-off for each, to be approved by a manager.
+func (s *expiryService) QuarantineExpiredBatches() (int, error) {
+	quarantined := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var batches []domain.Batch
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("quantity > 0 AND quarantined_at IS NULL AND expiry_date <= ?", now).
+			Order("id").Find(&batches).Error; err != nil {
+			return fmt.Errorf("failed to fetch expired batches: %w", err)
+		}
+
+		for i := range batches {
+			batch := &batches[i]
+			if err := tx.Model(batch).Update("quarantined_at", now).Error; err != nil {
+				return fmt.Errorf("failed to quarantine batch %d: %w", batch.ID, err)
+			}
+			if err := endMarkdowns(tx, "batch_id = ?", batch.ID); err != nil {
+				return fmt.Errorf("failed to end markdowns for batch %d: %w", batch.ID, err)
+			}
+			writeOff := domain.StockWriteOff{
+				ProductID:  batch.ProductID,
+				LocationID: batch.LocationID,
+				BatchID:    batch.ID,
+				Quantity:   batch.Quantity,
+				ReasonCode: "EXPIRED",
+				Status:     domain.WriteOffStatusPending,
+				Notes:      fmt.Sprintf("Batch %s expired on %s", batch.BatchNumber, batch.ExpiryDate.Format("2006-01-02")),
+			}
+			if err := tx.Create(&writeOff).Error; err != nil {
+				return fmt.Errorf("failed to raise write-off for batch %d: %w", batch.ID, err)
+			}
+			quarantined++
+		}
+		return nil
+	})
+	return quarantined, err
+}
+
+// RequestWriteOff raises a pending write-off for damaged, spoiled or expired stock in a batch.
+// Serialized batches are written off whole, since their units cannot be told apart here.
+func (s *expiryService) RequestWriteOff(req *requests.StockWriteOffRequest, userID uint) (*domain.StockWriteOff, error) {
+	var batch domain.Batch
+	if err := s.db.Preload("Product").First(&batch, req.BatchID).Error; err != nil {
+		return nil, err
+	}
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = batch.Quantity
+	}
+	switch {
+	case quantity <= 0 || quantity > batch.Quantity:
+		return nil, fmt.Errorf("%w: batch %s holds %g %s", ErrWriteOffQuantity, batch.BatchNumber, batch.Quantity, batch.Product.UnitOfMeasure)
+	case !batch.Product.IsValidQuantity(quantity):
+		return nil, fmt.Errorf("%w: at most %d decimal places allowed", ErrWriteOffQuantity, batch.Product.QuantityPrecision)
+	case batch.Product.IsSerialized && quantity != batch.Quantity:
+		return nil, fmt.Errorf("%w: serialized batches are written off whole", ErrWriteOffQuantity)
+	}
+
+	writeOff := domain.StockWriteOff{
+		ProductID:   batch.ProductID,
+		LocationID:  batch.LocationID,
+		BatchID:     batch.ID,
+		Quantity:    quantity,
+		ReasonCode:  req.ReasonCode,
+		Status:      domain.WriteOffStatusPending,
+		Notes:       req.Notes,
+		RequestedBy: &userID,
+	}
+	if err := s.db.Create(&writeOff).Error; err != nil {
+		return nil, err
+	}
+	return &writeOff, nil
+}
+
+func (s *expiryService) ListWriteOffs(status string) ([]domain.StockWriteOff, error) {
+	var writeOffs []domain.StockWriteOff
+	query := s.db.Preload("Product").Preload("Location").Preload("Batch").Order("created_at desc").Limit(200)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&writeOffs).Error; err != nil {
+		return nil, err
+	}
+	return writeOffs, nil
+}
+
+// ApproveWriteOff takes the stock out of its batch and posts a stock-out adjustment with the
+// write-off's reason, valued at the batch's cost.
+func (s *expiryService) ApproveWriteOff(id uint, req *requests.ReviewStockWriteOffRequest, userID uint) (*domain.StockWriteOff, error) {
+	var writeOff domain.StockWriteOff
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingWriteOff(tx, id, &writeOff); err != nil {
+			return err
+		}
+		products, err := repository.LockProducts(tx, []uint{writeOff.ProductID})
+		if err != nil {
+			return fmt.Errorf("failed to lock product: %w", err)
+		}
+		if len(products) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		product := products[0]
+
+		var batch domain.Batch
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&batch, writeOff.BatchID).Error; err != nil {
+			return fmt.Errorf("failed to lock batch: %w", err)
+		}
+		if batch.Quantity < writeOff.Quantity {
+			return fmt.Errorf("%w: batch %s now holds %g %s", ErrWriteOffQuantity, batch.BatchNumber, batch.Quantity, product.UnitOfMeasure)
+		}
+
+		previousQuantity, err := repository.GetLocationStock(tx, product.ID, batch.LocationID)
+		if err != nil {
+			return fmt.Errorf("failed to read stock: %w", err)
+		}
+		previous := batch.Quantity
+		batch.Quantity = product.RoundQuantity(batch.Quantity - writeOff.Quantity)
+		if err := repository.SaveBatchQuantity(tx, &batch, previous); err != nil {
+			return err
+		}
+
+		if product.IsSerialized {
+			var units []domain.SerialNumber
+			if err := tx.Where("batch_id = ? AND status = ?", batch.ID, domain.SerialStatusInStock).Find(&units).Error; err != nil {
+				return fmt.Errorf("failed to load serial numbers: %w", err)
+			}
+			event := domain.SerialNumberEvent{EventType: "REMOVED", ReferenceType: "WRITE_OFF", ReferenceID: writeOff.ID, Notes: writeOff.ReasonCode, UserID: userID}
+			if err := repository.TransitionSerialNumbers(tx, units, map[string]interface{}{"status": domain.SerialStatusRemoved}, event); err != nil {
+				return fmt.Errorf("failed to remove serial numbers: %w", err)
+			}
+		}
+
+		draws := []domain.CostLayerDraw{{BatchID: batch.ID, Quantity: writeOff.Quantity, UnitCost: batch.LayerCost(&product)}}
+		totalCost := product.IssueCost(draws)
+		unitCost := domain.UnitCostOf(totalCost, writeOff.Quantity)
+		adjustment := domain.StockAdjustment{
+			ProductID:        product.ID,
+			LocationID:       batch.LocationID,
+			Type:             "STOCK_OUT",
+			Quantity:         writeOff.Quantity,
+			ReasonCode:       writeOff.ReasonCode,
+			Notes:            fmt.Sprintf("Write-off #%d of batch %s", writeOff.ID, batch.BatchNumber),
+			AdjustedBy:       userID,
+			AdjustedAt:       time.Now(),
+			PreviousQuantity: previousQuantity,
+			NewQuantity:      product.RoundQuantity(previousQuantity - writeOff.Quantity),
+			UnitCost:         &unitCost,
+		}
+		if err := tx.Create(&adjustment).Error; err != nil {
+			return fmt.Errorf("failed to post write-off adjustment: %w", err)
+		}
+
+		return reviewWriteOff(tx, &writeOff, domain.WriteOffStatusApproved, req, userID, map[string]interface{}{
+			"total_cost":          domain.RoundMoney(totalCost),
+			"stock_adjustment_id": adjustment.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &writeOff, nil
+}
+
+// RejectWriteOff closes the write-off without moving stock. A rejected expiry write-off leaves
+// its batch quarantined.
+func (s *expiryService) RejectWriteOff(id uint, req *requests.ReviewStockWriteOffRequest, userID uint) (*domain.StockWriteOff, error) {
+	var writeOff domain.StockWriteOff
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingWriteOff(tx, id, &writeOff); err != nil {
+			return err
+		}
+		return reviewWriteOff(tx, &writeOff, domain.WriteOffStatusRejected, req, userID, map[string]interface{}{})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &writeOff, nil
+}
+
+func lockPendingWriteOff(tx *gorm.DB, id uint, writeOff *domain.StockWriteOff) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(writeOff, id).Error; err != nil {
+		return err
+	}
+	if writeOff.Status != domain.WriteOffStatusPending {
+		return fmt.Errorf("%w: write-off is %s", ErrWriteOffState, writeOff.Status)
+	}
+	return nil
+}
+
+func reviewWriteOff(tx *gorm.DB, writeOff *domain.StockWriteOff, status string, req *requests.ReviewStockWriteOffRequest, userID uint, updates map[string]interface{}) error {
+	now := time.Now()
+	updates["status"] = status
+	updates["reviewed_by"] = userID
+	updates["reviewed_at"] = now
+	if req.Notes != "" {
+		updates["notes"] = req.Notes
+	}
+	if err := tx.Model(writeOff).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to update write-off: %w", err)
+	}
+	return tx.Preload("Product").Preload("Location").Preload("Batch").First(writeOff, writeOff.ID).Error
+}
+
+// RunScheduledExpiryChecks quarantines expired batches, then marks down those nearing expiry.
+func RunScheduledExpiryChecks(s ExpiryService) {
+	quarantined, err := s.QuarantineExpiredBatches()
+	if err != nil {
+		logrus.Errorf("Expiry quarantine failed: %v", err)
+	} else if quarantined > 0 {
+		logrus.Warnf("Quarantined %d expired batches; write-offs await approval", quarantined)
+	}
+
+	markdowns, err := s.ApplyMarkdownRules()
+	if err != nil {
+		logrus.Errorf("Expiry markdowns failed: %v", err)
+		return
+	}
+	logrus.Infof("Created %d expiry markdowns", markdowns)
+}
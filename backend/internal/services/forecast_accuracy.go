@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/forecasting"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// accuracyWindowDays is how far back forecasts count towards accuracy figures.
+	accuracyWindowDays = 180
+	// maxBacktestRuns caps the forecasts a single backtest replays.
+	maxBacktestRuns = 200
+	// unknownForecastMethod groups forecasts made before the method was recorded.
+	unknownForecastMethod = "UNKNOWN"
+)
+
+// ErrInvalidBacktest is returned when a backtest window cannot hold a single forecast period.
+var ErrInvalidBacktest = errors.New("invalid backtest window")
+
+// ForecastAccuracy is the accuracy of matured forecasts for one product or one method.
+type ForecastAccuracy struct {
+	ProductID   uint   `json:"productId,omitempty"`
+	ProductName string `json:"productName,omitempty"`
+	Method      string `json:"method,omitempty"`
+	forecasting.Accuracy
+}
+
+// BacktestRun is one replayed forecast: made at Origin from the history before it, for the period after it.
+type BacktestRun struct {
+	Origin   time.Time `json:"origin"`
+	Method   string    `json:"method"` // The method automatic selection picked
+	Forecast float64   `json:"forecast"`
+	Lower    float64   `json:"lower"`
+	Upper    float64   `json:"upper"`
+	Actual   float64   `json:"actual"`
+}
+
+// BacktestResult replays forecasting over a past window. Accuracy compares automatic selection
+// ("AUTO") with each method forced on every run.
+type BacktestResult struct {
+	ProductID   uint                            `json:"productId"`
+	Start       time.Time                       `json:"start"`
+	End         time.Time                       `json:"end"`
+	HorizonDays int                             `json:"horizonDays"`
+	Runs        []BacktestRun                   `json:"runs"`
+	Accuracy    map[string]forecasting.Accuracy `json:"accuracy"`
+}
+
+// EvaluateForecasts records actual sales against every forecast whose period has ended.
+func (s *forecastingService) EvaluateForecasts() (int, error) {
+	now := time.Now()
+	forecasts, err := s.repo.GetUnevaluatedForecasts(now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch forecasts: %w", err)
+	}
+
+	evaluated := 0
+	for i := range forecasts {
+		forecast := &forecasts[i]
+		if forecast.PeriodDays() == 0 || forecast.PeriodEnd().After(now) {
+			continue
+		}
+		actual, err := s.repo.GetSalesTotal(forecast.ProductID, forecast.GeneratedAt, forecast.PeriodEnd())
+		if err != nil {
+			return evaluated, fmt.Errorf("failed to total sales for forecast %d: %w", forecast.ID, err)
+		}
+		forecast.ActualDemand = &actual
+		forecast.EvaluatedAt = &now
+		if err := s.repo.SaveForecastEvaluation(forecast); err != nil {
+			return evaluated, fmt.Errorf("failed to save evaluation of forecast %d: %w", forecast.ID, err)
+		}
+		evaluated++
+	}
+	return evaluated, nil
+}
+
+// GetForecastAccuracy scores the forecasts evaluated over the accuracy window, grouped by "product" or "method".
+func (s *forecastingService) GetForecastAccuracy(groupBy string) ([]ForecastAccuracy, error) {
+	forecasts, err := s.evaluatedForecasts()
+	if err != nil {
+		return nil, err
+	}
+	return forecastAccuracy(forecasts, groupBy), nil
+}
+
+// evaluatedForecasts loads the forecasts evaluated over the accuracy window.
+func (s *forecastingService) evaluatedForecasts() ([]domain.DemandForecast, error) {
+	forecasts, err := s.repo.GetEvaluatedForecasts(time.Now().AddDate(0, 0, -accuracyWindowDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch evaluated forecasts: %w", err)
+	}
+	return forecasts, nil
+}
+
+// forecastAccuracy scores evaluated forecasts grouped by "product" or "method", worst first.
+func forecastAccuracy(forecasts []domain.DemandForecast, groupBy string) []ForecastAccuracy {
+	type group struct {
+		row       ForecastAccuracy
+		forecasts []float64
+		actuals   []float64
+	}
+	groups := make(map[string]*group)
+	var keys []string
+	for _, f := range forecasts {
+		row := ForecastAccuracy{Method: f.Method}
+		if row.Method == "" {
+			row.Method = unknownForecastMethod
+		}
+		key := row.Method
+		if groupBy == "product" {
+			row = ForecastAccuracy{ProductID: f.ProductID, ProductName: f.Product.Name}
+			key = fmt.Sprint(f.ProductID)
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{row: row}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.forecasts = append(g.forecasts, float64(f.PredictedDemand))
+		g.actuals = append(g.actuals, *f.ActualDemand)
+	}
+
+	results := make([]ForecastAccuracy, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		g.row.Accuracy = forecasting.Evaluate(g.forecasts, g.actuals)
+		results = append(results, g.row)
+	}
+	// Worst first: the largest weighted error, with groups that sold nothing last
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].WAPE, results[j].WAPE
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a > *b
+	})
+	return results
+}
+
+// Backtest replays forecasting for a product over a past window: every horizonDays from start, a
+// forecast is made from the sales before that day and compared with the sales that followed.
+func (s *forecastingService) Backtest(productID uint, start, end time.Time, horizonDays int) (*BacktestResult, error) {
+	start = startOfDay(start)
+	end = startOfDay(end)
+	if horizonDays <= 0 || start.AddDate(0, 0, horizonDays).After(end) {
+		return nil, fmt.Errorf("%w: the window must span at least one %d-day forecast period", ErrInvalidBacktest, horizonDays)
+	}
+	if end.After(time.Now()) {
+		return nil, fmt.Errorf("%w: the window must end by today", ErrInvalidBacktest)
+	}
+	if _, err := s.repo.GetProduct(productID); err != nil {
+		return nil, err
+	}
+
+	sales, err := s.repo.GetSalesBetween(productID, start.AddDate(0, 0, -forecastHistoryDays), end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sales history: %w", err)
+	}
+
+	result := &BacktestResult{ProductID: productID, Start: start, End: end, HorizonDays: horizonDays, Runs: []BacktestRun{}}
+	methods := []forecasting.Method{forecasting.MovingAverage, forecasting.HoltWinters, forecasting.Croston}
+	predicted := map[string][]float64{}
+	var actuals []float64
+	for origin := start; !origin.AddDate(0, 0, horizonDays).After(end) && len(result.Runs) < maxBacktestRuns; origin = origin.AddDate(0, 0, horizonDays) {
+		history := DailySalesSeries(sales, origin, forecastHistoryDays)
+		var actual float64
+		for _, v := range DailySalesSeries(sales, origin.AddDate(0, 0, horizonDays), horizonDays) {
+			actual += v
+		}
+		actuals = append(actuals, actual)
+
+		auto := forecasting.Predict(history, horizonDays)
+		predicted["AUTO"] = append(predicted["AUTO"], auto.Total)
+		for _, method := range methods {
+			predicted[string(method)] = append(predicted[string(method)], forecasting.PredictWith(method, history, horizonDays).Total)
+		}
+		result.Runs = append(result.Runs, BacktestRun{
+			Origin:   origin,
+			Method:   string(auto.Method),
+			Forecast: auto.Total,
+			Lower:    auto.Lower,
+			Upper:    auto.Upper,
+			Actual:   actual,
+		})
+	}
+
+	result.Accuracy = make(map[string]forecasting.Accuracy, len(predicted))
+	for method, totals := range predicted {
+		result.Accuracy[method] = forecasting.Evaluate(totals, actuals)
+	}
+	return result, nil
+}
+
+// RunScheduledForecastEvaluation scores forecasts whose period has ended against actual sales.
+func RunScheduledForecastEvaluation(s ForecastingService) {
+	evaluated, err := s.EvaluateForecasts()
+	if err != nil {
+		logrus.Errorf("Forecast evaluation failed: %v", err)
+		return
+	}
+	logrus.Infof("Evaluated %d matured demand forecasts", evaluated)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// worstForecastProducts keeps the first rows of accuracy sorted worst first, skipping products that sold nothing.
+func worstForecastProducts(rows []ForecastAccuracy, limit int) []ForecastAccuracy {
+	worst := make([]ForecastAccuracy, 0, limit)
+	for _, row := range rows {
+		if len(worst) == limit || row.WAPE == nil {
+			break
+		}
+		worst = append(worst, row)
+	}
+	return worst
+}
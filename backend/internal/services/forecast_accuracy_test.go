@@ -0,0 +1,166 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/forecasting"
+	"inventory/backend/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubForecastingRepository serves sales and evaluated forecasts from memory. Methods the tests do
+// not use fall through to the nil embedded interface.
+type stubForecastingRepository struct {
+	repository.ForecastingRepository
+	sales     []domain.StockAdjustment
+	evaluated []domain.DemandForecast
+	loads     int
+}
+
+func (r *stubForecastingRepository) GetProduct(id uint) (*domain.Product, error) {
+	product := &domain.Product{Name: "Stub"}
+	product.ID = id
+	return product, nil
+}
+
+func (r *stubForecastingRepository) GetSalesBetween(productID uint, start, end time.Time) ([]domain.StockAdjustment, error) {
+	var sales []domain.StockAdjustment
+	for _, s := range r.sales {
+		if !s.AdjustedAt.Before(start) && s.AdjustedAt.Before(end) {
+			sales = append(sales, s)
+		}
+	}
+	return sales, nil
+}
+
+func (r *stubForecastingRepository) GetEvaluatedForecasts(time.Time) ([]domain.DemandForecast, error) {
+	r.loads++
+	return r.evaluated, nil
+}
+
+func (r *stubForecastingRepository) GetTopForecasts(int) ([]repository.ForecastDashboardItem, error) {
+	return nil, nil
+}
+
+func (r *stubForecastingRepository) GetLowStockPredictions(int) ([]repository.ForecastDashboardItem, error) {
+	return nil, nil
+}
+
+// dailySales records perDay units sold at noon on every day from first up to, not including, last.
+func dailySales(first, last time.Time, perDay float64) []domain.StockAdjustment {
+	var sales []domain.StockAdjustment
+	for day := first; day.Before(last); day = day.AddDate(0, 0, 1) {
+		sales = append(sales, domain.StockAdjustment{Type: "STOCK_OUT", Quantity: perDay, AdjustedAt: day.Add(12 * time.Hour)})
+	}
+	return sales
+}
+
+func TestBacktestWindowing(t *testing.T) {
+	today := startOfDay(time.Now())
+	start := today.AddDate(0, 0, -21)
+	end := today.AddDate(0, 0, -7)
+
+	// A flat 2 a day before the window and 10 a day inside it, so any day on the wrong side of an
+	// origin shows up in the first run
+	sales := dailySales(start.AddDate(0, 0, -forecastHistoryDays), start, 2)
+	sales = append(sales, dailySales(start, today, 10)...)
+	// A sale after the window must not count towards the last run
+	sales = append(sales, domain.StockAdjustment{Type: "STOCK_OUT", Quantity: 500, AdjustedAt: end.Add(time.Hour)})
+	service := &forecastingService{repo: &stubForecastingRepository{sales: sales}}
+
+	result, err := service.Backtest(1, start, end, 7)
+	require.NoError(t, err)
+	require.Len(t, result.Runs, 2)
+
+	first := result.Runs[0]
+	assert.Equal(t, start, first.Origin)
+	// History stops before the origin: a flat 2 a day forecasts exactly 14
+	assert.Equal(t, string(forecasting.MovingAverage), first.Method)
+	assert.Equal(t, 14.0, first.Forecast)
+	// Actual covers [origin, origin+7)
+	assert.Equal(t, 70.0, first.Actual)
+
+	second := result.Runs[1]
+	assert.Equal(t, start.AddDate(0, 0, 7), second.Origin)
+	assert.Greater(t, second.Forecast, 14.0)
+	assert.Equal(t, 70.0, second.Actual)
+
+	for _, method := range []string{"AUTO", string(forecasting.MovingAverage), string(forecasting.HoltWinters), string(forecasting.Croston)} {
+		acc, ok := result.Accuracy[method]
+		require.True(t, ok, method)
+		assert.Equal(t, 2, acc.Forecasts)
+		assert.Equal(t, 140.0, acc.ActualTotal)
+	}
+}
+
+func TestBacktestRunsOnlyWholePeriods(t *testing.T) {
+	today := startOfDay(time.Now())
+	service := &forecastingService{repo: &stubForecastingRepository{}}
+
+	// 20 days hold two whole 7-day periods; the last 6 days are left out
+	result, err := service.Backtest(1, today.AddDate(0, 0, -30), today.AddDate(0, 0, -10), 7)
+	require.NoError(t, err)
+	assert.Len(t, result.Runs, 2)
+}
+
+func TestBacktestRejectsInvalidWindows(t *testing.T) {
+	today := startOfDay(time.Now())
+	service := &forecastingService{repo: &stubForecastingRepository{}}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		horizon    int
+	}{
+		{"no horizon", today.AddDate(0, 0, -30), today, 0},
+		{"window shorter than the horizon", today.AddDate(0, 0, -5), today, 7},
+		{"window ending in the future", today.AddDate(0, 0, -14), today.AddDate(0, 0, 7), 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Backtest(1, tt.start, tt.end, tt.horizon)
+			assert.ErrorIs(t, err, ErrInvalidBacktest)
+		})
+	}
+}
+
+func TestForecastAccuracyGrouping(t *testing.T) {
+	actual := func(v float64) *float64 { return &v }
+	forecasts := []domain.DemandForecast{
+		{ProductID: 1, Product: domain.Product{Name: "Good"}, Method: "MOVING_AVERAGE", PredictedDemand: 10, ActualDemand: actual(10)},
+		{ProductID: 2, Product: domain.Product{Name: "Bad"}, Method: "CROSTON", PredictedDemand: 20, ActualDemand: actual(10)},
+		{ProductID: 3, Product: domain.Product{Name: "Unsold"}, PredictedDemand: 5, ActualDemand: actual(0)},
+	}
+
+	byProduct := forecastAccuracy(forecasts, "product")
+	require.Len(t, byProduct, 3)
+	assert.Equal(t, []uint{2, 1, 3}, []uint{byProduct[0].ProductID, byProduct[1].ProductID, byProduct[2].ProductID})
+	assert.Equal(t, "Bad", byProduct[0].ProductName)
+	assert.Nil(t, byProduct[2].WAPE)
+
+	byMethod := forecastAccuracy(forecasts, "method")
+	require.Len(t, byMethod, 3)
+	assert.Equal(t, "CROSTON", byMethod[0].Method)
+	assert.Equal(t, unknownForecastMethod, byMethod[2].Method)
+
+	// Products that sold nothing never count as the worst forecasts
+	assert.Len(t, worstForecastProducts(byProduct, 10), 2)
+}
+
+func TestForecastDashboardLoadsEvaluatedForecastsOnce(t *testing.T) {
+	actual := 4.0
+	repo := &stubForecastingRepository{evaluated: []domain.DemandForecast{
+		{ProductID: 1, Method: "MOVING_AVERAGE", PredictedDemand: 5, ActualDemand: &actual},
+	}}
+	service := &forecastingService{repo: repo}
+
+	dashboard, err := service.GetForecastDashboard()
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.loads)
+	assert.Len(t, dashboard["worstForecasts"], 1)
+	assert.Len(t, dashboard["accuracyByMethod"], 1)
+}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
 	"inventory/backend/internal/config"
 	"inventory/backend/internal/domain"
+	"inventory/backend/internal/forecasting"
 	"inventory/backend/internal/repository"
 
 	"github.com/sirupsen/logrus"
@@ -17,6 +19,9 @@ import (
 type ForecastingService interface {
 	GenerateDemandForecast(productID *uint, periodInDays int) (*domain.DemandForecast, error)
 	GetForecastDashboard() (map[string]interface{}, error)
+	EvaluateForecasts() (int, error)
+	GetForecastAccuracy(groupBy string) ([]ForecastAccuracy, error)
+	Backtest(productID uint, start, end time.Time, horizonDays int) (*BacktestResult, error)
 }
 
 type forecastingService struct {
@@ -32,12 +37,15 @@ func (s *forecastingService) GenerateDemandForecast(productID *uint, periodInDay
 	var products []domain.Product
 
 	if productID != nil {
-		// Use AI Service for single product forecast
+		// Use AI Service for single product forecast, falling back to the native engine if it fails
 		if s.cfg.AIServiceURL != "" {
-			return s.generateAIForecast(*productID, periodInDays)
+			forecast, err := s.generateAIForecast(*productID, periodInDays)
+			if err == nil {
+				return forecast, nil
+			}
+			logrus.Warnf("AI forecast for product %d failed, using statistical forecast: %v", *productID, err)
 		}
 
-		// Fallback to local logic if AI service not configured
 		product, err := s.repo.GetProduct(*productID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get product: %w", err)
@@ -71,6 +79,9 @@ func (s *forecastingService) GenerateDemandForecast(productID *uint, periodInDay
 	return nil, nil
 }
 
+// aiClient bounds the AI service call so a hung service falls back to the statistical forecast.
+var aiClient = &http.Client{Timeout: 30 * time.Second}
+
 func (s *forecastingService) generateAIForecast(productID uint, periodInDays int) (*domain.DemandForecast, error) {
 	url := fmt.Sprintf("%s/forecast", s.cfg.AIServiceURL)
 	payload := map[string]interface{}{
@@ -79,7 +90,7 @@ func (s *forecastingService) generateAIForecast(productID uint, periodInDays int
 	}
 
 	jsonPayload, _ := json.Marshal(payload)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonPayload))
+	resp, err := aiClient.Post(url, "application/json", bytes.NewBuffer(jsonPayload))
 	if err != nil {
 		return nil, fmt.Errorf("failed to call AI service: %w", err)
 	}
@@ -106,6 +117,7 @@ func (s *forecastingService) generateAIForecast(productID uint, periodInDays int
 		ConfidenceScore: result.ConfidenceScore,
 		Reasoning:       result.Reasoning,
 		GeneratedAt:     time.Now(),
+		Method:          "AI",
 	}
 
 	if err := s.repo.CreateForecast(&forecast); err != nil {
@@ -116,75 +128,68 @@ func (s *forecastingService) generateAIForecast(productID uint, periodInDays int
 	return &forecast, nil
 }
 
+// forecastHistoryDays is the least sales history fed to the statistical engine: enough weeks for
+// Holt-Winters to see the weekly pattern.
+const forecastHistoryDays = 91
+
 func (s *forecastingService) processForecastForProducts(products []domain.Product, periodInDays int) (*domain.DemandForecast, error) {
+	historyDays := periodInDays
+	if historyDays < forecastHistoryDays {
+		historyDays = forecastHistoryDays
+	}
+
 	var lastForecast *domain.DemandForecast
 	for _, product := range products {
-		salesData, err := s.repo.GetSalesDataForForecast(product.ID, periodInDays)
+		salesData, err := s.repo.GetSalesDataForForecast(product.ID, historyDays+1) // +1 covers the first, partial day
 		if err != nil {
 			logrus.Errorf("Failed to get sales data for product %d: %v", product.ID, err)
 			continue
 		}
 
-		// Map sales to dates to handle missing days (0 sales)
-		salesMap := make(map[string]int)
-		for _, sale := range salesData {
-			dateStr := sale.AdjustedAt.Format("2006-01-02")
-			salesMap[dateStr] += sale.Quantity
-		}
-
-		var weightedSum float64
-		var weightSum float64
-
-		// Iterate through each day of the period
-		now := time.Now()
-		for i := 0; i < periodInDays; i++ {
-			// Calculate date: start from (now - period) + i
-			// actually, usually weighted average gives more weight to recent.
-			// So let's iterate i from 1 to periodInDays.
-			// Day 1 = (now - period) + 1 ... Day N = now.
-			// Let's align:
-			// targetDate = now.AddDate(0, 0, -periodInDays + i + 1)
-
-			// Let's say period is 30 days.
-			// i=0 -> weight=1. Date = now - 29 days.
-			// ...
-			// i=29 -> weight=30. Date = now.
-
-			targetDate := now.AddDate(0, 0, -periodInDays+i+1)
-			dateStr := targetDate.Format("2006-01-02")
-
-			quantity := salesMap[dateStr] // 0 if missing
-
-			weight := float64(i + 1)
-			weightedSum += float64(quantity) * weight
-			weightSum += weight
-		}
-
-		var predictedDemand int
-		if weightSum > 0 {
-			dailyDemand := weightedSum / weightSum
-			predictedDemand = int(dailyDemand * float64(periodInDays))
-		} else {
-			predictedDemand = 0
-		}
+		prediction := forecasting.Predict(DailySalesSeries(salesData, time.Now(), historyDays), periodInDays)
 
 		forecast := domain.DemandForecast{
 			ProductID:       product.ID,
 			ForecastPeriod:  fmt.Sprintf("%d_DAYS", periodInDays),
-			PredictedDemand: predictedDemand,
+			PredictedDemand: int(math.Round(prediction.Total)),
+			ConfidenceScore: prediction.ConfidenceScore,
+			Reasoning:       prediction.Reasoning,
 			GeneratedAt:     time.Now(),
+			Method:          string(prediction.Method),
 		}
 		if err := s.repo.CreateForecast(&forecast); err != nil {
 			logrus.Errorf("Failed to save demand forecast for product %d: %v", product.ID, err)
 			continue
 		}
-		logrus.Infof("Generated forecast for product %d: PredictedDemand=%d", product.ID, predictedDemand)
+		logrus.Infof("Generated %s forecast for product %d: PredictedDemand=%d", prediction.Method, product.ID, forecast.PredictedDemand)
 		lastForecast = &forecast
 	}
 
 	return lastForecast, nil
 }
 
+// DailySalesSeries buckets sales into the days full days before end, oldest first, with zeros for
+// days without sales. The partial current day is left out so it does not read as a drop in demand.
+func DailySalesSeries(sales []domain.StockAdjustment, end time.Time, days int) []float64 {
+	year, month, day := end.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, end.Location())
+	start := today.AddDate(0, 0, -days)
+
+	series := make([]float64, days)
+	for _, sale := range sales {
+		at := sale.AdjustedAt.In(end.Location())
+		if at.Before(start) || !at.Before(today) {
+			continue
+		}
+		y, m, d := at.Date()
+		index := int(time.Date(y, m, d, 0, 0, 0, 0, end.Location()).Sub(start).Hours()/24 + 0.5)
+		if index >= 0 && index < days {
+			series[index] += sale.Quantity
+		}
+	}
+	return series
+}
+
 func (s *forecastingService) GetForecastDashboard() (map[string]interface{}, error) {
 	topForecasts, err := s.repo.GetTopForecasts(10)
 	if err != nil {
@@ -196,8 +201,16 @@ func (s *forecastingService) GetForecastDashboard() (map[string]interface{}, err
 		return nil, fmt.Errorf("failed to get low stock predictions: %w", err)
 	}
 
+	// Both accuracy views group the same evaluated forecasts
+	evaluated, err := s.evaluatedForecasts()
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
-		"topForecasts": topForecasts,
-		"lowStock":     lowStock,
+		"topForecasts":     topForecasts,
+		"lowStock":         lowStock,
+		"worstForecasts":   worstForecastProducts(forecastAccuracy(evaluated, "product"), 10),
+		"accuracyByMethod": forecastAccuracy(evaluated, "method"),
 	}, nil
 }
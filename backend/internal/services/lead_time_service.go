@@ -0,0 +1,187 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"inventory/backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// leadTimeHistoryDays is how far back received purchase orders count towards lead-time statistics.
+	leadTimeHistoryDays = 365
+	// minLeadTimeSamples is the number of deliveries needed before learned statistics are trusted.
+	minLeadTimeSamples = 3
+)
+
+// LeadTimeStats summarises how long a supplier took to deliver, in days from order to full receipt.
+type LeadTimeStats struct {
+	SupplierID   uint    `json:"supplierId"`
+	ProductID    uint    `json:"productId,omitempty"` // 0 for statistics across all of the supplier's orders
+	Samples      int     `json:"samples"`
+	MeanDays     float64 `json:"meanDays"`
+	VarianceDays float64 `json:"varianceDays"` // Sample variance, in days squared
+	StdDevDays   float64 `json:"stdDevDays"`
+	MinDays      float64 `json:"minDays"`
+	MaxDays      float64 `json:"maxDays"`
+}
+
+// Reliable reports whether there are enough deliveries behind the statistics to plan with them.
+func (s *LeadTimeStats) Reliable() bool {
+	return s != nil && s.Samples >= minLeadTimeSamples
+}
+
+// supplierProductKey identifies a product bought from one supplier.
+type supplierProductKey struct {
+	SupplierID uint
+	ProductID  uint
+}
+
+// LeadTimeTable holds learned lead times by supplier and by supplier and product.
+type LeadTimeTable struct {
+	suppliers map[uint]*LeadTimeStats
+	products  map[supplierProductKey]*LeadTimeStats
+}
+
+// Lookup returns the most specific reliable statistics for buying the product from the supplier:
+// the product's own deliveries, else all of the supplier's deliveries. It returns nil when neither
+// has enough history.
+func (t *LeadTimeTable) Lookup(supplierID, productID uint) *LeadTimeStats {
+	if t == nil {
+		return nil
+	}
+	if stats := t.products[supplierProductKey{SupplierID: supplierID, ProductID: productID}]; stats.Reliable() {
+		return stats
+	}
+	if stats := t.suppliers[supplierID]; stats.Reliable() {
+		return stats
+	}
+	return nil
+}
+
+type LeadTimeService interface {
+	// SupplierLeadTime returns the supplier's lead-time statistics across all its received orders.
+	SupplierLeadTime(supplierID uint) (*LeadTimeStats, error)
+	// ProductLeadTimes returns the supplier's lead-time statistics for each product it delivered.
+	ProductLeadTimes(supplierID uint) ([]LeadTimeStats, error)
+	// LeadTimeTable returns the statistics for every supplier and product.
+	LeadTimeTable() (*LeadTimeTable, error)
+}
+
+type leadTimeService struct {
+	db *gorm.DB
+}
+
+func NewLeadTimeService(db *gorm.DB) LeadTimeService {
+	return &leadTimeService{db: db}
+}
+
+func (s *leadTimeService) SupplierLeadTime(supplierID uint) (*LeadTimeStats, error) {
+	table, err := s.table(supplierID)
+	if err != nil {
+		return nil, err
+	}
+	if stats := table.suppliers[supplierID]; stats != nil {
+		return stats, nil
+	}
+	return &LeadTimeStats{SupplierID: supplierID}, nil
+}
+
+func (s *leadTimeService) ProductLeadTimes(supplierID uint) ([]LeadTimeStats, error) {
+	table, err := s.table(supplierID)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]LeadTimeStats, 0, len(table.products))
+	for _, st := range table.products {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ProductID < stats[j].ProductID })
+	return stats, nil
+}
+
+func (s *leadTimeService) LeadTimeTable() (*LeadTimeTable, error) {
+	return s.table(0)
+}
+
+// table builds the statistics from received purchase orders, for one supplier or all (0).
+func (s *leadTimeService) table(supplierID uint) (*LeadTimeTable, error) {
+	samples, err := repository.GetLeadTimeSamples(s.db, supplierID, time.Now().AddDate(0, 0, -leadTimeHistoryDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch received purchase orders: %w", err)
+	}
+
+	// A supplier's overall statistics count each order once, however many lines it had
+	supplierDays := make(map[uint][]float64)
+	productDays := make(map[supplierProductKey][]float64)
+	seenOrders := make(map[uint]bool)
+	for _, sample := range samples {
+		days := sample.ActualDeliveryDate.Sub(sample.OrderDate).Hours() / 24
+		if days < 0 {
+			continue // Order date entered after delivery; not a usable sample
+		}
+		if !seenOrders[sample.PurchaseOrderID] {
+			seenOrders[sample.PurchaseOrderID] = true
+			supplierDays[sample.SupplierID] = append(supplierDays[sample.SupplierID], days)
+		}
+		key := supplierProductKey{SupplierID: sample.SupplierID, ProductID: sample.ProductID}
+		productDays[key] = append(productDays[key], days)
+	}
+
+	table := &LeadTimeTable{
+		suppliers: make(map[uint]*LeadTimeStats),
+		products:  make(map[supplierProductKey]*LeadTimeStats),
+	}
+	for id, days := range supplierDays {
+		table.suppliers[id] = leadTimeStats(id, 0, days)
+	}
+	for key, days := range productDays {
+		table.products[key] = leadTimeStats(key.SupplierID, key.ProductID, days)
+	}
+	return table, nil
+}
+
+func leadTimeStats(supplierID, productID uint, days []float64) *LeadTimeStats {
+	stats := &LeadTimeStats{SupplierID: supplierID, ProductID: productID, Samples: len(days)}
+	mean, variance := meanVariance(days)
+	stats.MeanDays = roundTo(mean, 2)
+	stats.VarianceDays = roundTo(variance, 2)
+	stats.StdDevDays = roundTo(math.Sqrt(variance), 2)
+	stats.MinDays, stats.MaxDays = days[0], days[0]
+	for _, d := range days {
+		stats.MinDays = math.Min(stats.MinDays, d)
+		stats.MaxDays = math.Max(stats.MaxDays, d)
+	}
+	stats.MinDays = roundTo(stats.MinDays, 2)
+	stats.MaxDays = roundTo(stats.MaxDays, 2)
+	return stats
+}
+
+// meanVariance returns the mean and sample variance of values (variance 0 for fewer than two values).
+func meanVariance(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, squares / float64(len(values)-1)
+}
+
+func roundTo(v float64, places int) float64 {
+	factor := math.Pow(10, float64(places))
+	return math.Round(v*factor) / factor
+}
@@ -0,0 +1,384 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrReconciliationState is returned when a reconciliation is not awaiting approval.
+	ErrReconciliationState = errors.New("reconciliation is not awaiting approval")
+	// ErrUncoveredShortfall is returned when a location's batches net below zero, so clearing its
+	// negative batches would create stock. The location needs a count before it can be corrected.
+	ErrUncoveredShortfall = errors.New("negative batches exceed the stock held at the location")
+)
+
+// snapshotTolerance absorbs float noise when comparing recorded quantity snapshots to the ledger.
+const snapshotTolerance = 0.0005
+
+type LedgerService interface {
+	RunReconciliation(triggeredBy *uint) (*domain.LedgerReconciliation, error)
+	ListReconciliations(status string) ([]domain.LedgerReconciliation, error)
+	GetReconciliation(id uint) (*domain.LedgerReconciliation, error)
+	ApproveReconciliation(id uint, req *requests.ApproveLedgerReconciliationRequest, userID uint) (*domain.LedgerReconciliation, error)
+	RejectReconciliation(id uint, req *requests.RejectLedgerReconciliationRequest, userID uint) (*domain.LedgerReconciliation, error)
+}
+
+type ledgerService struct {
+	db *gorm.DB
+}
+
+func NewLedgerService(db *gorm.DB) LedgerService {
+	return &ledgerService{db: db}
+}
+
+type stockPairKey struct {
+	productID  uint
+	locationID uint
+}
+
+// RunReconciliation replays the stock ledger per product and location, compares it with batch
+// sums, flags negative batches and counts quantity snapshots that drifted from the ledger. Pending
+// transfers are already in the ledger but not yet in the batches, so they are allowed for.
+func (s *ledgerService) RunReconciliation(triggeredBy *uint) (*domain.LedgerReconciliation, error) {
+	var ledgerRows []struct {
+		ProductID  uint
+		LocationID uint
+		Quantity   float64
+	}
+	if err := s.db.Model(&domain.StockAdjustment{}).
+		Select("product_id, location_id, SUM(CASE WHEN type = 'STOCK_IN' THEN quantity ELSE -quantity END) AS quantity").
+		Group("product_id, location_id").
+		Scan(&ledgerRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to replay stock ledger: %w", err)
+	}
+
+	var batchRows []struct {
+		ProductID        uint
+		LocationID       uint
+		Quantity         float64
+		NegativeBatches  int
+		NegativeQuantity float64
+	}
+	if err := s.db.Model(&domain.Batch{}).
+		Select(`product_id, location_id, SUM(quantity) AS quantity,
+			SUM(CASE WHEN quantity < 0 THEN 1 ELSE 0 END) AS negative_batches,
+			SUM(CASE WHEN quantity < 0 THEN quantity ELSE 0 END) AS negative_quantity`).
+		Group("product_id, location_id").
+		Scan(&batchRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum batches: %w", err)
+	}
+
+	pairs := make(map[stockPairKey]*domain.LedgerDiscrepancy)
+	var order []stockPairKey
+	pair := func(productID, locationID uint) *domain.LedgerDiscrepancy {
+		key := stockPairKey{productID, locationID}
+		if d, ok := pairs[key]; ok {
+			return d
+		}
+		d := &domain.LedgerDiscrepancy{ProductID: productID, LocationID: locationID, Status: domain.DiscrepancyStatusOpen}
+		pairs[key] = d
+		order = append(order, key)
+		return d
+	}
+	for _, row := range ledgerRows {
+		pair(row.ProductID, row.LocationID).LedgerQuantity = row.Quantity
+	}
+	for _, row := range batchRows {
+		d := pair(row.ProductID, row.LocationID)
+		d.BatchQuantity = row.Quantity
+		d.NegativeBatches = row.NegativeBatches
+		d.NegativeQuantity = -row.NegativeQuantity
+	}
+
+	pending, err := repository.GetPendingTransferQuantities(s.db, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pending transfers: %w", err)
+	}
+	for key, quantity := range pending {
+		pair(key.ProductID, key.LocationID).InTransitQuantity = quantity
+	}
+
+	drifts, err := s.countSnapshotDrifts()
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]uint, 0, len(order))
+	seen := make(map[uint]bool)
+	for _, key := range order {
+		if !seen[key.productID] {
+			seen[key.productID] = true
+			productIDs = append(productIDs, key.productID)
+		}
+	}
+	products := make(map[uint]domain.Product, len(productIDs))
+	if len(productIDs) > 0 {
+		var list []domain.Product
+		if err := s.db.Unscoped().Where("id IN ?", productIDs).Find(&list).Error; err != nil {
+			return nil, fmt.Errorf("failed to load products: %w", err)
+		}
+		for _, p := range list {
+			products[p.ID] = p
+		}
+	}
+
+	reconciliation := domain.LedgerReconciliation{
+		RunAt:        time.Now(),
+		TriggeredBy:  triggeredBy,
+		PairsChecked: len(order),
+	}
+	for _, key := range order {
+		d := pairs[key]
+		product := products[key.productID]
+		d.LedgerQuantity = product.RoundQuantity(d.LedgerQuantity)
+		d.BatchQuantity = product.RoundQuantity(d.BatchQuantity)
+		d.InTransitQuantity = product.RoundQuantity(d.InTransitQuantity)
+		d.NegativeQuantity = product.RoundQuantity(d.NegativeQuantity)
+		d.Difference = product.RoundQuantity(d.BatchQuantity - d.LedgerQuantity - d.InTransitQuantity)
+		d.SnapshotDrifts = drifts[key.productID]
+		if !d.NeedsCorrection() {
+			continue
+		}
+		reconciliation.Discrepancies = append(reconciliation.Discrepancies, *d)
+		reconciliation.DiscrepancyCount++
+		reconciliation.NegativeBatchCount += d.NegativeBatches
+	}
+	for _, count := range drifts {
+		reconciliation.SnapshotDriftCount += count
+	}
+
+	reconciliation.Status = domain.ReconciliationStatusPendingApproval
+	if reconciliation.DiscrepancyCount == 0 {
+		reconciliation.Status = domain.ReconciliationStatusClean
+	}
+	if err := s.db.Create(&reconciliation).Error; err != nil {
+		return nil, fmt.Errorf("failed to save reconciliation: %w", err)
+	}
+	return &reconciliation, nil
+}
+
+// countSnapshotDrifts walks each product's adjustments in order and counts those whose recorded
+// PreviousQuantity differs from the product-wide ledger balance at that point.
+func (s *ledgerService) countSnapshotDrifts() (map[uint]int, error) {
+	rows, err := s.db.Model(&domain.StockAdjustment{}).
+		Select("product_id, type, quantity, previous_quantity, new_quantity").
+		Order("product_id, adjusted_at, id").
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stock ledger: %w", err)
+	}
+	defer rows.Close()
+
+	drifts := make(map[uint]int)
+	var currentProduct uint
+	var balance float64
+	for rows.Next() {
+		var (
+			productID                    uint
+			adjustmentType               string
+			quantity, previous, newValue float64
+		)
+		if err := rows.Scan(&productID, &adjustmentType, &quantity, &previous, &newValue); err != nil {
+			return nil, err
+		}
+		if productID != currentProduct {
+			currentProduct = productID
+			balance = 0
+		}
+		// Movements recorded without snapshots (transfers, receipts) have nothing to compare
+		if previous != 0 || newValue != 0 {
+			if math.Abs(previous-balance) > snapshotTolerance {
+				drifts[productID]++
+			}
+		}
+		if adjustmentType == "STOCK_IN" {
+			balance += quantity
+		} else {
+			balance -= quantity
+		}
+	}
+	return drifts, rows.Err()
+}
+
+func (s *ledgerService) ListReconciliations(status string) ([]domain.LedgerReconciliation, error) {
+	var reconciliations []domain.LedgerReconciliation
+	query := s.db.Order("run_at desc").Limit(100)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&reconciliations).Error; err != nil {
+		return nil, err
+	}
+	return reconciliations, nil
+}
+
+func (s *ledgerService) GetReconciliation(id uint) (*domain.LedgerReconciliation, error) {
+	var reconciliation domain.LedgerReconciliation
+	if err := s.db.Preload("Discrepancies.Product").Preload("Discrepancies.Location").
+		First(&reconciliation, id).Error; err != nil {
+		return nil, err
+	}
+	return &reconciliation, nil
+}
+
+// ApproveReconciliation posts corrections for the selected discrepancies. Batches are taken as the
+// physical truth: negative batches are netted off against the location's other batches, then a
+// LEDGER_CORRECTION adjustment brings the ledger in line with the batch total, allowing for pending
+// transfers. Quantities are re-read at approval time so stock that moved since the run is not
+// over-corrected. A location whose batches net below zero is refused rather than topped up.
+func (s *ledgerService) ApproveReconciliation(id uint, req *requests.ApproveLedgerReconciliationRequest, userID uint) (*domain.LedgerReconciliation, error) {
+	selected := make(map[uint]bool, len(req.DiscrepancyIDs))
+	for _, discrepancyID := range req.DiscrepancyIDs {
+		selected[discrepancyID] = true
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var reconciliation domain.LedgerReconciliation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reconciliation, id).Error; err != nil {
+			return err
+		}
+		if reconciliation.Status != domain.ReconciliationStatusPendingApproval {
+			return fmt.Errorf("%w: reconciliation is %s", ErrReconciliationState, reconciliation.Status)
+		}
+
+		var discrepancies []domain.LedgerDiscrepancy
+		if err := tx.Where("reconciliation_id = ? AND status = ?", id, domain.DiscrepancyStatusOpen).
+			Order("id").Find(&discrepancies).Error; err != nil {
+			return fmt.Errorf("failed to load discrepancies: %w", err)
+		}
+
+		now := time.Now()
+		for i := range discrepancies {
+			d := &discrepancies[i]
+			if len(selected) > 0 && !selected[d.ID] {
+				if err := tx.Model(d).Update("status", domain.DiscrepancyStatusIgnored).Error; err != nil {
+					return fmt.Errorf("failed to update discrepancy %d: %w", d.ID, err)
+				}
+				continue
+			}
+
+			var product domain.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, d.ProductID).Error; err != nil {
+				return fmt.Errorf("failed to lock product %d: %w", d.ProductID, err)
+			}
+			uncovered, err := repository.NormalizeNegativeBatches(tx, &product, d.LocationID)
+			if err != nil {
+				return fmt.Errorf("failed to clear negative batches for product %d: %w", product.ID, err)
+			}
+			if uncovered > 0 {
+				return fmt.Errorf("%w: product %d at location %d is short %g %s; count it before correcting",
+					ErrUncoveredShortfall, product.ID, d.LocationID, uncovered, product.UnitOfMeasure)
+			}
+
+			batchQuantity, err := repository.GetLocationStock(tx, product.ID, d.LocationID)
+			if err != nil {
+				return fmt.Errorf("failed to read stock for product %d: %w", product.ID, err)
+			}
+			ledgerQuantity, err := repository.GetLedgerBalance(tx, product.ID, d.LocationID)
+			if err != nil {
+				return fmt.Errorf("failed to replay ledger for product %d: %w", product.ID, err)
+			}
+			pending, err := repository.GetPendingTransferQuantities(tx, product.ID, d.LocationID)
+			if err != nil {
+				return fmt.Errorf("failed to load pending transfers for product %d: %w", product.ID, err)
+			}
+			inTransit := pending[repository.StockKey{ProductID: product.ID, LocationID: d.LocationID}]
+			batchQuantity = product.RoundQuantity(batchQuantity)
+			ledgerQuantity = product.RoundQuantity(ledgerQuantity)
+			difference := product.RoundQuantity(batchQuantity - ledgerQuantity - inTransit)
+
+			updates := map[string]interface{}{"status": domain.DiscrepancyStatusCorrected}
+			if difference != 0 {
+				unitCost := product.CurrentUnitCost()
+				adjustment := domain.StockAdjustment{
+					ProductID:        product.ID,
+					LocationID:       d.LocationID,
+					Type:             "STOCK_IN",
+					Quantity:         difference,
+					ReasonCode:       "LEDGER_CORRECTION",
+					Notes:            fmt.Sprintf("Ledger reconciliation #%d", reconciliation.ID),
+					AdjustedBy:       userID,
+					AdjustedAt:       now,
+					PreviousQuantity: ledgerQuantity,
+					NewQuantity:      product.RoundQuantity(ledgerQuantity + difference),
+					UnitCost:         &unitCost,
+				}
+				if difference < 0 {
+					adjustment.Type = "STOCK_OUT"
+					adjustment.Quantity = -difference
+				}
+				if err := tx.Create(&adjustment).Error; err != nil {
+					return fmt.Errorf("failed to post correction for product %d: %w", product.ID, err)
+				}
+				updates["adjustment_id"] = adjustment.ID
+			}
+			if err := tx.Model(d).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update discrepancy %d: %w", d.ID, err)
+			}
+		}
+
+		return tx.Model(&reconciliation).Updates(map[string]interface{}{
+			"status":      domain.ReconciliationStatusApproved,
+			"reviewed_by": userID,
+			"reviewed_at": now,
+			"notes":       req.Notes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetReconciliation(id)
+}
+
+func (s *ledgerService) RejectReconciliation(id uint, req *requests.RejectLedgerReconciliationRequest, userID uint) (*domain.LedgerReconciliation, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var reconciliation domain.LedgerReconciliation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reconciliation, id).Error; err != nil {
+			return err
+		}
+		if reconciliation.Status != domain.ReconciliationStatusPendingApproval {
+			return fmt.Errorf("%w: reconciliation is %s", ErrReconciliationState, reconciliation.Status)
+		}
+		if err := tx.Model(&domain.LedgerDiscrepancy{}).
+			Where("reconciliation_id = ? AND status = ?", id, domain.DiscrepancyStatusOpen).
+			Update("status", domain.DiscrepancyStatusIgnored).Error; err != nil {
+			return fmt.Errorf("failed to update discrepancies: %w", err)
+		}
+		return tx.Model(&reconciliation).Updates(map[string]interface{}{
+			"status":      domain.ReconciliationStatusRejected,
+			"reviewed_by": userID,
+			"reviewed_at": time.Now(),
+			"notes":       req.Notes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetReconciliation(id)
+}
+
+// RunScheduledReconciliation is the nightly integrity check. Findings wait for manager approval.
+func RunScheduledReconciliation(s LedgerService) {
+	reconciliation, err := s.RunReconciliation(nil)
+	if err != nil {
+		logrus.Errorf("Ledger reconciliation failed: %v", err)
+		return
+	}
+	if reconciliation.DiscrepancyCount > 0 {
+		logrus.Warnf("Ledger reconciliation #%d found %d discrepancies (%d negative batches) awaiting approval",
+			reconciliation.ID, reconciliation.DiscrepancyCount, reconciliation.NegativeBatchCount)
+		return
+	}
+	logrus.Infof("Ledger reconciliation #%d: %d product/location pairs balanced", reconciliation.ID, reconciliation.PairsChecked)
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/repository"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxThresholdTopUpShare bounds how far an order is topped up to reach a supplier's minimum order value
+// or free-shipping threshold: only when the shortfall is at most this share of the threshold.
+const maxThresholdTopUpShare = 0.25
+
+// ConsolidatedPurchaseOrder is a purchase order created from approved reorder suggestions.
+type ConsolidatedPurchaseOrder struct {
+	PurchaseOrderID uint    `json:"purchaseOrderId"`
+	SupplierID      uint    `json:"supplierId"`
+	LocationID      uint    `json:"locationId"`
+	SuggestionIDs   []uint  `json:"suggestionIds"`
+	Lines           int     `json:"lines"`
+	OrderValue      float64 `json:"orderValue"`
+	ToppedUpBy      float64 `json:"toppedUpBy"` // Value added to reach the minimum order value or free-shipping threshold
+	FreeShipping    bool    `json:"freeShipping"`
+	AutoApproved    bool    `json:"autoApproved"`
+}
+
+// HeldSupplierOrder is a group of approved suggestions left unordered because it falls too far short
+// of the supplier's minimum order value. The suggestions stay approved for the next run.
+type HeldSupplierOrder struct {
+	SupplierID        uint    `json:"supplierId"`
+	LocationID        uint    `json:"locationId"`
+	SuggestionIDs     []uint  `json:"suggestionIds"`
+	OrderValue        float64 `json:"orderValue"`
+	MinimumOrderValue float64 `json:"minimumOrderValue"`
+}
+
+// ConsolidationResult lists the purchase orders a consolidation run created and the orders it held back.
+type ConsolidationResult struct {
+	PurchaseOrders []ConsolidatedPurchaseOrder `json:"purchaseOrders"`
+	Held           []HeldSupplierOrder         `json:"held"`
+}
+
+type POConsolidationService interface {
+	// ConsolidateSuggestions turns approved reorder suggestions, for one supplier or all (0), into
+	// purchase orders: one per supplier and delivery location.
+	ConsolidateSuggestions(supplierID, userID uint) (*ConsolidationResult, error)
+}
+
+type poConsolidationService struct {
+	db *gorm.DB
+}
+
+func NewPOConsolidationService(db *gorm.DB) POConsolidationService {
+	return &poConsolidationService{db: db}
+}
+
+// supplierLocationKey identifies the purchase order for one supplier delivering to one location.
+type supplierLocationKey struct {
+	SupplierID uint
+	LocationID uint
+}
+
+// consolidationLine is one product's line on a consolidated order.
+type consolidationLine struct {
+	ProductID   uint
+	Quantity    float64
+	UnitPrice   float64
+	DailyDemand float64
+	Increment   float64 // Smallest quantity that can be added: the supplier's pack size, else one unit
+}
+
+type consolidationGroup struct {
+	key          supplierLocationKey
+	supplier     domain.Supplier
+	suggestions  []uint
+	lines        []*consolidationLine
+	leadTimeDays int
+}
+
+func (g *consolidationGroup) value() float64 {
+	var total float64
+	for _, line := range g.lines {
+		total += line.Quantity * line.UnitPrice
+	}
+	return total
+}
+
+// topUp raises quantities until the order is worth at least target, one pack at a time and starting
+// with the fastest-selling products, so the extra stock is the quickest to sell through.
+func (g *consolidationGroup) topUp(target float64) {
+	lines := make([]*consolidationLine, 0, len(g.lines))
+	for _, line := range g.lines {
+		if line.UnitPrice > 0 {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].DailyDemand > lines[j].DailyDemand })
+	for value := g.value(); value < target; {
+		for _, line := range lines {
+			line.Quantity += line.Increment
+			value += line.Increment * line.UnitPrice
+			if value >= target {
+				break
+			}
+		}
+	}
+}
+
+func (s *poConsolidationService) ConsolidateSuggestions(supplierID, userID uint) (*ConsolidationResult, error) {
+	result := &ConsolidationResult{PurchaseOrders: []ConsolidatedPurchaseOrder{}, Held: []HeldSupplierOrder{}}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Preload("Product").Preload("Supplier").Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ?", "APPROVED")
+		if supplierID != 0 {
+			query = query.Where("supplier_id = ?", supplierID)
+		}
+		var suggestions []domain.ReorderSuggestion
+		if err := query.Order("supplier_id, id").Find(&suggestions).Error; err != nil {
+			return fmt.Errorf("failed to fetch approved suggestions: %w", err)
+		}
+		if len(suggestions) == 0 {
+			return nil
+		}
+
+		productIDs := make([]uint, 0, len(suggestions))
+		for _, suggestion := range suggestions {
+			productIDs = append(productIDs, suggestion.ProductID)
+		}
+		catalogs, err := repository.GetProductSuppliers(tx, productIDs)
+		if err != nil {
+			return fmt.Errorf("failed to fetch supplier catalogs: %w", err)
+		}
+
+		groups := make(map[supplierLocationKey]*consolidationGroup)
+		var order []supplierLocationKey
+		for _, suggestion := range suggestions {
+			// Suggestions for all locations are delivered to the product's default location
+			key := supplierLocationKey{SupplierID: suggestion.SupplierID, LocationID: suggestion.LocationID}
+			if key.LocationID == 0 {
+				key.LocationID = suggestion.Product.LocationID
+			}
+			group, ok := groups[key]
+			if !ok {
+				group = &consolidationGroup{key: key, supplier: suggestion.Supplier}
+				groups[key] = group
+				order = append(order, key)
+			}
+			group.suggestions = append(group.suggestions, suggestion.ID)
+			if suggestion.LeadTimeDays > group.leadTimeDays {
+				group.leadTimeDays = suggestion.LeadTimeDays
+			}
+			addConsolidationLine(group, &suggestion, catalogs[suggestion.ProductID])
+		}
+
+		now := time.Now()
+		for _, key := range order {
+			group := groups[key]
+			ordered, held, err := placeConsolidatedOrder(tx, group, userID, now)
+			if err != nil {
+				return err
+			}
+			if held != nil {
+				result.Held = append(result.Held, *held)
+				continue
+			}
+			result.PurchaseOrders = append(result.PurchaseOrders, *ordered)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// addConsolidationLine adds a suggestion to its group, merging it with another suggestion for the same product.
+func addConsolidationLine(group *consolidationGroup, suggestion *domain.ReorderSuggestion, catalog []domain.ProductSupplier) {
+	for _, line := range group.lines {
+		if line.ProductID == suggestion.ProductID {
+			line.Quantity += suggestion.SuggestedOrderQuantity
+			line.DailyDemand += suggestion.DailyDemand
+			return
+		}
+	}
+
+	line := &consolidationLine{
+		ProductID:   suggestion.ProductID,
+		Quantity:    suggestion.SuggestedOrderQuantity,
+		UnitPrice:   suggestion.UnitCost,
+		DailyDemand: suggestion.DailyDemand,
+		Increment:   1,
+	}
+	if line.UnitPrice == 0 {
+		line.UnitPrice = suggestion.Product.PurchasePrice
+	}
+	for _, entry := range catalog {
+		if entry.SupplierID == suggestion.SupplierID && entry.PackSize > 0 {
+			line.Increment = entry.PackSize
+		}
+	}
+	group.lines = append(group.lines, line)
+}
+
+// applyPurchasingTerms tops the group up to the supplier's minimum order value and, when close
+// enough, its free-shipping threshold. It returns the held order instead when the group is too far
+// below the minimum order value to top up.
+func (g *consolidationGroup) applyPurchasingTerms() *HeldSupplierOrder {
+	supplier := g.supplier
+	initialValue := g.value()
+	if supplier.MinimumOrderValue > initialValue {
+		if supplier.MinimumOrderValue-initialValue > maxThresholdTopUpShare*supplier.MinimumOrderValue {
+			return &HeldSupplierOrder{
+				SupplierID:        g.key.SupplierID,
+				LocationID:        g.key.LocationID,
+				SuggestionIDs:     g.suggestions,
+				OrderValue:        roundTo(initialValue, 2),
+				MinimumOrderValue: supplier.MinimumOrderValue,
+			}
+		}
+		g.topUp(supplier.MinimumOrderValue)
+	}
+	if threshold := supplier.FreeShippingThreshold; threshold > g.value() && threshold-g.value() <= maxThresholdTopUpShare*threshold {
+		g.topUp(threshold)
+	}
+	return nil
+}
+
+// freeShipping reports whether the order reaches the supplier's free-shipping threshold. Suppliers
+// without a threshold always charge for shipping.
+func (g *consolidationGroup) freeShipping() bool {
+	return g.supplier.FreeShippingThreshold > 0 && g.value() >= g.supplier.FreeShippingThreshold
+}
+
+// placeConsolidatedOrder applies the supplier's purchasing terms to a group and creates its purchase
+// order, or holds the group back when it is too far below the minimum order value.
+func placeConsolidatedOrder(tx *gorm.DB, group *consolidationGroup, userID uint, now time.Time) (*ConsolidatedPurchaseOrder, *HeldSupplierOrder, error) {
+	supplier := group.supplier
+	initialValue := group.value()
+	if held := group.applyPurchasingTerms(); held != nil {
+		return nil, held, nil
+	}
+
+	value := group.value()
+	expected := now.AddDate(0, 0, group.leadTimeDays)
+	po := domain.PurchaseOrder{
+		SupplierID:           group.key.SupplierID,
+		Status:               "DRAFT",
+		OrderDate:            now,
+		ExpectedDeliveryDate: &expected,
+		CreatedBy:            userID,
+		LocationID:           group.key.LocationID,
+	}
+	autoApproved := supplier.AutoApproveLimit > 0 && value <= supplier.AutoApproveLimit
+	if autoApproved {
+		po.Status = "APPROVED"
+		po.ApprovedAt = &now
+	}
+	for _, line := range group.lines {
+		po.PurchaseOrderItems = append(po.PurchaseOrderItems, domain.PurchaseOrderItem{
+			ProductID:       line.ProductID,
+			OrderedQuantity: line.Quantity,
+			UnitPrice:       line.UnitPrice,
+		})
+	}
+	if err := tx.Omit("Supplier").Create(&po).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create purchase order for supplier %d: %w", group.key.SupplierID, err)
+	}
+	if autoApproved {
+		// Recorded as a decided step so the approval history shows why nobody signed the order off
+		approval := domain.POApproval{
+			PurchaseOrderID: po.ID,
+			Round:           1,
+			OrderValue:      value,
+			Status:          domain.ApprovalStatusApproved,
+			DecidedAt:       &now,
+			Comments:        fmt.Sprintf("Approved automatically: within %s's auto-approve limit of %.2f", supplier.Name, supplier.AutoApproveLimit),
+		}
+		if err := tx.Create(&approval).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to record approval of purchase order %d: %w", po.ID, err)
+		}
+	}
+	if err := tx.Model(&domain.ReorderSuggestion{}).Where("id IN ?", group.suggestions).
+		Updates(map[string]interface{}{"Status": "PO_CREATED", "PurchaseOrderID": po.ID}).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to update suggestions for purchase order %d: %w", po.ID, err)
+	}
+
+	return &ConsolidatedPurchaseOrder{
+		PurchaseOrderID: po.ID,
+		SupplierID:      po.SupplierID,
+		LocationID:      po.LocationID,
+		SuggestionIDs:   group.suggestions,
+		Lines:           len(po.PurchaseOrderItems),
+		OrderValue:      roundTo(value, 2),
+		ToppedUpBy:      roundTo(value-initialValue, 2),
+		FreeShipping:    group.freeShipping(),
+		AutoApproved:    autoApproved,
+	}, nil, nil
+}
+
+// RunScheduledSuggestionConsolidation is invoked by the scheduler; it is a no-op when consolidation is disabled.
+func RunScheduledSuggestionConsolidation(s POConsolidationService, settings SettingsService) {
+	if !settingBool(settings, "po_consolidation_enabled", true) {
+		return
+	}
+	result, err := s.ConsolidateSuggestions(0, 0)
+	if err != nil {
+		logrus.Errorf("Failed to consolidate reorder suggestions: %v", err)
+		return
+	}
+	logrus.Infof("Consolidated approved reorder suggestions into %d purchase orders (%d held below minimum order value)",
+		len(result.PurchaseOrders), len(result.Held))
+}
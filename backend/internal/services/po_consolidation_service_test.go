@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+
+	"inventory/backend/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func consolidationTestGroup(supplier domain.Supplier, lines ...consolidationLine) *consolidationGroup {
+	group := &consolidationGroup{key: supplierLocationKey{SupplierID: 7, LocationID: 2}, supplier: supplier, suggestions: []uint{11, 12}}
+	for i := range lines {
+		line := lines[i]
+		group.lines = append(group.lines, &line)
+	}
+	return group
+}
+
+func quantities(group *consolidationGroup) []float64 {
+	q := make([]float64, len(group.lines))
+	for i, line := range group.lines {
+		q[i] = line.Quantity
+	}
+	return q
+}
+
+func TestConsolidationGroupTopUp(t *testing.T) {
+	slow := consolidationLine{ProductID: 1, Quantity: 2, UnitPrice: 10, DailyDemand: 1, Increment: 1}
+	fast := consolidationLine{ProductID: 2, Quantity: 2, UnitPrice: 10, DailyDemand: 5, Increment: 1}
+
+	tests := []struct {
+		name   string
+		lines  []consolidationLine
+		target float64
+		want   []float64
+	}{
+		{"fastest seller first", []consolidationLine{slow, fast}, 50, []float64{2, 3}},
+		{"one pack per line in turn", []consolidationLine{slow, fast}, 70, []float64{3, 4}},
+		{"already at the target", []consolidationLine{slow, fast}, 40, []float64{2, 2}},
+		{
+			name:   "whole packs only",
+			lines:  []consolidationLine{{ProductID: 3, Quantity: 10, UnitPrice: 1, DailyDemand: 1, Increment: 6}},
+			target: 12,
+			want:   []float64{16},
+		},
+		{
+			name:   "unpriced lines are not topped up",
+			lines:  []consolidationLine{{ProductID: 4, Quantity: 5, Increment: 1}, {ProductID: 5, Quantity: 1, UnitPrice: 4, Increment: 1}},
+			target: 10,
+			want:   []float64{5, 3},
+		},
+		{
+			name:   "nothing priced to top up",
+			lines:  []consolidationLine{{ProductID: 4, Quantity: 5, Increment: 1}},
+			target: 10,
+			want:   []float64{5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := consolidationTestGroup(domain.Supplier{}, tt.lines...)
+			group.topUp(tt.target)
+			assert.Equal(t, tt.want, quantities(group))
+		})
+	}
+}
+
+func TestConsolidationGroupApplyPurchasingTerms(t *testing.T) {
+	line := func(quantity float64) consolidationLine {
+		return consolidationLine{ProductID: 1, Quantity: quantity, UnitPrice: 1, DailyDemand: 1, Increment: 1}
+	}
+
+	tests := []struct {
+		name             string
+		supplier         domain.Supplier
+		quantity         float64
+		wantHeld         bool
+		wantValue        float64
+		wantFreeShipping bool
+	}{
+		{"no terms", domain.Supplier{}, 40, false, 40, false},
+		{"at the minimum order value", domain.Supplier{MinimumOrderValue: 100}, 100, false, 100, false},
+		{"exactly 25% short of the minimum is topped up", domain.Supplier{MinimumOrderValue: 100}, 75, false, 100, false},
+		{"more than 25% short of the minimum is held", domain.Supplier{MinimumOrderValue: 100}, 74, true, 74, false},
+		{"within 25% of free shipping is topped up", domain.Supplier{FreeShippingThreshold: 200}, 150, false, 200, true},
+		{"further from free shipping is left alone", domain.Supplier{FreeShippingThreshold: 200}, 149, false, 149, false},
+		{"above the free-shipping threshold", domain.Supplier{FreeShippingThreshold: 200}, 250, false, 250, true},
+		{"minimum first, then free shipping", domain.Supplier{MinimumOrderValue: 100, FreeShippingThreshold: 120}, 80, false, 120, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := consolidationTestGroup(tt.supplier, line(tt.quantity))
+			held := group.applyPurchasingTerms()
+			if tt.wantHeld {
+				require.NotNil(t, held)
+				assert.Equal(t, uint(7), held.SupplierID)
+				assert.Equal(t, uint(2), held.LocationID)
+				assert.Equal(t, []uint{11, 12}, held.SuggestionIDs)
+				assert.Equal(t, tt.wantValue, held.OrderValue)
+				assert.Equal(t, tt.supplier.MinimumOrderValue, held.MinimumOrderValue)
+			} else {
+				assert.Nil(t, held)
+			}
+			assert.Equal(t, tt.wantValue, group.value())
+			assert.Equal(t, tt.wantFreeShipping, group.freeShipping())
+		})
+	}
+}
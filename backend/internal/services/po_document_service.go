@@ -0,0 +1,330 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/storage"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// purchaseOrderBucket is the object storage bucket purchase order PDFs are kept in.
+const purchaseOrderBucket = "purchase-orders"
+
+// PurchaseOrderDocument is a rendered purchase order PDF.
+type PurchaseOrderDocument struct {
+	FileName   string // Name offered to the supplier, e.g. PO-000042.pdf
+	ObjectName string // Key in the purchase order bucket; empty when storing failed
+	Content    []byte
+}
+
+type PODocumentService interface {
+	// RenderPurchaseOrderPDF renders the order as a branded PDF without storing it, e.g. for downloads.
+	RenderPurchaseOrderPDF(poID uint) (*PurchaseOrderDocument, error)
+	// GeneratePurchaseOrderPDF renders the order and stores it in object storage, replacing the copy
+	// from an earlier rendering. It is called when the order is sent and whenever it changes after that.
+	GeneratePurchaseOrderPDF(poID uint) (*PurchaseOrderDocument, error)
+}
+
+type poDocumentService struct {
+	db       *gorm.DB
+	uploader storage.Uploader
+	settings SettingsService
+}
+
+func NewPODocumentService(db *gorm.DB, uploader storage.Uploader, settings SettingsService) PODocumentService {
+	return &poDocumentService{db: db, uploader: uploader, settings: settings}
+}
+
+// businessDetails is the letterhead printed on documents sent to suppliers.
+type businessDetails struct {
+	Name     string
+	Address  string
+	Phone    string
+	Email    string
+	TaxID    string
+	Currency string
+}
+
+func (s *poDocumentService) businessDetails() businessDetails {
+	return businessDetails{
+		Name:     settingString(s.settings, "business_name", "Quantify Business"),
+		Address:  settingString(s.settings, "business_address", ""),
+		Phone:    settingString(s.settings, "business_phone", ""),
+		Email:    settingString(s.settings, "business_email", ""),
+		TaxID:    settingString(s.settings, "business_tax_id", ""),
+		Currency: settingString(s.settings, "currency_symbol", "$"),
+	}
+}
+
+func (s *poDocumentService) RenderPurchaseOrderPDF(poID uint) (*PurchaseOrderDocument, error) {
+	var po domain.PurchaseOrder
+	if err := s.db.Preload("Supplier").Preload("PurchaseOrderItems", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("PurchaseOrderItems.Product").First(&po, poID).Error; err != nil {
+		return nil, err
+	}
+
+	locationIDs := make([]uint, 0, len(po.PurchaseOrderItems)+1)
+	locationIDs = append(locationIDs, po.LocationID)
+	for _, item := range po.PurchaseOrderItems {
+		locationIDs = append(locationIDs, po.DeliveryLocation(&item.Product))
+	}
+	var locations []domain.Location
+	if err := s.db.Where("id IN ?", locationIDs).Find(&locations).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch delivery locations: %w", err)
+	}
+	locationsByID := make(map[uint]domain.Location, len(locations))
+	for _, location := range locations {
+		locationsByID[location.ID] = location
+	}
+
+	content, err := renderPurchaseOrderPDF(&po, locationsByID, s.businessDetails())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render purchase order %d: %w", po.ID, err)
+	}
+
+	return &PurchaseOrderDocument{FileName: fmt.Sprintf("PO-%06d.pdf", po.ID), Content: content}, nil
+}
+
+func (s *poDocumentService) GeneratePurchaseOrderPDF(poID uint) (*PurchaseOrderDocument, error) {
+	doc, err := s.RenderPurchaseOrderPDF(poID)
+	if err != nil {
+		return nil, err
+	}
+
+	objectName := fmt.Sprintf("po-%d.pdf", poID)
+	if s.uploader == nil {
+		logrus.Warnf("Object storage not configured; purchase order %d PDF was not stored", poID)
+		return doc, nil
+	}
+	if _, err := s.uploader.UploadFile(purchaseOrderBucket, objectName, bytes.NewBuffer(doc.Content), int64(len(doc.Content))); err != nil {
+		// The document is still usable for the email that asked for it
+		logrus.Errorf("Failed to store purchase order %d PDF: %v", poID, err)
+		return doc, nil
+	}
+	doc.ObjectName = objectName
+	return doc, nil
+}
+
+// renderPurchaseOrderPDF lays out the order on A4: letterhead and order details, supplier and delivery
+// addresses, the lines and the order total. A location column is added when lines go to different locations.
+func renderPurchaseOrderPDF(po *domain.PurchaseOrder, locations map[uint]domain.Location, business businessDetails) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 10, tr(fmt.Sprintf("%s - Purchase Order PO-%06d - Page %d of {nb}", business.Name, po.ID, pdf.PageNo())), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	money := func(v float64) string { return tr(fmt.Sprintf("%s%.2f", business.Currency, v)) }
+
+	// Letterhead on the left, order details on the right
+	pdf.SetFont("Arial", "B", 18)
+	pdf.SetTextColor(33, 37, 41)
+	pdf.CellFormat(100, 9, tr(business.Name), "", 0, "L", false, 0, "")
+	pdf.SetFont("Arial", "B", 16)
+	pdf.SetTextColor(0, 86, 179)
+	pdf.CellFormat(0, 9, "PURCHASE ORDER", "", 1, "R", false, 0, "")
+
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetTextColor(80, 80, 80)
+	var letterhead []string
+	if business.Address != "" {
+		letterhead = append(letterhead, strings.Split(business.Address, "\n")...)
+	}
+	var contact []string
+	for _, v := range []string{business.Phone, business.Email} {
+		if v != "" {
+			contact = append(contact, v)
+		}
+	}
+	if len(contact) > 0 {
+		letterhead = append(letterhead, strings.Join(contact, " | "))
+	}
+	if business.TaxID != "" {
+		letterhead = append(letterhead, "Tax ID: "+business.TaxID)
+	}
+	details := [][2]string{
+		{"PO Number", fmt.Sprintf("PO-%06d", po.ID)},
+		{"Order Date", po.OrderDate.Format("2006-01-02")},
+		{"Expected Delivery", "ASAP"},
+	}
+	if po.ExpectedDeliveryDate != nil {
+		details[2][1] = po.ExpectedDeliveryDate.Format("2006-01-02")
+	}
+	for i := 0; i < len(letterhead) || i < len(details); i++ {
+		left := ""
+		if i < len(letterhead) {
+			left = letterhead[i]
+		}
+		pdf.SetFont("Arial", "", 9)
+		pdf.CellFormat(100, 5, tr(left), "", 0, "L", false, 0, "")
+		if i < len(details) {
+			pdf.SetFont("Arial", "B", 9)
+			pdf.CellFormat(40, 5, details[i][0]+":", "", 0, "R", false, 0, "")
+			pdf.SetFont("Arial", "", 9)
+			pdf.CellFormat(0, 5, tr(details[i][1]), "", 0, "R", false, 0, "")
+		}
+		pdf.Ln(5)
+	}
+	pdf.Ln(4)
+	pdf.SetDrawColor(0, 86, 179)
+	pdf.Line(15, pdf.GetY(), 195, pdf.GetY())
+	pdf.Ln(5)
+
+	// Supplier and delivery addresses
+	lineLocations := make([]uint, len(po.PurchaseOrderItems))
+	distinct := make(map[uint]bool)
+	for i, item := range po.PurchaseOrderItems {
+		lineLocations[i] = po.DeliveryLocation(&item.Product)
+		distinct[lineLocations[i]] = true
+	}
+	perLineLocation := len(distinct) > 1
+
+	supplier := []string{po.Supplier.Name}
+	if po.Supplier.ContactPerson != "" {
+		supplier = append(supplier, "Attn: "+po.Supplier.ContactPerson)
+	}
+	if po.Supplier.Address != "" {
+		supplier = append(supplier, strings.Split(po.Supplier.Address, "\n")...)
+	}
+	for _, v := range []string{po.Supplier.Phone, po.Supplier.Email} {
+		if v != "" {
+			supplier = append(supplier, v)
+		}
+	}
+	var deliverTo []string
+	switch {
+	case perLineLocation:
+		deliverTo = []string{"Multiple locations - see the lines below"}
+	case len(lineLocations) > 0:
+		location := locations[lineLocations[0]]
+		deliverTo = append(deliverTo, location.Name)
+		if location.Address != "" {
+			deliverTo = append(deliverTo, strings.Split(location.Address, "\n")...)
+		}
+	default:
+		if location, ok := locations[po.LocationID]; ok {
+			deliverTo = []string{location.Name}
+		}
+	}
+
+	pdf.SetFont("Arial", "B", 10)
+	pdf.SetTextColor(0, 86, 179)
+	pdf.CellFormat(90, 6, "SUPPLIER", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "DELIVER TO", "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.SetTextColor(33, 37, 41)
+	for i := 0; i < len(supplier) || i < len(deliverTo); i++ {
+		left, right := "", ""
+		if i < len(supplier) {
+			left = supplier[i]
+		}
+		if i < len(deliverTo) {
+			right = deliverTo[i]
+		}
+		pdf.CellFormat(90, 5, tr(left), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, tr(right), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	// Lines
+	type column struct {
+		title string
+		width float64
+		align string
+	}
+	columns := []column{{"#", 8, "C"}, {"SKU", 28, "L"}, {"Description", 62, "L"}, {"Qty", 18, "R"}, {"Unit", 14, "L"}, {"Unit Price", 25, "R"}, {"Total", 25, "R"}}
+	if perLineLocation {
+		columns = []column{{"#", 8, "C"}, {"SKU", 24, "L"}, {"Description", 46, "L"}, {"Deliver To", 30, "L"}, {"Qty", 16, "R"}, {"Unit", 12, "L"}, {"Unit Price", 22, "R"}, {"Total", 22, "R"}}
+	}
+	header := func() {
+		pdf.SetFont("Arial", "B", 9)
+		pdf.SetFillColor(0, 86, 179)
+		pdf.SetTextColor(255, 255, 255)
+		for _, col := range columns {
+			pdf.CellFormat(col.width, 7, col.title, "1", 0, col.align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Arial", "", 9)
+		pdf.SetTextColor(33, 37, 41)
+	}
+	header()
+
+	var subtotal, totalQuantity float64
+	for i, item := range po.PurchaseOrderItems {
+		if pdf.GetY() > 265 {
+			pdf.AddPage()
+			header()
+		}
+		name := item.Product.Name
+		if name == "" {
+			name = fmt.Sprintf("Product ID %d", item.ProductID)
+		}
+		lineTotal := item.OrderedQuantity * item.UnitPrice
+		subtotal += lineTotal
+		totalQuantity += item.OrderedQuantity
+		values := []string{fmt.Sprintf("%d", i+1), item.Product.SKU, name}
+		if perLineLocation {
+			values = append(values, locations[lineLocations[i]].Name)
+		}
+		values = append(values, fmt.Sprintf("%g", item.OrderedQuantity), item.Product.UnitOfMeasure, money(item.UnitPrice), money(lineTotal))
+
+		fill := i%2 == 1
+		pdf.SetFillColor(242, 246, 252)
+		for j, col := range columns {
+			pdf.CellFormat(col.width, 6, fitText(pdf, tr(values[j]), col.width-2), "LR", 0, col.align, fill, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	var tableWidth float64
+	for _, col := range columns {
+		tableWidth += col.width
+	}
+	pdf.Line(15, pdf.GetY(), 15+tableWidth, pdf.GetY())
+	pdf.Ln(3)
+
+	// Totals
+	totalWidth := columns[len(columns)-1].width
+	labelWidth := tableWidth - totalWidth
+	pdf.SetFont("Arial", "", 9)
+	pdf.CellFormat(labelWidth, 6, "Total Quantity", "", 0, "R", false, 0, "")
+	pdf.CellFormat(totalWidth, 6, fmt.Sprintf("%g", totalQuantity), "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(labelWidth, 8, "Order Total", "", 0, "R", false, 0, "")
+	pdf.SetFillColor(230, 238, 250)
+	pdf.CellFormat(totalWidth, 8, money(subtotal), "T", 1, "R", true, 0, "")
+	pdf.Ln(8)
+
+	pdf.SetFont("Arial", "", 8)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.MultiCell(0, 4, tr(fmt.Sprintf("Please quote PO-%06d on all delivery notes and invoices. Prices exclude tax unless stated otherwise.", po.ID)), "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// fitText shortens text with an ellipsis until it fits in width, keeping every line on one row.
+func fitText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 1 && pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
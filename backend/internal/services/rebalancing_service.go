@@ -0,0 +1,358 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/repository"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTransferNotDraft is returned when approving or cancelling a transfer that is no longer a draft.
+var ErrTransferNotDraft = errors.New("transfer is not a draft")
+
+const (
+	// rebalanceSalesWindowDays is the sales history used to split demand between locations.
+	rebalanceSalesWindowDays = 28
+	// rebalanceCoverDays is how many days of forecast demand each location should hold.
+	rebalanceCoverDays = 14
+)
+
+// TransferApprovalFailure explains why one draft in a bulk approval was not posted.
+type TransferApprovalFailure struct {
+	TransferID uint   `json:"transferId"`
+	Error      string `json:"error"`
+}
+
+// TransferApprovalResult lists the drafts posted and rejected by a bulk approval.
+type TransferApprovalResult struct {
+	Approved []domain.StockTransfer    `json:"approved"`
+	Failed   []TransferApprovalFailure `json:"failed"`
+}
+
+type RebalancingService interface {
+	GenerateRebalancingDrafts(userID uint) ([]domain.StockTransfer, error)
+	ApproveDrafts(ids []uint, userID uint) *TransferApprovalResult
+	CancelDrafts(ids []uint) (int64, error)
+}
+
+type rebalancingService struct {
+	db *gorm.DB
+}
+
+func NewRebalancingService(db *gorm.DB) RebalancingService {
+	return &rebalancingService{db: db}
+}
+
+// locationBalance is one location's stock measured against its thresholds and expected demand.
+type locationBalance struct {
+	setting   domain.ProductAlertSettings
+	available float64
+	demand    float64
+	shortage  float64
+	surplus   float64
+	batches   []domain.Batch // Surplus candidates, earliest expiry first
+}
+
+// GenerateRebalancingDrafts compares every location that has its own thresholds against its
+// min/max and forecast demand, and drafts transfers from overstocked to short locations. Donor
+// stock is drawn from near-expiry batches first so it sells before it spoils. Products that
+// already have open rebalancing drafts are skipped until those are approved or cancelled.
+func (s *rebalancingService) GenerateRebalancingDrafts(userID uint) ([]domain.StockTransfer, error) {
+	var settings []domain.ProductAlertSettings
+	if err := s.db.Preload("Product").Where("location_id <> 0").Order("product_id, location_id").Find(&settings).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch location settings: %w", err)
+	}
+
+	byProduct := make(map[uint][]domain.ProductAlertSettings)
+	var productIDs []uint
+	for _, setting := range settings {
+		if _, ok := byProduct[setting.ProductID]; !ok {
+			productIDs = append(productIDs, setting.ProductID)
+		}
+		byProduct[setting.ProductID] = append(byProduct[setting.ProductID], setting)
+	}
+	if len(productIDs) == 0 {
+		return []domain.StockTransfer{}, nil
+	}
+
+	var drafted []uint
+	if err := s.db.Model(&domain.StockTransfer{}).
+		Where("product_id IN ? AND status = ? AND origin = ?", productIDs, "DRAFT", "REBALANCE").
+		Distinct().Pluck("product_id", &drafted).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch open drafts: %w", err)
+	}
+	skip := make(map[uint]bool, len(drafted))
+	for _, id := range drafted {
+		skip[id] = true
+	}
+
+	sales, err := s.locationSalesRates(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	forecasts, err := s.latestForecasts(productIDs)
+	if err != nil {
+		return nil, err
+	}
+	inbound, err := s.inboundTransfers(productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	drafts := []domain.StockTransfer{}
+	for _, productID := range productIDs {
+		if skip[productID] {
+			continue
+		}
+		locations := byProduct[productID]
+		if len(locations) < 2 {
+			continue // Nothing to balance against
+		}
+		product := locations[0].Product
+
+		positions, err := repository.GetStockPositions(s.db, &product, 0, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch stock positions for product %d: %w", productID, err)
+		}
+		available := make(map[uint]float64, len(positions))
+		for _, position := range positions {
+			available[position.LocationID] = position.Available
+		}
+
+		demand := locationDemand(locations, sales, forecasts[productID])
+
+		var short, over []*locationBalance
+		for _, setting := range locations {
+			key := repository.StockKey{ProductID: productID, LocationID: setting.LocationID}
+			balance := &locationBalance{
+				setting:   setting,
+				available: available[setting.LocationID] + inbound[key],
+				demand:    demand[setting.LocationID],
+			}
+			target := math.Max(setting.OrderUpToLevel(), balance.demand)
+			if balance.available <= math.Max(setting.ReorderPoint(), balance.demand) {
+				balance.shortage = floorQuantity(&product, target-balance.available)
+			} else if excess := floorQuantity(&product, available[setting.LocationID]-target); excess > 0 {
+				balance.surplus = excess
+			}
+			switch {
+			case balance.shortage > 0:
+				short = append(short, balance)
+			case balance.surplus > 0:
+				if err := s.db.Where("product_id = ? AND location_id = ? AND quantity > 0", productID, setting.LocationID).
+					Where("expiry_date IS NULL OR expiry_date > ?", time.Now()).
+					Order("CASE WHEN expiry_date IS NULL THEN 1 ELSE 0 END, expiry_date ASC, id ASC").
+					Find(&balance.batches).Error; err != nil {
+					return nil, fmt.Errorf("failed to fetch batches for product %d: %w", productID, err)
+				}
+				over = append(over, balance)
+			}
+		}
+		if len(short) == 0 || len(over) == 0 {
+			continue
+		}
+
+		// Fill the largest shortages first, from the donors holding the nearest expiry
+		sort.SliceStable(short, func(i, j int) bool { return short[i].shortage > short[j].shortage })
+		sort.SliceStable(over, func(i, j int) bool {
+			return earlierExpiry(over[i].batches, over[j].batches)
+		})
+
+		for _, dest := range short {
+			for _, source := range over {
+				reason := fmt.Sprintf("Destination holds %g against reorder point %g and %g forecast demand; source holds %g above its max",
+					dest.available, dest.setting.ReorderPoint(), dest.demand, source.surplus)
+				for i := range source.batches {
+					if dest.shortage <= 0 || source.surplus <= 0 {
+						break
+					}
+					batch := &source.batches[i]
+					quantity := floorQuantity(&product, math.Min(math.Min(dest.shortage, source.surplus), batch.Quantity))
+					if quantity <= 0 {
+						continue
+					}
+					batchID := batch.ID
+					drafts = append(drafts, domain.StockTransfer{
+						ProductID:        productID,
+						SourceLocationID: source.setting.LocationID,
+						DestLocationID:   dest.setting.LocationID,
+						Quantity:         quantity,
+						Status:           "DRAFT",
+						Origin:           "REBALANCE",
+						BatchID:          &batchID,
+						Reason:           reason,
+						TransferredBy:    userID,
+						TransferredAt:    time.Now(),
+					})
+					batch.Quantity -= quantity
+					dest.shortage -= quantity
+					source.surplus -= quantity
+				}
+			}
+		}
+	}
+
+	if len(drafts) == 0 {
+		return drafts, nil
+	}
+	if err := s.db.Create(&drafts).Error; err != nil {
+		return nil, fmt.Errorf("failed to save rebalancing drafts: %w", err)
+	}
+	logrus.Infof("Drafted %d rebalancing transfers", len(drafts))
+	return drafts, nil
+}
+
+// ApproveDrafts posts each draft in its own transaction, so one short source location does not
+// hold back the rest of the batch. Posted drafts ship like any transfer and move their batch when
+// received with repository.CompleteStockTransfer.
+func (s *rebalancingService) ApproveDrafts(ids []uint, userID uint) *TransferApprovalResult {
+	result := &TransferApprovalResult{
+		Approved: []domain.StockTransfer{},
+		Failed:   []TransferApprovalFailure{},
+	}
+	for _, id := range ids {
+		var transfer domain.StockTransfer
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, id).Error; err != nil {
+				return err
+			}
+			if transfer.Status != "DRAFT" {
+				return ErrTransferNotDraft
+			}
+			now := time.Now()
+			transfer.ApprovedBy = &userID
+			transfer.ApprovedAt = &now
+			var product domain.Product
+			return repository.PostStockTransfer(tx, &product, &transfer, userID)
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				err = errors.New("transfer not found")
+			}
+			result.Failed = append(result.Failed, TransferApprovalFailure{TransferID: id, Error: err.Error()})
+			continue
+		}
+		result.Approved = append(result.Approved, transfer)
+	}
+	return result
+}
+
+// CancelDrafts cancels the listed transfers that are still drafts and reports how many changed.
+func (s *rebalancingService) CancelDrafts(ids []uint) (int64, error) {
+	res := s.db.Model(&domain.StockTransfer{}).
+		Where("id IN ? AND status = ?", ids, "DRAFT").
+		Update("status", "CANCELLED")
+	return res.RowsAffected, res.Error
+}
+
+// locationSalesRates returns each product's average daily sales per location over the window.
+func (s *rebalancingService) locationSalesRates(productIDs []uint) (map[repository.StockKey]float64, error) {
+	var rows []struct {
+		ProductID  uint
+		LocationID uint
+		Quantity   float64
+	}
+	if err := s.db.Model(&domain.StockAdjustment{}).
+		Select("product_id, location_id, SUM(quantity) AS quantity").
+		Where("product_id IN ? AND type = ? AND reason_code = ? AND adjusted_at >= ?",
+			productIDs, "STOCK_OUT", "SALE", time.Now().AddDate(0, 0, -rebalanceSalesWindowDays)).
+		Group("product_id, location_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch sales by location: %w", err)
+	}
+	rates := make(map[repository.StockKey]float64, len(rows))
+	for _, row := range rows {
+		rates[repository.StockKey{ProductID: row.ProductID, LocationID: row.LocationID}] = row.Quantity / rebalanceSalesWindowDays
+	}
+	return rates, nil
+}
+
+// latestForecasts returns the most recent demand forecast for each product that has one.
+func (s *rebalancingService) latestForecasts(productIDs []uint) (map[uint]*domain.DemandForecast, error) {
+	var forecasts []domain.DemandForecast
+	if err := s.db.Where("product_id IN ?", productIDs).Order("generated_at DESC, id DESC").Find(&forecasts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch demand forecasts: %w", err)
+	}
+	latest := make(map[uint]*domain.DemandForecast)
+	for i := range forecasts {
+		if _, ok := latest[forecasts[i].ProductID]; !ok {
+			latest[forecasts[i].ProductID] = &forecasts[i]
+		}
+	}
+	return latest, nil
+}
+
+// inboundTransfers sums posted transfers still on their way to each location.
+func (s *rebalancingService) inboundTransfers(productIDs []uint) (map[repository.StockKey]float64, error) {
+	var rows []struct {
+		ProductID      uint
+		DestLocationID uint
+		Quantity       float64
+	}
+	if err := s.db.Model(&domain.StockTransfer{}).
+		Select("product_id, dest_location_id, SUM(quantity) AS quantity").
+		Where("product_id IN ? AND status = ?", productIDs, "PENDING").
+		Group("product_id, dest_location_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch inbound transfers: %w", err)
+	}
+	inbound := make(map[repository.StockKey]float64, len(rows))
+	for _, row := range rows {
+		inbound[repository.StockKey{ProductID: row.ProductID, LocationID: row.DestLocationID}] = row.Quantity
+	}
+	return inbound, nil
+}
+
+// locationDemand estimates each location's demand over the cover period. A product forecast is
+// split by each location's share of recent sales (evenly when nothing sold); without a forecast
+// the location's own sales rate is used.
+func locationDemand(locations []domain.ProductAlertSettings, sales map[repository.StockKey]float64, forecast *domain.DemandForecast) map[uint]float64 {
+	var totalRate float64
+	for _, setting := range locations {
+		totalRate += sales[repository.StockKey{ProductID: setting.ProductID, LocationID: setting.LocationID}]
+	}
+
+	demand := make(map[uint]float64, len(locations))
+	for _, setting := range locations {
+		rate := sales[repository.StockKey{ProductID: setting.ProductID, LocationID: setting.LocationID}]
+		if forecast != nil {
+			share := 1 / float64(len(locations))
+			if totalRate > 0 {
+				share = rate / totalRate
+			}
+			rate = forecast.DailyDemand() * share
+		}
+		demand[setting.LocationID] = rate * rebalanceCoverDays
+	}
+	return demand
+}
+
+// earlierExpiry reports whether the first batch list expires before the second; batches without
+// an expiry date sort last.
+func earlierExpiry(a, b []domain.Batch) bool {
+	if len(a) == 0 || a[0].ExpiryDate == nil {
+		return false
+	}
+	if len(b) == 0 || b[0].ExpiryDate == nil {
+		return true
+	}
+	return a[0].ExpiryDate.Before(*b[0].ExpiryDate)
+}
+
+// floorQuantity rounds q down to the product's quantity precision so drafts never exceed the surplus.
+func floorQuantity(product *domain.Product, q float64) float64 {
+	rounded := product.RoundQuantity(q)
+	if rounded > q {
+		step := math.Pow(10, -float64(product.QuantityPrecision))
+		rounded = product.RoundQuantity(rounded - step)
+	}
+	return rounded
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"inventory/backend/internal/domain"
+)
+
+// demandStats is the mean and sample variance of daily sales.
+type demandStats struct {
+	Mean     float64
+	Variance float64
+}
+
+// replenishmentPlan is how one product at one location is replenished under its reorder policy, given
+// the supplier it would be bought from, together with the inputs that produced it.
+type replenishmentPlan struct {
+	Policy          domain.ReorderPolicy
+	Z               float64
+	DailyDemand     float64
+	DemandStdDev    float64
+	LeadTimeDays    float64
+	LeadTimeStdDev  float64
+	LeadTimeSamples int // Deliveries behind the learned lead time; 0 = catalog lead time
+	UnitCost        float64
+	LeadTimeDemand  float64
+	SafetyStock     float64
+	ReorderPoint    float64
+	OrderUpToLevel  float64
+	EOQ             float64
+}
+
+// planReplenishment applies a reorder policy to demand and lead-time statistics. Safety stock covers
+// variation in both daily demand and lead time over the protection interval P: z * sqrt(P*σd² + d²*σL²),
+// where z comes from the policy's service level and P is the lead time, plus the review period under
+// periodic review. The reorder point is the demand expected over the lead time plus that safety stock.
+// Configured levels act as floors throughout.
+//
+//   - MIN_MAX orders up to a level covering at least one further lead time of demand.
+//   - EOQ orders the economic order quantity for the policy's ordering and holding costs.
+//   - PERIODIC_REVIEW orders up to the demand expected over the protection interval plus safety stock.
+func planReplenishment(policy domain.ReorderPolicy, setting *domain.ProductAlertSettings, demand demandStats, leadTime *LeadTimeStats, catalogLeadTime int, unitCost float64) replenishmentPlan {
+	plan := replenishmentPlan{
+		Policy:       policy,
+		Z:            policy.ZScore(),
+		DailyDemand:  demand.Mean,
+		DemandStdDev: math.Sqrt(demand.Variance),
+		LeadTimeDays: float64(catalogLeadTime),
+		UnitCost:     unitCost,
+	}
+	var leadTimeVariance float64
+	if leadTime != nil {
+		plan.LeadTimeDays = leadTime.MeanDays
+		plan.LeadTimeStdDev = leadTime.StdDevDays
+		plan.LeadTimeSamples = leadTime.Samples
+		leadTimeVariance = leadTime.VarianceDays
+	}
+
+	protection := plan.LeadTimeDays
+	if policy.PolicyType == domain.PolicyPeriodicReview {
+		protection += float64(policy.ReviewPeriodDays)
+	}
+	plan.LeadTimeDemand = demand.Mean * plan.LeadTimeDays
+	plan.SafetyStock = plan.Z * math.Sqrt(protection*demand.Variance+demand.Mean*demand.Mean*leadTimeVariance)
+	if setting.SafetyStock > plan.SafetyStock {
+		plan.SafetyStock = setting.SafetyStock
+	}
+	plan.ReorderPoint = math.Max(setting.ReorderPoint(), plan.LeadTimeDemand+plan.SafetyStock)
+
+	switch policy.PolicyType {
+	case domain.PolicyPeriodicReview:
+		plan.OrderUpToLevel = math.Max(setting.OrderUpToLevel(), demand.Mean*protection+plan.SafetyStock)
+	case domain.PolicyEOQ:
+		plan.EOQ = policy.EconomicOrderQuantity(demand.Mean, unitCost)
+		if plan.EOQ > 0 {
+			plan.OrderUpToLevel = plan.ReorderPoint + plan.EOQ
+			break
+		}
+		// Without demand or costs to balance, fall back to min/max
+		fallthrough
+	default:
+		plan.OrderUpToLevel = math.Max(setting.OrderUpToLevel(), plan.ReorderPoint+plan.LeadTimeDemand)
+	}
+	return plan
+}
+
+// needsOrder reports whether stock calls for an order: at or below the reorder point under any policy,
+// and below the order-up-to level when a periodic review is due.
+func (p *replenishmentPlan) needsOrder(currentStock float64, reviewDue bool) bool {
+	if currentStock <= p.ReorderPoint {
+		return true
+	}
+	return p.Policy.PolicyType == domain.PolicyPeriodicReview && reviewDue && currentStock < p.OrderUpToLevel
+}
+
+// reviewPeriodElapsed reports whether a full review period has passed since the last suggestion.
+func reviewPeriodElapsed(policy domain.ReorderPolicy, last, now time.Time) bool {
+	return now.Sub(last) >= time.Duration(policy.ReviewPeriodDays)*24*time.Hour
+}
+
+// orderQuantity is the quantity the policy orders at the given stock, before rounding to packs. EOQ
+// orders never leave the stock position below the reorder point.
+func (p *replenishmentPlan) orderQuantity(currentStock float64) float64 {
+	if p.EOQ > 0 {
+		return math.Max(p.EOQ, p.ReorderPoint-currentStock)
+	}
+	return p.OrderUpToLevel - currentStock
+}
+
+// rationale explains the suggested quantity in terms of the plan's inputs.
+func (p *replenishmentPlan) rationale(currentStock, quantity float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s policy at %.1f%% service level (z %.2f). ", p.Policy.PolicyType, p.Policy.ServiceLevel*100, p.Z)
+	fmt.Fprintf(&b, "Demand %.2f/day (sd %.2f); ", p.DailyDemand, p.DemandStdDev)
+	if p.LeadTimeSamples > 0 {
+		fmt.Fprintf(&b, "lead time %.1f days (sd %.1f) learned from %d deliveries", p.LeadTimeDays, p.LeadTimeStdDev, p.LeadTimeSamples)
+	} else {
+		fmt.Fprintf(&b, "catalog lead time %.0f days", p.LeadTimeDays)
+	}
+	if p.Policy.PolicyType == domain.PolicyPeriodicReview {
+		fmt.Fprintf(&b, ", reviewed every %d days", p.Policy.ReviewPeriodDays)
+	}
+	fmt.Fprintf(&b, ". Safety stock %.1f, reorder point %.1f, order-up-to level %.1f. ", p.SafetyStock, p.ReorderPoint, p.OrderUpToLevel)
+	if p.EOQ > 0 {
+		fmt.Fprintf(&b, "EOQ %.1f from ordering cost %.2f and holding cost %.0f%% of unit cost %.2f a year. ",
+			p.EOQ, p.Policy.OrderingCost, p.Policy.HoldingCostRate*100, p.UnitCost)
+	}
+	fmt.Fprintf(&b, "Stock %.1f, ordering %g.", currentStock, quantity)
+	return b.String()
+}
+
+// reorderPolicyFor returns the policy of the product's category, else the default policy, else min/max
+// at the default service level. policies is keyed by category, with the default policy under 0.
+func reorderPolicyFor(policies map[uint]domain.ReorderPolicy, categoryID uint) domain.ReorderPolicy {
+	if policy, ok := policies[categoryID]; ok {
+		return policy
+	}
+	if policy, ok := policies[0]; ok {
+		return policy
+	}
+	return domain.DefaultReorderPolicy()
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"inventory/backend/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanReplenishment(t *testing.T) {
+	z := (&domain.ReorderPolicy{ServiceLevel: 0.95}).ZScore()
+	demand := demandStats{Mean: 10, Variance: 4}
+	learned := &LeadTimeStats{Samples: 6, MeanDays: 4, VarianceDays: 1, StdDevDays: 1}
+	eoq := math.Sqrt(2 * 10 * 365 * 50 / (0.25 * 5))
+
+	tests := []struct {
+		name          string
+		policy        domain.ReorderPolicy
+		setting       domain.ProductAlertSettings
+		demand        demandStats
+		leadTime      *LeadTimeStats
+		wantSafety    float64
+		wantReorder   float64
+		wantOrderUpTo float64
+		wantEOQ       float64
+	}{
+		{
+			// Catalog lead time of 5 days: only demand varies
+			name:          "min/max on the catalog lead time",
+			policy:        domain.ReorderPolicy{PolicyType: domain.PolicyMinMax, ServiceLevel: 0.95},
+			demand:        demand,
+			wantSafety:    z * math.Sqrt(5*4),
+			wantReorder:   50 + z*math.Sqrt(5*4),
+			wantOrderUpTo: 100 + z*math.Sqrt(5*4),
+		},
+		{
+			// z * sqrt(L*σd² + d²*σL²) = z * sqrt(4*4 + 100*1)
+			name:          "learned lead time adds its variance",
+			policy:        domain.ReorderPolicy{PolicyType: domain.PolicyMinMax, ServiceLevel: 0.95},
+			demand:        demand,
+			leadTime:      learned,
+			wantSafety:    z * math.Sqrt(116),
+			wantReorder:   40 + z*math.Sqrt(116),
+			wantOrderUpTo: 80 + z*math.Sqrt(116),
+		},
+		{
+			// Protection interval is lead time plus review period: 5 + 7 days
+			name:          "periodic review protects over lead time and review period",
+			policy:        domain.ReorderPolicy{PolicyType: domain.PolicyPeriodicReview, ServiceLevel: 0.95, ReviewPeriodDays: 7},
+			demand:        demand,
+			wantSafety:    z * math.Sqrt(12*4),
+			wantReorder:   50 + z*math.Sqrt(12*4),
+			wantOrderUpTo: 120 + z*math.Sqrt(12*4),
+		},
+		{
+			name:          "EOQ orders the economic quantity above the reorder point",
+			policy:        domain.ReorderPolicy{PolicyType: domain.PolicyEOQ, ServiceLevel: 0.95, OrderingCost: 50, HoldingCostRate: 0.25},
+			demand:        demand,
+			wantSafety:    z * math.Sqrt(5*4),
+			wantReorder:   50 + z*math.Sqrt(5*4),
+			wantOrderUpTo: 50 + z*math.Sqrt(5*4) + eoq,
+			wantEOQ:       eoq,
+		},
+		{
+			name:          "EOQ without demand falls back to min/max",
+			policy:        domain.ReorderPolicy{PolicyType: domain.PolicyEOQ, ServiceLevel: 0.95, OrderingCost: 50, HoldingCostRate: 0.25},
+			setting:       domain.ProductAlertSettings{LowStockLevel: 5, MaxStockLevel: 30},
+			wantSafety:    0,
+			wantReorder:   5,
+			wantOrderUpTo: 30,
+		},
+		{
+			name:          "configured levels are floors",
+			policy:        domain.ReorderPolicy{PolicyType: domain.PolicyMinMax, ServiceLevel: 0.95},
+			setting:       domain.ProductAlertSettings{LowStockLevel: 100, SafetyStock: 20, MaxStockLevel: 400},
+			demand:        demand,
+			wantSafety:    20,
+			wantReorder:   100,
+			wantOrderUpTo: 400,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planReplenishment(tt.policy, &tt.setting, tt.demand, tt.leadTime, 5, 5)
+			assert.InDelta(t, z, plan.Z, 1e-9)
+			assert.InDelta(t, tt.wantSafety, plan.SafetyStock, 1e-9)
+			assert.InDelta(t, tt.wantReorder, plan.ReorderPoint, 1e-9)
+			assert.InDelta(t, tt.wantOrderUpTo, plan.OrderUpToLevel, 1e-9)
+			assert.InDelta(t, tt.wantEOQ, plan.EOQ, 1e-9)
+		})
+	}
+}
+
+func TestPlanReplenishmentRecordsLeadTimeSource(t *testing.T) {
+	policy := domain.DefaultReorderPolicy()
+	setting := domain.ProductAlertSettings{}
+
+	catalog := planReplenishment(policy, &setting, demandStats{Mean: 1}, nil, 9, 2)
+	assert.Equal(t, 9.0, catalog.LeadTimeDays)
+	assert.Zero(t, catalog.LeadTimeSamples)
+
+	learned := planReplenishment(policy, &setting, demandStats{Mean: 1}, &LeadTimeStats{Samples: 4, MeanDays: 6.5, StdDevDays: 1.5}, 9, 2)
+	assert.Equal(t, 6.5, learned.LeadTimeDays)
+	assert.Equal(t, 1.5, learned.LeadTimeStdDev)
+	assert.Equal(t, 4, learned.LeadTimeSamples)
+}
+
+func TestReplenishmentPlanNeedsOrder(t *testing.T) {
+	minMax := replenishmentPlan{Policy: domain.ReorderPolicy{PolicyType: domain.PolicyMinMax}, ReorderPoint: 50, OrderUpToLevel: 120}
+	periodic := replenishmentPlan{Policy: domain.ReorderPolicy{PolicyType: domain.PolicyPeriodicReview}, ReorderPoint: 50, OrderUpToLevel: 120}
+
+	tests := []struct {
+		name      string
+		plan      replenishmentPlan
+		stock     float64
+		reviewDue bool
+		want      bool
+	}{
+		{"min/max at the reorder point", minMax, 50, false, true},
+		{"min/max above the reorder point", minMax, 51, true, false},
+		{"periodic review below the reorder point between reviews", periodic, 40, false, true},
+		{"periodic review due below the order-up-to level", periodic, 80, true, true},
+		{"periodic review not due", periodic, 80, false, false},
+		{"periodic review due at the order-up-to level", periodic, 120, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.plan.needsOrder(tt.stock, tt.reviewDue))
+		})
+	}
+}
+
+func TestReviewPeriodElapsed(t *testing.T) {
+	policy := domain.ReorderPolicy{PolicyType: domain.PolicyPeriodicReview, ReviewPeriodDays: 7}
+	now := time.Date(2025, 3, 15, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		last time.Time
+		want bool
+	}{
+		{"reviewed three days ago", now.AddDate(0, 0, -3), false},
+		{"an hour short of the period", now.AddDate(0, 0, -7).Add(time.Hour), false},
+		{"exactly one period ago", now.AddDate(0, 0, -7), true},
+		{"overdue", now.AddDate(0, 0, -20), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, reviewPeriodElapsed(policy, tt.last, now))
+		})
+	}
+}
+
+func TestReplenishmentPlanOrderQuantity(t *testing.T) {
+	orderUpTo := replenishmentPlan{ReorderPoint: 50, OrderUpToLevel: 120}
+	assert.Equal(t, 90.0, orderUpTo.orderQuantity(30))
+
+	// EOQ orders never leave the position below the reorder point
+	eoq := replenishmentPlan{ReorderPoint: 50, OrderUpToLevel: 90, EOQ: 40}
+	assert.Equal(t, 40.0, eoq.orderQuantity(30))
+	assert.Equal(t, 60.0, eoq.orderQuantity(-10))
+}
+
+func TestReorderPolicyFor(t *testing.T) {
+	category := domain.ReorderPolicy{PolicyType: domain.PolicyEOQ}
+	fallback := domain.ReorderPolicy{PolicyType: domain.PolicyPeriodicReview}
+
+	assert.Equal(t, category, reorderPolicyFor(map[uint]domain.ReorderPolicy{3: category, 0: fallback}, 3))
+	assert.Equal(t, fallback, reorderPolicyFor(map[uint]domain.ReorderPolicy{3: category, 0: fallback}, 4))
+	assert.Equal(t, domain.DefaultReorderPolicy(), reorderPolicyFor(map[uint]domain.ReorderPolicy{3: category}, 4))
+}
@@ -4,21 +4,28 @@ import (
 	"fmt"
 	"inventory/backend/internal/domain"
 	"inventory/backend/internal/repository"
+	"math"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// demandWindowDays is the sales history used to estimate daily demand and its variability.
+	demandWindowDays = 28
+)
+
 type ReplenishmentService interface {
 	GenerateReorderSuggestions() error
 }
 
 type replenishmentService struct {
-	repo repository.ReplenishmentRepository
+	repo      repository.ReplenishmentRepository
+	leadTimes LeadTimeService
 }
 
-func NewReplenishmentService(repo repository.ReplenishmentRepository) ReplenishmentService {
-	return &replenishmentService{repo: repo}
+func NewReplenishmentService(repo repository.ReplenishmentRepository, leadTimes LeadTimeService) ReplenishmentService {
+	return &replenishmentService{repo: repo, leadTimes: leadTimes}
 }
 
 func (s *replenishmentService) GenerateReorderSuggestions() error {
@@ -43,6 +50,11 @@ func (s *replenishmentService) GenerateReorderSuggestions() error {
 		return fmt.Errorf("failed to fetch stock levels: %w", err)
 	}
 
+	locationStockMap, err := s.repo.GetLocationStockLevels(productIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch location stock levels: %w", err)
+	}
+
 	pendingSuggestions, err := s.repo.GetPendingSuggestionsMap(productIDs)
 	if err != nil {
 		return fmt.Errorf("failed to fetch pending suggestions: %w", err)
@@ -53,64 +65,173 @@ func (s *replenishmentService) GenerateReorderSuggestions() error {
 		return fmt.Errorf("failed to fetch pending POs: %w", err)
 	}
 
+	catalogs, err := s.repo.GetProductSuppliersMap(productIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch supplier catalogs: %w", err)
+	}
+
+	demand, err := s.dailyDemand(productIDs)
+	if err != nil {
+		return err
+	}
+
+	leadTimes, err := s.leadTimes.LeadTimeTable()
+	if err != nil {
+		return fmt.Errorf("failed to learn lead times: %w", err)
+	}
+
+	lastSuggested, err := s.repo.GetLastSuggestionTimes(productIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch previous suggestions: %w", err)
+	}
+
+	policies, err := s.reorderPolicies()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
 	for _, setting := range settings {
+		// Location-level settings are measured against that location's own stock
+		key := repository.StockKey{ProductID: setting.ProductID, LocationID: setting.LocationID}
 		currentStock := stockMap[setting.ProductID] // Default 0 if not found
+		if setting.LocationID != 0 {
+			currentStock = locationStockMap[key]
+		}
+
+		// Pick the supplier first: the reorder point depends on how long it takes to deliver. Products
+		// without a catalog fall back to their default supplier and lead time.
+		supplierID := setting.Product.SupplierID
+		catalogLeadTime := domain.DefaultLeadTimeDays
+		unitCost := setting.Product.PurchasePrice
+		urgent := currentStock <= 0 || currentStock < setting.SafetyStock
+		best := domain.ChooseSupplier(catalogs[setting.ProductID], math.Max(setting.OrderUpToLevel()-currentStock, 1), urgent)
+		if best != nil {
+			supplierID = best.SupplierID
+			catalogLeadTime = best.LeadTimeDays
+			unitCost = best.UnitCost
+		}
+
+		policy := reorderPolicyFor(policies, setting.Product.CategoryID)
+		plan := planReplenishment(policy, &setting, demand[key], leadTimes.Lookup(supplierID, setting.ProductID), catalogLeadTime, unitCost)
+		last, reviewed := lastSuggested[key]
+		reviewDue := !reviewed || reviewPeriodElapsed(policy, last, now)
+		if !plan.needsOrder(currentStock, reviewDue) {
+			continue
+		}
+
+		// Check for pending suggestions
+		if pendingSuggestions[key] {
+			continue // Already suggested
+		}
 
-		if currentStock <= setting.LowStockLevel {
-			// Check for pending suggestions
-			if pendingSuggestions[setting.ProductID] {
-				continue // Already suggested
-			}
-
-			// Check for pending POs
-			if pendingPOs[setting.ProductID] {
-				continue // Already ordered
-			}
-
-			// Create Suggestion
-			// Calculate quantity: Target (OverStockLevel) - Current
-			// If OverStockLevel is 0 or less than LowStock, use a default or just LowStock * 2
-			targetStock := setting.OverStockLevel
-			if targetStock <= setting.LowStockLevel {
-				targetStock = setting.LowStockLevel * 3 // Default heuristic
-			}
-			suggestedQty := targetStock - currentStock
-			if suggestedQty <= 0 {
-				suggestedQty = 10 // Fallback
-			}
-
-			// We need the supplier ID. Since we preloaded Product in GetAllProductAlertSettings,
-			// we might have it if Product has SupplierID.
-			// Let's assume Product is preloaded in settings.
-			// If not, we might need to fetch it. But wait, GetAllProductAlertSettings preloads Product.
-			// Does Product have SupplierID? Yes.
-			// Does it have the full Supplier object? Maybe not fully preloaded if nested.
-			// But we only need SupplierID for the suggestion.
-
-			supplierID := setting.Product.SupplierID
-			if supplierID == 0 {
-				logrus.Warnf("Product %d has no supplier configured, skipping suggestion", setting.ProductID)
-				continue
-			}
-
-			suggestion := &domain.ReorderSuggestion{
-				ProductID:              setting.ProductID,
-				SupplierID:             supplierID,
-				CurrentStock:           currentStock,
-				PredictedDemand:        0, // TODO: Integrate with forecasting
-				SuggestedOrderQuantity: suggestedQty,
-				LeadTimeDays:           7, // Default
-				Status:                 "PENDING",
-				SuggestedAt:            time.Now(),
-			}
-
-			if err := s.repo.CreateReorderSuggestion(suggestion); err != nil {
-				logrus.Errorf("Failed to create suggestion for product %d: %v", setting.ProductID, err)
-			} else {
-				logrus.Infof("Created reorder suggestion for product %d, qty %d", setting.ProductID, suggestedQty)
-			}
+		// Check for pending POs. Purchase orders only cover shortfalls at the location they deliver
+		// to, or in the product-wide total.
+		if pendingPOs[key] {
+			continue // Already ordered
+		}
+
+		if supplierID == 0 {
+			logrus.Warnf("Product %d has no supplier configured, skipping suggestion", setting.ProductID)
+			continue
+		}
+
+		// Create Suggestion
+		// Calculate quantity: what the policy orders, rounded to the supplier's minimum and pack size
+		suggestedQty := setting.Product.RoundQuantity(plan.orderQuantity(currentStock))
+		if suggestedQty <= 0 {
+			suggestedQty = 10 // Fallback
+		}
+		var catalogCost float64
+		if best != nil {
+			catalogCost = best.UnitCost
+			suggestedQty = best.OrderQuantity(suggestedQty)
+		}
+
+		suggestion := &domain.ReorderSuggestion{
+			ProductID:              setting.ProductID,
+			SupplierID:             supplierID,
+			LocationID:             setting.LocationID,
+			CurrentStock:           currentStock,
+			PredictedDemand:        int(math.Ceil(plan.LeadTimeDemand)),
+			SuggestedOrderQuantity: suggestedQty,
+			LeadTimeDays:           int(math.Ceil(plan.LeadTimeDays)),
+			UnitCost:               catalogCost,
+			Status:                 "PENDING",
+			SuggestedAt:            now,
+			PolicyType:             policy.PolicyType,
+			ServiceLevel:           policy.ServiceLevel,
+			DailyDemand:            roundTo(plan.DailyDemand, 3),
+			DemandStdDev:           roundTo(plan.DemandStdDev, 3),
+			ExpectedLeadTimeDays:   plan.LeadTimeDays,
+			LeadTimeStdDev:         plan.LeadTimeStdDev,
+			ReviewPeriodDays:       policy.ReviewPeriodDays,
+			SafetyStock:            roundTo(plan.SafetyStock, 2),
+			ReorderPoint:           roundTo(plan.ReorderPoint, 2),
+			OrderUpToLevel:         roundTo(plan.OrderUpToLevel, 2),
+			EconomicOrderQuantity:  roundTo(plan.EOQ, 2),
+			Rationale:              plan.rationale(currentStock, suggestedQty),
+		}
+
+		if err := s.repo.CreateReorderSuggestion(suggestion); err != nil {
+			logrus.Errorf("Failed to create suggestion for product %d at location %d: %v", setting.ProductID, setting.LocationID, err)
+		} else {
+			logrus.Infof("Created reorder suggestion for product %d at location %d, qty %g (%s, reorder point %.2f, safety stock %.2f, lead time %.1f days)",
+				setting.ProductID, setting.LocationID, suggestedQty, policy.PolicyType, plan.ReorderPoint, plan.SafetyStock, plan.LeadTimeDays)
 		}
 	}
 
 	return nil
 }
+
+// reorderPolicies returns the configured reorder policies keyed by category, with the default policy under 0.
+func (s *replenishmentService) reorderPolicies() (map[uint]domain.ReorderPolicy, error) {
+	policies, err := s.repo.GetReorderPolicies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reorder policies: %w", err)
+	}
+	byCategory := make(map[uint]domain.ReorderPolicy, len(policies))
+	for _, policy := range policies {
+		var categoryID uint
+		if policy.CategoryID != nil {
+			categoryID = *policy.CategoryID
+		}
+		byCategory[categoryID] = policy
+	}
+	return byCategory, nil
+}
+
+// dailyDemand returns the mean and variance of each product's daily sales over the demand window, per
+// location and for all locations together (LocationID 0). Days without sales count as zero demand.
+func (s *replenishmentService) dailyDemand(productIDs []uint) (map[repository.StockKey]demandStats, error) {
+	start := time.Now().AddDate(0, 0, -demandWindowDays)
+	sales, err := s.repo.GetSalesSince(productIDs, start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sales history: %w", err)
+	}
+
+	daily := make(map[repository.StockKey][]float64)
+	record := func(key repository.StockKey, day int, qty float64) {
+		if daily[key] == nil {
+			daily[key] = make([]float64, demandWindowDays)
+		}
+		daily[key][day] += qty
+	}
+	for _, sale := range sales {
+		day := int(sale.AdjustedAt.Sub(start).Hours() / 24)
+		if day < 0 || day >= demandWindowDays {
+			continue
+		}
+		record(repository.StockKey{ProductID: sale.ProductID}, day, sale.Quantity)
+		if sale.LocationID != 0 {
+			record(repository.StockKey{ProductID: sale.ProductID, LocationID: sale.LocationID}, day, sale.Quantity)
+		}
+	}
+
+	stats := make(map[repository.StockKey]demandStats, len(daily))
+	for key, days := range daily {
+		mean, variance := meanVariance(days)
+		stats[key] = demandStats{Mean: mean, Variance: variance}
+	}
+	return stats, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupReplenishmentDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&domain.Supplier{},
+		&domain.Category{},
+		&domain.Product{},
+		&domain.ProductSupplier{},
+		&domain.ProductAlertSettings{},
+		&domain.Batch{},
+		&domain.StockAdjustment{},
+		&domain.PurchaseOrder{},
+		&domain.PurchaseOrderItem{},
+		&domain.ReorderSuggestion{},
+		&domain.ReorderPolicy{},
+	))
+	return db
+}
+
+// seedLowStockProduct creates a product of the supplier below its reorder point at location 1.
+func seedLowStockProduct(t *testing.T, db *gorm.DB, sku string, supplierID uint) domain.Product {
+	t.Helper()
+	product := domain.Product{Name: sku, SKU: sku, SupplierID: supplierID, PurchasePrice: 4, LocationID: 1, Status: "Active"}
+	require.NoError(t, db.Create(&product).Error)
+	require.NoError(t, db.Create(&domain.Batch{ProductID: product.ID, LocationID: 1, BatchNumber: sku + "-1", Quantity: 2, UnitCost: 4}).Error)
+	require.NoError(t, db.Create(&domain.ProductAlertSettings{ProductID: product.ID, LowStockLevel: 10, MaxStockLevel: 50}).Error)
+	return product
+}
+
+func TestGenerateReorderSuggestionsSkipsOpenOrders(t *testing.T) {
+	db := setupReplenishmentDB(t)
+	supplier := domain.Supplier{Name: "Acme"}
+	require.NoError(t, db.Create(&supplier).Error)
+
+	awaitingApproval := seedLowStockProduct(t, db, "AWAITING", supplier.ID)
+	cancelled := seedLowStockProduct(t, db, "CANCELLED", supplier.ID)
+	unordered := seedLowStockProduct(t, db, "UNORDERED", supplier.ID)
+
+	for status, product := range map[string]domain.Product{"PENDING_APPROVAL": awaitingApproval, "CANCELLED": cancelled} {
+		po := domain.PurchaseOrder{
+			SupplierID: supplier.ID,
+			Status:     status,
+			OrderDate:  time.Now(),
+			LocationID: 1,
+			PurchaseOrderItems: []domain.PurchaseOrderItem{
+				{ProductID: product.ID, OrderedQuantity: 48, UnitPrice: 4},
+			},
+		}
+		require.NoError(t, db.Omit("Supplier").Create(&po).Error)
+	}
+
+	service := NewReplenishmentService(repository.NewReplenishmentRepository(db), NewLeadTimeService(db))
+	require.NoError(t, service.GenerateReorderSuggestions())
+
+	var suggested []uint
+	require.NoError(t, db.Model(&domain.ReorderSuggestion{}).Order("product_id").Pluck("product_id", &suggested).Error)
+	// Stock already waiting for sign-off is not ordered a second time
+	assert.Equal(t, []uint{cancelled.ID, unordered.ID}, suggested)
+}
@@ -173,6 +173,28 @@ func (s *ReportingService) GenerateReport(job *domain.Job) error {
 		if err != nil {
 			return err
 		}
+	case "inventory_valuation_csv", "inventory_valuation_excel":
+		fileType = "csv"
+		if job.Type == "inventory_valuation_excel" {
+			fileType = "xlsx"
+		}
+		asOf, err := time.Parse(time.RFC3339, params["asOf"].(string))
+		if err != nil {
+			return fmt.Errorf("invalid asOf date: %w", err)
+		}
+		var locationID *uint
+		if locID, ok := params["locationId"]; ok && locID != nil {
+			val := uint(locID.(float64))
+			locationID = &val
+		}
+		if fileType == "xlsx" {
+			err = s.ExportInventoryValuationReportExcel(&buffer, asOf, locationID)
+		} else {
+			err = s.ExportInventoryValuationReport(&buffer, asOf, locationID)
+		}
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("unknown report type: %s", job.Type)
 	}
@@ -318,6 +340,93 @@ func (s *ReportingService) ExportSalesTrendsReportExcel(writer io.Writer, startD
 	return f.Write(writer)
 }
 
+// GetInventoryValuationReport reconstructs stock quantity and value per product and location as of a past date.
+func (s *ReportingService) GetInventoryValuationReport(asOf time.Time, locationID *uint) (map[string]interface{}, error) {
+	lines, err := s.repo.GetInventoryValuationAt(asOf, locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var totalValue float64
+	for _, line := range lines {
+		totalValue += line.Value
+	}
+
+	return map[string]interface{}{
+		"asOf":       asOf,
+		"totalValue": domain.RoundMoney(totalValue),
+		"lines":      lines,
+	}, nil
+}
+
+var inventoryValuationHeader = []string{"SKU", "Product", "Category", "Location", "Quantity", "Unit Cost", "Value"}
+
+func (s *ReportingService) ExportInventoryValuationReport(writer io.Writer, asOf time.Time, locationID *uint) error {
+	lines, err := s.repo.GetInventoryValuationAt(asOf, locationID)
+	if err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(writer)
+	defer csvWriter.Flush()
+
+	csvWriter.Write([]string{"Inventory valuation as of " + asOf.Format(time.RFC3339)})
+	csvWriter.Write(inventoryValuationHeader)
+
+	var totalValue float64
+	for _, line := range lines {
+		totalValue += line.Value
+		csvWriter.Write([]string{
+			line.SKU,
+			line.ProductName,
+			line.CategoryName,
+			line.LocationName,
+			strconv.FormatFloat(line.Quantity, 'f', -1, 64),
+			strconv.FormatFloat(line.UnitCost, 'f', 4, 64),
+			strconv.FormatFloat(line.Value, 'f', 2, 64),
+		})
+	}
+	csvWriter.Write([]string{"Total", "", "", "", "", "", strconv.FormatFloat(totalValue, 'f', 2, 64)})
+
+	return nil
+}
+
+func (s *ReportingService) ExportInventoryValuationReportExcel(writer io.Writer, asOf time.Time, locationID *uint) error {
+	lines, err := s.repo.GetInventoryValuationAt(asOf, locationID)
+	if err != nil {
+		return err
+	}
+
+	f := excelize.NewFile()
+	sheetName := "Inventory Valuation"
+	f.NewSheet(sheetName)
+	f.DeleteSheet("Sheet1")
+
+	f.SetCellValue(sheetName, "A1", "Inventory valuation as of "+asOf.Format(time.RFC3339))
+	for i, title := range inventoryValuationHeader {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 2)
+		f.SetCellValue(sheetName, cell, title)
+	}
+
+	var totalValue float64
+	row := 3
+	for _, line := range lines {
+		totalValue += line.Value
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), line.SKU)
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), line.ProductName)
+		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), line.CategoryName)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), line.LocationName)
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), line.Quantity)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), line.UnitCost)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), line.Value)
+		row++
+	}
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), "Total")
+	f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), domain.RoundMoney(totalValue))
+
+	return f.Write(writer)
+}
+
 func (s *ReportingService) GetInventoryTurnoverReport(startDate, endDate time.Time, categoryID, locationID *uint) (map[string]interface{}, error) {
 	cacheKey := fmt.Sprintf("inventory_turnover:%s:%s:%v:%v", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), categoryID, locationID)
 	if cached, err := repository.GetCache(cacheKey); err == nil && cached != "" {
@@ -442,6 +551,10 @@ func (s *ReportingService) GetSupplierPerformanceReport(startDate, endDate time.
 	return s.repo.GetSupplierPerformanceReport(startDate, endDate)
 }
 
+func (s *ReportingService) GetLandedCostReport(startDate, endDate time.Time, groupBy string) ([]repository.LandedCostSummary, error) {
+	return s.repo.GetLandedCostReport(startDate, endDate, groupBy)
+}
+
 func (s *ReportingService) GetHourlySalesHeatmap(startDate, endDate time.Time) ([]repository.HeatmapPoint, error) {
 	cacheKey := fmt.Sprintf("heatmap:%s:%s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 	if cached, err := repository.GetCache(cacheKey); err == nil && cached != "" {
@@ -542,6 +655,10 @@ func (s *ReportingService) GetShrinkageReport(startDate, endDate time.Time) ([]r
 	return s.repo.GetShrinkageReport(startDate, endDate)
 }
 
+func (s *ReportingService) GetWasteReport(startDate, endDate time.Time, groupBy string) ([]repository.WasteSummary, error) {
+	return s.repo.GetWasteReport(startDate, endDate, groupBy)
+}
+
 func (s *ReportingService) GetCustomerReturnAnalysisReport(startDate, endDate time.Time) ([]repository.ReturnAnalysisItem, error) {
 	return s.repo.GetCustomerReturnAnalysisReport(startDate, endDate)
 }
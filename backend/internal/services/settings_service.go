@@ -1,6 +1,9 @@
 package services
 
 import (
+	"strconv"
+	"strings"
+
 	"inventory/backend/internal/domain"
 	"inventory/backend/internal/repository"
 )
@@ -73,3 +76,47 @@ func (s *settingsService) GetPublicSettings() (map[string]string, error) {
 	}
 	return publicSettings, nil
 }
+
+// settingString reads a text setting, falling back to def when it is missing.
+func settingString(settings SettingsService, key string, def string) string {
+	if settings == nil {
+		return def
+	}
+	val, err := settings.GetSetting(key)
+	if err != nil {
+		return def
+	}
+	return strings.TrimSpace(val)
+}
+
+// settingFloat reads a numeric setting, falling back to def when it is missing or malformed.
+func settingFloat(settings SettingsService, key string, def float64) float64 {
+	if settings == nil {
+		return def
+	}
+	val, err := settings.GetSetting(key)
+	if err != nil {
+		return def
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// settingBool reads a boolean setting, falling back to def when it is missing or malformed.
+func settingBool(settings SettingsService, key string, def bool) bool {
+	if settings == nil {
+		return def
+	}
+	val, err := settings.GetSetting(key)
+	if err != nil {
+		return def
+	}
+	v, err := strconv.ParseBool(strings.TrimSpace(val))
+	if err != nil {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,711 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/repository"
+	"inventory/backend/internal/requests"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrStockTakeState is returned when a session is not in the status an operation requires.
+	ErrStockTakeState = errors.New("stock take is not in a valid status for this operation")
+	// ErrInvalidStockTake is returned for requests the stock take cannot accept as given.
+	ErrInvalidStockTake = errors.New("invalid stock take request")
+)
+
+type StockTakeService interface {
+	CreateSession(req *requests.CreateStockTakeRequest, userID uint) (*domain.StockTakeSession, error)
+	ListSessions(status string) ([]domain.StockTakeSession, error)
+	GetSession(id uint) (*domain.StockTakeSession, error)
+	GetCountSheet(id uint) ([]StockTakeCountSheetLine, error)
+	RecordCount(id uint, req *requests.StockTakeCountRequest, userID uint) (*StockTakeCountSheetLine, error)
+	SubmitSession(id uint, userID uint) (*domain.StockTakeSession, error)
+	GetVarianceReport(id uint) (*StockTakeVarianceReport, error)
+	ApproveSession(id uint, req *requests.ApproveStockTakeRequest, userID uint) (*StockTakeVarianceReport, error)
+	CancelSession(id uint) (*domain.StockTakeSession, error)
+	GetCycleCountPlan() ([]CycleCountPlanItem, error)
+	GenerateCycleCountSessions(userID uint) ([]domain.StockTakeSession, error)
+}
+
+// StockTakeCountSheetLine is what counters see. SystemQuantity is omitted for blind counts
+// until the session has been submitted.
+type StockTakeCountSheetLine struct {
+	LineID          uint     `json:"lineId"`
+	ProductID       uint     `json:"productId"`
+	ProductName     string   `json:"productName"`
+	SKU             string   `json:"sku"`
+	BarcodeUPC      string   `json:"barcodeUpc"`
+	UnitOfMeasure   string   `json:"unitOfMeasure"`
+	LocationID      uint     `json:"locationId"`
+	CountedQuantity *float64 `json:"countedQuantity"`
+	SystemQuantity  *float64 `json:"systemQuantity,omitempty"`
+}
+
+// StockTakeVarianceLine compares the frozen system quantity to the count for one product.
+type StockTakeVarianceLine struct {
+	LineID           uint     `json:"lineId"`
+	ProductID        uint     `json:"productId"`
+	ProductName      string   `json:"productName"`
+	SKU              string   `json:"sku"`
+	LocationID       uint     `json:"locationId"`
+	SystemQuantity   float64  `json:"systemQuantity"`
+	CountedQuantity  *float64 `json:"countedQuantity"`
+	Variance         float64  `json:"variance"`
+	VariancePercent  float64  `json:"variancePercent"`
+	UnitCost         float64  `json:"unitCost"`
+	VarianceValue    float64  `json:"varianceValue"`
+	Status           string   `json:"status"` // MATCH, OVER, SHORT, NOT_COUNTED
+	Counters         int64    `json:"counters"`
+	AddedDuringCount bool     `json:"addedDuringCount"`
+	AdjustmentID     *uint    `json:"adjustmentId,omitempty"`
+}
+
+// StockTakeVarianceReport is the manager review of a session.
+type StockTakeVarianceReport struct {
+	Session           *domain.StockTakeSession `json:"session"`
+	Lines             []StockTakeVarianceLine  `json:"lines"`
+	TotalLines        int                      `json:"totalLines"`
+	CountedLines      int                      `json:"countedLines"`
+	LinesWithVariance int                      `json:"linesWithVariance"`
+	NetVarianceValue  float64                  `json:"netVarianceValue"`
+	AbsVarianceValue  float64                  `json:"absVarianceValue"`
+}
+
+// CycleCountPlanItem is a product due for a cycle count.
+type CycleCountPlanItem struct {
+	ProductID     uint       `json:"productId"`
+	ProductName   string     `json:"productName"`
+	SKU           string     `json:"sku"`
+	LocationID    uint       `json:"locationId"`
+	ABCClass      string     `json:"abcClass"`
+	IntervalDays  int        `json:"intervalDays"`
+	LastCountedAt *time.Time `json:"lastCountedAt"`
+	DueDate       time.Time  `json:"dueDate"`
+}
+
+type stockTakeService struct {
+	repo     repository.StockTakeRepository
+	db       *gorm.DB
+	settings SettingsService
+	barcodes BarcodeService
+}
+
+func NewStockTakeService(repo repository.StockTakeRepository, db *gorm.DB, settings SettingsService, barcodes BarcodeService) StockTakeService {
+	return &stockTakeService{
+		repo:     repo,
+		db:       db,
+		settings: settings,
+		barcodes: barcodes,
+	}
+}
+
+func (s *stockTakeService) CreateSession(req *requests.CreateStockTakeRequest, userID uint) (*domain.StockTakeSession, error) {
+	session := &domain.StockTakeSession{
+		Name:       req.Name,
+		Type:       "FULL",
+		LocationID: req.LocationID,
+		CategoryID: req.CategoryID,
+		BlindCount: true,
+		Status:     domain.StockTakeStatusOpen,
+		CreatedBy:  userID,
+		Notes:      req.Notes,
+	}
+	if req.BlindCount != nil {
+		session.BlindCount = *req.BlindCount
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		products, err := s.repo.GetProductsInScope(tx, req.LocationID, req.CategoryID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch products in scope: %w", err)
+		}
+		if len(products) == 0 {
+			return fmt.Errorf("%w: no products found in the selected scope", ErrInvalidStockTake)
+		}
+		return s.createSessionWithSnapshot(tx, session, products)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// createSessionWithSnapshot saves the session and freezes the current on-hand quantity of each
+// product at the location the session (or the product) is scoped to.
+func (s *stockTakeService) createSessionWithSnapshot(tx *gorm.DB, session *domain.StockTakeSession, products []domain.Product) error {
+	if err := tx.Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create stock take: %w", err)
+	}
+
+	lines := make([]domain.StockTakeLine, 0, len(products))
+	for i := range products {
+		line, err := snapshotLine(tx, session, &products[i])
+		if err != nil {
+			return err
+		}
+		lines = append(lines, *line)
+	}
+	if err := tx.CreateInBatches(&lines, 200).Error; err != nil {
+		return fmt.Errorf("failed to create stock take lines: %w", err)
+	}
+	session.Lines = lines
+	return nil
+}
+
+func snapshotLine(tx *gorm.DB, session *domain.StockTakeSession, product *domain.Product) (*domain.StockTakeLine, error) {
+	locationID := product.LocationID
+	if session.LocationID != nil {
+		locationID = *session.LocationID
+	}
+	qty, err := repository.GetLocationStock(tx, product.ID, locationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot stock for product %d: %w", product.ID, err)
+	}
+	return &domain.StockTakeLine{
+		SessionID:      session.ID,
+		ProductID:      product.ID,
+		LocationID:     locationID,
+		SystemQuantity: product.RoundQuantity(qty),
+		UnitCost:       product.CurrentUnitCost(),
+	}, nil
+}
+
+func (s *stockTakeService) ListSessions(status string) ([]domain.StockTakeSession, error) {
+	return s.repo.ListSessions(status)
+}
+
+func (s *stockTakeService) GetSession(id uint) (*domain.StockTakeSession, error) {
+	return s.repo.GetSession(id)
+}
+
+func (s *stockTakeService) GetCountSheet(id uint) ([]StockTakeCountSheetLine, error) {
+	session, err := s.repo.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.repo.GetLines(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := make([]StockTakeCountSheetLine, 0, len(lines))
+	for i := range lines {
+		sheet = append(sheet, toCountSheetLine(session, &lines[i]))
+	}
+	return sheet, nil
+}
+
+func toCountSheetLine(session *domain.StockTakeSession, line *domain.StockTakeLine) StockTakeCountSheetLine {
+	entry := StockTakeCountSheetLine{
+		LineID:          line.ID,
+		ProductID:       line.ProductID,
+		ProductName:     line.Product.Name,
+		SKU:             line.Product.SKU,
+		BarcodeUPC:      line.Product.BarcodeUPC,
+		UnitOfMeasure:   line.Product.UnitOfMeasure,
+		LocationID:      line.LocationID,
+		CountedQuantity: line.CountedQuantity,
+	}
+	if !session.BlindCount || session.Status != domain.StockTakeStatusOpen {
+		systemQty := line.SystemQuantity
+		entry.SystemQuantity = &systemQty
+	}
+	return entry
+}
+
+// RecordCount adds a count entry to a line. Entries from several counters accumulate; SET mode
+// records the difference needed to reach the given total.
+func (s *stockTakeService) RecordCount(id uint, req *requests.StockTakeCountRequest, userID uint) (*StockTakeCountSheetLine, error) {
+	var product *domain.Product
+	quantity := 1.0
+	source := "MANUAL"
+
+	switch {
+	case req.ProductID != nil:
+		var p domain.Product
+		if err := s.db.First(&p, *req.ProductID).Error; err != nil {
+			return nil, err
+		}
+		product = &p
+	case req.Barcode != "":
+		result, err := s.barcodes.LookupProductByBarcode(req.Barcode)
+		if err != nil {
+			return nil, err
+		}
+		product = result.Product
+		source = "SCAN"
+		if result.ScaleBarcode != nil && result.ScaleBarcode.Quantity > 0 {
+			quantity = result.ScaleBarcode.Quantity
+		}
+	default:
+		return nil, fmt.Errorf("%w: either productId or barcode is required", ErrInvalidStockTake)
+	}
+
+	if req.Quantity != nil {
+		quantity = *req.Quantity
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = "INCREMENT"
+	}
+	if mode == "INCREMENT" && quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be greater than zero", ErrInvalidStockTake)
+	}
+	if quantity > 0 && !product.IsValidQuantity(quantity) {
+		return nil, fmt.Errorf("%w: quantity %g for product '%s' has more than %d decimal places", ErrInvalidStockTake, quantity, product.Name, product.QuantityPrecision)
+	}
+
+	var session domain.StockTakeSession
+	var line domain.StockTakeLine
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, id).Error; err != nil {
+			return err
+		}
+		if session.Status != domain.StockTakeStatusOpen {
+			return fmt.Errorf("%w: counts can only be recorded while the session is OPEN", ErrStockTakeState)
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("session_id = ? AND product_id = ?", session.ID, product.ID).
+			First(&line).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Found on the shelf but not in the snapshot
+			if session.CategoryID != nil && product.CategoryID != *session.CategoryID {
+				return fmt.Errorf("%w: product '%s' is outside this stock take's category", ErrInvalidStockTake, product.Name)
+			}
+			if session.Type == "CYCLE" {
+				return fmt.Errorf("%w: product '%s' is not part of this cycle count", ErrInvalidStockTake, product.Name)
+			}
+			newLine, err := snapshotLine(tx, &session, product)
+			if err != nil {
+				return err
+			}
+			newLine.AddedDuringCount = true
+			if err := tx.Create(newLine).Error; err != nil {
+				return fmt.Errorf("failed to add product to stock take: %w", err)
+			}
+			line = *newLine
+		} else if err != nil {
+			return err
+		}
+
+		current := 0.0
+		if line.CountedQuantity != nil {
+			current = *line.CountedQuantity
+		}
+		delta := quantity
+		if mode == "SET" {
+			delta = quantity - current
+			source = "SET"
+		}
+
+		now := time.Now()
+		count := domain.StockTakeCount{
+			SessionID: session.ID,
+			LineID:    line.ID,
+			ProductID: product.ID,
+			Quantity:  product.RoundQuantity(delta),
+			Source:    source,
+			Barcode:   req.Barcode,
+			CountedBy: userID,
+			CountedAt: now,
+		}
+		if err := tx.Create(&count).Error; err != nil {
+			return fmt.Errorf("failed to record count: %w", err)
+		}
+
+		total := product.RoundQuantity(current + delta)
+		line.CountedQuantity = &total
+		line.LastCountedAt = &now
+		return tx.Model(&line).Updates(map[string]interface{}{
+			"counted_quantity": total,
+			"last_counted_at":  now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	line.Product = *product
+	entry := toCountSheetLine(&session, &line)
+	return &entry, nil
+}
+
+func (s *stockTakeService) SubmitSession(id uint, userID uint) (*domain.StockTakeSession, error) {
+	var session domain.StockTakeSession
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, id).Error; err != nil {
+			return err
+		}
+		if session.Status != domain.StockTakeStatusOpen {
+			return fmt.Errorf("%w: only OPEN sessions can be submitted", ErrStockTakeState)
+		}
+		now := time.Now()
+		session.Status = domain.StockTakeStatusSubmitted
+		session.SubmittedBy = &userID
+		session.SubmittedAt = &now
+		return tx.Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *stockTakeService) GetVarianceReport(id uint) (*StockTakeVarianceReport, error) {
+	session, err := s.repo.GetSession(id)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.repo.GetLines(id)
+	if err != nil {
+		return nil, err
+	}
+	counters, err := s.repo.GetCounterCounts(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StockTakeVarianceReport{Session: session, TotalLines: len(lines)}
+	for i := range lines {
+		line := &lines[i]
+		entry := StockTakeVarianceLine{
+			LineID:           line.ID,
+			ProductID:        line.ProductID,
+			ProductName:      line.Product.Name,
+			SKU:              line.Product.SKU,
+			LocationID:       line.LocationID,
+			SystemQuantity:   line.SystemQuantity,
+			CountedQuantity:  line.CountedQuantity,
+			UnitCost:         line.UnitCost,
+			Counters:         counters[line.ID],
+			AddedDuringCount: line.AddedDuringCount,
+			AdjustmentID:     line.AdjustmentID,
+		}
+
+		if line.CountedQuantity == nil {
+			entry.Status = "NOT_COUNTED"
+		} else {
+			report.CountedLines++
+			entry.Variance = line.Product.RoundQuantity(line.Variance())
+			entry.VarianceValue = math.Round(entry.Variance*line.UnitCost*100) / 100
+			if line.SystemQuantity != 0 {
+				entry.VariancePercent = math.Round(entry.Variance/line.SystemQuantity*10000) / 100
+			}
+			switch {
+			case entry.Variance > 0:
+				entry.Status = "OVER"
+			case entry.Variance < 0:
+				entry.Status = "SHORT"
+			default:
+				entry.Status = "MATCH"
+			}
+			if entry.Variance != 0 {
+				report.LinesWithVariance++
+			}
+			report.NetVarianceValue += entry.VarianceValue
+			report.AbsVarianceValue += math.Abs(entry.VarianceValue)
+		}
+		report.Lines = append(report.Lines, entry)
+	}
+
+	// Largest value discrepancies first
+	sort.SliceStable(report.Lines, func(i, j int) bool {
+		return math.Abs(report.Lines[i].VarianceValue) > math.Abs(report.Lines[j].VarianceValue)
+	})
+	report.NetVarianceValue = math.Round(report.NetVarianceValue*100) / 100
+	report.AbsVarianceValue = math.Round(report.AbsVarianceValue*100) / 100
+	return report, nil
+}
+
+// ApproveSession posts a STOCK_TAKE_CORRECTION adjustment for every counted line with a variance,
+// all in one transaction. The variance is measured against the frozen snapshot and applied to the
+// current stock, so sales made while counting are preserved.
+func (s *stockTakeService) ApproveSession(id uint, req *requests.ApproveStockTakeRequest, userID uint) (*StockTakeVarianceReport, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var session domain.StockTakeSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, id).Error; err != nil {
+			return err
+		}
+		if session.Status != domain.StockTakeStatusSubmitted {
+			return fmt.Errorf("%w: only SUBMITTED sessions can be approved", ErrStockTakeState)
+		}
+
+		var lines []domain.StockTakeLine
+		if err := tx.Preload("Product").Where("session_id = ?", session.ID).Find(&lines).Error; err != nil {
+			return fmt.Errorf("failed to load stock take lines: %w", err)
+		}
+
+		// Lock the counted products, in id order like checkout, before their stock is corrected
+		productIDs := make([]uint, 0, len(lines))
+		for _, line := range lines {
+			productIDs = append(productIDs, line.ProductID)
+		}
+		if _, err := repository.LockProducts(tx, productIDs); err != nil {
+			return fmt.Errorf("failed to lock products: %w", err)
+		}
+
+		now := time.Now()
+		for i := range lines {
+			line := &lines[i]
+			if line.CountedQuantity == nil {
+				if !req.TreatUncountedAsZero {
+					continue
+				}
+				zero := 0.0
+				line.CountedQuantity = &zero
+				if err := tx.Model(line).Update("counted_quantity", 0).Error; err != nil {
+					return fmt.Errorf("failed to update stock take line: %w", err)
+				}
+			}
+
+			product := &line.Product
+			variance := product.RoundQuantity(line.Variance())
+			if variance == 0 {
+				continue
+			}
+
+			previous, err := repository.GetLocationStock(tx, product.ID, line.LocationID)
+			if err != nil {
+				return fmt.Errorf("failed to read stock for product %d: %w", product.ID, err)
+			}
+
+			adjustment := domain.StockAdjustment{
+				ProductID:        product.ID,
+				LocationID:       line.LocationID,
+				ReasonCode:       "STOCK_TAKE_CORRECTION",
+				Notes:            fmt.Sprintf("Stock take #%d: %s", session.ID, session.Name),
+				AdjustedBy:       userID,
+				AdjustedAt:       now,
+				PreviousQuantity: previous,
+			}
+
+			if variance > 0 {
+				batchNumber := fmt.Sprintf("STOCKTAKE-%d-%d", session.ID, line.ID)
+				unitCost := product.CurrentUnitCost()
+				if _, err := repository.AddStock(tx, product, line.LocationID, variance, unitCost, batchNumber, nil); err != nil {
+					return fmt.Errorf("failed to add stock for product %d: %w", product.ID, err)
+				}
+				adjustment.Type = "STOCK_IN"
+				adjustment.Quantity = variance
+				adjustment.UnitCost = &unitCost
+			} else {
+				removed, cost, err := repository.RemoveStockFEFO(tx, product, line.LocationID, -variance)
+				if err != nil {
+					return fmt.Errorf("failed to remove stock for product %d: %w", product.ID, err)
+				}
+				if removed == 0 {
+					logrus.Warnf("Stock take %d: no stock left to remove for product %d", session.ID, product.ID)
+					continue
+				}
+				unitCost := domain.UnitCostOf(cost, removed)
+				adjustment.Type = "STOCK_OUT"
+				adjustment.Quantity = removed
+				adjustment.UnitCost = &unitCost
+			}
+			adjustment.NewQuantity = product.RoundQuantity(previous + variance)
+			if adjustment.NewQuantity < 0 {
+				adjustment.NewQuantity = 0
+			}
+
+			if err := tx.Create(&adjustment).Error; err != nil {
+				return fmt.Errorf("failed to record adjustment for product %d: %w", product.ID, err)
+			}
+			if err := tx.Model(line).Update("adjustment_id", adjustment.ID).Error; err != nil {
+				return fmt.Errorf("failed to link adjustment to stock take line: %w", err)
+			}
+		}
+
+		session.Status = domain.StockTakeStatusApproved
+		session.ApprovedBy = &userID
+		session.ApprovedAt = &now
+		return tx.Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetVarianceReport(id)
+}
+
+func (s *stockTakeService) CancelSession(id uint) (*domain.StockTakeSession, error) {
+	var session domain.StockTakeSession
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, id).Error; err != nil {
+			return err
+		}
+		if session.Status == domain.StockTakeStatusApproved || session.Status == domain.StockTakeStatusCancelled {
+			return fmt.Errorf("%w: session is already %s", ErrStockTakeState, session.Status)
+		}
+		session.Status = domain.StockTakeStatusCancelled
+		return tx.Save(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetCycleCountPlan classifies products by ABC consumption value over the last year and returns
+// those whose class interval has elapsed since their last approved count, A-class first. It only
+// reads; the classes are stored when sessions are generated.
+func (s *stockTakeService) GetCycleCountPlan() ([]CycleCountPlanItem, error) {
+	plan, _, err := s.cycleCountPlan()
+	return plan, err
+}
+
+// cycleCountPlan builds the plan together with the ABC class of every active product.
+func (s *stockTakeService) cycleCountPlan() ([]CycleCountPlanItem, map[uint]string, error) {
+	var products []domain.Product
+	if err := s.db.Where("status <> ?", "Archived").Find(&products).Error; err != nil {
+		return nil, nil, err
+	}
+
+	values, err := s.repo.GetConsumptionValues(time.Now().AddDate(-1, 0, 0))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range products {
+		if _, ok := values[p.ID]; !ok {
+			values[p.ID] = 0
+		}
+	}
+	classes := domain.ClassifyABC(values,
+		settingFloat(s.settings, "abc_class_a_threshold", 80),
+		settingFloat(s.settings, "abc_class_b_threshold", 95))
+
+	lastCounted, err := s.repo.GetLastCountDates()
+	if err != nil {
+		return nil, nil, err
+	}
+	inProgress, err := s.repo.GetProductsInOpenSessions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	intervals := map[string]int{
+		domain.ABCClassA: int(settingFloat(s.settings, "cycle_count_interval_a_days", 30)),
+		domain.ABCClassB: int(settingFloat(s.settings, "cycle_count_interval_b_days", 90)),
+		domain.ABCClassC: int(settingFloat(s.settings, "cycle_count_interval_c_days", 180)),
+	}
+
+	now := time.Now()
+	var plan []CycleCountPlanItem
+	for _, p := range products {
+		if inProgress[p.ID] {
+			continue
+		}
+		class := classes[p.ID]
+		item := CycleCountPlanItem{
+			ProductID:    p.ID,
+			ProductName:  p.Name,
+			SKU:          p.SKU,
+			LocationID:   p.LocationID,
+			ABCClass:     class,
+			IntervalDays: intervals[class],
+			DueDate:      p.CreatedAt,
+		}
+		if last, ok := lastCounted[p.ID]; ok {
+			lastCopy := last
+			item.LastCountedAt = &lastCopy
+			item.DueDate = last.AddDate(0, 0, item.IntervalDays)
+		}
+		if item.DueDate.After(now) {
+			continue
+		}
+		plan = append(plan, item)
+	}
+
+	sort.SliceStable(plan, func(i, j int) bool {
+		if plan[i].ABCClass != plan[j].ABCClass {
+			return plan[i].ABCClass < plan[j].ABCClass
+		}
+		return plan[i].DueDate.Before(plan[j].DueDate)
+	})
+	return plan, classes, nil
+}
+
+// GenerateCycleCountSessions stores the products' current ABC classes and opens one CYCLE session per
+// location and ABC class for the products that are due, up to the configured maximum per run.
+func (s *stockTakeService) GenerateCycleCountSessions(userID uint) ([]domain.StockTakeSession, error) {
+	plan, classes, err := s.cycleCountPlan()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateABCClasses(classes); err != nil {
+		return nil, fmt.Errorf("failed to store ABC classes: %w", err)
+	}
+	maxItems := int(settingFloat(s.settings, "cycle_count_max_items", 50))
+	if maxItems > 0 && len(plan) > maxItems {
+		plan = plan[:maxItems]
+	}
+	if len(plan) == 0 {
+		return nil, nil
+	}
+
+	type planGroup struct {
+		locationID uint
+		class      string
+	}
+	byGroup := make(map[planGroup][]uint)
+	var groupOrder []planGroup
+	for _, item := range plan {
+		key := planGroup{locationID: item.LocationID, class: item.ABCClass}
+		if _, ok := byGroup[key]; !ok {
+			groupOrder = append(groupOrder, key)
+		}
+		byGroup[key] = append(byGroup[key], item.ProductID)
+	}
+
+	var sessions []domain.StockTakeSession
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for _, group := range groupOrder {
+			var products []domain.Product
+			if err := tx.Where("id IN ?", byGroup[group]).Order("id asc").Find(&products).Error; err != nil {
+				return err
+			}
+			locationID := group.locationID
+			session := domain.StockTakeSession{
+				Name:       fmt.Sprintf("Cycle count %s (class %s)", time.Now().Format("2006-01-02"), group.class),
+				Type:       "CYCLE",
+				LocationID: &locationID,
+				ABCClass:   group.class,
+				BlindCount: true,
+				Status:     domain.StockTakeStatusOpen,
+				CreatedBy:  userID,
+				Notes:      "Generated from the ABC cycle-count plan",
+			}
+			if err := s.createSessionWithSnapshot(tx, &session, products); err != nil {
+				return err
+			}
+			sessions = append(sessions, session)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RunScheduledCycleCounts is invoked by the scheduler; it is a no-op when cycle counting is disabled.
+func RunScheduledCycleCounts(s StockTakeService, settings SettingsService) {
+	if !settingBool(settings, "cycle_count_enabled", true) {
+		return
+	}
+	sessions, err := s.GenerateCycleCountSessions(0)
+	if err != nil {
+		logrus.Errorf("Failed to generate cycle-count sessions: %v", err)
+		return
+	}
+	logrus.Infof("Generated %d cycle-count sessions", len(sessions))
+}
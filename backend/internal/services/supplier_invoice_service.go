@@ -0,0 +1,582 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/requests"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvoiceState is returned when an invoice or line cannot be changed in its current status.
+	ErrInvoiceState = errors.New("invoice cannot be changed in its current status")
+	// ErrInvoiceMismatch is returned when approving an invoice with unresolved quantity or price mismatches.
+	ErrInvoiceMismatch = errors.New("invoice has unresolved mismatches")
+	// ErrInvalidInvoice is returned when an invoice does not fit its purchase order or a payment does not fit the invoice.
+	ErrInvalidInvoice = errors.New("invalid invoice")
+	// ErrDuplicateInvoice is returned when the supplier's invoice number is already recorded.
+	ErrDuplicateInvoice = errors.New("invoice already recorded")
+	// ErrDuplicateToleranceRule is returned when a supplier, or the default, already has a tolerance rule.
+	ErrDuplicateToleranceRule = errors.New("tolerance rule already exists")
+)
+
+// APAgingInvoice is one unpaid invoice on the accounts-payable aging report.
+type APAgingInvoice struct {
+	InvoiceID     uint      `json:"invoiceId"`
+	InvoiceNumber string    `json:"invoiceNumber"`
+	SupplierID    uint      `json:"supplierId"`
+	Status        string    `json:"status"`
+	DueDate       time.Time `json:"dueDate"`
+	DaysPastDue   int       `json:"daysPastDue"`
+	Outstanding   float64   `json:"outstanding"`
+	Bucket        string    `json:"bucket"` // CURRENT, 1-30, 31-60, 61-90, 90+
+}
+
+// APAgingBuckets holds outstanding amounts by how far past due they are.
+type APAgingBuckets struct {
+	Current    float64 `json:"current"`
+	Days1To30  float64 `json:"days1To30"`
+	Days31To60 float64 `json:"days31To60"`
+	Days61To90 float64 `json:"days61To90"`
+	Over90     float64 `json:"over90"`
+	Total      float64 `json:"total"`
+}
+
+// add books an amount past due by daysPastDue and returns the bucket it went into.
+func (b *APAgingBuckets) add(amount float64, daysPastDue int) string {
+	b.Total = roundTo(b.Total+amount, 2)
+	switch {
+	case daysPastDue <= 0:
+		b.Current = roundTo(b.Current+amount, 2)
+		return "CURRENT"
+	case daysPastDue <= 30:
+		b.Days1To30 = roundTo(b.Days1To30+amount, 2)
+		return "1-30"
+	case daysPastDue <= 60:
+		b.Days31To60 = roundTo(b.Days31To60+amount, 2)
+		return "31-60"
+	case daysPastDue <= 90:
+		b.Days61To90 = roundTo(b.Days61To90+amount, 2)
+		return "61-90"
+	default:
+		b.Over90 = roundTo(b.Over90+amount, 2)
+		return "90+"
+	}
+}
+
+// APAgingSupplier is one supplier's row on the accounts-payable aging report.
+type APAgingSupplier struct {
+	SupplierID   uint   `json:"supplierId"`
+	SupplierName string `json:"supplierName"`
+	Invoices     int    `json:"invoices"`
+	APAgingBuckets
+}
+
+// APAgingReport lists what is owed to suppliers, by supplier and by how far past due.
+type APAgingReport struct {
+	AsOf      time.Time         `json:"asOf"`
+	Suppliers []APAgingSupplier `json:"suppliers"`
+	Totals    APAgingBuckets    `json:"totals"`
+	Invoices  []APAgingInvoice  `json:"invoices"`
+}
+
+type InvoiceService interface {
+	ListInvoices(status string, supplierID uint) ([]domain.SupplierInvoice, error)
+	GetInvoice(id uint) (*domain.SupplierInvoice, error)
+	CreateInvoice(req *requests.SupplierInvoiceRequest, userID uint) (*domain.SupplierInvoice, error)
+	// RematchInvoice matches an unapproved invoice again, e.g. after the rest of the goods were received.
+	RematchInvoice(id uint) (*domain.SupplierInvoice, error)
+	ResolveLine(invoiceID, lineID uint, req *requests.ResolveInvoiceLineRequest, userID uint) (*domain.SupplierInvoice, error)
+	ApproveInvoice(id, userID uint) (*domain.SupplierInvoice, error)
+	RecordPayment(id uint, req *requests.InvoicePaymentRequest) (*domain.SupplierInvoice, error)
+	DeleteInvoice(id uint) error
+	ListToleranceRules() ([]domain.InvoiceToleranceRule, error)
+	CreateToleranceRule(req *requests.InvoiceToleranceRuleRequest) (*domain.InvoiceToleranceRule, error)
+	UpdateToleranceRule(id uint, req *requests.InvoiceToleranceRuleRequest) (*domain.InvoiceToleranceRule, error)
+	DeleteToleranceRule(id uint) error
+	APAging(asOf time.Time) (*APAgingReport, error)
+}
+
+type invoiceService struct {
+	db       *gorm.DB
+	settings SettingsService
+}
+
+func NewInvoiceService(db *gorm.DB, settings SettingsService) InvoiceService {
+	return &invoiceService{db: db, settings: settings}
+}
+
+func (s *invoiceService) ListInvoices(status string, supplierID uint) ([]domain.SupplierInvoice, error) {
+	query := s.db.Preload("Supplier")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplierID != 0 {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	var invoices []domain.SupplierInvoice
+	if err := query.Order("due_date, id").Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (s *invoiceService) GetInvoice(id uint) (*domain.SupplierInvoice, error) {
+	var invoice domain.SupplierInvoice
+	if err := s.db.Preload("Supplier").Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Lines.Product").First(&invoice, id).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (s *invoiceService) CreateInvoice(req *requests.SupplierInvoiceRequest, userID uint) (*domain.SupplierInvoice, error) {
+	var invoice domain.SupplierInvoice
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var po domain.PurchaseOrder
+		if err := tx.Preload("PurchaseOrderItems").First(&po, req.PurchaseOrderID).Error; err != nil {
+			return err
+		}
+		switch po.Status {
+		case "DRAFT", "PENDING_APPROVAL", "CANCELLED":
+			return fmt.Errorf("%w: purchase order %d is %s", ErrInvalidInvoice, po.ID, po.Status)
+		}
+
+		var existing int64
+		if err := tx.Model(&domain.SupplierInvoice{}).
+			Where("supplier_id = ? AND invoice_number = ?", po.SupplierID, req.InvoiceNumber).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return fmt.Errorf("%w: supplier invoice %s", ErrDuplicateInvoice, req.InvoiceNumber)
+		}
+
+		dueDate := req.InvoiceDate.AddDate(0, 0, int(settingFloat(s.settings, "invoice_payment_terms_days", 30)))
+		if req.DueDate != nil {
+			dueDate = *req.DueDate
+		}
+		invoice = domain.SupplierInvoice{
+			SupplierID:      po.SupplierID,
+			InvoiceNumber:   req.InvoiceNumber,
+			PurchaseOrderID: po.ID,
+			InvoiceDate:     req.InvoiceDate,
+			DueDate:         dueDate,
+			TaxAmount:       req.TaxAmount,
+			Notes:           req.Notes,
+			CreatedBy:       userID,
+		}
+
+		items := make(map[uint]domain.PurchaseOrderItem, len(po.PurchaseOrderItems))
+		for _, item := range po.PurchaseOrderItems {
+			items[item.ID] = item
+		}
+		billed := make(map[uint]bool, len(req.Lines))
+		for _, line := range req.Lines {
+			item, ok := items[line.PurchaseOrderItemID]
+			if !ok {
+				return fmt.Errorf("%w: line %d is not on purchase order %d", ErrInvalidInvoice, line.PurchaseOrderItemID, po.ID)
+			}
+			if billed[item.ID] {
+				return fmt.Errorf("%w: purchase order line %d is billed twice", ErrInvalidInvoice, item.ID)
+			}
+			billed[item.ID] = true
+			invoice.Lines = append(invoice.Lines, domain.SupplierInvoiceLine{
+				PurchaseOrderItemID: item.ID,
+				ProductID:           item.ProductID,
+				Quantity:            line.Quantity,
+				UnitPrice:           line.UnitPrice,
+			})
+		}
+
+		if err := matchInvoice(tx, &invoice); err != nil {
+			return err
+		}
+		return tx.Omit("Supplier", "PurchaseOrder").Create(&invoice).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetInvoice(invoice.ID)
+}
+
+// matchInvoice matches the invoice's lines against their purchase order lines and the supplier's
+// tolerance rule, then totals the invoice and sets its status. The order lines are locked so that
+// invoices for the same goods are matched one after the other.
+func matchInvoice(tx *gorm.DB, invoice *domain.SupplierInvoice) error {
+	tolerance, err := toleranceRuleFor(tx, invoice.SupplierID)
+	if err != nil {
+		return err
+	}
+
+	itemIDs := make([]uint, 0, len(invoice.Lines))
+	for _, line := range invoice.Lines {
+		itemIDs = append(itemIDs, line.PurchaseOrderItemID)
+	}
+	var items []domain.PurchaseOrderItem
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", itemIDs).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to fetch purchase order lines: %w", err)
+	}
+	itemsByID := make(map[uint]*domain.PurchaseOrderItem, len(items))
+	for i := range items {
+		itemsByID[items[i].ID] = &items[i]
+	}
+
+	// Only invoices entered before this one count as already billed, so rematching an older invoice
+	// is not thrown off by later ones for the same goods
+	var billed []struct {
+		PurchaseOrderItemID uint
+		Quantity            float64
+	}
+	billedQuery := tx.Model(&domain.SupplierInvoiceLine{}).
+		Select("supplier_invoice_lines.purchase_order_item_id, SUM(supplier_invoice_lines.quantity) AS quantity").
+		Joins("JOIN supplier_invoices ON supplier_invoices.id = supplier_invoice_lines.supplier_invoice_id AND supplier_invoices.deleted_at IS NULL").
+		Where("supplier_invoice_lines.purchase_order_item_id IN ?", itemIDs)
+	if invoice.ID != 0 {
+		billedQuery = billedQuery.Where("supplier_invoices.id < ?", invoice.ID)
+	}
+	if err := billedQuery.Group("supplier_invoice_lines.purchase_order_item_id").Scan(&billed).Error; err != nil {
+		return fmt.Errorf("failed to fetch previously invoiced quantities: %w", err)
+	}
+	previouslyInvoiced := make(map[uint]float64, len(billed))
+	for _, b := range billed {
+		previouslyInvoiced[b.PurchaseOrderItemID] = b.Quantity
+	}
+
+	var subtotal float64
+	invoice.Status = domain.InvoiceStatusMatched
+	for i := range invoice.Lines {
+		line := &invoice.Lines[i]
+		item, ok := itemsByID[line.PurchaseOrderItemID]
+		if !ok {
+			return fmt.Errorf("%w: purchase order line %d no longer exists", ErrInvalidInvoice, line.PurchaseOrderItemID)
+		}
+		line.Match(item, previouslyInvoiced[item.ID], tolerance)
+		line.LineTotal = roundTo(line.Quantity*line.UnitPrice, 2)
+		subtotal += line.LineTotal
+		if line.Blocking() {
+			invoice.Status = domain.InvoiceStatusException
+		}
+	}
+	invoice.Subtotal = roundTo(subtotal, 2)
+	invoice.TotalAmount = roundTo(invoice.Subtotal+invoice.TaxAmount, 2)
+	return nil
+}
+
+// toleranceRuleFor returns the supplier's tolerance rule, else the default rule, else no tolerance at all.
+func toleranceRuleFor(tx *gorm.DB, supplierID uint) (*domain.InvoiceToleranceRule, error) {
+	var rules []domain.InvoiceToleranceRule
+	if err := tx.Where("supplier_id = ? OR supplier_id IS NULL", supplierID).Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch tolerance rules: %w", err)
+	}
+	rule := &domain.InvoiceToleranceRule{}
+	for i := range rules {
+		if rules[i].SupplierID != nil {
+			return &rules[i], nil
+		}
+		rule = &rules[i]
+	}
+	return rule, nil
+}
+
+// lockInvoice locks the invoice with its lines and checks that it is in one of the allowed statuses.
+func lockInvoice(tx *gorm.DB, id uint, invoice *domain.SupplierInvoice, allowed ...string) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).First(invoice, id).Error; err != nil {
+		return err
+	}
+	for _, status := range allowed {
+		if invoice.Status == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: invoice %s is %s", ErrInvoiceState, invoice.InvoiceNumber, invoice.Status)
+}
+
+// saveMatch stores the outcome of matching an invoice.
+func saveMatch(tx *gorm.DB, invoice *domain.SupplierInvoice) error {
+	for i := range invoice.Lines {
+		if err := tx.Omit(clause.Associations).Save(&invoice.Lines[i]).Error; err != nil {
+			return fmt.Errorf("failed to save invoice line %d: %w", invoice.Lines[i].ID, err)
+		}
+	}
+	return tx.Model(invoice).Omit(clause.Associations).Updates(map[string]interface{}{
+		"Subtotal":    invoice.Subtotal,
+		"TotalAmount": invoice.TotalAmount,
+		"Status":      invoice.Status,
+	}).Error
+}
+
+func (s *invoiceService) RematchInvoice(id uint) (*domain.SupplierInvoice, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var invoice domain.SupplierInvoice
+		if err := lockInvoice(tx, id, &invoice, domain.InvoiceStatusMatched, domain.InvoiceStatusException); err != nil {
+			return err
+		}
+		if err := matchInvoice(tx, &invoice); err != nil {
+			return err
+		}
+		return saveMatch(tx, &invoice)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetInvoice(id)
+}
+
+// ResolveLine accepts a mismatched line, e.g. after the supplier agreed a credit note. The invoice
+// becomes approvable once no unresolved mismatch is left.
+func (s *invoiceService) ResolveLine(invoiceID, lineID uint, req *requests.ResolveInvoiceLineRequest, userID uint) (*domain.SupplierInvoice, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var invoice domain.SupplierInvoice
+		if err := lockInvoice(tx, invoiceID, &invoice, domain.InvoiceStatusMatched, domain.InvoiceStatusException); err != nil {
+			return err
+		}
+		var line *domain.SupplierInvoiceLine
+		for i := range invoice.Lines {
+			if invoice.Lines[i].ID == lineID {
+				line = &invoice.Lines[i]
+			}
+		}
+		if line == nil {
+			return gorm.ErrRecordNotFound
+		}
+		if !line.Blocking() {
+			return fmt.Errorf("%w: line %d has no unresolved mismatch", ErrInvoiceState, lineID)
+		}
+
+		now := time.Now()
+		if err := tx.Model(line).Updates(map[string]interface{}{
+			"ResolvedBy":     userID,
+			"ResolvedAt":     now,
+			"ResolutionNote": req.Note,
+		}).Error; err != nil {
+			return err
+		}
+		line.ResolvedAt = &now
+
+		status := domain.InvoiceStatusMatched
+		for _, l := range invoice.Lines {
+			if l.Blocking() {
+				status = domain.InvoiceStatusException
+			}
+		}
+		return tx.Model(&invoice).Omit(clause.Associations).Update("Status", status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetInvoice(invoiceID)
+}
+
+// ApproveInvoice matches the invoice once more against the latest receipts and approves it for
+// payment, unless a mismatch is left unresolved.
+func (s *invoiceService) ApproveInvoice(id, userID uint) (*domain.SupplierInvoice, error) {
+	var blocking []uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var invoice domain.SupplierInvoice
+		if err := lockInvoice(tx, id, &invoice, domain.InvoiceStatusMatched, domain.InvoiceStatusException); err != nil {
+			return err
+		}
+		if err := matchInvoice(tx, &invoice); err != nil {
+			return err
+		}
+		if err := saveMatch(tx, &invoice); err != nil {
+			return err
+		}
+		if invoice.Status != domain.InvoiceStatusMatched {
+			// The refreshed match is committed even though the approval is refused
+			for _, line := range invoice.Lines {
+				if line.Blocking() {
+					blocking = append(blocking, line.ID)
+				}
+			}
+			return nil
+		}
+
+		now := time.Now()
+		return tx.Model(&invoice).Omit(clause.Associations).Updates(map[string]interface{}{
+			"Status":     domain.InvoiceStatusApproved,
+			"ApprovedBy": userID,
+			"ApprovedAt": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(blocking) > 0 {
+		return nil, fmt.Errorf("%w: resolve lines %v before approving", ErrInvoiceMismatch, blocking)
+	}
+	return s.GetInvoice(id)
+}
+
+func (s *invoiceService) RecordPayment(id uint, req *requests.InvoicePaymentRequest) (*domain.SupplierInvoice, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var invoice domain.SupplierInvoice
+		if err := lockInvoice(tx, id, &invoice, domain.InvoiceStatusApproved, domain.InvoiceStatusPartiallyPaid); err != nil {
+			return err
+		}
+		outstanding := invoice.Outstanding()
+		if req.Amount > outstanding+0.005 {
+			return fmt.Errorf("%w: payment of %.2f exceeds the outstanding %.2f", ErrInvalidInvoice, req.Amount, outstanding)
+		}
+
+		paidAt := time.Now()
+		if req.PaidAt != nil {
+			paidAt = *req.PaidAt
+		}
+		updates := map[string]interface{}{
+			"AmountPaid": roundTo(invoice.AmountPaid+req.Amount, 2),
+			"Status":     domain.InvoiceStatusPartiallyPaid,
+		}
+		if outstanding-req.Amount < 0.005 {
+			updates["Status"] = domain.InvoiceStatusPaid
+			updates["PaidAt"] = paidAt
+		}
+		return tx.Model(&invoice).Omit(clause.Associations).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetInvoice(id)
+}
+
+// DeleteInvoice removes an invoice that was not approved yet, e.g. one entered against the wrong order.
+// It is removed for good so the supplier's invoice number can be entered again.
+func (s *invoiceService) DeleteInvoice(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var invoice domain.SupplierInvoice
+		if err := lockInvoice(tx, id, &invoice, domain.InvoiceStatusMatched, domain.InvoiceStatusException); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("supplier_invoice_id = ?", id).Delete(&domain.SupplierInvoiceLine{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&invoice).Error
+	})
+}
+
+func (s *invoiceService) ListToleranceRules() ([]domain.InvoiceToleranceRule, error) {
+	var rules []domain.InvoiceToleranceRule
+	if err := s.db.Preload("Supplier").Order("supplier_id, id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *invoiceService) CreateToleranceRule(req *requests.InvoiceToleranceRuleRequest) (*domain.InvoiceToleranceRule, error) {
+	rule := domain.InvoiceToleranceRule{}
+	if err := s.saveToleranceRule(&rule, req); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *invoiceService) UpdateToleranceRule(id uint, req *requests.InvoiceToleranceRuleRequest) (*domain.InvoiceToleranceRule, error) {
+	var rule domain.InvoiceToleranceRule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	if err := s.saveToleranceRule(&rule, req); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// saveToleranceRule applies the request to the rule and saves it, keeping one rule per supplier and one default.
+func (s *invoiceService) saveToleranceRule(rule *domain.InvoiceToleranceRule, req *requests.InvoiceToleranceRuleRequest) error {
+	query := s.db.Model(&domain.InvoiceToleranceRule{}).Where("id <> ?", rule.ID)
+	if req.SupplierID != nil {
+		query = query.Where("supplier_id = ?", *req.SupplierID)
+	} else {
+		query = query.Where("supplier_id IS NULL")
+	}
+	var existing int64
+	if err := query.Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return ErrDuplicateToleranceRule
+	}
+
+	rule.SupplierID = req.SupplierID
+	rule.PricePercent = req.PricePercent
+	rule.PriceAmount = req.PriceAmount
+	rule.QuantityPercent = req.QuantityPercent
+	return s.db.Omit("Supplier").Save(rule).Error
+}
+
+// DeleteToleranceRule removes the rule for good; the supplier falls back to the default rule.
+func (s *invoiceService) DeleteToleranceRule(id uint) error {
+	res := s.db.Unscoped().Delete(&domain.InvoiceToleranceRule{}, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// APAging reports the unpaid balance of every recorded invoice, approved or not, by supplier and by
+// days past due on the given date.
+func (s *invoiceService) APAging(asOf time.Time) (*APAgingReport, error) {
+	var invoices []domain.SupplierInvoice
+	if err := s.db.Preload("Supplier").
+		Where("status IN ?", []string{domain.InvoiceStatusMatched, domain.InvoiceStatusException, domain.InvoiceStatusApproved, domain.InvoiceStatusPartiallyPaid}).
+		Order("due_date, id").Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+
+	day := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location())
+	report := &APAgingReport{AsOf: day, Suppliers: []APAgingSupplier{}, Invoices: []APAgingInvoice{}}
+	rows := make(map[uint]*APAgingSupplier)
+	for _, invoice := range invoices {
+		outstanding := roundTo(invoice.Outstanding(), 2)
+		if outstanding <= 0 {
+			continue
+		}
+		due := invoice.DueDate.In(asOf.Location())
+		dueDay := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, asOf.Location())
+		daysPastDue := int(math.Round(day.Sub(dueDay).Hours() / 24))
+
+		row, ok := rows[invoice.SupplierID]
+		if !ok {
+			row = &APAgingSupplier{SupplierID: invoice.SupplierID, SupplierName: invoice.Supplier.Name}
+			rows[invoice.SupplierID] = row
+		}
+		row.Invoices++
+		bucket := row.add(outstanding, daysPastDue)
+		report.Totals.add(outstanding, daysPastDue)
+		report.Invoices = append(report.Invoices, APAgingInvoice{
+			InvoiceID:     invoice.ID,
+			InvoiceNumber: invoice.InvoiceNumber,
+			SupplierID:    invoice.SupplierID,
+			Status:        invoice.Status,
+			DueDate:       invoice.DueDate,
+			DaysPastDue:   daysPastDue,
+			Outstanding:   outstanding,
+			Bucket:        bucket,
+		})
+	}
+
+	for _, row := range rows {
+		report.Suppliers = append(report.Suppliers, *row)
+	}
+	// Suppliers owed the most overdue money first
+	sort.Slice(report.Suppliers, func(i, j int) bool {
+		a, b := report.Suppliers[i], report.Suppliers[j]
+		if overdueA, overdueB := a.Total-a.Current, b.Total-b.Current; overdueA != overdueB {
+			return overdueA > overdueB
+		}
+		return a.SupplierID < b.SupplierID
+	})
+	return report, nil
+}
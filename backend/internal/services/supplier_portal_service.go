@@ -0,0 +1,739 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"inventory/backend/internal/domain"
+	"inventory/backend/internal/requests"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SupplierRoleName is the role of supplier portal users.
+const SupplierRoleName = "Supplier"
+
+var (
+	// ErrSupplierNotLinked is returned when a portal user has no supplier to act for.
+	ErrSupplierNotLinked = errors.New("user is not linked to a supplier")
+	// ErrPortalOrderState is returned when a purchase order or change proposal cannot be acted on in its current status.
+	ErrPortalOrderState = errors.New("purchase order cannot be changed in its current status")
+	// ErrInvalidPortalRequest is returned when a confirmation, change proposal or shipping notice does not fit the order.
+	ErrInvalidPortalRequest = errors.New("invalid supplier portal request")
+	// ErrDuplicateShipment is returned when the supplier's shipment number is already recorded.
+	ErrDuplicateShipment = errors.New("shipping notice already submitted")
+	// ErrDuplicateSupplierUser is returned when the username, email or phone number is already taken.
+	ErrDuplicateSupplierUser = errors.New("user already exists")
+)
+
+// portalOrderStatuses are the order statuses a supplier sees; orders still being drafted or approved stay internal.
+var portalOrderStatuses = []string{"SENT", "PARTIALLY_RECEIVED", "RECEIVED"}
+
+// SupplierPortalOrderLine is a purchase order line as the supplier sees it.
+type SupplierPortalOrderLine struct {
+	PurchaseOrderItemID uint    `json:"purchaseOrderItemId"`
+	ProductID           uint    `json:"productId"`
+	SKU                 string  `json:"sku"`
+	SupplierSKU         string  `json:"supplierSku,omitempty"`
+	ProductName         string  `json:"productName"`
+	UnitOfMeasure       string  `json:"unitOfMeasure"`
+	OrderedQuantity     float64 `json:"orderedQuantity"`
+	ShippedQuantity     float64 `json:"shippedQuantity"` // On advance shipping notices
+	ReceivedQuantity    float64 `json:"receivedQuantity"`
+	UnitPrice           float64 `json:"unitPrice"`
+	LineTotal           float64 `json:"lineTotal"`
+}
+
+// SupplierPortalOrder is a purchase order as the supplier sees it: no internal approvals, costs or selling prices.
+type SupplierPortalOrder struct {
+	ID                   uint                      `json:"id"`
+	Status               string                    `json:"status"`
+	OrderDate            time.Time                 `json:"orderDate"`
+	SentAt               *time.Time                `json:"sentAt"`
+	ExpectedDeliveryDate *time.Time                `json:"expectedDeliveryDate"`
+	PromisedDeliveryDate *time.Time                `json:"promisedDeliveryDate"`
+	ActualDeliveryDate   *time.Time                `json:"actualDeliveryDate"`
+	SupplierResponse     string                    `json:"supplierResponse"` // Empty until the supplier confirms or proposes changes
+	SupplierRespondedAt  *time.Time                `json:"supplierRespondedAt"`
+	Total                float64                   `json:"total"`
+	Lines                []SupplierPortalOrderLine `json:"lines"`
+	Proposals            []domain.POChangeProposal `json:"proposals,omitempty"`
+}
+
+// SupplierPortalScorecard rates a supplier's recent orders.
+type SupplierPortalScorecard struct {
+	SupplierID           uint      `json:"supplierId"`
+	SupplierName         string    `json:"supplierName"`
+	From                 time.Time `json:"from"`
+	To                   time.Time `json:"to"`
+	OrdersSent           int       `json:"ordersSent"`
+	OrdersConfirmed      int       `json:"ordersConfirmed"`
+	ConfirmationRate     float64   `json:"confirmationRate"`     // Percentage of sent orders confirmed
+	AvgConfirmationHours float64   `json:"avgConfirmationHours"` // From sending to the supplier's first response
+	OrdersDelivered      int       `json:"ordersDelivered"`
+	OnTimeDeliveries     int       `json:"onTimeDeliveries"`
+	OnTimeRate           float64   `json:"onTimeRate"`         // Percentage of delivered orders in full by the promised, else expected, date
+	AvgLeadTimeDays      float64   `json:"avgLeadTimeDays"`    // From order to full delivery
+	FillRate             float64   `json:"fillRate"`           // Percentage of the ordered quantity received on orders with deliveries
+	ShippingNoticeRate   float64   `json:"shippingNoticeRate"` // Percentage of orders with deliveries announced by a shipping notice
+	InvoicesSubmitted    int       `json:"invoicesSubmitted"`
+	InvoiceMatchRate     float64   `json:"invoiceMatchRate"` // Percentage of invoices whose every line matched the order and receipts
+}
+
+type SupplierPortalService interface {
+	// SupplierIDForUser returns the supplier a portal user acts for.
+	SupplierIDForUser(userID uint) (uint, error)
+	ListPurchaseOrders(supplierID uint, status string) ([]SupplierPortalOrder, error)
+	GetPurchaseOrder(supplierID, poID uint) (*SupplierPortalOrder, error)
+	ConfirmPurchaseOrder(supplierID, poID uint) error
+	ProposeChanges(supplierID, poID uint, req *requests.POChangeProposalRequest, userID uint) (*domain.POChangeProposal, error)
+	SubmitShippingNotice(supplierID, poID uint, req *requests.AdvanceShippingNoticeRequest, userID uint) (*domain.AdvanceShippingNotice, error)
+	// ListShippingNotices lists shipping notices, optionally for one supplier and one order (0 = any).
+	ListShippingNotices(supplierID, poID uint) ([]domain.AdvanceShippingNotice, error)
+	Scorecard(supplierID uint, days int) (*SupplierPortalScorecard, error)
+	ListChangeProposals(poID uint) ([]domain.POChangeProposal, error)
+	AcceptChangeProposal(poID, proposalID, userID uint, notes string) (*domain.POChangeProposal, error)
+	RejectChangeProposal(poID, proposalID, userID uint, notes string) (*domain.POChangeProposal, error)
+	ListSupplierUsers(supplierID uint) ([]domain.User, error)
+	CreateSupplierUser(supplierID uint, req *requests.SupplierUserRequest) (*domain.User, error)
+}
+
+type supplierPortalService struct {
+	db *gorm.DB
+}
+
+func NewSupplierPortalService(db *gorm.DB) SupplierPortalService {
+	return &supplierPortalService{db: db}
+}
+
+func (s *supplierPortalService) SupplierIDForUser(userID uint) (uint, error) {
+	var user domain.User
+	if err := s.db.Select("id", "supplier_id").First(&user, userID).Error; err != nil {
+		return 0, err
+	}
+	if user.SupplierID == nil {
+		return 0, ErrSupplierNotLinked
+	}
+	return *user.SupplierID, nil
+}
+
+// sentToSupplier limits a purchase order query to one supplier's orders that were sent to it.
+func sentToSupplier(supplierID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("supplier_id = ?", supplierID).
+			Where("status IN ? OR sent_at IS NOT NULL", portalOrderStatuses)
+	}
+}
+
+func (s *supplierPortalService) ListPurchaseOrders(supplierID uint, status string) ([]SupplierPortalOrder, error) {
+	query := s.db.Scopes(sentToSupplier(supplierID)).Preload("PurchaseOrderItems.Product")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var orders []domain.PurchaseOrder
+	if err := query.Order("order_date DESC, id DESC").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	views := make([]SupplierPortalOrder, 0, len(orders))
+	for i := range orders {
+		view, err := s.portalOrder(&orders[i])
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *view)
+	}
+	return views, nil
+}
+
+func (s *supplierPortalService) GetPurchaseOrder(supplierID, poID uint) (*SupplierPortalOrder, error) {
+	var po domain.PurchaseOrder
+	if err := s.db.Scopes(sentToSupplier(supplierID)).Preload("PurchaseOrderItems.Product").First(&po, poID).Error; err != nil {
+		return nil, err
+	}
+	view, err := s.portalOrder(&po)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Preload("Lines").Where("purchase_order_id = ?", po.ID).Order("id").Find(&view.Proposals).Error; err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// portalOrder builds the supplier's view of an order loaded with its items and products.
+func (s *supplierPortalService) portalOrder(po *domain.PurchaseOrder) (*SupplierPortalOrder, error) {
+	shippedByItem, err := shippedQuantities(s.db, po.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog []domain.ProductSupplier
+	if err := s.db.Where("supplier_id = ?", po.SupplierID).Find(&catalog).Error; err != nil {
+		return nil, err
+	}
+	supplierSKUs := make(map[uint]string, len(catalog))
+	for _, entry := range catalog {
+		supplierSKUs[entry.ProductID] = entry.SupplierSKU
+	}
+
+	view := &SupplierPortalOrder{
+		ID:                   po.ID,
+		Status:               po.Status,
+		OrderDate:            po.OrderDate,
+		SentAt:               po.SentAt,
+		ExpectedDeliveryDate: po.ExpectedDeliveryDate,
+		PromisedDeliveryDate: po.PromisedDeliveryDate,
+		ActualDeliveryDate:   po.ActualDeliveryDate,
+		SupplierResponse:     po.SupplierResponse,
+		SupplierRespondedAt:  po.SupplierRespondedAt,
+		Lines:                make([]SupplierPortalOrderLine, 0, len(po.PurchaseOrderItems)),
+	}
+	for _, item := range po.PurchaseOrderItems {
+		line := SupplierPortalOrderLine{
+			PurchaseOrderItemID: item.ID,
+			ProductID:           item.ProductID,
+			SKU:                 item.Product.SKU,
+			SupplierSKU:         supplierSKUs[item.ProductID],
+			ProductName:         item.Product.Name,
+			UnitOfMeasure:       item.Product.UnitOfMeasure,
+			OrderedQuantity:     item.OrderedQuantity,
+			ShippedQuantity:     shippedByItem[item.ID],
+			ReceivedQuantity:    item.ReceivedQuantity,
+			UnitPrice:           item.UnitPrice,
+			LineTotal:           roundTo(item.OrderedQuantity*item.UnitPrice, 2),
+		}
+		view.Total = roundTo(view.Total+line.LineTotal, 2)
+		view.Lines = append(view.Lines, line)
+	}
+	return view, nil
+}
+
+// shippedQuantities totals the quantities on the order's shipping notices by order line.
+func shippedQuantities(db *gorm.DB, poID uint) (map[uint]float64, error) {
+	var rows []struct {
+		PurchaseOrderItemID uint
+		Quantity            float64
+	}
+	if err := db.Model(&domain.AdvanceShippingNoticeLine{}).
+		Select("advance_shipping_notice_lines.purchase_order_item_id, SUM(advance_shipping_notice_lines.quantity) AS quantity").
+		Joins("JOIN advance_shipping_notices ON advance_shipping_notices.id = advance_shipping_notice_lines.shipping_notice_id AND advance_shipping_notices.deleted_at IS NULL").
+		Where("advance_shipping_notices.purchase_order_id = ?", poID).
+		Group("advance_shipping_notice_lines.purchase_order_item_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	shipped := make(map[uint]float64, len(rows))
+	for _, row := range rows {
+		shipped[row.PurchaseOrderItemID] = row.Quantity
+	}
+	return shipped, nil
+}
+
+// lockPortalOrder locks one of the supplier's sent orders with its items and checks its status.
+func lockPortalOrder(tx *gorm.DB, supplierID, poID uint, po *domain.PurchaseOrder, allowed ...string) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(sentToSupplier(supplierID)).
+		Preload("PurchaseOrderItems.Product").First(po, poID).Error; err != nil {
+		return err
+	}
+	for _, status := range allowed {
+		if po.Status == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: purchase order %d is %s", ErrPortalOrderState, po.ID, po.Status)
+}
+
+func (s *supplierPortalService) ConfirmPurchaseOrder(supplierID, poID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var po domain.PurchaseOrder
+		if err := lockPortalOrder(tx, supplierID, poID, &po, "SENT", "PARTIALLY_RECEIVED"); err != nil {
+			return err
+		}
+		switch po.SupplierResponse {
+		case domain.SupplierResponseConfirmed:
+			return fmt.Errorf("%w: purchase order %d is already confirmed", ErrPortalOrderState, po.ID)
+		case domain.SupplierResponseChangesProposed:
+			return fmt.Errorf("%w: purchase order %d has changes awaiting review", ErrPortalOrderState, po.ID)
+		}
+		now := time.Now()
+		return tx.Model(&po).Updates(map[string]interface{}{
+			"SupplierResponse":     domain.SupplierResponseConfirmed,
+			"SupplierRespondedAt":  now,
+			"PromisedDeliveryDate": po.ExpectedDeliveryDate,
+		}).Error
+	})
+}
+
+func (s *supplierPortalService) ProposeChanges(supplierID, poID uint, req *requests.POChangeProposalRequest, userID uint) (*domain.POChangeProposal, error) {
+	var proposal domain.POChangeProposal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var po domain.PurchaseOrder
+		if err := lockPortalOrder(tx, supplierID, poID, &po, "SENT"); err != nil {
+			return err
+		}
+		switch po.SupplierResponse {
+		case domain.SupplierResponseConfirmed:
+			return fmt.Errorf("%w: purchase order %d is already confirmed", ErrPortalOrderState, po.ID)
+		case domain.SupplierResponseChangesProposed:
+			return fmt.Errorf("%w: purchase order %d already has changes awaiting review", ErrPortalOrderState, po.ID)
+		}
+
+		proposal = domain.POChangeProposal{
+			PurchaseOrderID: po.ID,
+			SupplierID:      po.SupplierID,
+			Notes:           req.Notes,
+			Status:          domain.ProposalStatusPending,
+			SubmittedBy:     userID,
+		}
+		if req.ProposedDeliveryDate != nil {
+			if req.ProposedDeliveryDate.Before(startOfDay(po.OrderDate)) {
+				return fmt.Errorf("%w: proposed delivery date is before the order date", ErrInvalidPortalRequest)
+			}
+			if po.ExpectedDeliveryDate == nil || !req.ProposedDeliveryDate.Equal(*po.ExpectedDeliveryDate) {
+				proposal.ProposedDeliveryDate = req.ProposedDeliveryDate
+			}
+		}
+
+		items := make(map[uint]*domain.PurchaseOrderItem, len(po.PurchaseOrderItems))
+		for i := range po.PurchaseOrderItems {
+			items[po.PurchaseOrderItems[i].ID] = &po.PurchaseOrderItems[i]
+		}
+		seen := make(map[uint]bool, len(req.Lines))
+		remaining := 0.0
+		for _, line := range req.Lines {
+			item, ok := items[line.PurchaseOrderItemID]
+			if !ok {
+				return fmt.Errorf("%w: item %d is not on purchase order %d", ErrInvalidPortalRequest, line.PurchaseOrderItemID, po.ID)
+			}
+			if seen[item.ID] {
+				return fmt.Errorf("%w: item %d is listed more than once", ErrInvalidPortalRequest, item.ID)
+			}
+			seen[item.ID] = true
+			if !item.Product.IsValidQuantity(line.Quantity) {
+				return fmt.Errorf("%w: quantity %g for item %d allows at most %d decimal places", ErrInvalidPortalRequest, line.Quantity, item.ID, item.Product.QuantityPrecision)
+			}
+			if line.Quantity < item.ReceivedQuantity {
+				return fmt.Errorf("%w: quantity %g for item %d is below the %g already received", ErrInvalidPortalRequest, line.Quantity, item.ID, item.ReceivedQuantity)
+			}
+			// Suppliers may only reduce what was ordered; more goods need a new, approved order
+			if line.Quantity > item.OrderedQuantity {
+				return fmt.Errorf("%w: quantity %g for item %d is above the %g ordered", ErrInvalidPortalRequest, line.Quantity, item.ID, item.OrderedQuantity)
+			}
+			if line.Quantity == item.OrderedQuantity {
+				continue
+			}
+			proposal.Lines = append(proposal.Lines, domain.POChangeProposalLine{
+				PurchaseOrderItemID: item.ID,
+				ProductID:           item.ProductID,
+				OrderedQuantity:     item.OrderedQuantity,
+				ProposedQuantity:    line.Quantity,
+			})
+		}
+		for _, item := range items {
+			if !seen[item.ID] {
+				remaining += item.OrderedQuantity
+			}
+		}
+		for _, line := range req.Lines {
+			remaining += line.Quantity
+		}
+		if proposal.ProposedDeliveryDate == nil && len(proposal.Lines) == 0 {
+			return fmt.Errorf("%w: the proposal does not change the order", ErrInvalidPortalRequest)
+		}
+		if remaining <= 0 {
+			// Declining the whole order is a cancellation, which the buyer has to make
+			return fmt.Errorf("%w: the proposal leaves nothing to deliver; ask the buyer to cancel the order", ErrInvalidPortalRequest)
+		}
+
+		if err := tx.Create(&proposal).Error; err != nil {
+			return err
+		}
+		return tx.Model(&po).Updates(map[string]interface{}{
+			"SupplierResponse":    domain.SupplierResponseChangesProposed,
+			"SupplierRespondedAt": proposal.CreatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+func (s *supplierPortalService) ListChangeProposals(poID uint) ([]domain.POChangeProposal, error) {
+	var proposals []domain.POChangeProposal
+	if err := s.db.Preload("Lines").Where("purchase_order_id = ?", poID).Order("id").Find(&proposals).Error; err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+// lockPendingProposal locks a pending proposal on the order, and the order itself.
+func lockPendingProposal(tx *gorm.DB, poID, proposalID uint, po *domain.PurchaseOrder, proposal *domain.POChangeProposal) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(po, poID).Error; err != nil {
+		return err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").
+		Where("purchase_order_id = ?", poID).First(proposal, proposalID).Error; err != nil {
+		return err
+	}
+	if proposal.Status != domain.ProposalStatusPending {
+		return fmt.Errorf("%w: change proposal %d is %s", ErrPortalOrderState, proposal.ID, proposal.Status)
+	}
+	return nil
+}
+
+func (s *supplierPortalService) AcceptChangeProposal(poID, proposalID, userID uint, notes string) (*domain.POChangeProposal, error) {
+	var proposal domain.POChangeProposal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var po domain.PurchaseOrder
+		if err := lockPendingProposal(tx, poID, proposalID, &po, &proposal); err != nil {
+			return err
+		}
+		if po.Status != "SENT" {
+			return fmt.Errorf("%w: purchase order %d is %s; reject the proposal instead", ErrPortalOrderState, po.ID, po.Status)
+		}
+
+		for _, line := range proposal.Lines {
+			var item domain.PurchaseOrderItem
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("purchase_order_id = ?", po.ID).First(&item, line.PurchaseOrderItemID).Error; err != nil {
+				return err
+			}
+			if line.ProposedQuantity < item.ReceivedQuantity {
+				return fmt.Errorf("%w: %g of item %d was received since the proposal", ErrPortalOrderState, item.ReceivedQuantity, item.ID)
+			}
+			if line.ProposedQuantity > item.OrderedQuantity {
+				return fmt.Errorf("%w: the proposal raises item %d above the %g ordered; reject it instead", ErrPortalOrderState, item.ID, item.OrderedQuantity)
+			}
+			if err := tx.Model(&item).Update("OrderedQuantity", line.ProposedQuantity).Error; err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{
+			"SupplierResponse":     domain.SupplierResponseConfirmed,
+			"PromisedDeliveryDate": po.ExpectedDeliveryDate,
+		}
+		if proposal.ProposedDeliveryDate != nil {
+			updates["ExpectedDeliveryDate"] = proposal.ProposedDeliveryDate
+			updates["PromisedDeliveryDate"] = proposal.ProposedDeliveryDate
+		}
+		if err := tx.Model(&po).Updates(updates).Error; err != nil {
+			return err
+		}
+		return reviewProposal(tx, &proposal, domain.ProposalStatusAccepted, userID, notes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+func (s *supplierPortalService) RejectChangeProposal(poID, proposalID, userID uint, notes string) (*domain.POChangeProposal, error) {
+	if notes == "" {
+		return nil, fmt.Errorf("%w: give the supplier a reason for rejecting the changes", ErrInvalidPortalRequest)
+	}
+	var proposal domain.POChangeProposal
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var po domain.PurchaseOrder
+		if err := lockPendingProposal(tx, poID, proposalID, &po, &proposal); err != nil {
+			return err
+		}
+		if err := tx.Model(&po).Update("SupplierResponse", domain.SupplierResponseChangesRejected).Error; err != nil {
+			return err
+		}
+		return reviewProposal(tx, &proposal, domain.ProposalStatusRejected, userID, notes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+// reviewProposal records the buyer's decision on a change proposal.
+func reviewProposal(tx *gorm.DB, proposal *domain.POChangeProposal, status string, userID uint, notes string) error {
+	now := time.Now()
+	proposal.Status = status
+	proposal.ReviewedBy = &userID
+	proposal.ReviewedAt = &now
+	proposal.ReviewNotes = notes
+	return tx.Model(proposal).Omit(clause.Associations).Updates(map[string]interface{}{
+		"Status":      proposal.Status,
+		"ReviewedBy":  proposal.ReviewedBy,
+		"ReviewedAt":  proposal.ReviewedAt,
+		"ReviewNotes": proposal.ReviewNotes,
+	}).Error
+}
+
+func (s *supplierPortalService) SubmitShippingNotice(supplierID, poID uint, req *requests.AdvanceShippingNoticeRequest, userID uint) (*domain.AdvanceShippingNotice, error) {
+	var notice domain.AdvanceShippingNotice
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var po domain.PurchaseOrder
+		if err := lockPortalOrder(tx, supplierID, poID, &po, "SENT", "PARTIALLY_RECEIVED"); err != nil {
+			return err
+		}
+		if po.SupplierResponse == domain.SupplierResponseChangesProposed {
+			return fmt.Errorf("%w: purchase order %d has changes awaiting review", ErrPortalOrderState, po.ID)
+		}
+
+		var existing int64
+		if err := tx.Model(&domain.AdvanceShippingNotice{}).
+			Where("supplier_id = ? AND shipment_number = ?", po.SupplierID, req.ShipmentNumber).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return fmt.Errorf("%w: shipment %s", ErrDuplicateShipment, req.ShipmentNumber)
+		}
+
+		notice = domain.AdvanceShippingNotice{
+			PurchaseOrderID:     po.ID,
+			SupplierID:          po.SupplierID,
+			ShipmentNumber:      req.ShipmentNumber,
+			ShippedAt:           time.Now(),
+			ExpectedArrivalDate: req.ExpectedArrivalDate,
+			Carrier:             req.Carrier,
+			TrackingNumber:      req.TrackingNumber,
+			Notes:               req.Notes,
+			SubmittedBy:         userID,
+		}
+		if req.ShippedAt != nil {
+			notice.ShippedAt = *req.ShippedAt
+		}
+		if notice.ExpectedArrivalDate != nil && notice.ExpectedArrivalDate.Before(startOfDay(notice.ShippedAt)) {
+			return fmt.Errorf("%w: expected arrival is before the shipping date", ErrInvalidPortalRequest)
+		}
+
+		shippedByItem, err := shippedQuantities(tx, po.ID)
+		if err != nil {
+			return err
+		}
+
+		items := make(map[uint]*domain.PurchaseOrderItem, len(po.PurchaseOrderItems))
+		for i := range po.PurchaseOrderItems {
+			items[po.PurchaseOrderItems[i].ID] = &po.PurchaseOrderItems[i]
+		}
+		for _, line := range req.Lines {
+			item, ok := items[line.PurchaseOrderItemID]
+			if !ok {
+				return fmt.Errorf("%w: item %d is not on purchase order %d", ErrInvalidPortalRequest, line.PurchaseOrderItemID, po.ID)
+			}
+			if !item.Product.IsValidQuantity(line.Quantity) {
+				return fmt.Errorf("%w: quantity %g for item %d allows at most %d decimal places", ErrInvalidPortalRequest, line.Quantity, item.ID, item.Product.QuantityPrecision)
+			}
+			if line.ExpiryDate != nil && !line.ExpiryDate.After(notice.ShippedAt) {
+				return fmt.Errorf("%w: batch %s of item %d has already expired", ErrInvalidPortalRequest, line.BatchNumber, item.ID)
+			}
+			// Every shipment counts, received or not: receipts do not say which shipment they came from
+			shippedByItem[item.ID] = item.Product.RoundQuantity(shippedByItem[item.ID] + line.Quantity)
+			if shippedByItem[item.ID] > item.OrderedQuantity {
+				return fmt.Errorf("%w: %g of item %d shipped in total, more than the %g ordered", ErrInvalidPortalRequest, shippedByItem[item.ID], item.ID, item.OrderedQuantity)
+			}
+			notice.Lines = append(notice.Lines, domain.AdvanceShippingNoticeLine{
+				PurchaseOrderItemID: item.ID,
+				ProductID:           item.ProductID,
+				Quantity:            line.Quantity,
+				BatchNumber:         line.BatchNumber,
+				ExpiryDate:          line.ExpiryDate,
+			})
+		}
+
+		return tx.Create(&notice).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &notice, nil
+}
+
+func (s *supplierPortalService) ListShippingNotices(supplierID, poID uint) ([]domain.AdvanceShippingNotice, error) {
+	query := s.db.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).Preload("Lines.Product")
+	if supplierID != 0 {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	if poID != 0 {
+		query = query.Where("purchase_order_id = ?", poID)
+	}
+	var notices []domain.AdvanceShippingNotice
+	if err := query.Order("shipped_at DESC, id DESC").Find(&notices).Error; err != nil {
+		return nil, err
+	}
+	return notices, nil
+}
+
+func (s *supplierPortalService) Scorecard(supplierID uint, days int) (*SupplierPortalScorecard, error) {
+	var supplier domain.Supplier
+	if err := s.db.First(&supplier, supplierID).Error; err != nil {
+		return nil, err
+	}
+	to := time.Now()
+	from := startOfDay(to.AddDate(0, 0, -days))
+	card := &SupplierPortalScorecard{SupplierID: supplier.ID, SupplierName: supplier.Name, From: from, To: to}
+
+	var orders []domain.PurchaseOrder
+	if err := s.db.Scopes(sentToSupplier(supplierID)).Preload("PurchaseOrderItems").
+		Where("order_date >= ?", from).Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	var noticeOrderIDs []uint
+	if err := s.db.Model(&domain.AdvanceShippingNotice{}).Where("supplier_id = ?", supplierID).
+		Distinct().Pluck("purchase_order_id", &noticeOrderIDs).Error; err != nil {
+		return nil, err
+	}
+	announced := make(map[uint]bool, len(noticeOrderIDs))
+	for _, id := range noticeOrderIDs {
+		announced[id] = true
+	}
+
+	var responseHours, leadDays []float64
+	var ordered, received float64
+	deliveriesDue, withDeliveries, withNotices := 0, 0, 0
+	for _, po := range orders {
+		card.OrdersSent++
+		if po.SupplierResponse == domain.SupplierResponseConfirmed {
+			card.OrdersConfirmed++
+		}
+		if po.SentAt != nil && po.SupplierRespondedAt != nil {
+			responseHours = append(responseHours, po.SupplierRespondedAt.Sub(*po.SentAt).Hours())
+		}
+
+		if po.ActualDeliveryDate != nil {
+			card.OrdersDelivered++
+			leadDays = append(leadDays, po.ActualDeliveryDate.Sub(po.OrderDate).Hours()/24)
+			due := po.PromisedDeliveryDate
+			if due == nil {
+				due = po.ExpectedDeliveryDate
+			}
+			if due != nil {
+				deliveriesDue++
+				// Anything delivered on the due day is on time
+				if po.ActualDeliveryDate.Before(startOfDay(*due).AddDate(0, 0, 1)) {
+					card.OnTimeDeliveries++
+				}
+			}
+		}
+
+		delivered := false
+		for _, item := range po.PurchaseOrderItems {
+			if item.ReceivedQuantity > 0 {
+				delivered = true
+			}
+		}
+		if !delivered {
+			continue
+		}
+		withDeliveries++
+		if announced[po.ID] {
+			withNotices++
+		}
+		for _, item := range po.PurchaseOrderItems {
+			ordered += item.OrderedQuantity
+			received += math.Min(item.ReceivedQuantity, item.OrderedQuantity)
+		}
+	}
+	card.ConfirmationRate = percentOf(card.OrdersConfirmed, card.OrdersSent)
+	card.AvgConfirmationHours = roundTo(mean(responseHours), 1)
+	card.OnTimeRate = percentOf(card.OnTimeDeliveries, deliveriesDue)
+	card.AvgLeadTimeDays = roundTo(mean(leadDays), 1)
+	if ordered > 0 {
+		card.FillRate = roundTo(received/ordered*100, 1)
+	}
+	card.ShippingNoticeRate = percentOf(withNotices, withDeliveries)
+
+	var invoices []domain.SupplierInvoice
+	if err := s.db.Preload("Lines").Where("supplier_id = ? AND invoice_date >= ?", supplierID, from).Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	matched := 0
+	for _, invoice := range invoices {
+		clean := true
+		for _, line := range invoice.Lines {
+			// A resolved mismatch still counts against the supplier
+			if line.MatchStatus != domain.LineMatchMatched {
+				clean = false
+				break
+			}
+		}
+		if clean {
+			matched++
+		}
+	}
+	card.InvoicesSubmitted = len(invoices)
+	card.InvoiceMatchRate = percentOf(matched, len(invoices))
+	return card, nil
+}
+
+func percentOf(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return roundTo(float64(n)/float64(total)*100, 1)
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func (s *supplierPortalService) ListSupplierUsers(supplierID uint) ([]domain.User, error) {
+	var users []domain.User
+	if err := s.db.Preload("Role").Where("supplier_id = ?", supplierID).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].Password = ""
+	}
+	return users, nil
+}
+
+func (s *supplierPortalService) CreateSupplierUser(supplierID uint, req *requests.SupplierUserRequest) (*domain.User, error) {
+	var supplier domain.Supplier
+	if err := s.db.First(&supplier, supplierID).Error; err != nil {
+		return nil, err
+	}
+	var role domain.Role
+	if err := s.db.Where("name = ?", SupplierRoleName).First(&role).Error; err != nil {
+		return nil, fmt.Errorf("supplier role: %w", err)
+	}
+
+	var existing int64
+	if err := s.db.Model(&domain.User{}).
+		Where("username = ? OR email = ? OR phone_number = ?", req.Username, req.Email, req.PhoneNumber).
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, fmt.Errorf("%w: username, email or phone number is taken", ErrDuplicateSupplierUser)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user := domain.User{
+		Username:    req.Username,
+		Password:    string(hashedPassword),
+		RoleID:      role.ID,
+		IsActive:    true, // Created by staff for a known supplier, unlike self-registrations
+		FirstName:   req.FirstName,
+		LastName:    req.LastName,
+		Email:       req.Email,
+		PhoneNumber: req.PhoneNumber,
+		SupplierID:  &supplier.ID,
+	}
+	if err := s.db.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	user.Role = role
+	return &user, nil
+}